@@ -1,18 +1,58 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	db "github.com/asshiddiq1306/simple_bank/db/sql"
 	"github.com/asshiddiq1306/simple_bank/token"
+	"github.com/asshiddiq1306/simple_bank/util"
 	"github.com/gin-gonic/gin"
 	"github.com/lib/pq"
 )
 
+// accountNumberAttempts bounds the account numbers tried for a new account.
+// With 12 random digits a second collision in a row is very unlikely.
+const accountNumberAttempts = 3
+
+type accountResponse struct {
+	ID                int64       `json:"id"`
+	Owner             string      `json:"owner"`
+	Balance           util.Money  `json:"balance"`
+	Currency          string      `json:"currency"`
+	CreatedAt         time.Time   `json:"created_at"`
+	AccountNumber     string      `json:"account_number"`
+	Type              string      `json:"type"`
+	InterestRateBps   int32       `json:"interest_rate_bps"`
+	ApprovalThreshold *util.Money `json:"approval_threshold"`
+}
+
+func accountResp(account db.Account) accountResponse {
+	rsp := accountResponse{
+		ID:              account.ID,
+		Owner:           account.Owner,
+		Balance:         util.NewMoney(account.Balance, account.Currency),
+		Currency:        account.Currency,
+		CreatedAt:       account.CreatedAt,
+		AccountNumber:   account.AccountNumber,
+		Type:            account.Type,
+		InterestRateBps: account.InterestRateBps,
+	}
+	if account.ApprovalThreshold.Valid {
+		threshold := util.NewMoney(account.ApprovalThreshold.Int64, account.Currency)
+		rsp.ApprovalThreshold = &threshold
+	}
+	return rsp
+}
+
 type createNewAccountReq struct {
 	Currency string `json:"currency" binding:"required,currency"`
+	Type     string `json:"type" binding:"omitempty,oneof=checking savings"`
 }
 
 func (server *Server) createNewAccountAPI(c *gin.Context) {
@@ -23,32 +63,75 @@ func (server *Server) createNewAccountAPI(c *gin.Context) {
 		return
 	}
 
+	if !util.IsEnabledCurrency(req.Currency) {
+		err := fmt.Errorf("new accounts cannot be opened in %s", req.Currency)
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.Type == "" {
+		req.Type = util.CheckingAccount
+	}
+
+	// The interest rate is fixed when the account is opened.
+	var rate int64
+	if req.Type == util.SavingsAccount {
+		var ok bool
+		rate, ok = server.savingsRates[req.Currency]
+		if !ok {
+			err := fmt.Errorf("savings accounts are not offered in %s", req.Currency)
+			c.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+
 	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
 	arg := db.CreateNewAccountArgs{
-		Owner:    authPayload.Username,
-		Balance:  0,
-		Currency: req.Currency,
+		Owner:           authPayload.Username,
+		Balance:         0,
+		Currency:        req.Currency,
+		Type:            req.Type,
+		InterestRateBps: int32(rate),
 	}
 
-	account, err := server.store.CreateNewAccount(c, arg)
+	// Account numbers are random, a new one is drawn when one is taken.
+	var account db.Account
+	for attempt := 1; ; attempt++ {
+		arg.AccountNumber, err = util.GenerateAccountNumber()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		account, err = server.store.CreateAccountTx(c.Request.Context(), arg)
+		if !isUniqueViolation(err, db.AccountNumberConstraint) || attempt == accountNumberAttempts {
+			break
+		}
+	}
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			switch pqErr.Code.Name() {
-			case "unique_violation":
-				c.JSON(http.StatusForbidden, errorResponse(err))
-				return
-			}
+		if isUniqueViolation(err, db.AccountOwnerCurrencyTypeConstraint) {
+			c.JSON(http.StatusForbidden, errorResponse(err))
+			return
 		}
 
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, account)
+	c.JSON(http.StatusOK, accountResp(account))
 }
 
+// isUniqueViolation reports whether err was raised by the unique constraint
+// or index named constraint.
+func isUniqueViolation(err error, constraint string) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code.Name() == "unique_violation" && pqErr.Constraint == constraint
+}
+
+var errInvalidAccountID = errors.New("account id must be a positive number or a valid account number")
+
 type getAccountByIDReq struct {
-	ID int64 `uri:"id" binding:"required,min=1"`
+	ID string `uri:"id" binding:"required,number|account_number"`
 }
 
 func (server *Server) getAccountByIDAPI(c *gin.Context) {
@@ -59,7 +142,19 @@ func (server *Server) getAccountByIDAPI(c *gin.Context) {
 		return
 	}
 
-	account, err := server.store.GetAccountByID(c, req.ID)
+	var id int64
+	var number string
+	if util.IsValidAccountNumber(req.ID) {
+		number = req.ID
+	} else {
+		id, err = strconv.ParseInt(req.ID, 10, 64)
+		if err != nil || id < 1 {
+			c.JSON(http.StatusBadRequest, errorResponse(errInvalidAccountID))
+			return
+		}
+	}
+
+	account, err := server.getAccount(c.Request.Context(), id, number)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -71,14 +166,20 @@ func (server *Server) getAccountByIDAPI(c *gin.Context) {
 	}
 
 	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
-
-	if account.Owner != authPayload.Username {
-		err := errors.New("this account doesn't belongs to auth user")
-		c.JSON(http.StatusUnauthorized, errorResponse(err))
+	if !server.authorizeAccount(c, account, authPayload.Username, nil) {
 		return
 	}
 
-	c.JSON(http.StatusOK, account)
+	c.JSON(http.StatusOK, accountResp(account))
+}
+
+// getAccount looks an account up by its account number when one is given,
+// otherwise by its ID.
+func (server *Server) getAccount(ctx context.Context, id int64, number string) (db.Account, error) {
+	if number != "" {
+		return server.store.GetAccountByNumber(ctx, number)
+	}
+	return server.store.GetAccountByID(ctx, id)
 }
 
 type getListAccountsReq struct {
@@ -102,12 +203,17 @@ func (server *Server) getListAccountsAPI(c *gin.Context) {
 		Offset: (req.PageID - 1) * req.PageSize,
 	}
 
-	accounts, err := server.store.GetListAccounts(c, arg)
+	accounts, err := server.store.GetListAccounts(c.Request.Context(), arg)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, accounts)
+	rsp := make([]accountResponse, len(accounts))
+	for i, account := range accounts {
+		rsp[i] = accountResp(account)
+	}
+
+	c.JSON(http.StatusOK, rsp)
 
 }
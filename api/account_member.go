@@ -0,0 +1,297 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/token"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+var (
+	errNotAccountMember = errors.New("this account doesn't belongs to auth user")
+	errAccountRole      = errors.New("your role on this account does not allow this")
+	errOwnerMember      = errors.New("the owner of an account cannot be invited or removed")
+)
+
+type accountMemberResp struct {
+	AccountID  int64      `json:"account_id"`
+	Username   string     `json:"username"`
+	Role       string     `json:"role"`
+	InvitedBy  string     `json:"invited_by"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func newAccountMemberResp(member db.AccountMember) accountMemberResp {
+	rsp := accountMemberResp{
+		AccountID: member.AccountID,
+		Username:  member.Username,
+		Role:      member.Role,
+		InvitedBy: member.InvitedBy,
+		CreatedAt: member.CreatedAt,
+	}
+	if member.AcceptedAt.Valid {
+		rsp.AcceptedAt = &member.AcceptedAt.Time
+	}
+	return rsp
+}
+
+func newAccountMembersResp(members []db.AccountMember) []accountMemberResp {
+	rsp := make([]accountMemberResp, len(members))
+	for i, member := range members {
+		rsp[i] = newAccountMemberResp(member)
+	}
+	return rsp
+}
+
+// accountRole returns the role of username on account, or "" when they are
+// not an accepted member. accounts.owner is always the owner member, so it
+// needs no lookup.
+func (server *Server) accountRole(ctx context.Context, account db.Account, username string) (string, error) {
+	if account.Owner == username {
+		return util.AccountOwnerRole, nil
+	}
+
+	member, err := server.store.GetAccountMember(ctx, db.GetAccountMemberArgs{
+		AccountID: account.ID,
+		Username:  username,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+
+	if !member.AcceptedAt.Valid {
+		return "", nil
+	}
+	return member.Role, nil
+}
+
+// authorizeAccount writes the error response and returns false unless
+// username is an accepted member of account. A nil allowed lets every role
+// through, which is enough to see the account.
+func (server *Server) authorizeAccount(c *gin.Context, account db.Account, username string, allowed func(role string) bool) bool {
+	role, err := server.accountRole(c.Request.Context(), account, username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	if role == "" {
+		c.JSON(http.StatusUnauthorized, errorResponse(errNotAccountMember))
+		return false
+	}
+
+	if allowed != nil && !allowed(role) {
+		c.JSON(http.StatusForbidden, errorResponse(errAccountRole))
+		return false
+	}
+	return true
+}
+
+// getMemberAccount loads the account of the request, writing the error
+// response and returning false when it does not exist.
+func (server *Server) getMemberAccount(c *gin.Context, id int64) (db.Account, bool) {
+	account, err := server.store.GetAccountByID(c.Request.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return account, false
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return account, false
+	}
+	return account, true
+}
+
+type accountMembersURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type inviteAccountMemberReq struct {
+	Username string `json:"username" binding:"required,alphanum"`
+	Role     string `json:"role" binding:"required,oneof=co_owner viewer"`
+}
+
+// inviteAccountMemberAPI lets the owner share the account. The invitation
+// only grants access once the invited user accepts it.
+func (server *Server) inviteAccountMemberAPI(c *gin.Context) {
+	var uri accountMembersURI
+	err := c.ShouldBindUri(&uri)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req inviteAccountMemberReq
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, ok := server.getMemberAccount(c, uri.ID)
+	if !ok {
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+	if !server.authorizeAccount(c, account, authPayload.Username, util.CanManageAccountMembers) {
+		return
+	}
+
+	if req.Username == account.Owner {
+		c.JSON(http.StatusBadRequest, errorResponse(errOwnerMember))
+		return
+	}
+
+	arg := db.CreateAccountMemberArgs{
+		AccountID: account.ID,
+		Username:  req.Username,
+		Role:      req.Role,
+		InvitedBy: authPayload.Username,
+	}
+
+	member, err := server.store.CreateAccountMember(c.Request.Context(), arg)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code.Name() {
+			case "unique_violation":
+				c.JSON(http.StatusForbidden, errorResponse(err))
+				return
+			case "foreign_key_violation":
+				c.JSON(http.StatusNotFound, errorResponse(err))
+				return
+			}
+		}
+
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, newAccountMemberResp(member))
+}
+
+func (server *Server) getListAccountMembersAPI(c *gin.Context) {
+	var uri accountMembersURI
+	err := c.ShouldBindUri(&uri)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, ok := server.getMemberAccount(c, uri.ID)
+	if !ok {
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+	if !server.authorizeAccount(c, account, authPayload.Username, nil) {
+		return
+	}
+
+	members, err := server.store.GetListAccountMembers(c.Request.Context(), account.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, newAccountMembersResp(members))
+}
+
+func (server *Server) acceptAccountInvitationAPI(c *gin.Context) {
+	var uri accountMembersURI
+	err := c.ShouldBindUri(&uri)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+
+	arg := db.AcceptAccountMemberArgs{
+		AccountID: uri.ID,
+		Username:  authPayload.Username,
+	}
+
+	member, err := server.store.AcceptAccountMember(c.Request.Context(), arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, newAccountMemberResp(member))
+}
+
+type deleteAccountMemberURI struct {
+	ID       int64  `uri:"id" binding:"required,min=1"`
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+// deleteAccountMemberAPI lets the owner remove a member, and any member
+// leave the account or decline their invitation.
+func (server *Server) deleteAccountMemberAPI(c *gin.Context) {
+	var uri deleteAccountMemberURI
+	err := c.ShouldBindUri(&uri)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, ok := server.getMemberAccount(c, uri.ID)
+	if !ok {
+		return
+	}
+
+	if uri.Username == account.Owner {
+		c.JSON(http.StatusBadRequest, errorResponse(errOwnerMember))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+	if uri.Username != authPayload.Username && !server.authorizeAccount(c, account, authPayload.Username, util.CanManageAccountMembers) {
+		return
+	}
+
+	arg := db.DeleteAccountMemberArgs{
+		AccountID: account.ID,
+		Username:  uri.Username,
+	}
+
+	member, err := server.store.DeleteAccountMember(c.Request.Context(), arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, newAccountMemberResp(member))
+}
+
+func (server *Server) getListAccountInvitationsAPI(c *gin.Context) {
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+
+	invitations, err := server.store.GetListAccountInvitations(c.Request.Context(), authPayload.Username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, newAccountMembersResp(invitations))
+}
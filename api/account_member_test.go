@@ -0,0 +1,461 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/asshiddiq1306/simple_bank/db/mock"
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomMember(account db.Account, username string, role string) db.AccountMember {
+	return db.AccountMember{
+		AccountID:  account.ID,
+		Username:   username,
+		Role:       role,
+		InvitedBy:  account.Owner,
+		AcceptedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		CreatedAt:  time.Now(),
+	}
+}
+
+func TestInviteAccountMemberAPI(t *testing.T) {
+	owner, _ := createRandomUser(t)
+	coOwner, _ := createRandomUser(t)
+	invited, _ := createRandomUser(t)
+	account := createRandomAccount(owner.Username)
+
+	testCases := []struct {
+		name          string
+		username      string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: owner.Username,
+			body:     gin.H{"username": invited.Username, "role": util.AccountViewerRole},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateAccountMemberArgs{
+					AccountID: account.ID,
+					Username:  invited.Username,
+					Role:      util.AccountViewerRole,
+					InvitedBy: owner.Username,
+				}
+				member := db.AccountMember{
+					AccountID: account.ID,
+					Username:  invited.Username,
+					Role:      util.AccountViewerRole,
+					InvitedBy: owner.Username,
+				}
+				store.EXPECT().GetAccountByID(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Eq(arg)).Times(1).Return(member, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp map[string]interface{}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, invited.Username, rsp["username"])
+				require.Nil(t, rsp["accepted_at"])
+			},
+		},
+		{
+			name:     "CoOwnerCannotInvite",
+			username: coOwner.Username,
+			body:     gin.H{"username": invited.Username, "role": util.AccountViewerRole},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByID(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Any()).Times(1).
+					Return(createRandomMember(account, coOwner.Username, util.AccountCoOwnerRole), nil)
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errAccountRole)
+			},
+		},
+		{
+			name:     "NotMember",
+			username: invited.Username,
+			body:     gin.H{"username": coOwner.Username, "role": util.AccountCoOwnerRole},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByID(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountMember{}, sql.ErrNoRows)
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "InviteOwner",
+			username: owner.Username,
+			body:     gin.H{"username": owner.Username, "role": util.AccountViewerRole},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByID(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errOwnerMember)
+			},
+		},
+		{
+			name:     "OwnerRole",
+			username: owner.Username,
+			body:     gin.H{"username": invited.Username, "role": util.AccountOwnerRole},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByID(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "AlreadyInvited",
+			username: owner.Username,
+			body:     gin.H{"username": invited.Username, "role": util.AccountCoOwnerRole},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByID(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountMember{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "UserNotFound",
+			username: owner.Username,
+			body:     gin.H{"username": "nobody", "role": util.AccountCoOwnerRole},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByID(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountMember{}, &pq.Error{Code: "23503"})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/account/%d/members", account.ID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestJointAccountTransferAPI(t *testing.T) {
+	owner, _ := createRandomUser(t)
+	member, _ := createRandomUser(t)
+	other, _ := createRandomUser(t)
+
+	account1 := createRandomAccount(owner.Username)
+	account2 := createRandomAccount(other.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+	account2.ID = account1.ID + 1
+
+	amount := int64(10)
+	body := gin.H{
+		"from_account_id": account1.ID,
+		"to_account_id":   account2.ID,
+		"amount":          util.NewMoney(amount, util.USD).String(),
+		"currency":        util.USD,
+	}
+
+	pending := createRandomMember(account1, member.Username, util.AccountCoOwnerRole)
+	pending.AcceptedAt = sql.NullTime{}
+
+	testCases := []struct {
+		name          string
+		member        db.AccountMember
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "CoOwner",
+			member: createRandomMember(account1, member.Username, util.AccountCoOwnerRole),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByID(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				arg := db.TransferTxArg{
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        amount,
+					InitiatedBy:   member.Username,
+				}
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "Viewer",
+			member: createRandomMember(account1, member.Username, util.AccountViewerRole),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errAccountRole)
+			},
+		},
+		{
+			name:   "PendingInvitation",
+			member: pending,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:   "RevokedDuringTransfer",
+			member: createRandomMember(account1, member.Username, util.AccountCoOwnerRole),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByID(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, db.ErrTransferNotAllowed)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetAccountByID(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+			arg := db.GetAccountMemberArgs{AccountID: account1.ID, Username: member.Username}
+			store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(arg)).Times(1).Return(tc.member, nil)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfer", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, member.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestGetJointAccountAPI(t *testing.T) {
+	owner, _ := createRandomUser(t)
+	viewer, _ := createRandomUser(t)
+	account := createRandomAccount(owner.Username)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccountByID(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+	store.EXPECT().GetAccountMember(gomock.Any(), gomock.Any()).Times(1).
+		Return(createRandomMember(account, viewer.Username, util.AccountViewerRole), nil)
+
+	server := newServerTest(t, store)
+	recorder := httptest.NewRecorder()
+
+	url := fmt.Sprintf("/account/%d", account.ID)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, viewer.Username, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	requireBodyMatchAccount(t, recorder.Body, account)
+}
+
+func TestAcceptAccountInvitationAPI(t *testing.T) {
+	owner, _ := createRandomUser(t)
+	invited, _ := createRandomUser(t)
+	account := createRandomAccount(owner.Username)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.AcceptAccountMemberArgs{AccountID: account.ID, Username: invited.Username}
+				store.EXPECT().AcceptAccountMember(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(createRandomMember(account, invited.Username, util.AccountCoOwnerRole), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "NoInvitation",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().AcceptAccountMember(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountMember{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/account/%d/members/accept", account.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, invited.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestDeleteAccountMemberAPI(t *testing.T) {
+	owner, _ := createRandomUser(t)
+	viewer, _ := createRandomUser(t)
+	coOwner, _ := createRandomUser(t)
+	account := createRandomAccount(owner.Username)
+
+	testCases := []struct {
+		name          string
+		username      string
+		target        string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OwnerRemovesMember",
+			username: owner.Username,
+			target:   viewer.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.DeleteAccountMemberArgs{AccountID: account.ID, Username: viewer.Username}
+				store.EXPECT().DeleteAccountMember(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(createRandomMember(account, viewer.Username, util.AccountViewerRole), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "MemberLeaves",
+			username: viewer.Username,
+			target:   viewer.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Any()).Times(0)
+				arg := db.DeleteAccountMemberArgs{AccountID: account.ID, Username: viewer.Username}
+				store.EXPECT().DeleteAccountMember(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(createRandomMember(account, viewer.Username, util.AccountViewerRole), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "CoOwnerRemovesMember",
+			username: coOwner.Username,
+			target:   viewer.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Any()).Times(1).
+					Return(createRandomMember(account, coOwner.Username, util.AccountCoOwnerRole), nil)
+				store.EXPECT().DeleteAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "RemoveOwner",
+			username: owner.Username,
+			target:   owner.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().DeleteAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetAccountByID(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/account/%d/members/%s", account.ID, tc.target)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
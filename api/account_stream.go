@@ -0,0 +1,238 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/token"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxStreamAccounts bounds the accounts a single stream follows.
+	maxStreamAccounts = 100
+	// accountUpdateBuffer is how many updates a stream may fall behind
+	// before it is closed.
+	accountUpdateBuffer = 32
+)
+
+type accountSubscriber struct {
+	accountIDs []int64
+	updates    chan db.AccountUpdate
+	closed     bool
+}
+
+// accountUpdateHub fans the account updates received by this instance out to
+// the streams following those accounts.
+type accountUpdateHub struct {
+	mu          sync.Mutex
+	subscribers map[int64]map[*accountSubscriber]bool
+}
+
+func newAccountUpdateHub() *accountUpdateHub {
+	return &accountUpdateHub{
+		subscribers: make(map[int64]map[*accountSubscriber]bool),
+	}
+}
+
+// subscribe returns the updates of accountIDs and a func ending the
+// subscription. The channel is closed when the subscriber falls too far
+// behind or the hub is reset, so it must reload the balances.
+func (hub *accountUpdateHub) subscribe(accountIDs []int64) (<-chan db.AccountUpdate, func()) {
+	subscriber := &accountSubscriber{
+		accountIDs: accountIDs,
+		updates:    make(chan db.AccountUpdate, accountUpdateBuffer),
+	}
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	for _, id := range accountIDs {
+		if hub.subscribers[id] == nil {
+			hub.subscribers[id] = make(map[*accountSubscriber]bool)
+		}
+		hub.subscribers[id][subscriber] = true
+	}
+
+	return subscriber.updates, func() {
+		hub.mu.Lock()
+		defer hub.mu.Unlock()
+		hub.remove(subscriber)
+	}
+}
+
+// remove must be called with mu held.
+func (hub *accountUpdateHub) remove(subscriber *accountSubscriber) {
+	if subscriber.closed {
+		return
+	}
+
+	for _, id := range subscriber.accountIDs {
+		delete(hub.subscribers[id], subscriber)
+		if len(hub.subscribers[id]) == 0 {
+			delete(hub.subscribers, id)
+		}
+	}
+	subscriber.closed = true
+	close(subscriber.updates)
+}
+
+func (hub *accountUpdateHub) publish(update db.AccountUpdate) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	for subscriber := range hub.subscribers[update.AccountID] {
+		select {
+		case subscriber.updates <- update:
+		default:
+			hub.remove(subscriber)
+		}
+	}
+}
+
+func (hub *accountUpdateHub) reset() {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	for _, subscribers := range hub.subscribers {
+		for subscriber := range subscribers {
+			hub.remove(subscriber)
+		}
+	}
+}
+
+// PublishAccountUpdate sends update to the streams following its account.
+func (server *Server) PublishAccountUpdate(update db.AccountUpdate) {
+	server.accountUpdates.publish(update)
+}
+
+// ResetAccountUpdates ends every stream, so clients reconnect and reload
+// their balances after updates may have been missed.
+func (server *Server) ResetAccountUpdates() {
+	server.accountUpdates.reset()
+}
+
+type accountUpdateResponse struct {
+	AccountID int64           `json:"account_id"`
+	Balance   util.Money      `json:"balance"`
+	Entries   []entryResponse `json:"entries"`
+}
+
+func accountUpdateResp(update db.AccountUpdate) accountUpdateResponse {
+	rsp := accountUpdateResponse{
+		AccountID: update.AccountID,
+		Balance:   util.NewMoney(update.Balance, update.Currency),
+		Entries:   make([]entryResponse, len(update.Entries)),
+	}
+	for i, entry := range update.Entries {
+		rsp.Entries[i] = entryResp(entry, update.Currency)
+	}
+	return rsp
+}
+
+// streamAccountsAPI streams the balance changes of the accounts of the user
+// as server-sent "balance" events. It first sends the current balance of
+// each account, and ends when the token expires or, at the next heartbeat,
+// once the credential is revoked or the user leaves one of the accounts.
+func (server *Server) streamAccountsAPI(c *gin.Context) {
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+	ctx := c.Request.Context()
+
+	accounts, err := server.store.GetListAccounts(ctx, db.GetListAccountsArgs{
+		Owner: authPayload.Username,
+		Limit: maxStreamAccounts,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	accountIDs := make([]int64, len(accounts))
+	for i, account := range accounts {
+		accountIDs[i] = account.ID
+	}
+
+	updates, unsubscribe := server.accountUpdates.subscribe(accountIDs)
+	defer unsubscribe()
+
+	// Reloaded once subscribed, so no update falls between the snapshot and
+	// the stream.
+	accounts, err = server.store.GetAccountsByIDs(ctx, accountIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	for _, account := range accounts {
+		c.SSEvent("balance", accountUpdateResp(db.AccountUpdate{
+			AccountID: account.ID,
+			Balance:   account.Balance,
+			Currency:  account.Currency,
+		}))
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(server.config.AccountStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	expired := time.NewTimer(time.Until(authPayload.ExpiredAt))
+	defer expired.Stop()
+
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			c.SSEvent("balance", accountUpdateResp(update))
+		case <-heartbeat.C:
+			if !server.streamAllowed(c, authPayload.Username, accountIDs) {
+				return
+			}
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+		case <-expired.C:
+			return
+		case <-ctx.Done():
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+// streamAllowed checks again the credential a stream was opened with and
+// that username is still a member of every account it follows.
+func (server *Server) streamAllowed(c *gin.Context, username string, accountIDs []int64) bool {
+	_, _, err := verifyAuthorization(c, server.tokenMaker, server.store)
+	if err != nil {
+		return false
+	}
+
+	accounts, err := server.store.GetListAccounts(c.Request.Context(), db.GetListAccountsArgs{
+		Owner: username,
+		Limit: maxStreamAccounts,
+	})
+	if err != nil {
+		return false
+	}
+
+	members := make(map[int64]bool, len(accounts))
+	for _, account := range accounts {
+		members[account.ID] = true
+	}
+	for _, id := range accountIDs {
+		if !members[id] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "github.com/asshiddiq1306/simple_bank/db/mock"
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// readBalanceEvent reads the next "balance" event of an account stream.
+func readBalanceEvent(t *testing.T, reader *bufio.Reader) map[string]interface{} {
+	event := ""
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\n")
+
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimPrefix(line, "event:")
+		case strings.HasPrefix(line, "data:"):
+			require.Equal(t, "balance", event)
+
+			var rsp map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), &rsp))
+			return rsp
+		}
+	}
+}
+
+func TestStreamAccountsAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	account := createRandomAccount(user.Username)
+	account.Currency = util.USD
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	arg := db.GetListAccountsArgs{Owner: user.Username, Limit: maxStreamAccounts}
+	store.EXPECT().GetListAccounts(gomock.Any(), gomock.Eq(arg)).Times(1).Return([]db.Account{account}, nil)
+	store.EXPECT().GetAccountsByIDs(gomock.Any(), gomock.Eq([]int64{account.ID})).Times(1).Return([]db.Account{account}, nil)
+
+	server := newServerTest(t, store)
+	httpServer := httptest.NewServer(server.router)
+	defer httpServer.Close()
+
+	request, err := http.NewRequest(http.MethodGet, httpServer.URL+"/accounts/stream", nil)
+	require.NoError(t, err)
+	addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(response.Body)
+	snapshot := readBalanceEvent(t, reader)
+	require.Equal(t, float64(account.ID), snapshot["account_id"])
+	require.Equal(t, util.NewMoney(account.Balance, util.USD).String(), snapshot["balance"])
+	require.Empty(t, snapshot["entries"])
+
+	// Updates of other accounts are not streamed.
+	server.PublishAccountUpdate(db.AccountUpdate{AccountID: account.ID + 1, Balance: 1, Currency: util.USD})
+
+	entry := db.Entry{ID: 7, AccountID: account.ID, Amount: 250, CreatedAt: time.Now()}
+	server.PublishAccountUpdate(db.AccountUpdate{
+		AccountID: account.ID,
+		Balance:   account.Balance + entry.Amount,
+		Currency:  util.USD,
+		Entries:   []db.Entry{entry},
+	})
+
+	update := readBalanceEvent(t, reader)
+	require.Equal(t, float64(account.ID), update["account_id"])
+	require.Equal(t, util.NewMoney(account.Balance+entry.Amount, util.USD).String(), update["balance"])
+
+	entries := update["entries"].([]interface{})
+	require.Len(t, entries, 1)
+	require.Equal(t, float64(entry.ID), entries[0].(map[string]interface{})["id"])
+	require.Equal(t, "2.50", entries[0].(map[string]interface{})["amount"])
+}
+
+func TestStreamAccountsAPIRechecksAccess(t *testing.T) {
+	user, _ := createRandomUser(t)
+	account := createRandomAccount(user.Username)
+	account.Currency = util.USD
+	arg := db.GetListAccountsArgs{Owner: user.Username, Limit: maxStreamAccounts}
+
+	apiKey, key := createRandomAPIKey(t, user.Username, util.AccountsReadScope)
+	revokedKey := apiKey
+	revokedKey.IsRevoked = true
+
+	testCases := []struct {
+		name       string
+		setupAuth  func(t *testing.T, request *http.Request, server *Server)
+		buildStubs func(store *mockdb.MockStore)
+	}{
+		{
+			name: "MemberRemoved",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthHeader(t, request, server.tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserPasswordChangedAt(gomock.Any(), gomock.Eq(user.Username)).Times(2).Return(time.Time{}, nil)
+				gomock.InOrder(
+					store.EXPECT().GetListAccounts(gomock.Any(), gomock.Eq(arg)).Times(1).Return([]db.Account{account}, nil),
+					store.EXPECT().GetListAccounts(gomock.Any(), gomock.Eq(arg)).Times(1).Return([]db.Account{}, nil),
+				)
+			},
+		},
+		{
+			name: "APIKeyRevoked",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAPIKeyAuth(request, key)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				gomock.InOrder(
+					store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(apiKey, nil),
+					store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(revokedKey, nil),
+				)
+				store.EXPECT().GetUserPasswordChangedAt(gomock.Any(), gomock.Eq(apiKey.Owner)).Times(1).Return(time.Time{}, nil)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Eq(apiKey.ID)).Times(1).Return(nil)
+				store.EXPECT().GetListAccounts(gomock.Any(), gomock.Eq(arg)).Times(1).Return([]db.Account{account}, nil)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			store.EXPECT().GetAccountsByIDs(gomock.Any(), gomock.Eq([]int64{account.ID})).Times(1).Return([]db.Account{account}, nil)
+
+			server := newServerTest(t, store)
+			server.config.AccountStreamHeartbeat = 10 * time.Millisecond
+			httpServer := httptest.NewServer(server.router)
+			defer httpServer.Close()
+
+			request, err := http.NewRequest(http.MethodGet, httpServer.URL+"/accounts/stream", nil)
+			require.NoError(t, err)
+			tc.setupAuth(t, request, server)
+
+			response, err := http.DefaultClient.Do(request)
+			require.NoError(t, err)
+			defer response.Body.Close()
+			require.Equal(t, http.StatusOK, response.StatusCode)
+
+			reader := bufio.NewReader(response.Body)
+			snapshot := readBalanceEvent(t, reader)
+			require.Equal(t, float64(account.ID), snapshot["account_id"])
+
+			// The stream ends at the first heartbeat, before sending it.
+			rest, err := ioutil.ReadAll(reader)
+			require.NoError(t, err)
+			require.NotContains(t, string(rest), "heartbeat")
+		})
+	}
+}
+
+func TestAccountUpdateHub(t *testing.T) {
+	hub := newAccountUpdateHub()
+
+	updates1, unsubscribe1 := hub.subscribe([]int64{1, 2})
+	updates2, unsubscribe2 := hub.subscribe([]int64{2})
+	defer unsubscribe2()
+
+	hub.publish(db.AccountUpdate{AccountID: 1, Balance: 10})
+	hub.publish(db.AccountUpdate{AccountID: 2, Balance: 20})
+
+	require.Equal(t, int64(10), (<-updates1).Balance)
+	require.Equal(t, int64(20), (<-updates1).Balance)
+	require.Equal(t, int64(20), (<-updates2).Balance)
+
+	unsubscribe1()
+	unsubscribe1()
+	_, ok := <-updates1
+	require.False(t, ok)
+
+	// A subscriber falling too far behind is closed instead of blocking.
+	for i := 0; i <= accountUpdateBuffer; i++ {
+		hub.publish(db.AccountUpdate{AccountID: 2, Balance: int64(i)})
+	}
+	for i := 0; i < accountUpdateBuffer; i++ {
+		<-updates2
+	}
+	_, ok = <-updates2
+	require.False(t, ok)
+	require.Empty(t, hub.subscribers)
+
+	updates3, unsubscribe3 := hub.subscribe([]int64{3})
+	defer unsubscribe3()
+	hub.reset()
+	_, ok = <-updates3
+	require.False(t, ok)
+}
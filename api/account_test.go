@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 	"time"
 
@@ -17,24 +18,51 @@ import (
 	"github.com/asshiddiq1306/simple_bank/util"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 )
 
+type eqCreateNewAccountArgMatcher struct {
+	arg db.CreateNewAccountArgs
+}
+
+func (e eqCreateNewAccountArgMatcher) Matches(x interface{}) bool {
+	arg, ok := x.(db.CreateNewAccountArgs)
+	if !ok {
+		return false
+	}
+
+	if !util.IsValidAccountNumber(arg.AccountNumber) {
+		return false
+	}
+
+	e.arg.AccountNumber = arg.AccountNumber
+	return reflect.DeepEqual(e.arg, arg)
+}
+
+func (e eqCreateNewAccountArgMatcher) String() string {
+	return fmt.Sprintf("arg with valid account number match %v", e.arg)
+}
+
+func EqCreateNewAccountArg(arg db.CreateNewAccountArgs) gomock.Matcher {
+	return eqCreateNewAccountArgMatcher{arg}
+}
+
 func TestCreateNewAccount(t *testing.T) {
 	user, _ := createRandomUser(t)
 	account := createRandomAccount(user.Username)
 
 	testCases := []struct {
 		name          string
-		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		setupAuth     func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker)
 		body          gin.H
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(recorder *httptest.ResponseRecorder)
 	}{
 		{
 			name: "OK",
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuth(t, request, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
 			},
 			body: gin.H{
 				"currency": account.Currency,
@@ -44,24 +72,153 @@ func TestCreateNewAccount(t *testing.T) {
 					Owner:    account.Owner,
 					Balance:  account.Balance,
 					Currency: account.Currency,
+					Type:     util.CheckingAccount,
+				}
+
+				store.EXPECT().CreateAccountTx(gomock.Any(), EqCreateNewAccountArg(arg)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+		},
+		{
+			name: "Savings",
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			},
+			body: gin.H{
+				"currency": util.USD,
+				"type":     util.SavingsAccount,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateNewAccountArgs{
+					Owner:           account.Owner,
+					Currency:        util.USD,
+					Type:            util.SavingsAccount,
+					InterestRateBps: 200,
+				}
+
+				store.EXPECT().CreateAccountTx(gomock.Any(), EqCreateNewAccountArg(arg)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "AccountNumberTaken",
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			},
+			body: gin.H{
+				"currency": account.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateNewAccountArgs{
+					Owner:    account.Owner,
+					Currency: account.Currency,
+					Type:     util.CheckingAccount,
 				}
 
-				store.EXPECT().CreateNewAccount(gomock.Any(), gomock.Eq(arg)).Times(1).Return(account, nil)
+				taken := &pq.Error{Code: "23505", Constraint: db.AccountNumberConstraint}
+				gomock.InOrder(
+					store.EXPECT().CreateAccountTx(gomock.Any(), EqCreateNewAccountArg(arg)).Times(1).Return(db.Account{}, taken),
+					store.EXPECT().CreateAccountTx(gomock.Any(), EqCreateNewAccountArg(arg)).Times(1).Return(account, nil),
+				)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
 				requireBodyMatchAccount(t, recorder.Body, account)
 			},
 		},
+		{
+			name: "AccountNumbersExhausted",
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			},
+			body: gin.H{
+				"currency": account.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				taken := &pq.Error{Code: "23505", Constraint: db.AccountNumberConstraint}
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(accountNumberAttempts).Return(db.Account{}, taken)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "DuplicateAccount",
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			},
+			body: gin.H{
+				"currency": account.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				duplicate := &pq.Error{Code: "23505", Constraint: db.AccountOwnerCurrencyTypeConstraint}
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, duplicate)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "SavingsNotOffered",
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			},
+			body: gin.H{
+				"currency": util.IDR,
+				"type":     util.SavingsAccount,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "CurrencyDisabled",
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			},
+			body: gin.H{
+				"currency": disabledCurrency.Code,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidType",
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			},
+			body: gin.H{
+				"currency": util.USD,
+				"type":     "brokerage",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
 		{
 			name: "NoAuthorization",
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
 			},
 			body: gin.H{
 				"currency": account.Currency,
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				store.EXPECT().CreateNewAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -69,14 +226,14 @@ func TestCreateNewAccount(t *testing.T) {
 		},
 		{
 			name: "ExpiredAuth",
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuth(t, request, tokenMaker, authorizationBearerTypeKey, user.Username, -time.Minute)
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, user.Username, -time.Minute)
 			},
 			body: gin.H{
 				"currency": account.Currency,
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				store.EXPECT().CreateNewAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -84,14 +241,14 @@ func TestCreateNewAccount(t *testing.T) {
 		},
 		{
 			name: "BadRequest",
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuth(t, request, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
 			},
 			body: gin.H{
 				"currency": "unsupported",
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				store.EXPECT().CreateNewAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
@@ -99,14 +256,14 @@ func TestCreateNewAccount(t *testing.T) {
 		},
 		{
 			name: "InternalError",
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuth(t, request, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
 			},
 			body: gin.H{
 				"currency": account.Currency,
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				store.EXPECT().CreateNewAccount(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, sql.ErrConnDone)
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, sql.ErrConnDone)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
@@ -134,7 +291,7 @@ func TestCreateNewAccount(t *testing.T) {
 			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
 			require.NoError(t, err)
 
-			tc.setupAuth(t, request, server.tokenMaker)
+			tc.setupAuth(t, request, store, server.tokenMaker)
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(recorder)
 		})
@@ -147,15 +304,15 @@ func TestGetAccountByIDAPI(t *testing.T) {
 
 	testCases := []struct {
 		name          string
-		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		setupAuth     func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker)
 		accountID     int64
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(recorder *httptest.ResponseRecorder)
 	}{
 		{
 			name: "OK",
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuth(t, request, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
 			},
 			accountID: account1.ID,
 			buildStubs: func(store *mockdb.MockStore) {
@@ -168,7 +325,7 @@ func TestGetAccountByIDAPI(t *testing.T) {
 		},
 		{
 			name: "NoAuthorization",
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
 			},
 			accountID: account1.ID,
 			buildStubs: func(store *mockdb.MockStore) {
@@ -180,8 +337,8 @@ func TestGetAccountByIDAPI(t *testing.T) {
 		},
 		{
 			name: "ExpiredToken",
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuth(t, request, tokenMaker, authorizationBearerTypeKey, user.Username, -time.Minute)
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, user.Username, -time.Minute)
 			},
 			accountID: account1.ID,
 			buildStubs: func(store *mockdb.MockStore) {
@@ -193,8 +350,8 @@ func TestGetAccountByIDAPI(t *testing.T) {
 		},
 		{
 			name: "BadRequest",
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuth(t, request, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
 			},
 			accountID: 0,
 			buildStubs: func(store *mockdb.MockStore) {
@@ -206,8 +363,8 @@ func TestGetAccountByIDAPI(t *testing.T) {
 		},
 		{
 			name: "InternalError",
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuth(t, request, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
 			},
 			accountID: account1.ID,
 			buildStubs: func(store *mockdb.MockStore) {
@@ -219,8 +376,8 @@ func TestGetAccountByIDAPI(t *testing.T) {
 		},
 		{
 			name: "AccountNotFound",
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuth(t, request, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
 			},
 			accountID: account1.ID,
 			buildStubs: func(store *mockdb.MockStore) {
@@ -249,7 +406,7 @@ func TestGetAccountByIDAPI(t *testing.T) {
 			request, err := http.NewRequest(http.MethodGet, url, nil)
 			require.NoError(t, err)
 
-			tc.setupAuth(t, request, server.tokenMaker)
+			tc.setupAuth(t, request, store, server.tokenMaker)
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(recorder)
 
@@ -257,6 +414,75 @@ func TestGetAccountByIDAPI(t *testing.T) {
 	}
 }
 
+func TestGetAccountByNumberAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	account := createRandomAccount(user.Username)
+
+	testCases := []struct {
+		name          string
+		accountID     string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			accountID: account.AccountNumber,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account.AccountNumber)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountByID(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+		},
+		{
+			name:      "AccountNotFound",
+			accountID: account.AccountNumber,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account.AccountNumber)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "BadChecksum",
+			accountID: badChecksumAccountNumber(account.AccountNumber),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetAccountByID(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/account/%s", tc.accountID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
 func TestGetListAccounts(t *testing.T) {
 	n := 5
 	accounts := make([]db.Account, n)
@@ -273,7 +499,7 @@ func TestGetListAccounts(t *testing.T) {
 	testCases := []struct {
 		name          string
 		query         Query
-		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		setupAuth     func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker)
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(recorder *httptest.ResponseRecorder)
 	}{
@@ -283,8 +509,8 @@ func TestGetListAccounts(t *testing.T) {
 				PageID:   1,
 				PageSize: n,
 			},
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuth(t, request, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				arg := db.GetListAccountsArgs{
@@ -305,7 +531,7 @@ func TestGetListAccounts(t *testing.T) {
 				PageID:   1,
 				PageSize: n,
 			},
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetListAccounts(gomock.Any(), gomock.Any()).Times(0)
@@ -320,8 +546,8 @@ func TestGetListAccounts(t *testing.T) {
 				PageID:   1,
 				PageSize: n,
 			},
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuth(t, request, tokenMaker, authorizationBearerTypeKey, user.Username, -time.Minute)
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, user.Username, -time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetListAccounts(gomock.Any(), gomock.Any()).Times(0)
@@ -336,8 +562,8 @@ func TestGetListAccounts(t *testing.T) {
 				PageID:   1,
 				PageSize: n,
 			},
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuth(t, request, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetListAccounts(gomock.Any(), gomock.Any()).Times(1).Return([]db.Account{}, sql.ErrConnDone)
@@ -352,8 +578,8 @@ func TestGetListAccounts(t *testing.T) {
 				PageID:   0,
 				PageSize: n,
 			},
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuth(t, request, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetListAccounts(gomock.Any(), gomock.Any()).Times(0)
@@ -386,7 +612,7 @@ func TestGetListAccounts(t *testing.T) {
 			q.Add("page_size", fmt.Sprintf("%d", tc.query.PageSize))
 			request.URL.RawQuery = q.Encode()
 
-			tc.setupAuth(t, request, server.tokenMaker)
+			tc.setupAuth(t, request, store, server.tokenMaker)
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(recorder)
 		})
@@ -394,22 +620,36 @@ func TestGetListAccounts(t *testing.T) {
 }
 
 func createRandomAccount(owner string) db.Account {
+	accountNumber, err := util.GenerateAccountNumber()
+	if err != nil {
+		panic(err)
+	}
+
 	return db.Account{
-		ID:       util.RandomInt(1, 1000),
-		Owner:    owner,
-		Balance:  0,
-		Currency: util.RandomCurrency(),
+		ID:            util.RandomInt(1, 1000),
+		Owner:         owner,
+		Balance:       0,
+		Currency:      util.RandomCurrency(),
+		AccountNumber: accountNumber,
+	}
+}
+
+// badChecksumAccountNumber returns number with its check digits changed.
+func badChecksumAccountNumber(number string) string {
+	check := number[2:4]
+	if check == "98" {
+		check = "97"
+	} else {
+		check = "98"
 	}
+	return number[:2] + check + number[4:]
 }
 
 func requireBodyMatchAccount(t *testing.T, body *bytes.Buffer, account db.Account) {
 	data, err := ioutil.ReadAll(body)
 	require.NoError(t, err)
 
-	var getAccount db.Account
-	err = json.Unmarshal(data, &getAccount)
+	expected, err := json.Marshal(accountResp(account))
 	require.NoError(t, err)
-	require.Equal(t, account.Owner, getAccount.Owner)
-	require.Equal(t, account.Balance, getAccount.Balance)
-	require.Equal(t, account.Currency, getAccount.Currency)
+	require.JSONEq(t, string(expected), string(data))
 }
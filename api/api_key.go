@@ -0,0 +1,160 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/token"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+)
+
+type createAPIKeyReq struct {
+	Name          string   `json:"name" binding:"required,max=64"`
+	Scopes        []string `json:"scopes" binding:"required,min=1,dive,scope"`
+	ExpiresInDays int32    `json:"expires_in_days" binding:"omitempty,min=1,max=365"`
+}
+
+type apiKeyResp struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	IsRevoked  bool       `json:"is_revoked"`
+	ExpiredAt  time.Time  `json:"expired_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func newAPIKeyResp(apiKey db.APIKey) apiKeyResp {
+	rsp := apiKeyResp{
+		ID:        apiKey.ID,
+		Name:      apiKey.Name,
+		Prefix:    apiKey.Prefix,
+		Scopes:    apiKey.Scopes,
+		IsRevoked: apiKey.IsRevoked,
+		ExpiredAt: apiKey.ExpiredAt,
+		CreatedAt: apiKey.CreatedAt,
+	}
+	if apiKey.LastUsedAt.Valid {
+		rsp.LastUsedAt = &apiKey.LastUsedAt.Time
+	}
+	return rsp
+}
+
+type createAPIKeyResp struct {
+	Key    string     `json:"key"`
+	APIKey apiKeyResp `json:"api_key"`
+}
+
+func (server *Server) createAPIKeyAPI(c *gin.Context) {
+	var req createAPIKeyReq
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+
+	prefix, key, err := util.GenerateAPIKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	duration := server.config.APIKeyDuration
+	if req.ExpiresInDays > 0 {
+		duration = time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	}
+
+	arg := db.CreateNewAPIKeyArgs{
+		Owner:     authPayload.Username,
+		Name:      req.Name,
+		Prefix:    prefix,
+		KeyHash:   util.HashSecureToken(key),
+		Scopes:    req.Scopes,
+		ExpiredAt: time.Now().Add(duration),
+	}
+
+	apiKey, err := server.store.CreateNewAPIKey(c.Request.Context(), arg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := createAPIKeyResp{
+		Key:    key,
+		APIKey: newAPIKeyResp(apiKey),
+	}
+
+	c.JSON(http.StatusOK, rsp)
+}
+
+type getListAPIKeysReq struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (server *Server) getListAPIKeysAPI(c *gin.Context) {
+	var req getListAPIKeysReq
+	err := c.ShouldBindQuery(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+
+	arg := db.GetListAPIKeysArgs{
+		Owner:  authPayload.Username,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	}
+
+	apiKeys, err := server.store.GetListAPIKeys(c.Request.Context(), arg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := []apiKeyResp{}
+	for _, apiKey := range apiKeys {
+		rsp = append(rsp, newAPIKeyResp(apiKey))
+	}
+
+	c.JSON(http.StatusOK, rsp)
+}
+
+type revokeAPIKeyReq struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) revokeAPIKeyAPI(c *gin.Context) {
+	var req revokeAPIKeyReq
+	err := c.ShouldBindUri(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+
+	apiKey, err := server.store.RevokeAPIKey(c.Request.Context(), db.RevokeAPIKeyArgs{
+		ID:    req.ID,
+		Owner: authPayload.Username,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, newAPIKeyResp(apiKey))
+}
@@ -0,0 +1,256 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/asshiddiq1306/simple_bank/db/mock"
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/token"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAPIKeyAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	name := util.RandomName()
+	scopes := []string{util.AccountsReadScope, util.TransfersWriteScope}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"name":            name,
+				"scopes":          scopes,
+				"expires_in_days": 30,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateNewAPIKey(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateNewAPIKeyArgs) (db.APIKey, error) {
+						require.Equal(t, user.Username, arg.Owner)
+						require.Equal(t, name, arg.Name)
+						require.Equal(t, scopes, arg.Scopes)
+						require.NotEmpty(t, arg.Prefix)
+						require.NotEmpty(t, arg.KeyHash)
+						require.WithinDuration(t, time.Now().Add(30*24*time.Hour), arg.ExpiredAt, time.Second)
+						return db.APIKey{
+							ID:        1,
+							Owner:     arg.Owner,
+							Name:      arg.Name,
+							Prefix:    arg.Prefix,
+							KeyHash:   arg.KeyHash,
+							Scopes:    arg.Scopes,
+							ExpiredAt: arg.ExpiredAt,
+						}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp createAPIKeyResp
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+
+				prefix, ok := util.ParseAPIKeyPrefix(rsp.Key)
+				require.True(t, ok)
+				require.Equal(t, prefix, rsp.APIKey.Prefix)
+				require.Equal(t, scopes, rsp.APIKey.Scopes)
+				require.NotContains(t, recorder.Body.String(), "key_hash")
+			},
+		},
+		{
+			name: "UnsupportedScope",
+			body: gin.H{
+				"name":   name,
+				"scopes": []string{"accounts:delete"},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateNewAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NoScopes",
+			body: gin.H{
+				"name":   name,
+				"scopes": []string{},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateNewAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "APIKeyAuth",
+			body: gin.H{
+				"name":   name,
+				"scopes": scopes,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAPIKeyAuth(request, "sbk_prefix.secret")
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				apiKey := db.APIKey{
+					ID:        1,
+					Owner:     user.Username,
+					Prefix:    "prefix",
+					KeyHash:   util.HashSecureToken("sbk_prefix.secret"),
+					Scopes:    scopes,
+					ExpiredAt: time.Now().Add(time.Hour),
+				}
+				store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Eq("prefix")).Times(1).Return(apiKey, nil)
+				store.EXPECT().GetUserPasswordChangedAt(gomock.Any(), gomock.Eq(apiKey.Owner)).Times(1).Return(time.Time{}, nil)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Eq(apiKey.ID)).Times(1).Return(nil)
+				store.EXPECT().CreateNewAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			body: gin.H{
+				"name":   name,
+				"scopes": scopes,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateNewAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/api_keys"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, store, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestRevokeAPIKeyAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	apiKey, _ := createRandomAPIKey(t, user.Username, util.AccountsReadScope)
+
+	testCases := []struct {
+		name          string
+		id            int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			id:   apiKey.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.RevokeAPIKeyArgs{
+					ID:    apiKey.ID,
+					Owner: user.Username,
+				}
+				revoked := apiKey
+				revoked.IsRevoked = true
+				store.EXPECT().RevokeAPIKey(gomock.Any(), gomock.Eq(arg)).Times(1).Return(revoked, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp apiKeyResp
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.True(t, rsp.IsRevoked)
+			},
+		},
+		{
+			name: "NotFound",
+			id:   apiKey.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RevokeAPIKey(gomock.Any(), gomock.Any()).Times(1).Return(db.APIKey{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidID",
+			id:   0,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RevokeAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api_keys/%d", tc.id)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+func (server *Server) getListCurrenciesAPI(c *gin.Context) {
+	currencies, err := server.store.GetListCurrencies(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, currencies)
+}
+
+type createCurrencyReq struct {
+	Code       string `json:"code" binding:"required,len=3,alpha,uppercase"`
+	MinorUnits *int32 `json:"minor_units" binding:"required,min=0,max=4"`
+	Enabled    *bool  `json:"enabled"`
+}
+
+func (server *Server) createCurrencyAPI(c *gin.Context) {
+	var req createCurrencyReq
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var accountNumbers [2]string
+	for i := range accountNumbers {
+		accountNumbers[i], err = util.GenerateAccountNumber()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	arg := db.CreateCurrencyTxArg{
+		Currency: db.CreateCurrencyArgs{
+			Code:       req.Code,
+			MinorUnits: *req.MinorUnits,
+			Enabled:    req.Enabled == nil || *req.Enabled,
+		},
+		FeeAccountNumber:             accountNumbers[0],
+		InterestExpenseAccountNumber: accountNumbers[1],
+	}
+
+	result, err := server.store.CreateCurrencyTx(c.Request.Context(), arg)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code.Name() {
+			case "unique_violation":
+				c.JSON(http.StatusForbidden, errorResponse(err))
+				return
+			}
+		}
+
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	cacheCurrency(result.Currency)
+	c.JSON(http.StatusOK, result.Currency)
+}
+
+type updateCurrencyURI struct {
+	Code string `uri:"code" binding:"required,len=3"`
+}
+
+type updateCurrencyReq struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// updateCurrencyAPI enables or disables a currency. Disabling only stops new
+// accounts from being opened in it, existing balances can still be moved.
+func (server *Server) updateCurrencyAPI(c *gin.Context) {
+	var uri updateCurrencyURI
+	err := c.ShouldBindUri(&uri)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateCurrencyReq
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.UpdateCurrencyEnabledArgs{
+		Code:    uri.Code,
+		Enabled: *req.Enabled,
+	}
+
+	currency, err := server.store.UpdateCurrencyEnabled(c.Request.Context(), arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	cacheCurrency(currency)
+	c.JSON(http.StatusOK, currency)
+}
+
+// cacheCurrency applies a change to this replica right away, the others
+// pick it up on their next refresh.
+func cacheCurrency(currency db.Currency) {
+	util.SetCurrency(util.Currency{
+		Code:       currency.Code,
+		MinorUnits: int(currency.MinorUnits),
+		Enabled:    currency.Enabled,
+	})
+}
@@ -0,0 +1,259 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	mockdb "github.com/asshiddiq1306/simple_bank/db/mock"
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+type eqCreateCurrencyTxArgMatcher struct {
+	arg db.CreateCurrencyArgs
+}
+
+func (e eqCreateCurrencyTxArgMatcher) Matches(x interface{}) bool {
+	arg, ok := x.(db.CreateCurrencyTxArg)
+	if !ok {
+		return false
+	}
+
+	if !util.IsValidAccountNumber(arg.FeeAccountNumber) || !util.IsValidAccountNumber(arg.InterestExpenseAccountNumber) {
+		return false
+	}
+
+	return arg.FeeAccountNumber != arg.InterestExpenseAccountNumber && reflect.DeepEqual(e.arg, arg.Currency)
+}
+
+func (e eqCreateCurrencyTxArgMatcher) String() string {
+	return fmt.Sprintf("currency %v with valid system account numbers", e.arg)
+}
+
+func EqCreateCurrencyTxArg(arg db.CreateCurrencyArgs) gomock.Matcher {
+	return eqCreateCurrencyTxArgMatcher{arg}
+}
+
+func TestCreateCurrencyAPI(t *testing.T) {
+	admin, _ := createRandomUser(t)
+	admin.Role = util.AdminRole
+	user, _ := createRandomUser(t)
+
+	// XXA is not an ISO 4217 code, it only lives in this test.
+	currency := db.Currency{Code: "XXA", MinorUnits: 3, Enabled: false}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"code":        currency.Code,
+				"minor_units": currency.MinorUnits,
+				"enabled":     currency.Enabled,
+			},
+			username: admin.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateCurrencyArgs{
+					Code:       currency.Code,
+					MinorUnits: currency.MinorUnits,
+					Enabled:    currency.Enabled,
+				}
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().CreateCurrencyTx(gomock.Any(), EqCreateCurrencyTxArg(arg)).Times(1).Return(db.CreateCurrencyTxResult{Currency: currency}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				cached, ok := util.LookupCurrency(currency.Code)
+				require.True(t, ok)
+				require.Equal(t, 3, cached.MinorUnits)
+				require.True(t, util.IsSupportedCurrency(currency.Code))
+				require.False(t, util.IsEnabledCurrency(currency.Code))
+			},
+		},
+		{
+			name: "Duplicate",
+			body: gin.H{
+				"code":        "XXB",
+				"minor_units": 0,
+			},
+			username: admin.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateCurrencyArgs{Code: "XXB", MinorUnits: 0, Enabled: true}
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().CreateCurrencyTx(gomock.Any(), EqCreateCurrencyTxArg(arg)).Times(1).Return(db.CreateCurrencyTxResult{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "NotAdmin",
+			body: gin.H{
+				"code":        currency.Code,
+				"minor_units": currency.MinorUnits,
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().CreateCurrencyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "MissingMinorUnits",
+			body: gin.H{
+				"code": currency.Code,
+			},
+			username: admin.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().CreateCurrencyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidCode",
+			body: gin.H{
+				"code":        "usd",
+				"minor_units": 2,
+			},
+			username: admin.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().CreateCurrencyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/currencies", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestUpdateCurrencyAPI(t *testing.T) {
+	admin, _ := createRandomUser(t)
+	admin.Role = util.AdminRole
+
+	// XXC is not an ISO 4217 code, it only lives in this test.
+	util.SetCurrency(util.Currency{Code: "XXC", MinorUnits: 2, Enabled: true})
+
+	testCases := []struct {
+		name          string
+		code          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Disable",
+			code: "XXC",
+			body: gin.H{"enabled": false},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpdateCurrencyEnabledArgs{Code: "XXC", Enabled: false}
+				store.EXPECT().UpdateCurrencyEnabled(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.Currency{Code: "XXC", MinorUnits: 2, Enabled: false}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				// Existing balances can still be moved.
+				require.True(t, util.IsSupportedCurrency("XXC"))
+				require.False(t, util.IsEnabledCurrency("XXC"))
+			},
+		},
+		{
+			name: "NotFound",
+			code: "XXD",
+			body: gin.H{"enabled": true},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateCurrencyEnabled(gomock.Any(), gomock.Any()).Times(1).Return(db.Currency{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "MissingEnabled",
+			code: "XXC",
+			body: gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateCurrencyEnabled(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/currencies/%s", tc.code)
+			request, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, admin.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
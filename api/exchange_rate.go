@@ -0,0 +1,49 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+)
+
+var errSameCurrency = errors.New("currency must differ from base_currency")
+
+type createExchangeRateReq struct {
+	BaseCurrency string `json:"base_currency" binding:"required,currency"`
+	Currency     string `json:"currency" binding:"required,currency"`
+	Rate         string `json:"rate" binding:"required,exchange_rate"`
+}
+
+// createExchangeRateAPI records how many units of base_currency one unit of
+// currency is worth. The latest rate of a pair is the one used.
+func (server *Server) createExchangeRateAPI(c *gin.Context) {
+	var req createExchangeRateReq
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.BaseCurrency == req.Currency {
+		c.JSON(http.StatusBadRequest, errorResponse(errSameCurrency))
+		return
+	}
+
+	arg := db.CreateExchangeRateArgs{
+		BaseCurrency: req.BaseCurrency,
+		Currency:     req.Currency,
+		Rate:         req.Rate,
+	}
+
+	rate, err := server.store.CreateExchangeRate(c.Request.Context(), arg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rate.Rate = util.TrimExchangeRate(rate.Rate)
+	c.JSON(http.StatusOK, rate)
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/asshiddiq1306/simple_bank/db/mock"
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateExchangeRateAPI(t *testing.T) {
+	admin, _ := createRandomUser(t)
+	admin.Role = util.AdminRole
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"base_currency": util.USD, "currency": util.EUR, "rate": "1.0823"},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateExchangeRateArgs{BaseCurrency: util.USD, Currency: util.EUR, Rate: "1.0823"}
+				store.EXPECT().CreateExchangeRate(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.ExchangeRate{ID: 1, BaseCurrency: util.USD, Currency: util.EUR, Rate: "1.0823000000"}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), `"rate":"1.0823"`)
+			},
+		},
+		{
+			name: "SameCurrency",
+			body: gin.H{"base_currency": util.USD, "currency": util.USD, "rate": "1"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateExchangeRate(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errSameCurrency)
+			},
+		},
+		{
+			name: "InvalidRate",
+			body: gin.H{"base_currency": util.USD, "currency": util.EUR, "rate": "0"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateExchangeRate(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/exchange_rates", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, admin.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+)
+
+var errNegativeFee = errors.New("min_amount, flat_fee and max_fee must not be negative")
+
+type feeScheduleResponse struct {
+	ID        int64      `json:"id"`
+	Currency  string     `json:"currency"`
+	MinAmount util.Money `json:"min_amount"`
+	FlatFee   util.Money `json:"flat_fee"`
+	RateBps   int32      `json:"rate_bps"`
+	MaxFee    util.Money `json:"max_fee"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func feeScheduleResp(schedule db.FeeSchedule) feeScheduleResponse {
+	return feeScheduleResponse{
+		ID:        schedule.ID,
+		Currency:  schedule.Currency,
+		MinAmount: util.NewMoney(schedule.MinAmount, schedule.Currency),
+		FlatFee:   util.NewMoney(schedule.FlatFee, schedule.Currency),
+		RateBps:   schedule.RateBps,
+		MaxFee:    util.NewMoney(schedule.MaxFee, schedule.Currency),
+		CreatedAt: schedule.CreatedAt,
+	}
+}
+
+type upsertFeeScheduleReq struct {
+	Currency  string `json:"currency" binding:"required,currency"`
+	MinAmount string `json:"min_amount"`
+	FlatFee   string `json:"flat_fee"`
+	RateBps   int32  `json:"rate_bps" binding:"min=0,max=10000"`
+	MaxFee    string `json:"max_fee"`
+}
+
+// parseFeeAmounts parses the decimal amounts of req, an empty one being zero.
+func parseFeeAmounts(req upsertFeeScheduleReq) (minAmount, flatFee, maxFee int64, err error) {
+	amounts := []*int64{&minAmount, &flatFee, &maxFee}
+	for i, value := range []string{req.MinAmount, req.FlatFee, req.MaxFee} {
+		if value == "" {
+			continue
+		}
+
+		money, err := util.ParseMoney(value, req.Currency)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		if money.Amount < 0 {
+			return 0, 0, 0, errNegativeFee
+		}
+		*amounts[i] = money.Amount
+	}
+	return minAmount, flatFee, maxFee, nil
+}
+
+// upsertFeeScheduleAPI creates the tier of a currency starting at
+// min_amount, or replaces its fees when it already exists.
+func (server *Server) upsertFeeScheduleAPI(c *gin.Context) {
+	var req upsertFeeScheduleReq
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	minAmount, flatFee, maxFee, err := parseFeeAmounts(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.UpsertFeeScheduleArgs{
+		Currency:  req.Currency,
+		MinAmount: minAmount,
+		FlatFee:   flatFee,
+		RateBps:   req.RateBps,
+		MaxFee:    maxFee,
+	}
+
+	schedule, err := server.store.UpsertFeeSchedule(c.Request.Context(), arg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, feeScheduleResp(schedule))
+}
+
+type getListFeeSchedulesReq struct {
+	Currency string `form:"currency" binding:"omitempty,currency"`
+}
+
+func (server *Server) getListFeeSchedulesAPI(c *gin.Context) {
+	var req getListFeeSchedulesReq
+	err := c.ShouldBindQuery(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	schedules, err := server.store.GetListFeeSchedules(c.Request.Context(), req.Currency)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]feeScheduleResponse, len(schedules))
+	for i, schedule := range schedules {
+		rsp[i] = feeScheduleResp(schedule)
+	}
+
+	c.JSON(http.StatusOK, rsp)
+}
+
+type deleteFeeScheduleReq struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) deleteFeeScheduleAPI(c *gin.Context) {
+	var req deleteFeeScheduleReq
+	err := c.ShouldBindUri(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	schedule, err := server.store.DeleteFeeSchedule(c.Request.Context(), req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, feeScheduleResp(schedule))
+}
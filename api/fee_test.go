@@ -0,0 +1,269 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/asshiddiq1306/simple_bank/db/mock"
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuoteTransferFeeAPI(t *testing.T) {
+	user1, _ := createRandomUser(t)
+	user2, _ := createRandomUser(t)
+
+	account1 := createRandomAccount(user1.Username)
+	account2 := createRandomAccount(user2.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+
+	amount := int64(100)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          util.NewMoney(amount, util.USD).String(),
+				"currency":        util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByID(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccountByID(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				arg := db.TransferFeeArg{
+					FromOwner: user1.Username,
+					ToOwner:   user2.Username,
+					Currency:  util.USD,
+					Amount:    amount,
+				}
+				store.EXPECT().QuoteTransferFee(gomock.Any(), gomock.Eq(arg)).Times(1).Return(int64(7), nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				expected, err := json.Marshal(transferQuoteResponse{
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Currency:      util.USD,
+					Amount:        util.NewMoney(amount, util.USD),
+					Fee:           util.NewMoney(7, util.USD),
+					Total:         util.NewMoney(amount+7, util.USD),
+				})
+				require.NoError(t, err)
+				require.JSONEq(t, string(expected), recorder.Body.String())
+				require.Contains(t, recorder.Body.String(), `"total":"1.07"`)
+			},
+		},
+		{
+			name: "NotOwner",
+			body: gin.H{
+				"from_account_id": account2.ID,
+				"to_account_id":   account1.ID,
+				"amount":          util.NewMoney(amount, util.USD).String(),
+				"currency":        util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByID(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountMember{}, sql.ErrNoRows)
+				store.EXPECT().QuoteTransferFee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          util.NewMoney(amount, util.USD).String(),
+				"currency":        util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByID(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccountByID(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().QuoteTransferFee(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfer/quote", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, user1.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestUpsertFeeScheduleAPI(t *testing.T) {
+	admin, _ := createRandomUser(t)
+	admin.Role = util.AdminRole
+	user, _ := createRandomUser(t)
+
+	schedule := db.FeeSchedule{
+		ID:        1,
+		Currency:  util.USD,
+		MinAmount: 1000,
+		FlatFee:   5,
+		RateBps:   25,
+		MaxFee:    100,
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"currency":   schedule.Currency,
+				"min_amount": "10.00",
+				"flat_fee":   "0.05",
+				"rate_bps":   schedule.RateBps,
+				"max_fee":    "1",
+			},
+			username: admin.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpsertFeeScheduleArgs{
+					Currency:  schedule.Currency,
+					MinAmount: schedule.MinAmount,
+					FlatFee:   schedule.FlatFee,
+					RateBps:   schedule.RateBps,
+					MaxFee:    schedule.MaxFee,
+				}
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().UpsertFeeSchedule(gomock.Any(), gomock.Eq(arg)).Times(1).Return(schedule, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), `"min_amount":"10.00"`)
+			},
+		},
+		{
+			name: "NotAdmin",
+			body: gin.H{
+				"currency": schedule.Currency,
+				"flat_fee": "0.05",
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UpsertFeeSchedule(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidPrecision",
+			body: gin.H{
+				"currency": schedule.Currency,
+				"flat_fee": "0.005",
+			},
+			username: admin.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().UpsertFeeSchedule(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NegativeFee",
+			body: gin.H{
+				"currency": schedule.Currency,
+				"flat_fee": "-0.05",
+			},
+			username: admin.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().UpsertFeeSchedule(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidRate",
+			body: gin.H{
+				"currency": schedule.Currency,
+				"rate_bps": 10001,
+			},
+			username: admin.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().UpsertFeeSchedule(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPut, "/fee_schedules", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/asshiddiq1306/simple_bank/token"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	requestIDHeaderKey = "X-Request-ID"
+	maxRequestIDLength = 128
+)
+
+func requestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader(requestIDHeaderKey)
+		if !isValidRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		c.Request = c.Request.WithContext(util.WithRequestID(c.Request.Context(), requestID))
+		c.Header(requestIDHeaderKey, requestID)
+
+		c.Next()
+
+		status := c.Writer.Status()
+
+		var event *zerolog.Event
+		switch {
+		case status >= http.StatusInternalServerError:
+			event = log.Error()
+		case status >= http.StatusBadRequest:
+			event = log.Warn()
+		default:
+			event = log.Info()
+		}
+
+		if payload, ok := c.Get(authorizationPayloadKey); ok {
+			event = event.Str("username", payload.(*token.AuthPay).Username)
+		}
+
+		if spanContext := trace.SpanContextFromContext(c.Request.Context()); spanContext.HasTraceID() {
+			event = event.Str("trace_id", spanContext.TraceID().String())
+		}
+
+		if len(c.Errors) > 0 {
+			event = event.Str("errors", c.Errors.String())
+		}
+
+		event.
+			Str("request_id", requestID).
+			Str("method", c.Request.Method).
+			Str("path", c.Request.URL.Path).
+			Str("client_ip", c.ClientIP()).
+			Int("status_code", status).
+			Dur("duration", time.Since(start)).
+			Msg("received HTTP request")
+	}
+}
+
+// isValidRequestID only accepts short printable IDs from clients so that a
+// caller cannot inject arbitrary content into the logs.
+func isValidRequestID(requestID string) bool {
+	if len(requestID) == 0 || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for _, r := range requestID {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
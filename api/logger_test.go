@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mockdb "github.com/asshiddiq1306/simple_bank/db/mock"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLogger(t *testing.T) {
+	testCases := []struct {
+		name          string
+		requestID     string
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, requestID string)
+	}{
+		{
+			name:      "AcceptRequestID",
+			requestID: "client-request-1",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, requestID string) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "client-request-1", recorder.Header().Get(requestIDHeaderKey))
+				require.Equal(t, "client-request-1", requestID)
+			},
+		},
+		{
+			name:      "GenerateRequestID",
+			requestID: "",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, requestID string) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				_, err := uuid.Parse(requestID)
+				require.NoError(t, err)
+				require.Equal(t, requestID, recorder.Header().Get(requestIDHeaderKey))
+			},
+		},
+		{
+			name:      "InvalidRequestID",
+			requestID: strings.Repeat("a", maxRequestIDLength+1),
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, requestID string) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				_, err := uuid.Parse(requestID)
+				require.NoError(t, err)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			var requestID string
+			path := "/request_id"
+			server.router.GET(path, func(c *gin.Context) {
+				requestID = util.RequestIDFromContext(c.Request.Context())
+				c.JSON(http.StatusOK, gin.H{})
+			})
+
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+			request.Header.Set(requestIDHeaderKey, tc.requestID)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder, requestID)
+		})
+	}
+}
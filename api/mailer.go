@@ -0,0 +1,29 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/asshiddiq1306/simple_bank/mail"
+)
+
+const (
+	mailerSMTP   = "smtp"
+	mailerFile   = "file"
+	mailerMemory = "memory"
+)
+
+func (server *Server) newMailer() (mail.Mailer, error) {
+	config := server.config
+
+	switch config.Mailer {
+	case "":
+		return nil, fmt.Errorf("mailer is not set : must be one of %s, %s or %s", mailerSMTP, mailerFile, mailerMemory)
+	case mailerMemory:
+		return mail.NewMemoryMailer(), nil
+	case mailerFile:
+		return mail.NewFileMailer(config.MailDir, config.MailSender)
+	case mailerSMTP:
+		return mail.NewSMTPMailer(config.SMTPHost, config.SMTPPort, config.SMTPUsername, config.SMTPPassword, config.MailSender), nil
+	}
+	return nil, fmt.Errorf("unsupported mailer %s", config.Mailer)
+}
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/asshiddiq1306/simple_bank/mail"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMailer(t *testing.T) {
+	server := &Server{config: util.Config{Mailer: mailerMemory}}
+	mailer, err := server.newMailer()
+	require.NoError(t, err)
+	require.IsType(t, &mail.MemoryMailer{}, mailer)
+
+	server.config.Mailer = ""
+	_, err = server.newMailer()
+	require.Error(t, err)
+
+	server.config.Mailer = "pigeon"
+	_, err = server.newMailer()
+	require.Error(t, err)
+}
@@ -13,15 +13,29 @@ import (
 
 func newServerTest(t *testing.T, store db.Store) *Server {
 	config := util.Config{
-		TokenSymmetricKey:   util.RandomString(32),
-		TokenAccessDuration: time.Minute,
+		TokenSymmetricKey:      util.RandomString(32),
+		TokenAccessDuration:    time.Minute,
+		TOTPEncryptionKey:      util.RandomString(32),
+		WebhookEncryptionKey:   util.RandomString(32),
+		APIKeyDuration:         time.Hour,
+		OAuthCodeDuration:      time.Minute,
+		OAuthTokenDuration:     time.Minute,
+		AccountStreamHeartbeat: time.Minute,
+		Mailer:                 mailerMemory,
+		// Savings accounts are not offered in IDR in tests.
+		SavingsInterestRates: "USD:200,EUR:150",
 	}
 	server, err := NewServer(config, store)
 	require.NoError(t, err)
 	return server
 }
 
+// disabledCurrency is cached for every test as a disabled currency. ISO 4217
+// reserves XTS for testing, so it never clashes with a real one.
+var disabledCurrency = util.Currency{Code: "XTS", MinorUnits: 0}
+
 func TestMain(m *testing.M) {
 	gin.SetMode(gin.TestMode)
+	util.SetCurrency(disabledCurrency)
 	os.Exit(m.Run())
 }
@@ -1,51 +1,259 @@
 package api
 
 import (
+	"crypto/subtle"
+	"database/sql"
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
 	"github.com/asshiddiq1306/simple_bank/token"
+	"github.com/asshiddiq1306/simple_bank/util"
 	"github.com/gin-gonic/gin"
 )
 
 const (
 	authorizationHeaderKey     = "authorization"
 	authorizationBearerTypeKey = "bearer"
+	authorizationAPIKeyTypeKey = "apikey"
 	authorizationPayloadKey    = "auth_payload_key"
 )
 
-func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+var (
+	errTokenRevoked      = errors.New("token was issued before the last password change")
+	errOAuthTokenRevoked = errors.New("token was revoked")
+	errInvalidAPIKey     = errors.New("invalid api key")
+	errAPIKeyRevoked     = errors.New("api key was revoked")
+	errSessionRequired   = errors.New("this endpoint requires a user session")
+)
+
+// authMiddleware accepts either a PASETO access token as "Bearer <token>" or
+// an API key as "ApiKey <key>". Access tokens issued to OAuth clients are
+// also checked against the revocation list.
+func authMiddleware(tokenMaker token.Maker, store db.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payload, status, err := verifyAuthorization(c, tokenMaker, store)
+		if err != nil {
+			c.AbortWithStatusJSON(status, errorResponse(err))
+			return
+		}
+
+		c.Set(authorizationPayloadKey, payload)
+		c.Next()
+	}
+}
+
+// verifyAuthorization checks the credential of the authorization header,
+// returning the status to answer with when it is not valid.
+func verifyAuthorization(c *gin.Context, tokenMaker token.Maker, store db.Store) (*token.AuthPay, int, error) {
+	authorizationHeader := c.GetHeader(authorizationHeaderKey)
+	if len(authorizationHeader) == 0 {
+		return nil, http.StatusUnauthorized, errors.New("invalid auth header")
+	}
+
+	fields := strings.Fields(authorizationHeader)
+	if len(fields) < 2 {
+		return nil, http.StatusUnauthorized, errors.New("invalid auth header")
+	}
+
+	authorizationType := strings.ToLower(fields[0])
+	switch authorizationType {
+	case authorizationBearerTypeKey:
+		return verifyAccessToken(c, tokenMaker, store, fields[1])
+	case authorizationAPIKeyTypeKey:
+		return verifyAPIKey(c, store, fields[1])
+	default:
+		return nil, http.StatusUnauthorized, errors.New("auth type not supported")
+	}
+}
+
+func verifyAccessToken(c *gin.Context, tokenMaker token.Maker, store db.Store, accessToken string) (*token.AuthPay, int, error) {
+	payload, err := tokenMaker.VerifyToken(accessToken)
+	if err != nil {
+		return nil, http.StatusUnauthorized, err
+	}
+
+	passwordChangedAt, err := store.GetUserPasswordChangedAt(c.Request.Context(), payload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, http.StatusUnauthorized, errors.New("auth user not found")
+		}
+
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if payload.IssuedAt.Before(passwordChangedAt) {
+		return nil, http.StatusUnauthorized, errTokenRevoked
+	}
+
+	if payload.ClientID != "" {
+		oauthToken, err := store.GetOAuthToken(c.Request.Context(), payload.ID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, http.StatusUnauthorized, errOAuthTokenRevoked
+			}
+
+			return nil, http.StatusInternalServerError, err
+		}
+
+		if oauthToken.IsRevoked {
+			return nil, http.StatusUnauthorized, errOAuthTokenRevoked
+		}
+	}
+	return payload, http.StatusOK, nil
+}
+
+func verifyAPIKey(c *gin.Context, store db.Store, key string) (*token.AuthPay, int, error) {
+	prefix, ok := util.ParseAPIKeyPrefix(key)
+	if !ok {
+		return nil, http.StatusUnauthorized, errInvalidAPIKey
+	}
+
+	apiKey, err := store.GetAPIKeyByPrefix(c.Request.Context(), prefix)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, http.StatusUnauthorized, errInvalidAPIKey
+		}
+
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(util.HashSecureToken(key)), []byte(apiKey.KeyHash)) != 1 {
+		return nil, http.StatusUnauthorized, errInvalidAPIKey
+	}
+
+	if apiKey.IsRevoked {
+		return nil, http.StatusUnauthorized, errAPIKeyRevoked
+	}
+
+	if time.Now().After(apiKey.ExpiredAt) {
+		return nil, http.StatusUnauthorized, token.ErrExpiredToken
+	}
+
+	// A password change ends the keys created before it, like the sessions.
+	passwordChangedAt, err := store.GetUserPasswordChangedAt(c.Request.Context(), apiKey.Owner)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, http.StatusUnauthorized, errors.New("auth user not found")
+		}
+
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if apiKey.CreatedAt.Before(passwordChangedAt) {
+		return nil, http.StatusUnauthorized, errAPIKeyRevoked
+	}
+
+	err = store.TouchAPIKey(c.Request.Context(), apiKey.ID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	scopes := apiKey.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	payload := &token.AuthPay{
+		Username:  apiKey.Owner,
+		MFALevel:  token.MFANone,
+		Scopes:    scopes,
+		IssuedAt:  apiKey.CreatedAt,
+		ExpiredAt: apiKey.ExpiredAt,
+	}
+	return payload, http.StatusOK, nil
+}
+
+// scopeMiddleware must run after authMiddleware. It rejects credentials that
+// were not granted scope; user sessions always pass.
+func scopeMiddleware(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+
+		if !authPayload.HasScope(scope) {
+			err := fmt.Errorf("scope %s required", scope)
+			c.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// sessionMiddleware must run after authMiddleware. It keeps delegated
+// credentials away from routes that manage the user's own security settings.
+func sessionMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authorizationHeader := c.GetHeader(authorizationHeaderKey)
-		if len(authorizationHeader) == 0 {
-			err := errors.New("invalid auth header")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+		authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+
+		if authPayload.Scopes != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, errorResponse(errSessionRequired))
 			return
 		}
 
-		fields := strings.Fields(authorizationHeader)
-		if len(fields) < 2 {
-			err := errors.New("invalid auth header")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+		c.Next()
+	}
+}
+
+// adminMiddleware must run after authMiddleware. The role is read from the
+// store on every request so that revoking it takes effect immediately.
+func adminMiddleware(store db.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+
+		user, err := store.GetUserByUsername(c.Request.Context(), authPayload.Username)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				err := errors.New("auth user not found")
+				c.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+				return
+			}
+
+			c.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
 			return
 		}
 
-		authorizationType := strings.ToLower(fields[0])
-		if authorizationType != authorizationBearerTypeKey {
-			err := errors.New("auth type not supported")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+		if user.Role != util.AdminRole {
+			err := errors.New("admin role required")
+			c.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
 			return
 		}
 
-		accessToken := fields[1]
-		payload, err := tokenMaker.VerifyToken(accessToken)
+		c.Next()
+	}
+}
+
+// verifiedEmailMiddleware must run after authMiddleware. It rejects users who
+// have not verified their email yet when required is set.
+func verifiedEmailMiddleware(store db.Store, required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !required {
+			c.Next()
+			return
+		}
+
+		authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+
+		user, err := store.GetUserByUsername(c.Request.Context(), authPayload.Username)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			if err == sql.ErrNoRows {
+				err := errors.New("auth user not found")
+				c.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+				return
+			}
+
+			c.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		if !user.IsEmailVerified {
+			c.AbortWithStatusJSON(http.StatusForbidden, errorResponse(errEmailNotVerified))
 			return
 		}
 
-		c.Set(authorizationPayloadKey, payload)
 		c.Next()
 	}
 }
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"database/sql"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -8,21 +9,42 @@ import (
 	"time"
 
 	mockdb "github.com/asshiddiq1306/simple_bank/db/mock"
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
 	"github.com/asshiddiq1306/simple_bank/token"
+	"github.com/asshiddiq1306/simple_bank/util"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 )
 
+// addAuth adds an access token and expects authMiddleware to check it
+// against the user's password change time once.
 func addAuth(
 	t *testing.T,
 	request *http.Request,
+	store *mockdb.MockStore,
 	tokenMaker token.Maker,
 	authorizationType string,
 	username string,
 	duration time.Duration,
 ) {
-	accessToken, err := tokenMaker.CreateToken(username, duration)
+	addAuthHeader(t, request, tokenMaker, authorizationType, username, duration)
+	if authorizationType == authorizationBearerTypeKey && duration > 0 {
+		store.EXPECT().GetUserPasswordChangedAt(gomock.Any(), gomock.Eq(username)).Times(1).Return(time.Time{}, nil)
+	}
+}
+
+// addAuthHeader adds an access token without any store expectation, for tests
+// that stub the password change time themselves.
+func addAuthHeader(
+	t *testing.T,
+	request *http.Request,
+	tokenMaker token.Maker,
+	authorizationType string,
+	username string,
+	duration time.Duration,
+) {
+	accessToken, err := tokenMaker.CreateToken(username, token.MFANone, duration)
 	require.NoError(t, err)
 
 	authHeader := fmt.Sprintf("%s %s", authorizationType, accessToken)
@@ -32,13 +54,14 @@ func addAuth(
 func TestAuthMiddleware(t *testing.T) {
 	testCases := []struct {
 		name          string
-		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		setupAuth     func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
 			name: "OK",
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuth(t, request, tokenMaker, authorizationBearerTypeKey, "user", time.Minute)
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, "user", time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -46,7 +69,7 @@ func TestAuthMiddleware(t *testing.T) {
 		},
 		{
 			name: "InvalidAuth",
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -54,8 +77,8 @@ func TestAuthMiddleware(t *testing.T) {
 		},
 		{
 			name: "UnsupportedType",
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuth(t, request, tokenMaker, "unsupported", "user", time.Minute)
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, "unsupported", "user", time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -63,8 +86,32 @@ func TestAuthMiddleware(t *testing.T) {
 		},
 		{
 			name: "ExpiredToken",
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuth(t, request, tokenMaker, authorizationBearerTypeKey, "user", -time.Minute)
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, "user", -time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "TokenRevoked",
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuthHeader(t, request, tokenMaker, authorizationBearerTypeKey, "user", time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserPasswordChangedAt(gomock.Any(), gomock.Eq("user")).Times(1).Return(time.Now().Add(time.Second), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "UserNotFound",
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuthHeader(t, request, tokenMaker, authorizationBearerTypeKey, "user", time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserPasswordChangedAt(gomock.Any(), gomock.Eq("user")).Times(1).Return(time.Time{}, sql.ErrNoRows)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -72,8 +119,8 @@ func TestAuthMiddleware(t *testing.T) {
 		},
 		{
 			name: "InvalidFormat",
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuth(t, request, tokenMaker, "", "user", time.Minute)
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, "", "user", time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -89,13 +136,17 @@ func TestAuthMiddleware(t *testing.T) {
 			defer ctrl.Finish()
 
 			store := mockdb.NewMockStore(ctrl)
+			if tc.buildStubs != nil {
+				tc.buildStubs(store)
+			}
+
 			server := newServerTest(t, store)
 			recorder := httptest.NewRecorder()
 
 			authPath := "/auth"
 			server.router.GET(
 				authPath,
-				authMiddleware(server.tokenMaker),
+				authMiddleware(server.tokenMaker, server.store),
 				func(c *gin.Context) {
 					c.JSON(http.StatusOK, gin.H{})
 				},
@@ -104,10 +155,206 @@ func TestAuthMiddleware(t *testing.T) {
 			request, err := http.NewRequest(http.MethodGet, authPath, nil)
 			require.NoError(t, err)
 
-			tc.setupAuth(t, request, server.tokenMaker)
+			tc.setupAuth(t, request, store, server.tokenMaker)
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(t, recorder)
 
 		})
 	}
 }
+
+func createRandomAPIKey(t *testing.T, owner string, scopes ...string) (db.APIKey, string) {
+	prefix, key, err := util.GenerateAPIKey()
+	require.NoError(t, err)
+
+	apiKey := db.APIKey{
+		ID:        util.RandomInt(1, 1000),
+		Owner:     owner,
+		Name:      util.RandomName(),
+		Prefix:    prefix,
+		KeyHash:   util.HashSecureToken(key),
+		Scopes:    scopes,
+		ExpiredAt: time.Now().Add(time.Hour),
+		CreatedAt: time.Now(),
+	}
+	return apiKey, key
+}
+
+func addAPIKeyAuth(request *http.Request, key string) {
+	request.Header.Set(authorizationHeaderKey, fmt.Sprintf("ApiKey %s", key))
+}
+
+func TestAPIKeyAuthMiddleware(t *testing.T) {
+	apiKey, key := createRandomAPIKey(t, "user", util.AccountsReadScope)
+
+	revokedKey := apiKey
+	revokedKey.IsRevoked = true
+
+	expiredKey := apiKey
+	expiredKey.ExpiredAt = time.Now().Add(-time.Minute)
+
+	writeKey := apiKey
+	writeKey.Scopes = []string{util.TransfersWriteScope}
+
+	testCases := []struct {
+		name          string
+		path          string
+		key           string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			path: "/scoped",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(apiKey, nil)
+				store.EXPECT().GetUserPasswordChangedAt(gomock.Any(), gomock.Eq(apiKey.Owner)).Times(1).Return(time.Time{}, nil)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Eq(apiKey.ID)).Times(1).Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "MissingScope",
+			path: "/scoped",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(writeKey, nil)
+				store.EXPECT().GetUserPasswordChangedAt(gomock.Any(), gomock.Eq(apiKey.Owner)).Times(1).Return(time.Time{}, nil)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Eq(apiKey.ID)).Times(1).Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "SessionRequired",
+			path: "/session",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(apiKey, nil)
+				store.EXPECT().GetUserPasswordChangedAt(gomock.Any(), gomock.Eq(apiKey.Owner)).Times(1).Return(time.Time{}, nil)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Eq(apiKey.ID)).Times(1).Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errSessionRequired)
+			},
+		},
+		{
+			name: "Revoked",
+			path: "/scoped",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(revokedKey, nil)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errAPIKeyRevoked)
+			},
+		},
+		{
+			name: "PasswordChanged",
+			path: "/scoped",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(apiKey, nil)
+				store.EXPECT().GetUserPasswordChangedAt(gomock.Any(), gomock.Eq(apiKey.Owner)).Times(1).Return(apiKey.CreatedAt.Add(time.Second), nil)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errAPIKeyRevoked)
+			},
+		},
+		{
+			name: "Expired",
+			path: "/scoped",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(expiredKey, nil)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "WrongSecret",
+			path: "/scoped",
+			key:  key + "x",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(apiKey, nil)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidAPIKey)
+			},
+		},
+		{
+			name: "NotFound",
+			path: "/scoped",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(db.APIKey{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidFormat",
+			path: "/scoped",
+			key:  "not-an-api-key",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			path: "/scoped",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(db.APIKey{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			handler := func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{})
+			}
+			server.router.GET("/scoped", authMiddleware(server.tokenMaker, server.store), scopeMiddleware(util.AccountsReadScope), handler)
+			server.router.GET("/session", authMiddleware(server.tokenMaker, server.store), sessionMiddleware(), handler)
+
+			request, err := http.NewRequest(http.MethodGet, tc.path, nil)
+			require.NoError(t, err)
+
+			addAPIKeyAuth(request, tc.key)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
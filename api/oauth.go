@@ -0,0 +1,551 @@
+package api
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/token"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+)
+
+// OAuth2 grant types and error codes from RFC 6749.
+const (
+	grantTypeAuthorizationCode = "authorization_code"
+	grantTypeClientCredentials = "client_credentials"
+
+	oauthErrInvalidRequest       = "invalid_request"
+	oauthErrInvalidClient        = "invalid_client"
+	oauthErrInvalidGrant         = "invalid_grant"
+	oauthErrInvalidScope         = "invalid_scope"
+	oauthErrUnauthorizedClient   = "unauthorized_client"
+	oauthErrUnsupportedGrantType = "unsupported_grant_type"
+	oauthErrAccessDenied         = "access_denied"
+	oauthErrServerError          = "server_error"
+
+	oauthCodeBytes = 32
+)
+
+var (
+	errInvalidOAuthClient   = errors.New("client authentication failed")
+	errInvalidRedirectURI   = errors.New("redirect uri is not registered for this client")
+	errInvalidOAuthCode     = errors.New("authorization code is invalid, expired or was issued to another client")
+	errInvalidCodeVerifier  = errors.New("code verifier does not match the code challenge")
+	errConfidentialRequired = errors.New("only confidential clients may use this grant")
+)
+
+func oauthErrorResponse(code string, err error) gin.H {
+	return gin.H{"error": code, "error_description": err.Error()}
+}
+
+// parseScopes splits a space separated scope parameter and checks that every
+// scope is supported and allowed for the client.
+func parseScopes(scope string, allowed []string) ([]string, error) {
+	scopes := strings.Fields(scope)
+	if len(scopes) == 0 {
+		return nil, errors.New("scope is required")
+	}
+
+	for _, s := range scopes {
+		if !util.IsSupportedScope(s) || !containsString(allowed, s) {
+			return nil, fmt.Errorf("scope %s is not allowed for this client", s)
+		}
+	}
+	return scopes, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+type oauthAuthorizeReq struct {
+	ResponseType        string `form:"response_type" json:"response_type" binding:"required,eq=code"`
+	ClientID            string `form:"client_id" json:"client_id" binding:"required"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri" binding:"required,url"`
+	Scope               string `form:"scope" json:"scope" binding:"required"`
+	State               string `form:"state" json:"state"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge" binding:"required,min=43,max=128"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method" binding:"required,eq=S256"`
+}
+
+// validateAuthorizeReq writes the error response itself and returns false
+// when the request must not continue. Errors are never redirected, because
+// the redirect uri cannot be trusted until it has been checked.
+func (server *Server) validateAuthorizeReq(c *gin.Context, req oauthAuthorizeReq) (db.OAuthClient, []string, bool) {
+	client, err := server.store.GetOAuthClient(c.Request.Context(), req.ClientID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, oauthErrorResponse(oauthErrInvalidClient, errInvalidOAuthClient))
+			return client, nil, false
+		}
+
+		c.JSON(http.StatusInternalServerError, oauthErrorResponse(oauthErrServerError, err))
+		return client, nil, false
+	}
+
+	if !containsString(client.RedirectURIs, req.RedirectURI) {
+		c.JSON(http.StatusBadRequest, oauthErrorResponse(oauthErrInvalidRequest, errInvalidRedirectURI))
+		return client, nil, false
+	}
+
+	scopes, err := parseScopes(req.Scope, client.Scopes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, oauthErrorResponse(oauthErrInvalidScope, err))
+		return client, nil, false
+	}
+	return client, scopes, true
+}
+
+type oauthConsentResp struct {
+	ClientID    string   `json:"client_id"`
+	ClientName  string   `json:"client_name"`
+	RedirectURI string   `json:"redirect_uri"`
+	Scopes      []string `json:"scopes"`
+	State       string   `json:"state"`
+}
+
+// getOAuthConsentAPI returns what the consent screen shows the user before
+// they approve or deny the authorization request.
+func (server *Server) getOAuthConsentAPI(c *gin.Context) {
+	var req oauthAuthorizeReq
+	err := c.ShouldBindQuery(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, oauthErrorResponse(oauthErrInvalidRequest, err))
+		return
+	}
+
+	client, scopes, ok := server.validateAuthorizeReq(c, req)
+	if !ok {
+		return
+	}
+
+	rsp := oauthConsentResp{
+		ClientID:    client.ClientID,
+		ClientName:  client.Name,
+		RedirectURI: req.RedirectURI,
+		Scopes:      scopes,
+		State:       req.State,
+	}
+
+	c.JSON(http.StatusOK, rsp)
+}
+
+type oauthApproveReq struct {
+	oauthAuthorizeReq
+	Approve bool `json:"approve"`
+}
+
+type oauthApproveResp struct {
+	RedirectURI string `json:"redirect_uri"`
+}
+
+// approveOAuthConsentAPI records the user's decision and returns the uri the
+// user agent should be sent back to, carrying either a code or an error.
+func (server *Server) approveOAuthConsentAPI(c *gin.Context) {
+	var req oauthApproveReq
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, oauthErrorResponse(oauthErrInvalidRequest, err))
+		return
+	}
+
+	client, scopes, ok := server.validateAuthorizeReq(c, req.oauthAuthorizeReq)
+	if !ok {
+		return
+	}
+
+	redirectURI, err := url.Parse(req.RedirectURI)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, oauthErrorResponse(oauthErrInvalidRequest, err))
+		return
+	}
+
+	query := redirectURI.Query()
+	if req.State != "" {
+		query.Set("state", req.State)
+	}
+
+	if !req.Approve {
+		query.Set("error", oauthErrAccessDenied)
+		redirectURI.RawQuery = query.Encode()
+		c.JSON(http.StatusOK, oauthApproveResp{RedirectURI: redirectURI.String()})
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+
+	code, err := util.RandomSecureToken(oauthCodeBytes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, oauthErrorResponse(oauthErrServerError, err))
+		return
+	}
+
+	_, err = server.store.CreateNewOAuthCode(c.Request.Context(), db.CreateNewOAuthCodeArgs{
+		CodeHash:      util.HashSecureToken(code),
+		ClientID:      client.ClientID,
+		Username:      authPayload.Username,
+		RedirectURI:   req.RedirectURI,
+		Scopes:        scopes,
+		CodeChallenge: req.CodeChallenge,
+		ExpiredAt:     time.Now().Add(server.config.OAuthCodeDuration),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, oauthErrorResponse(oauthErrServerError, err))
+		return
+	}
+
+	query.Set("code", code)
+	redirectURI.RawQuery = query.Encode()
+	c.JSON(http.StatusOK, oauthApproveResp{RedirectURI: redirectURI.String()})
+}
+
+// authenticateOAuthClient reads client credentials from HTTP basic auth or
+// the form body. Public clients only present their id.
+func (server *Server) authenticateOAuthClient(c *gin.Context) (db.OAuthClient, bool) {
+	clientID, clientSecret, ok := c.Request.BasicAuth()
+	if !ok {
+		clientID = c.PostForm("client_id")
+		clientSecret = c.PostForm("client_secret")
+	}
+
+	if clientID == "" {
+		c.JSON(http.StatusUnauthorized, oauthErrorResponse(oauthErrInvalidClient, errInvalidOAuthClient))
+		return db.OAuthClient{}, false
+	}
+
+	client, err := server.store.GetOAuthClient(c.Request.Context(), clientID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusUnauthorized, oauthErrorResponse(oauthErrInvalidClient, errInvalidOAuthClient))
+			return client, false
+		}
+
+		c.JSON(http.StatusInternalServerError, oauthErrorResponse(oauthErrServerError, err))
+		return client, false
+	}
+
+	if client.IsConfidential {
+		secretHash := util.HashSecureToken(clientSecret)
+		if clientSecret == "" || subtle.ConstantTimeCompare([]byte(secretHash), []byte(client.ClientSecretHash)) != 1 {
+			c.JSON(http.StatusUnauthorized, oauthErrorResponse(oauthErrInvalidClient, errInvalidOAuthClient))
+			return client, false
+		}
+	}
+	return client, true
+}
+
+type oauthTokenReq struct {
+	GrantType    string `form:"grant_type" binding:"required"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	Scope        string `form:"scope"`
+}
+
+type oauthTokenResp struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+func (server *Server) oauthTokenAPI(c *gin.Context) {
+	var req oauthTokenReq
+	err := c.ShouldBind(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, oauthErrorResponse(oauthErrInvalidRequest, err))
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+
+	client, ok := server.authenticateOAuthClient(c)
+	if !ok {
+		return
+	}
+
+	switch req.GrantType {
+	case grantTypeAuthorizationCode:
+		server.exchangeOAuthCode(c, client, req)
+	case grantTypeClientCredentials:
+		server.issueClientCredentialsToken(c, client, req)
+	default:
+		err := fmt.Errorf("grant type %s is not supported", req.GrantType)
+		c.JSON(http.StatusBadRequest, oauthErrorResponse(oauthErrUnsupportedGrantType, err))
+	}
+}
+
+func (server *Server) exchangeOAuthCode(c *gin.Context, client db.OAuthClient, req oauthTokenReq) {
+	if req.Code == "" || req.RedirectURI == "" || req.CodeVerifier == "" {
+		err := errors.New("code, redirect_uri and code_verifier are required")
+		c.JSON(http.StatusBadRequest, oauthErrorResponse(oauthErrInvalidRequest, err))
+		return
+	}
+
+	// The code is only consumed once the request is known to come from the
+	// client it was issued to, so a stolen code cannot be burned by others.
+	code, err := server.store.GetOAuthCode(c.Request.Context(), util.HashSecureToken(req.Code))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, oauthErrorResponse(oauthErrInvalidGrant, errInvalidOAuthCode))
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, oauthErrorResponse(oauthErrServerError, err))
+		return
+	}
+
+	if code.ClientID != client.ClientID || code.RedirectURI != req.RedirectURI || time.Now().After(code.ExpiredAt) {
+		c.JSON(http.StatusBadRequest, oauthErrorResponse(oauthErrInvalidGrant, errInvalidOAuthCode))
+		return
+	}
+
+	if code.IsUsed {
+		server.revokeReplayedOAuthCode(c, code)
+		return
+	}
+
+	if !util.VerifyPKCE(req.CodeVerifier, code.CodeChallenge) {
+		c.JSON(http.StatusBadRequest, oauthErrorResponse(oauthErrInvalidGrant, errInvalidCodeVerifier))
+		return
+	}
+
+	accessToken, payload, err := server.tokenMaker.CreateDelegatedToken(code.Username, client.ClientID, code.Scopes, server.config.OAuthTokenDuration)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, oauthErrorResponse(oauthErrServerError, err))
+		return
+	}
+
+	_, err = server.store.ExchangeOAuthCodeTx(c.Request.Context(), db.ExchangeOAuthCodeTxArg{
+		Code: db.UseOAuthCodeArgs{
+			CodeHash:    code.CodeHash,
+			ClientID:    client.ClientID,
+			RedirectURI: req.RedirectURI,
+		},
+		Token: db.CreateNewOAuthTokenArgs{
+			ID:        payload.ID,
+			ClientID:  client.ClientID,
+			Username:  code.Username,
+			GrantType: grantTypeAuthorizationCode,
+			Scopes:    code.Scopes,
+			ExpiredAt: payload.ExpiredAt,
+		},
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			// Another request used the code first.
+			server.revokeReplayedOAuthCode(c, code)
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, oauthErrorResponse(oauthErrServerError, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, server.oauthTokenResp(accessToken, code.Scopes))
+}
+
+// revokeReplayedOAuthCode revokes every token the user granted to the client
+// when one of its codes is used twice, since either use may have been an
+// attacker's (RFC 6749 section 10.5).
+func (server *Server) revokeReplayedOAuthCode(c *gin.Context, code db.OAuthCode) {
+	_, err := server.store.RevokeOAuthGrant(c.Request.Context(), db.RevokeOAuthGrantArgs{
+		Username: code.Username,
+		ClientID: code.ClientID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, oauthErrorResponse(oauthErrServerError, err))
+		return
+	}
+
+	c.JSON(http.StatusBadRequest, oauthErrorResponse(oauthErrInvalidGrant, errInvalidOAuthCode))
+}
+
+// issueClientCredentialsToken lets a machine client act as the user who
+// registered it, limited to the scopes the client was registered with.
+func (server *Server) issueClientCredentialsToken(c *gin.Context, client db.OAuthClient, req oauthTokenReq) {
+	if !client.IsConfidential {
+		c.JSON(http.StatusBadRequest, oauthErrorResponse(oauthErrUnauthorizedClient, errConfidentialRequired))
+		return
+	}
+
+	scopes := client.Scopes
+	if req.Scope != "" {
+		var err error
+		scopes, err = parseScopes(req.Scope, client.Scopes)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, oauthErrorResponse(oauthErrInvalidScope, err))
+			return
+		}
+	}
+
+	server.issueOAuthToken(c, client, client.Owner, grantTypeClientCredentials, scopes)
+}
+
+// issueOAuthToken records every delegated token, so that users can see and
+// revoke what each client was granted.
+func (server *Server) issueOAuthToken(c *gin.Context, client db.OAuthClient, username string, grantType string, scopes []string) {
+	accessToken, payload, err := server.tokenMaker.CreateDelegatedToken(username, client.ClientID, scopes, server.config.OAuthTokenDuration)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, oauthErrorResponse(oauthErrServerError, err))
+		return
+	}
+
+	_, err = server.store.CreateNewOAuthToken(c.Request.Context(), db.CreateNewOAuthTokenArgs{
+		ID:        payload.ID,
+		ClientID:  client.ClientID,
+		Username:  username,
+		GrantType: grantType,
+		Scopes:    scopes,
+		ExpiredAt: payload.ExpiredAt,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, oauthErrorResponse(oauthErrServerError, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, server.oauthTokenResp(accessToken, scopes))
+}
+
+func (server *Server) oauthTokenResp(accessToken string, scopes []string) oauthTokenResp {
+	return oauthTokenResp{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(server.config.OAuthTokenDuration / time.Second),
+		Scope:       strings.Join(scopes, " "),
+	}
+}
+
+type oauthTokenParamReq struct {
+	Token string `form:"token" binding:"required"`
+}
+
+type oauthIntrospectResp struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	ExpiredAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+}
+
+// activeOAuthToken returns the payload of a delegated token issued to client
+// that is neither expired nor revoked, by the user or by a password change.
+func (server *Server) activeOAuthToken(c *gin.Context, client db.OAuthClient, accessToken string) (*token.AuthPay, bool, error) {
+	payload, err := server.tokenMaker.VerifyToken(accessToken)
+	if err != nil || payload.ClientID != client.ClientID {
+		return nil, false, nil
+	}
+
+	passwordChangedAt, err := server.store.GetUserPasswordChangedAt(c.Request.Context(), payload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	if payload.IssuedAt.Before(passwordChangedAt) {
+		return nil, false, nil
+	}
+
+	oauthToken, err := server.store.GetOAuthToken(c.Request.Context(), payload.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return payload, !oauthToken.IsRevoked, nil
+}
+
+// oauthIntrospectAPI implements RFC 7662 for confidential clients. A client
+// can only introspect tokens that were issued to it.
+func (server *Server) oauthIntrospectAPI(c *gin.Context) {
+	var req oauthTokenParamReq
+	err := c.ShouldBind(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, oauthErrorResponse(oauthErrInvalidRequest, err))
+		return
+	}
+
+	client, ok := server.authenticateOAuthClient(c)
+	if !ok {
+		return
+	}
+
+	if !client.IsConfidential {
+		c.JSON(http.StatusUnauthorized, oauthErrorResponse(oauthErrInvalidClient, errConfidentialRequired))
+		return
+	}
+
+	payload, active, err := server.activeOAuthToken(c, client, req.Token)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, oauthErrorResponse(oauthErrServerError, err))
+		return
+	}
+
+	if !active {
+		c.JSON(http.StatusOK, oauthIntrospectResp{Active: false})
+		return
+	}
+
+	rsp := oauthIntrospectResp{
+		Active:    true,
+		Scope:     strings.Join(payload.Scopes, " "),
+		ClientID:  payload.ClientID,
+		Username:  payload.Username,
+		TokenType: "Bearer",
+		ExpiredAt: payload.ExpiredAt.Unix(),
+		IssuedAt:  payload.IssuedAt.Unix(),
+	}
+
+	c.JSON(http.StatusOK, rsp)
+}
+
+// oauthRevokeAPI implements RFC 7009. Unknown tokens are not an error, so the
+// response does not reveal whether a token existed.
+func (server *Server) oauthRevokeAPI(c *gin.Context) {
+	var req oauthTokenParamReq
+	err := c.ShouldBind(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, oauthErrorResponse(oauthErrInvalidRequest, err))
+		return
+	}
+
+	client, ok := server.authenticateOAuthClient(c)
+	if !ok {
+		return
+	}
+
+	payload, active, err := server.activeOAuthToken(c, client, req.Token)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, oauthErrorResponse(oauthErrServerError, err))
+		return
+	}
+
+	if active {
+		err = server.store.RevokeOAuthToken(c.Request.Context(), db.RevokeOAuthTokenArgs{
+			ID:       payload.ID,
+			ClientID: client.ClientID,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, oauthErrorResponse(oauthErrServerError, err))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{})
+}
@@ -0,0 +1,194 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/token"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	oauthClientIDBytes     = 16
+	oauthClientSecretBytes = 32
+)
+
+var errRedirectURIRequired = errors.New("public clients must register at least one redirect uri")
+
+type createOAuthClientReq struct {
+	Name         string   `json:"name" binding:"required,max=64"`
+	RedirectURIs []string `json:"redirect_uris" binding:"omitempty,dive,url"`
+	Scopes       []string `json:"scopes" binding:"required,min=1,dive,scope"`
+	Confidential bool     `json:"confidential"`
+}
+
+type oauthClientResp struct {
+	ClientID       string    `json:"client_id"`
+	Name           string    `json:"name"`
+	RedirectURIs   []string  `json:"redirect_uris"`
+	Scopes         []string  `json:"scopes"`
+	IsConfidential bool      `json:"is_confidential"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func newOAuthClientResp(client db.OAuthClient) oauthClientResp {
+	return oauthClientResp{
+		ClientID:       client.ClientID,
+		Name:           client.Name,
+		RedirectURIs:   client.RedirectURIs,
+		Scopes:         client.Scopes,
+		IsConfidential: client.IsConfidential,
+		CreatedAt:      client.CreatedAt,
+	}
+}
+
+type createOAuthClientResp struct {
+	ClientSecret string          `json:"client_secret,omitempty"`
+	Client       oauthClientResp `json:"client"`
+}
+
+func (server *Server) createOAuthClientAPI(c *gin.Context) {
+	var req createOAuthClientReq
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !req.Confidential && len(req.RedirectURIs) == 0 {
+		c.JSON(http.StatusBadRequest, errorResponse(errRedirectURIRequired))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+
+	clientID, err := util.RandomSecureToken(oauthClientIDBytes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	arg := db.CreateNewOAuthClientArgs{
+		ClientID:       clientID,
+		Owner:          authPayload.Username,
+		Name:           req.Name,
+		RedirectURIs:   req.RedirectURIs,
+		Scopes:         req.Scopes,
+		IsConfidential: req.Confidential,
+	}
+	if arg.RedirectURIs == nil {
+		arg.RedirectURIs = []string{}
+	}
+
+	var rsp createOAuthClientResp
+	if req.Confidential {
+		rsp.ClientSecret, err = util.RandomSecureToken(oauthClientSecretBytes)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		arg.ClientSecretHash = util.HashSecureToken(rsp.ClientSecret)
+	}
+
+	client, err := server.store.CreateNewOAuthClient(c.Request.Context(), arg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp.Client = newOAuthClientResp(client)
+	c.JSON(http.StatusOK, rsp)
+}
+
+type oauthIssuedTokenResp struct {
+	ID        uuid.UUID `json:"id"`
+	ClientID  string    `json:"client_id"`
+	GrantType string    `json:"grant_type"`
+	Scopes    []string  `json:"scopes"`
+	IsRevoked bool      `json:"is_revoked"`
+	ExpiredAt time.Time `json:"expired_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newOAuthIssuedTokenResp(oauthToken db.OAuthToken) oauthIssuedTokenResp {
+	return oauthIssuedTokenResp{
+		ID:        oauthToken.ID,
+		ClientID:  oauthToken.ClientID,
+		GrantType: oauthToken.GrantType,
+		Scopes:    oauthToken.Scopes,
+		IsRevoked: oauthToken.IsRevoked,
+		ExpiredAt: oauthToken.ExpiredAt,
+		CreatedAt: oauthToken.CreatedAt,
+	}
+}
+
+type getListOAuthTokensReq struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// getListOAuthTokensAPI lets users audit which clients were granted access
+// to their accounts.
+func (server *Server) getListOAuthTokensAPI(c *gin.Context) {
+	var req getListOAuthTokensReq
+	err := c.ShouldBindQuery(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+
+	arg := db.GetListOAuthTokensArgs{
+		Username: authPayload.Username,
+		Limit:    req.PageSize,
+		Offset:   (req.PageID - 1) * req.PageSize,
+	}
+
+	tokens, err := server.store.GetListOAuthTokens(c.Request.Context(), arg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]oauthIssuedTokenResp, len(tokens))
+	for i, oauthToken := range tokens {
+		rsp[i] = newOAuthIssuedTokenResp(oauthToken)
+	}
+
+	c.JSON(http.StatusOK, rsp)
+}
+
+type revokeOAuthGrantReq struct {
+	ClientID string `uri:"client_id" binding:"required"`
+}
+
+type revokeOAuthGrantResp struct {
+	RevokedTokens int64 `json:"revoked_tokens"`
+}
+
+func (server *Server) revokeOAuthGrantAPI(c *gin.Context) {
+	var req revokeOAuthGrantReq
+	err := c.ShouldBindUri(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+
+	revoked, err := server.store.RevokeOAuthGrant(c.Request.Context(), db.RevokeOAuthGrantArgs{
+		Username: authPayload.Username,
+		ClientID: req.ClientID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, revokeOAuthGrantResp{RevokedTokens: revoked})
+}
@@ -0,0 +1,824 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "github.com/asshiddiq1306/simple_bank/db/mock"
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/token"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomOAuthClient(t *testing.T, owner string, confidential bool) (db.OAuthClient, string) {
+	client := db.OAuthClient{
+		ClientID:       util.RandomString(16),
+		Owner:          owner,
+		Name:           util.RandomName(),
+		RedirectURIs:   []string{"https://partner.example.com/callback"},
+		Scopes:         []string{util.AccountsReadScope, util.TransfersWriteScope},
+		IsConfidential: confidential,
+		CreatedAt:      time.Now(),
+	}
+
+	var secret string
+	if confidential {
+		secret = util.RandomString(32)
+		client.ClientSecretHash = util.HashSecureToken(secret)
+	}
+	return client, secret
+}
+
+func newOAuthFormRequest(t *testing.T, path string, form url.Values) *http.Request {
+	request, err := http.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	require.NoError(t, err)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return request
+}
+
+func requireOAuthError(t *testing.T, recorder *httptest.ResponseRecorder, code string) {
+	var rsp gin.H
+	err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+	require.NoError(t, err)
+	require.Equal(t, code, rsp["error"])
+}
+
+func TestCreateOAuthClientAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Confidential",
+			body: gin.H{
+				"name":         "payroll",
+				"scopes":       []string{util.TransfersWriteScope},
+				"confidential": true,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateNewOAuthClient(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateNewOAuthClientArgs) (db.OAuthClient, error) {
+						require.Equal(t, user.Username, arg.Owner)
+						require.NotEmpty(t, arg.ClientID)
+						require.NotEmpty(t, arg.ClientSecretHash)
+						require.True(t, arg.IsConfidential)
+						return db.OAuthClient{
+							ClientID:         arg.ClientID,
+							ClientSecretHash: arg.ClientSecretHash,
+							Owner:            arg.Owner,
+							Name:             arg.Name,
+							RedirectURIs:     arg.RedirectURIs,
+							Scopes:           arg.Scopes,
+							IsConfidential:   arg.IsConfidential,
+						}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp createOAuthClientResp
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.ClientSecret)
+				require.NotEmpty(t, rsp.Client.ClientID)
+				require.NotContains(t, recorder.Body.String(), "client_secret_hash")
+			},
+		},
+		{
+			name: "PublicWithoutRedirectURI",
+			body: gin.H{
+				"name":   "budget app",
+				"scopes": []string{util.AccountsReadScope},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateNewOAuthClient(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidRedirectURI",
+			body: gin.H{
+				"name":          "budget app",
+				"redirect_uris": []string{"not a url"},
+				"scopes":        []string{util.AccountsReadScope},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateNewOAuthClient(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/oauth/clients", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestApproveOAuthConsentAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	partner, _ := createRandomUser(t)
+	client, _ := createRandomOAuthClient(t, partner.Username, false)
+	challenge := util.PKCEChallenge(util.RandomString(64))
+	state := util.RandomString(8)
+
+	validBody := func() gin.H {
+		return gin.H{
+			"response_type":         "code",
+			"client_id":             client.ClientID,
+			"redirect_uri":          client.RedirectURIs[0],
+			"scope":                 util.AccountsReadScope,
+			"state":                 state,
+			"code_challenge":        challenge,
+			"code_challenge_method": util.PKCEMethodS256,
+			"approve":               true,
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		body          func() gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: validBody,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ClientID)).Times(1).Return(client, nil)
+				store.EXPECT().CreateNewOAuthCode(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateNewOAuthCodeArgs) (db.OAuthCode, error) {
+						require.Equal(t, client.ClientID, arg.ClientID)
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, []string{util.AccountsReadScope}, arg.Scopes)
+						require.Equal(t, challenge, arg.CodeChallenge)
+						return db.OAuthCode{CodeHash: arg.CodeHash}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp oauthApproveResp
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+
+				redirectURI, err := url.Parse(rsp.RedirectURI)
+				require.NoError(t, err)
+				require.True(t, strings.HasPrefix(rsp.RedirectURI, client.RedirectURIs[0]))
+				require.NotEmpty(t, redirectURI.Query().Get("code"))
+				require.Equal(t, state, redirectURI.Query().Get("state"))
+			},
+		},
+		{
+			name: "Denied",
+			body: func() gin.H {
+				body := validBody()
+				body["approve"] = false
+				return body
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ClientID)).Times(1).Return(client, nil)
+				store.EXPECT().CreateNewOAuthCode(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp oauthApproveResp
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+
+				redirectURI, err := url.Parse(rsp.RedirectURI)
+				require.NoError(t, err)
+				require.Equal(t, oauthErrAccessDenied, redirectURI.Query().Get("error"))
+				require.Empty(t, redirectURI.Query().Get("code"))
+			},
+		},
+		{
+			name: "UnregisteredRedirectURI",
+			body: func() gin.H {
+				body := validBody()
+				body["redirect_uri"] = "https://attacker.example.com/callback"
+				return body
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ClientID)).Times(1).Return(client, nil)
+				store.EXPECT().CreateNewOAuthCode(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireOAuthError(t, recorder, oauthErrInvalidRequest)
+			},
+		},
+		{
+			name: "ScopeNotAllowed",
+			body: func() gin.H {
+				body := validBody()
+				body["scope"] = util.AccountsReadScope + " " + util.AccountsWriteScope
+				return body
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ClientID)).Times(1).Return(client, nil)
+				store.EXPECT().CreateNewOAuthCode(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireOAuthError(t, recorder, oauthErrInvalidScope)
+			},
+		},
+		{
+			name: "UnknownClient",
+			body: validBody,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ClientID)).Times(1).Return(db.OAuthClient{}, sql.ErrNoRows)
+				store.EXPECT().CreateNewOAuthCode(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireOAuthError(t, recorder, oauthErrInvalidClient)
+			},
+		},
+		{
+			name: "PlainChallengeMethod",
+			body: func() gin.H {
+				body := validBody()
+				body["code_challenge_method"] = "plain"
+				return body
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body())
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/oauth/authorize", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestOAuthTokenAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	partner, _ := createRandomUser(t)
+	publicClient, _ := createRandomOAuthClient(t, partner.Username, false)
+	machineClient, secret := createRandomOAuthClient(t, partner.Username, true)
+	otherClient, _ := createRandomOAuthClient(t, partner.Username, false)
+
+	verifier := util.RandomString(64)
+	code := util.RandomString(32)
+	oauthCode := db.OAuthCode{
+		CodeHash:      util.HashSecureToken(code),
+		ClientID:      publicClient.ClientID,
+		Username:      user.Username,
+		RedirectURI:   publicClient.RedirectURIs[0],
+		Scopes:        []string{util.AccountsReadScope},
+		CodeChallenge: util.PKCEChallenge(verifier),
+		ExpiredAt:     time.Now().Add(time.Minute),
+	}
+	usedCode := oauthCode
+	usedCode.IsUsed = true
+	expiredCode := oauthCode
+	expiredCode.ExpiredAt = time.Now().Add(-time.Minute)
+	grant := db.RevokeOAuthGrantArgs{Username: user.Username, ClientID: publicClient.ClientID}
+
+	codeForm := func() url.Values {
+		return url.Values{
+			"grant_type":    {grantTypeAuthorizationCode},
+			"client_id":     {publicClient.ClientID},
+			"code":          {code},
+			"redirect_uri":  {publicClient.RedirectURIs[0]},
+			"code_verifier": {verifier},
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		form          func() url.Values
+		setupAuth     func(request *http.Request)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker)
+	}{
+		{
+			name:      "AuthorizationCode",
+			form:      codeForm,
+			setupAuth: func(request *http.Request) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(publicClient.ClientID)).Times(1).Return(publicClient, nil)
+				store.EXPECT().GetOAuthCode(gomock.Any(), gomock.Eq(oauthCode.CodeHash)).Times(1).Return(oauthCode, nil)
+				store.EXPECT().ExchangeOAuthCodeTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg db.ExchangeOAuthCodeTxArg) (db.ExchangeOAuthCodeTxResult, error) {
+						require.Equal(t, db.UseOAuthCodeArgs{
+							CodeHash:    oauthCode.CodeHash,
+							ClientID:    publicClient.ClientID,
+							RedirectURI: publicClient.RedirectURIs[0],
+						}, arg.Code)
+						require.Equal(t, publicClient.ClientID, arg.Token.ClientID)
+						require.Equal(t, user.Username, arg.Token.Username)
+						require.Equal(t, grantTypeAuthorizationCode, arg.Token.GrantType)
+						return db.ExchangeOAuthCodeTxResult{Code: usedCode, Token: db.OAuthToken{ID: arg.Token.ID}}, nil
+					})
+				store.EXPECT().RevokeOAuthGrant(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+
+				var rsp oauthTokenResp
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, util.AccountsReadScope, rsp.Scope)
+
+				payload, err := tokenMaker.VerifyToken(rsp.AccessToken)
+				require.NoError(t, err)
+				require.Equal(t, user.Username, payload.Username)
+				require.Equal(t, publicClient.ClientID, payload.ClientID)
+				require.True(t, payload.HasScope(util.AccountsReadScope))
+				require.False(t, payload.HasScope(util.TransfersWriteScope))
+			},
+		},
+		{
+			name: "WrongCodeVerifier",
+			form: func() url.Values {
+				form := codeForm()
+				form.Set("code_verifier", util.RandomString(64))
+				return form
+			},
+			setupAuth: func(request *http.Request) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(publicClient.ClientID)).Times(1).Return(publicClient, nil)
+				store.EXPECT().GetOAuthCode(gomock.Any(), gomock.Eq(oauthCode.CodeHash)).Times(1).Return(oauthCode, nil)
+				store.EXPECT().ExchangeOAuthCodeTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().RevokeOAuthGrant(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireOAuthError(t, recorder, oauthErrInvalidGrant)
+			},
+		},
+		{
+			name: "WrongRedirectURI",
+			form: func() url.Values {
+				form := codeForm()
+				form.Set("redirect_uri", "https://partner.example.com/other")
+				return form
+			},
+			setupAuth: func(request *http.Request) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(publicClient.ClientID)).Times(1).Return(publicClient, nil)
+				store.EXPECT().GetOAuthCode(gomock.Any(), gomock.Eq(oauthCode.CodeHash)).Times(1).Return(oauthCode, nil)
+				store.EXPECT().ExchangeOAuthCodeTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().RevokeOAuthGrant(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireOAuthError(t, recorder, oauthErrInvalidGrant)
+			},
+		},
+		{
+			name:      "CodeNotFound",
+			form:      codeForm,
+			setupAuth: func(request *http.Request) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(publicClient.ClientID)).Times(1).Return(publicClient, nil)
+				store.EXPECT().GetOAuthCode(gomock.Any(), gomock.Eq(oauthCode.CodeHash)).Times(1).Return(db.OAuthCode{}, sql.ErrNoRows)
+				store.EXPECT().ExchangeOAuthCodeTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().RevokeOAuthGrant(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireOAuthError(t, recorder, oauthErrInvalidGrant)
+			},
+		},
+		{
+			name:      "CodeExpired",
+			form:      codeForm,
+			setupAuth: func(request *http.Request) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(publicClient.ClientID)).Times(1).Return(publicClient, nil)
+				store.EXPECT().GetOAuthCode(gomock.Any(), gomock.Eq(oauthCode.CodeHash)).Times(1).Return(expiredCode, nil)
+				store.EXPECT().ExchangeOAuthCodeTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().RevokeOAuthGrant(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireOAuthError(t, recorder, oauthErrInvalidGrant)
+			},
+		},
+		{
+			name:      "CodeReplayed",
+			form:      codeForm,
+			setupAuth: func(request *http.Request) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(publicClient.ClientID)).Times(1).Return(publicClient, nil)
+				store.EXPECT().GetOAuthCode(gomock.Any(), gomock.Eq(oauthCode.CodeHash)).Times(1).Return(usedCode, nil)
+				store.EXPECT().ExchangeOAuthCodeTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().RevokeOAuthGrant(gomock.Any(), gomock.Eq(grant)).Times(1).Return(int64(1), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireOAuthError(t, recorder, oauthErrInvalidGrant)
+			},
+		},
+		{
+			name:      "CodeUsedConcurrently",
+			form:      codeForm,
+			setupAuth: func(request *http.Request) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(publicClient.ClientID)).Times(1).Return(publicClient, nil)
+				store.EXPECT().GetOAuthCode(gomock.Any(), gomock.Eq(oauthCode.CodeHash)).Times(1).Return(oauthCode, nil)
+				store.EXPECT().ExchangeOAuthCodeTx(gomock.Any(), gomock.Any()).Times(1).Return(db.ExchangeOAuthCodeTxResult{}, sql.ErrNoRows)
+				store.EXPECT().RevokeOAuthGrant(gomock.Any(), gomock.Eq(grant)).Times(1).Return(int64(1), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireOAuthError(t, recorder, oauthErrInvalidGrant)
+			},
+		},
+		{
+			name: "CodeOfAnotherClient",
+			form: func() url.Values {
+				form := codeForm()
+				form.Set("client_id", otherClient.ClientID)
+				return form
+			},
+			setupAuth: func(request *http.Request) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(otherClient.ClientID)).Times(1).Return(otherClient, nil)
+				store.EXPECT().GetOAuthCode(gomock.Any(), gomock.Eq(oauthCode.CodeHash)).Times(1).Return(usedCode, nil)
+				store.EXPECT().ExchangeOAuthCodeTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().RevokeOAuthGrant(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireOAuthError(t, recorder, oauthErrInvalidGrant)
+			},
+		},
+		{
+			name: "ClientCredentials",
+			form: func() url.Values {
+				return url.Values{
+					"grant_type": {grantTypeClientCredentials},
+					"scope":      {util.TransfersWriteScope},
+				}
+			},
+			setupAuth: func(request *http.Request) {
+				request.SetBasicAuth(machineClient.ClientID, secret)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(machineClient.ClientID)).Times(1).Return(machineClient, nil)
+				store.EXPECT().CreateNewOAuthToken(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateNewOAuthTokenArgs) (db.OAuthToken, error) {
+						require.Equal(t, partner.Username, arg.Username)
+						require.Equal(t, grantTypeClientCredentials, arg.GrantType)
+						require.Equal(t, []string{util.TransfersWriteScope}, arg.Scopes)
+						return db.OAuthToken{ID: arg.ID}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "ClientCredentialsWrongSecret",
+			form: func() url.Values {
+				return url.Values{"grant_type": {grantTypeClientCredentials}}
+			},
+			setupAuth: func(request *http.Request) {
+				request.SetBasicAuth(machineClient.ClientID, "wrong")
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(machineClient.ClientID)).Times(1).Return(machineClient, nil)
+				store.EXPECT().CreateNewOAuthToken(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireOAuthError(t, recorder, oauthErrInvalidClient)
+			},
+		},
+		{
+			name: "ClientCredentialsPublicClient",
+			form: func() url.Values {
+				return url.Values{
+					"grant_type": {grantTypeClientCredentials},
+					"client_id":  {publicClient.ClientID},
+				}
+			},
+			setupAuth: func(request *http.Request) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(publicClient.ClientID)).Times(1).Return(publicClient, nil)
+				store.EXPECT().CreateNewOAuthToken(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireOAuthError(t, recorder, oauthErrUnauthorizedClient)
+			},
+		},
+		{
+			name: "UnsupportedGrantType",
+			form: func() url.Values {
+				return url.Values{"grant_type": {"password"}}
+			},
+			setupAuth: func(request *http.Request) {
+				request.SetBasicAuth(machineClient.ClientID, secret)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(machineClient.ClientID)).Times(1).Return(machineClient, nil)
+				store.EXPECT().CreateNewOAuthToken(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireOAuthError(t, recorder, oauthErrUnsupportedGrantType)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			request := newOAuthFormRequest(t, "/oauth/token", tc.form())
+			tc.setupAuth(request)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder, server.tokenMaker)
+		})
+	}
+}
+
+func TestOAuthIntrospectAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	partner, _ := createRandomUser(t)
+	client, secret := createRandomOAuthClient(t, partner.Username, true)
+	otherClient, _ := createRandomOAuthClient(t, partner.Username, true)
+
+	testCases := []struct {
+		name          string
+		clientID      string
+		revoked       bool
+		buildStubs    func(store *mockdb.MockStore, payload *token.AuthPay)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Active",
+			clientID: client.ClientID,
+			buildStubs: func(store *mockdb.MockStore, payload *token.AuthPay) {
+				store.EXPECT().GetUserPasswordChangedAt(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(time.Time{}, nil)
+				store.EXPECT().GetOAuthToken(gomock.Any(), gomock.Eq(payload.ID)).Times(1).Return(db.OAuthToken{ID: payload.ID}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp oauthIntrospectResp
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.True(t, rsp.Active)
+				require.Equal(t, user.Username, rsp.Username)
+				require.Equal(t, util.AccountsReadScope, rsp.Scope)
+			},
+		},
+		{
+			name:     "Revoked",
+			clientID: client.ClientID,
+			buildStubs: func(store *mockdb.MockStore, payload *token.AuthPay) {
+				store.EXPECT().GetUserPasswordChangedAt(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(time.Time{}, nil)
+				store.EXPECT().GetOAuthToken(gomock.Any(), gomock.Eq(payload.ID)).Times(1).Return(db.OAuthToken{ID: payload.ID, IsRevoked: true}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"active":false}`, recorder.Body.String())
+			},
+		},
+		{
+			name:     "PasswordChanged",
+			clientID: client.ClientID,
+			buildStubs: func(store *mockdb.MockStore, payload *token.AuthPay) {
+				store.EXPECT().GetUserPasswordChangedAt(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(payload.IssuedAt.Add(time.Second), nil)
+				store.EXPECT().GetOAuthToken(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"active":false}`, recorder.Body.String())
+			},
+		},
+		{
+			name:     "OtherClient",
+			clientID: otherClient.ClientID,
+			buildStubs: func(store *mockdb.MockStore, payload *token.AuthPay) {
+				store.EXPECT().GetOAuthToken(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"active":false}`, recorder.Body.String())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			accessToken, payload, err := server.tokenMaker.CreateDelegatedToken(user.Username, tc.clientID, []string{util.AccountsReadScope}, time.Minute)
+			require.NoError(t, err)
+
+			store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ClientID)).Times(1).Return(client, nil)
+			tc.buildStubs(store, payload)
+
+			request := newOAuthFormRequest(t, "/oauth/introspect", url.Values{"token": {accessToken}})
+			request.SetBasicAuth(client.ClientID, secret)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestOAuthTokenAuthMiddleware(t *testing.T) {
+	user, _ := createRandomUser(t)
+	clientID := util.RandomString(16)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore, payload *token.AuthPay)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore, payload *token.AuthPay) {
+				store.EXPECT().GetOAuthToken(gomock.Any(), gomock.Eq(payload.ID)).Times(1).Return(db.OAuthToken{ID: payload.ID}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Revoked",
+			buildStubs: func(store *mockdb.MockStore, payload *token.AuthPay) {
+				store.EXPECT().GetOAuthToken(gomock.Any(), gomock.Eq(payload.ID)).Times(1).Return(db.OAuthToken{ID: payload.ID, IsRevoked: true}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errOAuthTokenRevoked)
+			},
+		},
+		{
+			name: "NotRecorded",
+			buildStubs: func(store *mockdb.MockStore, payload *token.AuthPay) {
+				store.EXPECT().GetOAuthToken(gomock.Any(), gomock.Eq(payload.ID)).Times(1).Return(db.OAuthToken{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			accessToken, payload, err := server.tokenMaker.CreateDelegatedToken(user.Username, clientID, []string{util.AccountsReadScope}, time.Minute)
+			require.NoError(t, err)
+			store.EXPECT().GetUserPasswordChangedAt(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(time.Time{}, nil)
+			tc.buildStubs(store, payload)
+
+			server.router.GET(
+				"/auth",
+				authMiddleware(server.tokenMaker, server.store),
+				scopeMiddleware(util.AccountsReadScope),
+				func(c *gin.Context) {
+					c.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			request, err := http.NewRequest(http.MethodGet, "/auth", nil)
+			require.NoError(t, err)
+
+			request.Header.Set(authorizationHeaderKey, "Bearer "+accessToken)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestGetListOAuthTokensAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	partner, _ := createRandomUser(t)
+	client, _ := createRandomOAuthClient(t, partner.Username, false)
+
+	oauthToken := db.OAuthToken{
+		ID:        uuid.New(),
+		ClientID:  client.ClientID,
+		Username:  user.Username,
+		GrantType: grantTypeAuthorizationCode,
+		Scopes:    []string{util.AccountsReadScope},
+		ExpiredAt: time.Now().Add(time.Minute),
+		CreatedAt: time.Now(),
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	arg := db.GetListOAuthTokensArgs{Username: user.Username, Limit: 5, Offset: 0}
+	store.EXPECT().GetListOAuthTokens(gomock.Any(), gomock.Eq(arg)).Times(1).Return([]db.OAuthToken{oauthToken}, nil)
+
+	server := newServerTest(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/oauth/tokens?page_id=1&page_size=5", nil)
+	require.NoError(t, err)
+
+	addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp []map[string]interface{}
+	err = json.Unmarshal(recorder.Body.Bytes(), &rsp)
+	require.NoError(t, err)
+	require.Len(t, rsp, 1)
+	require.Equal(t, oauthToken.ID.String(), rsp[0]["id"])
+	require.Equal(t, client.ClientID, rsp[0]["client_id"])
+	require.Equal(t, grantTypeAuthorizationCode, rsp[0]["grant_type"])
+	require.NotContains(t, rsp[0], "username")
+}
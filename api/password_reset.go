@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/mail"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	resetTokenBytes = 32
+	mailSendTimeout = 30 * time.Second
+)
+
+var errInvalidResetToken = errors.New("reset token is invalid or expired")
+
+type forgotPasswordReq struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type forgotPasswordResp struct {
+	Message string `json:"message"`
+}
+
+// forgotPasswordAPI answers the same way whether or not the email belongs to
+// a user, so it cannot be used to find out who has an account. The reset link
+// is created and mailed in the background, so that neither its latency nor
+// its failure shows in the response.
+func (server *Server) forgotPasswordAPI(c *gin.Context) {
+	var req forgotPasswordReq
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	rsp := forgotPasswordResp{
+		Message: "if the email belongs to an account, a reset link has been sent to it",
+	}
+
+	user, err := server.store.GetUserByEmail(c.Request.Context(), req.Email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusOK, rsp)
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	server.mailJobs.Add(1)
+	go func() {
+		defer server.mailJobs.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+		defer cancel()
+
+		err := server.sendResetPasswordEmail(ctx, user)
+		if err != nil {
+			log.Error().Err(err).Str("username", user.Username).Msg("cannot send reset password email")
+		}
+	}()
+
+	c.JSON(http.StatusOK, rsp)
+}
+
+func (server *Server) sendResetPasswordEmail(ctx context.Context, user db.User) error {
+	resetToken, err := util.RandomSecureToken(resetTokenBytes)
+	if err != nil {
+		return err
+	}
+
+	_, err = server.store.CreateNewResetToken(ctx, db.CreateNewResetTokenArgs{
+		Username:  user.Username,
+		TokenHash: util.HashSecureToken(resetToken),
+		ExpiredAt: time.Now().Add(server.config.ResetPasswordDuration),
+	})
+	if err != nil {
+		return err
+	}
+
+	return server.mailer.Send(ctx, resetPasswordMessage(user, server.resetPasswordLink(resetToken), server.config.ResetPasswordDuration))
+}
+
+func (server *Server) resetPasswordLink(resetToken string) string {
+	return fmt.Sprintf("%s?token=%s", server.config.ResetPasswordURL, url.QueryEscape(resetToken))
+}
+
+func resetPasswordMessage(user db.User, link string, duration time.Duration) mail.Message {
+	body := fmt.Sprintf(`Hello %s,
+
+We received a request to reset the password of your Simple Bank account.
+Use the link below to choose a new password. It expires in %s and can only
+be used once.
+
+%s
+
+If you did not ask for this, you can ignore this email.
+`, user.FullName, duration, link)
+
+	return mail.Message{
+		To:      []string{user.Email},
+		Subject: "Reset your Simple Bank password",
+		Body:    body,
+	}
+}
+
+type resetPasswordReq struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+func (server *Server) resetPasswordAPI(c *gin.Context) {
+	var req resetPasswordReq
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	tokenHash := util.HashSecureToken(req.Token)
+	resetToken, err := server.store.GetResetTokenByHash(c.Request.Context(), tokenHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, errorResponse(errInvalidResetToken))
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if resetToken.IsUsed || time.Now().After(resetToken.ExpiredAt) {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidResetToken))
+		return
+	}
+
+	user, err := server.store.GetUserByUsername(c.Request.Context(), resetToken.Username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.policy.Validate(req.NewPassword, user.Username, user.Email)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hashPassword, err := util.HashedPassword(req.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.ResetPasswordTx(c.Request.Context(), db.ResetPasswordTxArg{
+		TokenHash:         tokenHash,
+		HashedPassword:    hashPassword,
+		PasswordChangedAt: time.Now(),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, errorResponse(errInvalidResetToken))
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, userResp(result.User))
+}
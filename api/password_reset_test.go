@@ -0,0 +1,324 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "github.com/asshiddiq1306/simple_bank/db/mock"
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/mail"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForgotPasswordAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"email": user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(user, nil)
+				store.EXPECT().CreateNewResetToken(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateNewResetTokenArgs) (db.ResetToken, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Len(t, arg.TokenHash, 64)
+						require.WithinDuration(t, time.Now().Add(15*time.Minute), arg.ExpiredAt, time.Second)
+						return db.ResetToken{Username: arg.Username, TokenHash: arg.TokenHash, ExpiredAt: arg.ExpiredAt}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				messages := mailer.Messages()
+				require.Len(t, messages, 1)
+				require.Equal(t, []string{user.Email}, messages[0].To)
+				require.Contains(t, messages[0].Body, "http://localhost/reset?token=")
+			},
+		},
+		{
+			name: "UnknownEmail",
+			body: gin.H{
+				"email": user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().CreateNewResetToken(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Empty(t, mailer.Messages())
+			},
+		},
+		{
+			name: "InvalidEmail",
+			body: gin.H{
+				"email": "invalid-email",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "CreateTokenError",
+			body: gin.H{
+				"email": user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(user, nil)
+				store.EXPECT().CreateNewResetToken(gomock.Any(), gomock.Any()).Times(1).Return(db.ResetToken{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Empty(t, mailer.Messages())
+			},
+		},
+		{
+			name: "GetUserError",
+			body: gin.H{
+				"email": user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(db.User{}, sql.ErrConnDone)
+				store.EXPECT().CreateNewResetToken(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			server.config.ResetPasswordURL = "http://localhost/reset"
+			server.config.ResetPasswordDuration = 15 * time.Minute
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/user/password/forgot", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			server.mailJobs.Wait()
+			tc.checkResponse(recorder, server.mailer.(*mail.MemoryMailer))
+		})
+	}
+}
+
+func TestResetPasswordAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	newPassword := util.RandomString(8)
+
+	token, err := util.RandomSecureToken(resetTokenBytes)
+	require.NoError(t, err)
+
+	resetToken := db.ResetToken{
+		ID:        1,
+		Username:  user.Username,
+		TokenHash: util.HashSecureToken(token),
+		ExpiredAt: time.Now().Add(time.Minute),
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"token":        token,
+				"new_password": newPassword,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetResetTokenByHash(gomock.Any(), gomock.Eq(resetToken.TokenHash)).Times(1).Return(resetToken, nil)
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg db.ResetPasswordTxArg) (db.ResetPasswordTxResult, error) {
+						require.Equal(t, resetToken.TokenHash, arg.TokenHash)
+						require.NoError(t, util.CheckPassword(newPassword, arg.HashedPassword))
+						return db.ResetPasswordTxResult{User: user, ResetToken: resetToken}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchUser(t, recorder.Body, user)
+			},
+		},
+		{
+			name: "UnknownToken",
+			body: gin.H{
+				"token":        token,
+				"new_password": newPassword,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetResetTokenByHash(gomock.Any(), gomock.Any()).Times(1).Return(db.ResetToken{}, sql.ErrNoRows)
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidResetToken)
+			},
+		},
+		{
+			name: "ExpiredToken",
+			body: gin.H{
+				"token":        token,
+				"new_password": newPassword,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expired := resetToken
+				expired.ExpiredAt = time.Now().Add(-time.Minute)
+				store.EXPECT().GetResetTokenByHash(gomock.Any(), gomock.Any()).Times(1).Return(expired, nil)
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UsedToken",
+			body: gin.H{
+				"token":        token,
+				"new_password": newPassword,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				used := resetToken
+				used.IsUsed = true
+				store.EXPECT().GetResetTokenByHash(gomock.Any(), gomock.Any()).Times(1).Return(used, nil)
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UsedConcurrently",
+			body: gin.H{
+				"token":        token,
+				"new_password": newPassword,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetResetTokenByHash(gomock.Any(), gomock.Any()).Times(1).Return(resetToken, nil)
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(1).Return(db.ResetPasswordTxResult{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidResetToken)
+			},
+		},
+		{
+			name: "PolicyViolation",
+			body: gin.H{
+				"token":        token,
+				"new_password": strings.ToUpper(user.Username),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetResetTokenByHash(gomock.Any(), gomock.Any()).Times(1).Return(resetToken, nil)
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/user/password/reset", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestResetPasswordLink(t *testing.T) {
+	server := &Server{config: util.Config{ResetPasswordURL: "http://localhost/reset"}}
+
+	link, err := url.Parse(server.resetPasswordLink("a+b/c"))
+	require.NoError(t, err)
+	require.Equal(t, "a+b/c", link.Query().Get("token"))
+}
+
+type failingMailer struct{}
+
+func (failingMailer) Send(ctx context.Context, msg mail.Message) error {
+	return errors.New("mail server is down")
+}
+
+func TestForgotPasswordMailerError(t *testing.T) {
+	user, _ := createRandomUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(user, nil)
+	store.EXPECT().CreateNewResetToken(gomock.Any(), gomock.Any()).Times(1).Return(db.ResetToken{}, nil)
+
+	server := newServerTest(t, store)
+	server.mailer = failingMailer{}
+	recorder := httptest.NewRecorder()
+
+	data, err := json.Marshal(gin.H{"email": user.Email})
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "/user/password/forgot", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	server.mailJobs.Wait()
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/token"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+var errPayeeAccount = errors.New("exactly one of account_id or account_number is required")
+
+type createPayeeReq struct {
+	Nickname      string `json:"nickname" binding:"required,max=64"`
+	AccountID     int64  `json:"account_id" binding:"omitempty,min=1"`
+	AccountNumber string `json:"account_number" binding:"omitempty,account_number"`
+	Currency      string `json:"currency" binding:"required,currency"`
+}
+
+func (server *Server) createPayeeAPI(c *gin.Context) {
+	var req createPayeeReq
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if (req.AccountID == 0) == (req.AccountNumber == "") {
+		c.JSON(http.StatusBadRequest, errorResponse(errPayeeAccount))
+		return
+	}
+
+	account, val := server.isValidCurrency(c, req.AccountID, req.AccountNumber, req.Currency)
+	if !val {
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+
+	arg := db.CreateNewPayeeArgs{
+		Owner:     authPayload.Username,
+		Nickname:  req.Nickname,
+		AccountID: account.ID,
+		Currency:  req.Currency,
+	}
+
+	payee, err := server.store.CreateNewPayee(c.Request.Context(), arg)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code.Name() {
+			case "unique_violation":
+				c.JSON(http.StatusForbidden, errorResponse(err))
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, payee)
+}
+
+type getListPayeesReq struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (server *Server) getListPayeesAPI(c *gin.Context) {
+	var req getListPayeesReq
+	err := c.ShouldBindQuery(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+
+	arg := db.GetListPayeesArgs{
+		Owner:  authPayload.Username,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	}
+
+	payees, err := server.store.GetListPayees(c.Request.Context(), arg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, payees)
+}
+
+type deletePayeeReq struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) deletePayeeAPI(c *gin.Context) {
+	var req deletePayeeReq
+	err := c.ShouldBindUri(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+
+	payee, err := server.store.DeletePayee(c.Request.Context(), db.DeletePayeeArgs{
+		ID:    req.ID,
+		Owner: authPayload.Username,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, payee)
+}
+
+// getTransferPayee looks up a payee of username for a transfer.
+func (server *Server) getTransferPayee(c *gin.Context, username string, payeeID int64) (db.Payee, bool) {
+	payee, err := server.store.GetPayeeByID(c.Request.Context(), payeeID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return payee, false
+		}
+
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return payee, false
+	}
+
+	if payee.Owner != username {
+		c.JSON(http.StatusNotFound, errorResponse(sql.ErrNoRows))
+		return payee, false
+	}
+	return payee, true
+}
+
+// checkPayeeCooldown holds back the first transfers of username to an account
+// they recently added as a payee, however the transfer names the account.
+// This gives the owner time to notice a payee added by someone who took over
+// their account.
+func (server *Server) checkPayeeCooldown(c *gin.Context, username string, accountID int64) bool {
+	if server.config.PayeeCooldown <= 0 {
+		return true
+	}
+
+	payee, err := server.store.GetCoolingPayee(c.Request.Context(), db.GetCoolingPayeeArgs{
+		Owner:     username,
+		AccountID: accountID,
+		Since:     time.Now().Add(-server.config.PayeeCooldown),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return true
+		}
+
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	availableAt := payee.CreatedAt.Add(server.config.PayeeCooldown)
+	err = fmt.Errorf("payee can receive transfers from %s", availableAt.UTC().Format(time.RFC3339))
+	c.JSON(http.StatusForbidden, errorResponse(err))
+	return false
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/token"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+)
+
+type getPortfolioReq struct {
+	BaseCurrency string `form:"base_currency" binding:"required,currency"`
+}
+
+type portfolioAccountResponse struct {
+	ID               int64       `json:"id"`
+	AccountNumber    string      `json:"account_number"`
+	Type             string      `json:"type"`
+	Currency         string      `json:"currency"`
+	Balance          util.Money  `json:"balance"`
+	AvailableBalance util.Money  `json:"available_balance"`
+	Rate             *string     `json:"rate"`
+	Value            *util.Money `json:"value"`
+}
+
+type portfolioResponse struct {
+	BaseCurrency       string                     `json:"base_currency"`
+	Accounts           []portfolioAccountResponse `json:"accounts"`
+	Total              util.Money                 `json:"total"`
+	UnvaluedCurrencies []string                   `json:"unvalued_currencies"`
+}
+
+// getPortfolioAPI lists every account of the user and values them in
+// base_currency at the latest rates. Accounts in a currency without a rate
+// are left out of the total and their currency is listed as unvalued. A
+// value or total too large for base_currency is rejected as out of range.
+func (server *Server) getPortfolioAPI(c *gin.Context) {
+	var req getPortfolioReq
+	err := c.ShouldBindQuery(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+
+	arg := db.GetPortfolioArgs{
+		Owner:        authPayload.Username,
+		BaseCurrency: req.BaseCurrency,
+	}
+
+	rows, err := server.store.GetPortfolio(c.Request.Context(), arg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := portfolioResponse{
+		BaseCurrency:       req.BaseCurrency,
+		Accounts:           make([]portfolioAccountResponse, len(rows)),
+		Total:              util.NewMoney(0, req.BaseCurrency),
+		UnvaluedCurrencies: []string{},
+	}
+
+	unvalued := make(map[string]bool)
+	for i, row := range rows {
+		account := portfolioAccountResponse{
+			ID:               row.AccountID,
+			AccountNumber:    row.AccountNumber,
+			Type:             row.Type,
+			Currency:         row.Currency,
+			Balance:          util.NewMoney(row.Balance, row.Currency),
+			AvailableBalance: util.NewMoney(row.AvailableBalance, row.Currency),
+		}
+
+		if row.Value.Valid {
+			amount, err := strconv.ParseInt(row.Value.String, 10, 64)
+			if err != nil {
+				err = fmt.Errorf("value of account %d in %s: %w", row.AccountID, req.BaseCurrency, util.ErrMoneyOverflow)
+				c.JSON(http.StatusBadRequest, errorResponse(err))
+				return
+			}
+
+			rate := util.TrimExchangeRate(row.Rate.String)
+			value := util.NewMoney(amount, req.BaseCurrency)
+			account.Rate = &rate
+			account.Value = &value
+
+			rsp.Total, err = rsp.Total.Add(value)
+			if err != nil {
+				err = fmt.Errorf("total in %s: %w", req.BaseCurrency, err)
+				c.JSON(http.StatusBadRequest, errorResponse(err))
+				return
+			}
+		} else if !unvalued[row.Currency] {
+			unvalued[row.Currency] = true
+			rsp.UnvaluedCurrencies = append(rsp.UnvaluedCurrencies, row.Currency)
+		}
+
+		rsp.Accounts[i] = account
+	}
+
+	c.JSON(http.StatusOK, rsp)
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	mockdb "github.com/asshiddiq1306/simple_bank/db/mock"
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPortfolioAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+
+	rows := []db.GetPortfolioRow{
+		{
+			AccountID:        1,
+			AccountNumber:    "SB77000000000001",
+			Type:             util.CheckingAccount,
+			Currency:         util.EUR,
+			Balance:          1000,
+			AvailableBalance: 1000,
+		},
+		{
+			AccountID:        2,
+			AccountNumber:    "SB50000000000002",
+			Type:             util.CheckingAccount,
+			Currency:         util.IDR,
+			Balance:          160000,
+			AvailableBalance: 160000,
+			Rate:             sql.NullString{String: "0.0000625000", Valid: true},
+			Value:            sql.NullString{String: "1000", Valid: true},
+		},
+		{
+			AccountID:        3,
+			AccountNumber:    "SB23000000000003",
+			Type:             util.SavingsAccount,
+			Currency:         util.USD,
+			Balance:          2550,
+			AvailableBalance: 2550,
+			Rate:             sql.NullString{String: "1", Valid: true},
+			Value:            sql.NullString{String: "2550", Valid: true},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: url.Values{"base_currency": {util.USD}},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.GetPortfolioArgs{Owner: user.Username, BaseCurrency: util.USD}
+				store.EXPECT().GetPortfolio(gomock.Any(), gomock.Eq(arg)).Times(1).Return(rows, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp map[string]interface{}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, util.USD, rsp["base_currency"])
+				require.Equal(t, "35.50", rsp["total"])
+				require.Equal(t, []interface{}{util.EUR}, rsp["unvalued_currencies"])
+
+				accounts := rsp["accounts"].([]interface{})
+				require.Len(t, accounts, len(rows))
+
+				eur := accounts[0].(map[string]interface{})
+				require.Equal(t, "10.00", eur["balance"])
+				require.Nil(t, eur["rate"])
+				require.Nil(t, eur["value"])
+
+				idr := accounts[1].(map[string]interface{})
+				require.Equal(t, "160000", idr["available_balance"])
+				require.Equal(t, "0.0000625", idr["rate"])
+				require.Equal(t, "10.00", idr["value"])
+			},
+		},
+		{
+			name:  "ValueOutOfRange",
+			query: url.Values{"base_currency": {util.USD}},
+			buildStubs: func(store *mockdb.MockStore) {
+				rows := []db.GetPortfolioRow{
+					{
+						AccountID: 1,
+						Currency:  util.IDR,
+						Balance:   math.MaxInt64,
+						Rate:      sql.NullString{String: "1000", Valid: true},
+						Value:     sql.NullString{String: "92233720368547758070", Valid: true},
+					},
+				}
+				store.EXPECT().GetPortfolio(gomock.Any(), gomock.Any()).Times(1).Return(rows, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "TotalOutOfRange",
+			query: url.Values{"base_currency": {util.USD}},
+			buildStubs: func(store *mockdb.MockStore) {
+				value := sql.NullString{String: strconv.FormatInt(math.MaxInt64, 10), Valid: true}
+				rows := []db.GetPortfolioRow{
+					{AccountID: 1, Currency: util.USD, Balance: math.MaxInt64, Rate: sql.NullString{String: "1", Valid: true}, Value: value},
+					{AccountID: 2, Currency: util.USD, Balance: math.MaxInt64, Rate: sql.NullString{String: "1", Valid: true}, Value: value},
+				}
+				store.EXPECT().GetPortfolio(gomock.Any(), gomock.Any()).Times(1).Return(rows, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "MissingBaseCurrency",
+			query: url.Values{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPortfolio(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "UnsupportedBaseCurrency",
+			query: url.Values{"base_currency": {"XYZ"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPortfolio(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: url.Values{"base_currency": {util.USD}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPortfolio(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/user/me/portfolio?"+tc.query.Encode(), nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/asshiddiq1306/simple_bank/ratelimit"
+	"github.com/asshiddiq1306/simple_bank/token"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	rateLimitBackendMemory   = "memory"
+	rateLimitBackendPostgres = "postgres"
+	retryAfterHeaderKey      = "Retry-After"
+)
+
+var errTooManyRequests = errors.New("too many requests")
+
+func (server *Server) newRateLimiter() (ratelimit.Limiter, error) {
+	switch server.config.RateLimitBackend {
+	case "", rateLimitBackendMemory:
+		return ratelimit.NewMemoryLimiter(), nil
+	case rateLimitBackendPostgres:
+		return ratelimit.NewPostgresLimiter(server.store), nil
+	}
+	return nil, fmt.Errorf("unsupported rate limit backend %s", server.config.RateLimitBackend)
+}
+
+func clientIPKey(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+func authUserKey(c *gin.Context) string {
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+	return "user:" + authPayload.Username
+}
+
+// rateLimitMiddleware limits requests per key within a route group. Errors
+// from the limiter are logged and the request is let through, so that an
+// unavailable backend does not take the whole API down.
+func rateLimitMiddleware(limiter ratelimit.Limiter, group string, limit ratelimit.Limit, keyFunc func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !limit.Enabled() {
+			c.Next()
+			return
+		}
+
+		key := fmt.Sprintf("%s:%s", group, keyFunc(c))
+		result, err := limiter.Allow(c.Request.Context(), key, limit)
+		if err != nil {
+			log.Error().Err(err).Str("key", key).Msg("cannot check rate limit")
+			c.Next()
+			return
+		}
+
+		if !result.Allowed {
+			retryAfter := int64(math.Ceil(result.RetryAfter.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Header(retryAfterHeaderKey, strconv.FormatInt(retryAfter, 10))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, errorResponse(errTooManyRequests))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// writeRateLimitMiddleware applies limiter only to requests that change
+// state, so reads do not use up the write bucket.
+func writeRateLimitMiddleware(limiter gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+		default:
+			limiter(c)
+		}
+	}
+}
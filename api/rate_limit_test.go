@@ -0,0 +1,175 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/asshiddiq1306/simple_bank/db/mock"
+	"github.com/asshiddiq1306/simple_bank/ratelimit"
+	"github.com/asshiddiq1306/simple_bank/token"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	limit := ratelimit.PerMinute(1, 2)
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker, i int)
+		checkResponse func(t *testing.T, recorders []*httptest.ResponseRecorder)
+	}{
+		{
+			name: "PerUser",
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker, i int) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, "user", time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorders []*httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorders[0].Code)
+				require.Equal(t, http.StatusOK, recorders[1].Code)
+				require.Equal(t, http.StatusTooManyRequests, recorders[2].Code)
+				require.Equal(t, "60", recorders[2].Header().Get(retryAfterHeaderKey))
+			},
+		},
+		{
+			name: "DifferentUsers",
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker, i int) {
+				username := "user1"
+				if i == 2 {
+					username = "user2"
+				}
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, username, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorders []*httptest.ResponseRecorder) {
+				for _, recorder := range recorders {
+					require.Equal(t, http.StatusOK, recorder.Code)
+				}
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newServerTest(t, store)
+
+			limitPath := "/limit"
+			server.router.GET(
+				limitPath,
+				authMiddleware(server.tokenMaker, server.store),
+				rateLimitMiddleware(ratelimit.NewMemoryLimiter(), "test", limit, authUserKey),
+				func(c *gin.Context) {
+					c.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			recorders := make([]*httptest.ResponseRecorder, 3)
+			for i := range recorders {
+				request, err := http.NewRequest(http.MethodGet, limitPath, nil)
+				require.NoError(t, err)
+
+				tc.setupAuth(t, request, store, server.tokenMaker, i)
+				recorders[i] = httptest.NewRecorder()
+				server.router.ServeHTTP(recorders[i], request)
+			}
+			tc.checkResponse(t, recorders)
+		})
+	}
+}
+
+func TestLoginRateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := newServerTest(t, store)
+	server.config.PublicRateLimit = 1
+	server.config.PublicRateBurst = 1
+	server.setupRouter()
+
+	var codes []int
+	for i := 0; i < 2; i++ {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodPost, "/user/login", nil)
+		require.NoError(t, err)
+
+		server.router.ServeHTTP(recorder, request)
+		codes = append(codes, recorder.Code)
+	}
+
+	require.Equal(t, []int{http.StatusBadRequest, http.StatusTooManyRequests}, codes)
+}
+
+func TestRouteGroupRateLimits(t *testing.T) {
+	type call struct {
+		method string
+		path   string
+		code   int
+	}
+
+	testCases := []struct {
+		name      string
+		setLimits func(config *util.Config)
+		calls     []call
+	}{
+		{
+			name: "Transfer",
+			setLimits: func(config *util.Config) {
+				config.TransferRateLimit = 1
+				config.TransferRateBurst = 1
+			},
+			calls: []call{
+				{http.MethodPost, "/transfer", http.StatusBadRequest},
+				{http.MethodPost, "/transfer", http.StatusTooManyRequests},
+				// Other writes do not share the transfer bucket.
+				{http.MethodPost, "/payees", http.StatusBadRequest},
+			},
+		},
+		{
+			name: "Write",
+			setLimits: func(config *util.Config) {
+				config.WriteRateLimit = 1
+				config.WriteRateBurst = 1
+			},
+			calls: []call{
+				{http.MethodPost, "/payees", http.StatusBadRequest},
+				{http.MethodPost, "/transfer", http.StatusTooManyRequests},
+				// Reads do not use up the write bucket.
+				{http.MethodGet, "/accounts", http.StatusBadRequest},
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newServerTest(t, store)
+			tc.setLimits(&server.config)
+			server.setupRouter()
+
+			for _, call := range tc.calls {
+				recorder := httptest.NewRecorder()
+				request, err := http.NewRequest(call.method, call.path, nil)
+				require.NoError(t, err)
+
+				addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, "user", time.Minute)
+				server.router.ServeHTTP(recorder, request)
+				require.Equal(t, call.code, recorder.Code, "%s %s", call.method, call.path)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+)
+
+type previewRecipientReq struct {
+	Recipient string `form:"recipient" binding:"required,max=255"`
+	Currency  string `form:"currency" binding:"required,currency"`
+}
+
+type recipientPreviewResponse struct {
+	Recipient string `json:"recipient"`
+	Currency  string `json:"currency"`
+	FullName  string `json:"full_name"`
+}
+
+// previewRecipientAPI lets the sender check who a username or email alias
+// belongs to before confirming a transfer. Only a masked name is returned.
+func (server *Server) previewRecipientAPI(c *gin.Context) {
+	var req previewRecipientReq
+	err := c.ShouldBindQuery(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	recipient, ok := server.getRecipientAccount(c, req.Recipient, req.Currency)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, recipientPreviewResponse{
+		Recipient: req.Recipient,
+		Currency:  recipient.Currency,
+		FullName:  util.MaskName(recipient.FullName),
+	})
+}
+
+func (server *Server) getRecipientAccount(c *gin.Context, recipient string, currency string) (db.GetRecipientAccountRow, bool) {
+	arg := db.GetRecipientAccountArgs{
+		Recipient: recipient,
+		Currency:  currency,
+	}
+
+	account, err := server.store.GetRecipientAccount(c.Request.Context(), arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return account, false
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return account, false
+	}
+	return account, true
+}
@@ -0,0 +1,223 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	mockdb "github.com/asshiddiq1306/simple_bank/db/mock"
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomRecipient(account db.Account, fullName string) db.GetRecipientAccountRow {
+	return db.GetRecipientAccountRow{
+		ID:            account.ID,
+		Owner:         account.Owner,
+		Balance:       account.Balance,
+		Currency:      account.Currency,
+		CreatedAt:     account.CreatedAt,
+		AccountNumber: account.AccountNumber,
+		FullName:      fullName,
+	}
+}
+
+func TestPreviewRecipientAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	recipientUser, _ := createRandomUser(t)
+	account := createRandomAccount(recipientUser.Username)
+	recipient := createRandomRecipient(account, "Alice Johnson")
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: url.Values{"recipient": {recipientUser.Email}, "currency": {account.Currency}},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.GetRecipientAccountArgs{
+					Recipient: recipientUser.Email,
+					Currency:  account.Currency,
+				}
+				store.EXPECT().GetRecipientAccount(gomock.Any(), gomock.Eq(arg)).Times(1).Return(recipient, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				data, err := ioutil.ReadAll(recorder.Body)
+				require.NoError(t, err)
+
+				var res map[string]interface{}
+				require.NoError(t, json.Unmarshal(data, &res))
+				require.Equal(t, "A**** J******", res["full_name"])
+				require.Equal(t, account.Currency, res["currency"])
+				require.NotContains(t, res, "account_number")
+				require.NotContains(t, res, "id")
+			},
+		},
+		{
+			name:  "NotFound",
+			query: url.Values{"recipient": {recipientUser.Username}, "currency": {account.Currency}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetRecipientAccount(gomock.Any(), gomock.Any()).Times(1).Return(db.GetRecipientAccountRow{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidCurrency",
+			query: url.Values{"recipient": {recipientUser.Username}, "currency": {"XYZ"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetRecipientAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/transfer/recipient?%s", tc.query.Encode()), nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestTransferToRecipientAPI(t *testing.T) {
+	user1, _ := createRandomUser(t)
+	user2, _ := createRandomUser(t)
+
+	account1 := createRandomAccount(user1.Username)
+	account2 := createRandomAccount(user2.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+	recipient := createRandomRecipient(account2, user2.FullName)
+
+	amount := int64(10)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_recipient":    user2.Username,
+				"amount":          util.NewMoney(amount, util.USD).String(),
+				"currency":        util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.GetRecipientAccountArgs{
+					Recipient: user2.Username,
+					Currency:  util.USD,
+				}
+				store.EXPECT().GetRecipientAccount(gomock.Any(), gomock.Eq(arg)).Times(1).Return(recipient, nil)
+				store.EXPECT().GetAccountByID(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccountByID(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				transferArg := db.TransferTxArg{
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        amount,
+					InitiatedBy:   user1.Username,
+				}
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(transferArg)).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "RecipientNotFound",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_recipient":    user2.Email,
+				"amount":          util.NewMoney(amount, util.USD).String(),
+				"currency":        util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetRecipientAccount(gomock.Any(), gomock.Any()).Times(1).Return(db.GetRecipientAccountRow{}, sql.ErrNoRows)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "RecipientAndAccount",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"to_recipient":    user2.Username,
+				"amount":          util.NewMoney(amount, util.USD).String(),
+				"currency":        util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetRecipientAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errTransferTarget)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfer", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, user1.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
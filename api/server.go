@@ -1,19 +1,42 @@
 package api
 
 import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
 	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/mail"
+	"github.com/asshiddiq1306/simple_bank/ratelimit"
 	"github.com/asshiddiq1306/simple_bank/token"
 	"github.com/asshiddiq1306/simple_bank/util"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"golang.org/x/crypto/chacha20poly1305"
 )
 
+// serverShutdownTimeout bounds how long Start waits for in-flight requests
+// once it is asked to stop.
+const serverShutdownTimeout = 30 * time.Second
+
 type Server struct {
-	config     util.Config
-	tokenMaker token.Maker
-	store      db.Store
-	router     *gin.Engine
+	config         util.Config
+	tokenMaker     token.Maker
+	store          db.Store
+	router         *gin.Engine
+	limiter        ratelimit.Limiter
+	policy         *util.PasswordPolicy
+	mailer         mail.Mailer
+	mfaThresholds  map[string]int64
+	savingsRates   map[string]int64
+	accountUpdates *accountUpdateHub
+	// mailJobs tracks mails that are still being sent in the background.
+	mailJobs sync.WaitGroup
 }
 
 func NewServer(config util.Config, store db.Store) (*Server, error) {
@@ -23,13 +46,57 @@ func NewServer(config util.Config, store db.Store) (*Server, error) {
 	}
 
 	server := &Server{
-		config:     config,
-		tokenMaker: maker,
-		store:      store,
+		config:         config,
+		tokenMaker:     maker,
+		store:          store,
+		accountUpdates: newAccountUpdateHub(),
 	}
 
 	if val, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		val.RegisterValidation("currency", util.CurrencyValidator)
+		val.RegisterValidation("scope", util.ScopeValidator)
+		val.RegisterValidation("account_number", util.AccountNumberValidator)
+		val.RegisterValidation("transfer_description", util.TransferDescriptionValidator)
+		val.RegisterValidation("transfer_reference", util.TransferReferenceValidator)
+		val.RegisterValidation("exchange_rate", util.ExchangeRateValidator)
+	}
+
+	if len(config.TOTPEncryptionKey) != chacha20poly1305.KeySize {
+		return nil, fmt.Errorf("invalid totp encryption key length : must be %d characters", chacha20poly1305.KeySize)
+	}
+
+	if len(config.WebhookEncryptionKey) != chacha20poly1305.KeySize {
+		return nil, fmt.Errorf("invalid webhook encryption key length : must be %d characters", chacha20poly1305.KeySize)
+	}
+
+	server.mfaThresholds, err = util.ParseCurrencyMoney(config.TransferMFAThresholds)
+	if err != nil {
+		return nil, err
+	}
+
+	server.savingsRates, err = util.ParseCurrencyAmounts(config.SavingsInterestRates)
+	if err != nil {
+		return nil, err
+	}
+	for currency, rate := range server.savingsRates {
+		if rate > 10000 {
+			return nil, fmt.Errorf("invalid savings interest rate for %s : must not exceed 10000 basis points", currency)
+		}
+	}
+
+	server.policy, err = util.NewPasswordPolicy(config)
+	if err != nil {
+		return nil, err
+	}
+
+	server.limiter, err = server.newRateLimiter()
+	if err != nil {
+		return nil, err
+	}
+
+	server.mailer, err = server.newMailer()
+	if err != nil {
+		return nil, err
 	}
 
 	server.setupRouter()
@@ -37,23 +104,127 @@ func NewServer(config util.Config, store db.Store) (*Server, error) {
 }
 
 func (server *Server) setupRouter() {
-	router := gin.Default()
+	router := gin.New()
+	router.Use(gin.Recovery(), otelgin.Middleware(util.ServiceName), requestLogger())
+
+	publicLimit := ratelimit.PerMinute(server.config.PublicRateLimit, server.config.PublicRateBurst)
+	publicRouter := router.Group("/").Use(rateLimitMiddleware(server.limiter, "public", publicLimit, clientIPKey))
+
+	publicRouter.POST("/user", server.createNewUserAPI)
+	publicRouter.POST("/user/login", server.userLoginAPI)
+	publicRouter.POST("/user/password/forgot", server.forgotPasswordAPI)
+	publicRouter.POST("/user/password/reset", server.resetPasswordAPI)
+	publicRouter.GET("/user/verify_email", server.verifyEmailAPI)
+	publicRouter.POST("/oauth/token", server.oauthTokenAPI)
+	publicRouter.POST("/oauth/introspect", server.oauthIntrospectAPI)
+	publicRouter.POST("/oauth/revoke", server.oauthRevokeAPI)
 
-	router.POST("/user", server.createNewUserAPI)
-	router.POST("/user/login", server.userLoginAPI)
+	authLimit := ratelimit.PerMinute(server.config.AuthRateLimit, server.config.AuthRateBurst)
+	writeLimit := ratelimit.PerMinute(server.config.WriteRateLimit, server.config.WriteRateBurst)
+	authRouter := router.Group("/").Use(
+		authMiddleware(server.tokenMaker, server.store),
+		rateLimitMiddleware(server.limiter, "auth", authLimit, authUserKey),
+		writeRateLimitMiddleware(rateLimitMiddleware(server.limiter, "write", writeLimit, authUserKey)),
+	)
 
-	authRouter := router.Group("/").Use(authMiddleware(server.tokenMaker))
+	// Requests moving money also share a tighter bucket of their own.
+	transferLimit := ratelimit.PerMinute(server.config.TransferRateLimit, server.config.TransferRateBurst)
+	transferRateLimit := rateLimitMiddleware(server.limiter, "transfer", transferLimit, authUserKey)
 
-	authRouter.POST("/account", server.createNewAccountAPI)
-	authRouter.GET("/account/:id", server.getAccountByIDAPI)
-	authRouter.GET("/accounts", server.getListAccountsAPI)
-	authRouter.POST("/transfer", server.transferTxAPI)
+	verifiedEmail := verifiedEmailMiddleware(server.store, server.config.RequireVerifiedEmail)
+	session := sessionMiddleware()
+
+	authRouter.GET("/currencies", scopeMiddleware(util.AccountsReadScope), server.getListCurrenciesAPI)
+	authRouter.POST("/account", scopeMiddleware(util.AccountsWriteScope), verifiedEmail, server.createNewAccountAPI)
+	authRouter.GET("/account/:id", scopeMiddleware(util.AccountsReadScope), server.getAccountByIDAPI)
+	authRouter.GET("/accounts", scopeMiddleware(util.AccountsReadScope), server.getListAccountsAPI)
+	authRouter.GET("/accounts/stream", scopeMiddleware(util.AccountsReadScope), server.streamAccountsAPI)
+	authRouter.GET("/account/:id/members", scopeMiddleware(util.AccountsReadScope), server.getListAccountMembersAPI)
+	authRouter.POST("/account/:id/members", session, server.inviteAccountMemberAPI)
+	authRouter.POST("/account/:id/members/accept", session, server.acceptAccountInvitationAPI)
+	authRouter.DELETE("/account/:id/members/:username", session, server.deleteAccountMemberAPI)
+	authRouter.GET("/user/me/invitations", session, server.getListAccountInvitationsAPI)
+	authRouter.PUT("/account/:id/approval_threshold", session, server.updateApprovalThresholdAPI)
+	authRouter.POST("/account/:id/webhooks", session, server.createWebhookEndpointAPI)
+	authRouter.GET("/account/:id/webhooks", scopeMiddleware(util.AccountsReadScope), server.getListWebhookEndpointsAPI)
+	authRouter.PATCH("/webhooks/:id", session, server.updateWebhookEndpointAPI)
+	authRouter.DELETE("/webhooks/:id", session, server.deleteWebhookEndpointAPI)
+	authRouter.GET("/webhooks/:id/deliveries", session, server.getListWebhookDeliveriesAPI)
+	authRouter.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", session, server.redeliverWebhookAPI)
+	authRouter.POST("/transfer", scopeMiddleware(util.TransfersWriteScope), transferRateLimit, verifiedEmail, server.transferTxAPI)
+	authRouter.POST("/transfer/quote", scopeMiddleware(util.TransfersWriteScope), server.quoteTransferFeeAPI)
+	authRouter.GET("/transfers", scopeMiddleware(util.AccountsReadScope), server.getListTransfersAPI)
+	authRouter.GET("/transfers/approvals", scopeMiddleware(util.AccountsReadScope), server.getListTransferApprovalsAPI)
+	authRouter.POST("/transfers/:id/approve", session, transferRateLimit, server.approveTransferAPI)
+	authRouter.POST("/transfers/:id/reject", session, server.rejectTransferAPI)
+	authRouter.GET("/transfer/recipient", scopeMiddleware(util.TransfersWriteScope), server.previewRecipientAPI)
+	authRouter.POST("/payees", session, server.createPayeeAPI)
+	authRouter.GET("/payees", scopeMiddleware(util.AccountsReadScope), server.getListPayeesAPI)
+	authRouter.DELETE("/payees/:id", session, server.deletePayeeAPI)
+	authRouter.GET("/user/me", session, server.getCurrentUserAPI)
+	authRouter.GET("/user/me/portfolio", scopeMiddleware(util.AccountsReadScope), server.getPortfolioAPI)
+	authRouter.PATCH("/user/me", session, server.updateCurrentUserAPI)
+	authRouter.PUT("/user/password", session, server.changePasswordAPI)
+	authRouter.POST("/user/verify_email/resend", session, server.resendVerifyEmailAPI)
+	authRouter.POST("/user/totp/enroll", session, server.enrollTOTPAPI)
+	authRouter.POST("/user/totp/confirm", session, server.confirmTOTPAPI)
+	authRouter.POST("/api_keys", session, server.createAPIKeyAPI)
+	authRouter.GET("/api_keys", session, server.getListAPIKeysAPI)
+	authRouter.DELETE("/api_keys/:id", session, server.revokeAPIKeyAPI)
+	authRouter.POST("/oauth/clients", session, server.createOAuthClientAPI)
+	authRouter.GET("/oauth/authorize", session, server.getOAuthConsentAPI)
+	authRouter.POST("/oauth/authorize", session, server.approveOAuthConsentAPI)
+	authRouter.GET("/oauth/tokens", session, server.getListOAuthTokensAPI)
+	authRouter.DELETE("/oauth/grants/:client_id", session, server.revokeOAuthGrantAPI)
+
+	adminRouter := router.Group("/").Use(
+		authMiddleware(server.tokenMaker, server.store),
+		rateLimitMiddleware(server.limiter, "auth", authLimit, authUserKey),
+		sessionMiddleware(),
+		adminMiddleware(server.store),
+	)
+
+	adminRouter.GET("/users", server.getListUsersAPI)
+	adminRouter.POST("/users/:username/unlock", server.unlockUserAPI)
+	adminRouter.GET("/fee_schedules", server.getListFeeSchedulesAPI)
+	adminRouter.PUT("/fee_schedules", server.upsertFeeScheduleAPI)
+	adminRouter.DELETE("/fee_schedules/:id", server.deleteFeeScheduleAPI)
+	adminRouter.POST("/currencies", server.createCurrencyAPI)
+	adminRouter.PATCH("/currencies/:code", server.updateCurrencyAPI)
+	adminRouter.POST("/exchange_rates", server.createExchangeRateAPI)
 
 	server.router = router
 }
 
-func (server *Server) Start(address string) error {
-	return server.router.Run(address)
+// Start serves requests on address until ctx is done. It then stops
+// accepting connections and waits, up to serverShutdownTimeout, for the
+// requests and the mails being sent to finish. Streams end with ctx.
+func (server *Server) Start(ctx context.Context, address string) error {
+	httpServer := &http.Server{
+		Addr:    address,
+		Handler: server.router,
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
+	defer cancel()
+
+	err := httpServer.Shutdown(shutdownCtx)
+	server.mailJobs.Wait()
+	return err
 }
 
 func errorResponse(err error) gin.H {
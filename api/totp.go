@@ -0,0 +1,252 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/token"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	totpIssuer         = "Simple Bank"
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 9
+)
+
+var (
+	errMFARequired           = errors.New("two-factor authentication code required")
+	errInvalidMFACode        = errors.New("invalid two-factor authentication code")
+	errMFAEnrollmentRequired = errors.New("two-factor authentication must be enabled for this transfer amount")
+	errTOTPAlreadyEnabled    = errors.New("two-factor authentication is already enabled")
+)
+
+type enrollTOTPResp struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+func (server *Server) enrollTOTPAPI(c *gin.Context) {
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+
+	secret, err := util.GenerateTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	encryptedSecret, err := util.Encrypt(server.config.TOTPEncryptionKey, secret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	_, err = server.store.CreatePendingUserTOTP(c.Request.Context(), db.CreatePendingUserTOTPArgs{
+		Username:        authPayload.Username,
+		EncryptedSecret: encryptedSecret,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusForbidden, errorResponse(errTOTPAlreadyEnabled))
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := enrollTOTPResp{
+		Secret: secret,
+		URI:    util.TOTPURI(totpIssuer, authPayload.Username, secret),
+	}
+
+	c.JSON(http.StatusOK, rsp)
+}
+
+type confirmTOTPReq struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+type confirmTOTPResp struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func (server *Server) confirmTOTPAPI(c *gin.Context) {
+	var req confirmTOTPReq
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+
+	userTOTP, err := server.store.GetUserTOTP(c.Request.Context(), authPayload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if userTOTP.IsEnabled {
+		c.JSON(http.StatusForbidden, errorResponse(errTOTPAlreadyEnabled))
+		return
+	}
+
+	secret, err := util.Decrypt(server.config.TOTPEncryptionKey, userTOTP.EncryptedSecret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	step, ok := util.ValidateTOTP(secret, req.Code, time.Now())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidMFACode))
+		return
+	}
+
+	rsp := confirmTOTPResp{}
+	arg := db.EnableTOTPTxArg{
+		Username: authPayload.Username,
+		Step:     step,
+	}
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := util.RandomSecureToken(recoveryCodeLength)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		rsp.RecoveryCodes = append(rsp.RecoveryCodes, code)
+		arg.RecoveryCodeHashes = append(arg.RecoveryCodeHashes, util.HashSecureToken(code))
+	}
+
+	_, err = server.store.EnableTOTPTx(c.Request.Context(), arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusForbidden, errorResponse(errTOTPAlreadyEnabled))
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, rsp)
+}
+
+// getEnabledTOTP returns the user's TOTP enrollment, or false when TOTP is
+// not enabled for the user.
+func (server *Server) getEnabledTOTP(ctx context.Context, username string) (db.UserTOTP, bool, error) {
+	userTOTP, err := server.store.GetUserTOTP(ctx, username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return userTOTP, false, nil
+		}
+		return userTOTP, false, err
+	}
+	return userTOTP, userTOTP.IsEnabled, nil
+}
+
+// verifyTOTPCode accepts a code only once: the matched step is stored and
+// any code for the same or an earlier step is rejected afterwards.
+func (server *Server) verifyTOTPCode(ctx context.Context, userTOTP db.UserTOTP, code string) (bool, error) {
+	secret, err := util.Decrypt(server.config.TOTPEncryptionKey, userTOTP.EncryptedSecret)
+	if err != nil {
+		return false, err
+	}
+
+	step, ok := util.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	_, err = server.store.UseTOTPStep(ctx, db.UseTOTPStepArgs{
+		Username: userTOTP.Username,
+		Step:     step,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (server *Server) verifyRecoveryCode(ctx context.Context, username string, code string) (bool, error) {
+	_, err := server.store.UseRecoveryCode(ctx, db.UseRecoveryCodeArgs{
+		Username: username,
+		CodeHash: util.HashSecureToken(code),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (server *Server) requiresStepUp(currency string, amount int64) bool {
+	threshold, ok := server.mfaThresholds[currency]
+	return ok && amount > threshold
+}
+
+// checkStepUp writes the error response itself and returns false when the
+// caller must stop handling the request. Codes count against the same
+// attempts as logins, so a stolen session cannot guess them either.
+func (server *Server) checkStepUp(c *gin.Context, username string, code string) bool {
+	userTOTP, enabled, err := server.getEnabledTOTP(c.Request.Context(), username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	if !enabled {
+		c.JSON(http.StatusForbidden, errorResponse(errMFAEnrollmentRequired))
+		return false
+	}
+
+	if code == "" {
+		c.JSON(http.StatusUnauthorized, errorResponse(errMFARequired))
+		return false
+	}
+
+	_, err = server.takeLoginAttempt(c, username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusUnauthorized, errorResponse(errInvalidMFACode))
+			return false
+		}
+
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	ok, err := server.verifyTOTPCode(c.Request.Context(), userTOTP, code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidMFACode))
+		return false
+	}
+
+	err = server.store.ResetLoginAttempts(c.Request.Context(), username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	return true
+}
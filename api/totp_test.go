@@ -0,0 +1,388 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/asshiddiq1306/simple_bank/db/mock"
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/token"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomUserTOTP(t *testing.T, key string, username string, enabled bool) (db.UserTOTP, string) {
+	secret, err := util.GenerateTOTPSecret()
+	require.NoError(t, err)
+
+	encryptedSecret, err := util.Encrypt(key, secret)
+	require.NoError(t, err)
+
+	userTOTP := db.UserTOTP{
+		Username:        username,
+		EncryptedSecret: encryptedSecret,
+		IsEnabled:       enabled,
+	}
+	return userTOTP, secret
+}
+
+func currentTOTPCode(t *testing.T, secret string) string {
+	code, err := util.TOTPCode(secret, util.TOTPStep(time.Now()))
+	require.NoError(t, err)
+	return code
+}
+
+func TestEnrollTOTPAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).AnyTimes().Return(user, nil)
+				store.EXPECT().CreatePendingUserTOTP(gomock.Any(), gomock.Any()).Times(1).Return(db.UserTOTP{Username: user.Username}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				data, err := ioutil.ReadAll(recorder.Body)
+				require.NoError(t, err)
+
+				var rsp enrollTOTPResp
+				err = json.Unmarshal(data, &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.Secret)
+				require.Equal(t, util.TOTPURI(totpIssuer, user.Username, rsp.Secret), rsp.URI)
+			},
+		},
+		{
+			name: "AlreadyEnabled",
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).AnyTimes().Return(user, nil)
+				store.EXPECT().CreatePendingUserTOTP(gomock.Any(), gomock.Any()).Times(1).Return(db.UserTOTP{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreatePendingUserTOTP(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := "/user/totp/enroll"
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, store, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestConfirmTOTPAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	key := util.RandomString(32)
+	userTOTP, secret := createRandomUserTOTP(t, key, user.Username, false)
+	enabledTOTP, _ := createRandomUserTOTP(t, key, user.Username, true)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"code": currentTOTPCode(t, secret),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(userTOTP, nil)
+				store.EXPECT().EnableTOTPTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg db.EnableTOTPTxArg) (db.EnableTOTPTxResult, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, util.TOTPStep(time.Now()), arg.Step)
+						require.Len(t, arg.RecoveryCodeHashes, recoveryCodeCount)
+						return db.EnableTOTPTxResult{}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				data, err := ioutil.ReadAll(recorder.Body)
+				require.NoError(t, err)
+
+				var rsp confirmTOTPResp
+				err = json.Unmarshal(data, &rsp)
+				require.NoError(t, err)
+				require.Len(t, rsp.RecoveryCodes, recoveryCodeCount)
+			},
+		},
+		{
+			name: "InvalidCode",
+			body: gin.H{
+				"code": "000000",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(userTOTP, nil)
+				store.EXPECT().EnableTOTPTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "AlreadyEnabled",
+			body: gin.H{
+				"code": currentTOTPCode(t, secret),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(enabledTOTP, nil)
+				store.EXPECT().EnableTOTPTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "NotEnrolled",
+			body: gin.H{
+				"code": currentTOTPCode(t, secret),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(db.UserTOTP{}, sql.ErrNoRows)
+				store.EXPECT().EnableTOTPTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "BadRequest",
+			body: gin.H{
+				"code": "abc",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().EnableTOTPTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).AnyTimes().Return(user, nil)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			server.config.TOTPEncryptionKey = key
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/user/totp/confirm"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestLoginUserTOTPAPI(t *testing.T) {
+	user, password := createRandomUser(t)
+	key := util.RandomString(32)
+	userTOTP, secret := createRandomUserTOTP(t, key, user.Username, true)
+	recoveryCode := util.RandomString(9)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"username":        user.Username,
+				"hashed_password": password,
+				"totp_code":       currentTOTPCode(t, secret),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UseTOTPStepArgs{
+					Username: user.Username,
+					Step:     util.TOTPStep(time.Now()),
+				}
+				store.EXPECT().UseTOTPStep(gomock.Any(), gomock.Eq(arg)).Times(1).Return(userTOTP, nil)
+				store.EXPECT().ResetLoginAttempts(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireMFALevel(t, recorder, tokenMaker, token.MFATOTP)
+			},
+		},
+		{
+			name: "RecoveryCode",
+			body: gin.H{
+				"username":        user.Username,
+				"hashed_password": password,
+				"recovery_code":   recoveryCode,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UseRecoveryCodeArgs{
+					Username: user.Username,
+					CodeHash: util.HashSecureToken(recoveryCode),
+				}
+				store.EXPECT().UseRecoveryCode(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.RecoveryCode{}, nil)
+				store.EXPECT().ResetLoginAttempts(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireMFALevel(t, recorder, tokenMaker, token.MFATOTP)
+			},
+		},
+		{
+			name: "MissingCode",
+			body: gin.H{
+				"username":        user.Username,
+				"hashed_password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ResetLoginAttempts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errMFARequired)
+			},
+		},
+		{
+			name: "ReplayedCode",
+			body: gin.H{
+				"username":        user.Username,
+				"hashed_password": password,
+				"totp_code":       currentTOTPCode(t, secret),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Times(1).Return(db.UserTOTP{}, sql.ErrNoRows)
+				store.EXPECT().ResetLoginAttempts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidMFACode)
+			},
+		},
+		{
+			name: "InvalidRecoveryCode",
+			body: gin.H{
+				"username":        user.Username,
+				"hashed_password": password,
+				"recovery_code":   recoveryCode,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UseRecoveryCode(gomock.Any(), gomock.Any()).Times(1).Return(db.RecoveryCode{}, sql.ErrNoRows)
+				store.EXPECT().ResetLoginAttempts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidMFACode)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+			store.EXPECT().TakeLoginAttempt(gomock.Any(), gomock.Any()).Times(1).Return(db.LoginAttempt{Username: user.Username, FailedAttempts: 1}, nil)
+			store.EXPECT().GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(userTOTP, nil)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			server.config.TOTPEncryptionKey = key
+			server.config.LoginMaxAttempts = 3
+			server.config.LoginLockout = time.Minute
+			server.config.LoginMaxLockout = time.Hour
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/user/login"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder, server.tokenMaker)
+		})
+	}
+}
+
+func requireMFALevel(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker, mfaLevel string) {
+	var rsp userLoginResp
+	err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+	require.NoError(t, err)
+
+	payload, err := tokenMaker.VerifyToken(rsp.AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, mfaLevel, payload.MFALevel)
+}
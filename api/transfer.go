@@ -2,20 +2,105 @@ package api
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	db "github.com/asshiddiq1306/simple_bank/db/sql"
 	"github.com/asshiddiq1306/simple_bank/token"
+	"github.com/asshiddiq1306/simple_bank/util"
 	"github.com/gin-gonic/gin"
 )
 
+var (
+	errTransferSource = errors.New("exactly one of from_account_id or from_account_number is required")
+	errTransferTarget = errors.New("exactly one of to_account_id, to_account_number, to_recipient or payee_id is required")
+	errTransferAmount = errors.New("amount must be greater than zero")
+)
+
+type transferResponse struct {
+	ID            int64           `json:"id"`
+	FromAccountID int64           `json:"from_account_id"`
+	ToAccountID   int64           `json:"to_account_id"`
+	Amount        util.Money      `json:"amount"`
+	CreatedAt     time.Time       `json:"created_at"`
+	Description   string          `json:"description"`
+	Reference     string          `json:"reference"`
+	Metadata      json.RawMessage `json:"metadata"`
+	Fee           util.Money      `json:"fee"`
+}
+
+func transferResp(transfer db.Transfer, currency string) transferResponse {
+	return transferResponse{
+		ID:            transfer.ID,
+		FromAccountID: transfer.FromAccountID,
+		ToAccountID:   transfer.ToAccountID,
+		Amount:        util.NewMoney(transfer.Amount, currency),
+		CreatedAt:     transfer.CreatedAt,
+		Description:   transfer.Description,
+		Reference:     transfer.Reference,
+		Metadata:      transfer.Metadata,
+		Fee:           util.NewMoney(transfer.Fee, currency),
+	}
+}
+
+type entryResponse struct {
+	ID        int64      `json:"id"`
+	AccountID int64      `json:"account_id"`
+	Amount    util.Money `json:"amount"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func entryResp(entry db.Entry, currency string) entryResponse {
+	return entryResponse{
+		ID:        entry.ID,
+		AccountID: entry.AccountID,
+		Amount:    util.NewMoney(entry.Amount, currency),
+		CreatedAt: entry.CreatedAt,
+	}
+}
+
+type transferTxResponse struct {
+	Transfer     transferResponse `json:"transfer"`
+	FromEntry    entryResponse    `json:"from_entry"`
+	ToEntry      entryResponse    `json:"to_entry"`
+	FromAccount  accountResponse  `json:"from_account"`
+	ToAccount    accountResponse  `json:"to_account"`
+	Fee          util.Money       `json:"fee"`
+	FeeEntry     entryResponse    `json:"fee_entry"`
+	RevenueEntry entryResponse    `json:"revenue_entry"`
+}
+
+// transferTxResp encodes the amounts of result in currency, which is the
+// currency of both of its accounts.
+func transferTxResp(result db.TransferTxResult, currency string) transferTxResponse {
+	return transferTxResponse{
+		Transfer:     transferResp(result.Transfer, currency),
+		FromEntry:    entryResp(result.FromEntry, currency),
+		ToEntry:      entryResp(result.ToEntry, currency),
+		FromAccount:  accountResp(result.FromAccount),
+		ToAccount:    accountResp(result.ToAccount),
+		Fee:          util.NewMoney(result.Fee, currency),
+		FeeEntry:     entryResp(result.FeeEntry, currency),
+		RevenueEntry: entryResp(result.RevenueEntry, currency),
+	}
+}
+
 type transferTxReq struct {
-	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
-	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
-	Amount        int64  `json:"amount" binding:"required,gt=0"`
-	Currency      string `json:"currency" binding:"required,currency"`
+	FromAccountID     int64           `json:"from_account_id" binding:"omitempty,min=1"`
+	FromAccountNumber string          `json:"from_account_number" binding:"omitempty,account_number"`
+	ToAccountID       int64           `json:"to_account_id" binding:"omitempty,min=1"`
+	ToAccountNumber   string          `json:"to_account_number" binding:"omitempty,account_number"`
+	ToRecipient       string          `json:"to_recipient" binding:"omitempty,max=255"`
+	PayeeID           int64           `json:"payee_id" binding:"omitempty,min=1"`
+	Amount            string          `json:"amount" binding:"required"`
+	Currency          string          `json:"currency" binding:"required,currency"`
+	Description       string          `json:"description" binding:"omitempty,transfer_description"`
+	Reference         string          `json:"reference" binding:"omitempty,transfer_reference"`
+	Metadata          json.RawMessage `json:"metadata"`
+	TOTPCode          string          `json:"totp_code" binding:"omitempty,len=6,numeric"`
 }
 
 func (server *Server) transferTxAPI(c *gin.Context) {
@@ -26,42 +111,244 @@ func (server *Server) transferTxAPI(c *gin.Context) {
 		return
 	}
 
-	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+	amount, ok := parseTransferAmount(c, &req)
+	if !ok {
+		return
+	}
 
-	fromAccount, val := server.isValidCurrency(c, req.FromAccountID, req.Currency)
-	if !val {
+	req.Metadata, err = util.NormalizeTransferMetadata(req.Metadata)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
-	if fromAccount.Owner != authPayload.Username {
-		err := errors.New("this account doesn't belongs to auth user")
-		c.JSON(http.StatusUnauthorized, errorResponse(err))
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+
+	fromAccount, toAccount, ok := server.getTransferAccounts(c, &req, authPayload.Username)
+	if !ok {
 		return
 	}
 
-	_, val = server.isValidCurrency(c, req.ToAccountID, req.Currency)
-	if !val {
+	if server.requiresStepUp(req.Currency, amount.Amount) && !server.checkStepUp(c, authPayload.Username, req.TOTPCode) {
 		return
 	}
 
 	arg := db.TransferTxArg{
-		FromAccountID: req.FromAccountID,
-		ToAccountID:   req.ToAccountID,
-		Amount:        req.Amount,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        amount.Amount,
+		Description:   req.Description,
+		Reference:     req.Reference,
+		Metadata:      req.Metadata,
+		InitiatedBy:   authPayload.Username,
+	}
+
+	if fromAccount.RequiresApproval(arg.Amount) {
+		server.requestTransferApproval(c, arg, req.Currency)
+		return
 	}
 
-	transfer, err := server.store.TransferTx(c, arg)
+	transfer, err := server.store.TransferTx(c.Request.Context(), arg)
 	if err != nil {
+		if err == db.ErrTransferNotAllowed || err == db.ErrTransferRequiresApproval {
+			c.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, transfer)
+	c.JSON(http.StatusOK, transferTxResp(transfer, req.Currency))
+
+}
+
+// parseTransferAmount parses the decimal amount of req in its currency,
+// writing the error response and returning false when it is invalid.
+func parseTransferAmount(c *gin.Context, req *transferTxReq) (util.Money, bool) {
+	amount, err := util.ParseMoney(req.Amount, req.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return amount, false
+	}
+
+	if amount.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse(errTransferAmount))
+		return amount, false
+	}
+	return amount, true
+}
+
+// getTransferAccounts resolves the source and target of req, writing the
+// error response and returning false when they are invalid.
+func (server *Server) getTransferAccounts(c *gin.Context, req *transferTxReq, username string) (fromAccount db.Account, toAccount db.Account, ok bool) {
+	if (req.FromAccountID == 0) == (req.FromAccountNumber == "") {
+		c.JSON(http.StatusBadRequest, errorResponse(errTransferSource))
+		return fromAccount, toAccount, false
+	}
+
+	targets := 0
+	for _, set := range []bool{req.ToAccountID != 0, req.ToAccountNumber != "", req.ToRecipient != "", req.PayeeID != 0} {
+		if set {
+			targets++
+		}
+	}
+	if targets != 1 {
+		c.JSON(http.StatusBadRequest, errorResponse(errTransferTarget))
+		return fromAccount, toAccount, false
+	}
+
+	if req.PayeeID != 0 {
+		payee, ok := server.getTransferPayee(c, username, req.PayeeID)
+		if !ok {
+			return fromAccount, toAccount, false
+		}
+		req.ToAccountID = payee.AccountID
+	}
+
+	if req.ToRecipient != "" {
+		recipient, ok := server.getRecipientAccount(c, req.ToRecipient, req.Currency)
+		if !ok {
+			return fromAccount, toAccount, false
+		}
+		req.ToAccountID = recipient.ID
+	}
+
+	fromAccount, ok = server.isValidCurrency(c, req.FromAccountID, req.FromAccountNumber, req.Currency)
+	if !ok {
+		return fromAccount, toAccount, false
+	}
+
+	if !server.authorizeAccount(c, fromAccount, username, util.CanTransferFromAccount) {
+		return fromAccount, toAccount, false
+	}
+
+	toAccount, ok = server.isValidCurrency(c, req.ToAccountID, req.ToAccountNumber, req.Currency)
+	if !ok {
+		return fromAccount, toAccount, false
+	}
+
+	if !server.checkPayeeCooldown(c, username, toAccount.ID) {
+		return fromAccount, toAccount, false
+	}
+	return fromAccount, toAccount, true
+}
+
+type transferQuoteResponse struct {
+	FromAccountID int64      `json:"from_account_id"`
+	ToAccountID   int64      `json:"to_account_id"`
+	Currency      string     `json:"currency"`
+	Amount        util.Money `json:"amount"`
+	Fee           util.Money `json:"fee"`
+	Total         util.Money `json:"total"`
+}
+
+// quoteTransferFeeAPI takes the same body as a transfer and returns the fee
+// it would be charged, without moving any money.
+func (server *Server) quoteTransferFeeAPI(c *gin.Context) {
+	var req transferTxReq
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	amount, ok := parseTransferAmount(c, &req)
+	if !ok {
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+
+	fromAccount, toAccount, ok := server.getTransferAccounts(c, &req, authPayload.Username)
+	if !ok {
+		return
+	}
+
+	arg := db.TransferFeeArg{
+		FromOwner: fromAccount.Owner,
+		ToOwner:   toAccount.Owner,
+		Currency:  fromAccount.Currency,
+		Amount:    amount.Amount,
+	}
+
+	fee, err := server.store.QuoteTransferFee(c.Request.Context(), arg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	feeMoney := util.NewMoney(fee, amount.Currency)
+	total, err := amount.Add(feeMoney)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, transferQuoteResponse{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Currency:      fromAccount.Currency,
+		Amount:        amount,
+		Fee:           feeMoney,
+		Total:         total,
+	})
+}
+
+type getListTransfersReq struct {
+	AccountID int64  `form:"account_id" binding:"required,min=1"`
+	Search    string `form:"search" binding:"omitempty,max=64"`
+	PageID    int32  `form:"page_id" binding:"required,min=1"`
+	PageSize  int32  `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// getListTransfersAPI lists the transfers in and out of one of the user's
+// accounts, newest first, optionally filtered by description or reference.
+func (server *Server) getListTransfersAPI(c *gin.Context) {
+	var req getListTransfersReq
+	err := c.ShouldBindQuery(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := server.store.GetAccountByID(c.Request.Context(), req.AccountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+	if !server.authorizeAccount(c, account, authPayload.Username, nil) {
+		return
+	}
+
+	arg := db.GetListTransfersArgs{
+		AccountID: account.ID,
+		Search:    escapeLike(req.Search),
+		Limit:     req.PageSize,
+		Offset:    (req.PageID - 1) * req.PageSize,
+	}
+
+	transfers, err := server.store.GetListTransfers(c.Request.Context(), arg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]transferResponse, len(transfers))
+	for i, transfer := range transfers {
+		rsp[i] = transferResp(transfer, account.Currency)
+	}
 
+	c.JSON(http.StatusOK, rsp)
 }
 
-func (server *Server) isValidCurrency(c *gin.Context, accountID int64, currency string) (db.Account, bool) {
-	account1, err := server.store.GetAccountByID(c, accountID)
+func (server *Server) isValidCurrency(c *gin.Context, accountID int64, accountNumber string, currency string) (db.Account, bool) {
+	account1, err := server.getAccount(c.Request.Context(), accountID, accountNumber)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, errorResponse(err))
@@ -0,0 +1,282 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/token"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+)
+
+var errApprovalThreshold = errors.New("approval threshold must be greater than zero")
+
+type transferApprovalResponse struct {
+	ID            int64           `json:"id"`
+	FromAccountID int64           `json:"from_account_id"`
+	ToAccountID   int64           `json:"to_account_id"`
+	Amount        util.Money      `json:"amount"`
+	Description   string          `json:"description"`
+	Reference     string          `json:"reference"`
+	Metadata      json.RawMessage `json:"metadata"`
+	Status        string          `json:"status"`
+	RequestedBy   string          `json:"requested_by"`
+	DecidedBy     *string         `json:"decided_by"`
+	DecidedAt     *time.Time      `json:"decided_at"`
+	TransferID    *int64          `json:"transfer_id"`
+	ExpiresAt     time.Time       `json:"expires_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+func transferApprovalResp(approval db.TransferApproval, currency string) transferApprovalResponse {
+	rsp := transferApprovalResponse{
+		ID:            approval.ID,
+		FromAccountID: approval.FromAccountID,
+		ToAccountID:   approval.ToAccountID,
+		Amount:        util.NewMoney(approval.Amount, currency),
+		Description:   approval.Description,
+		Reference:     approval.Reference,
+		Metadata:      approval.Metadata,
+		Status:        approval.Status,
+		RequestedBy:   approval.RequestedBy,
+		ExpiresAt:     approval.ExpiresAt,
+		CreatedAt:     approval.CreatedAt,
+	}
+	if approval.DecidedBy.Valid {
+		rsp.DecidedBy = &approval.DecidedBy.String
+	}
+	if approval.DecidedAt.Valid {
+		rsp.DecidedAt = &approval.DecidedAt.Time
+	}
+	if approval.TransferID.Valid {
+		rsp.TransferID = &approval.TransferID.Int64
+	}
+	return rsp
+}
+
+// requestTransferApproval stores the transfer of arg as pending approval
+// instead of making it.
+func (server *Server) requestTransferApproval(c *gin.Context, arg db.TransferTxArg, currency string) {
+	approval, err := server.store.CreateTransferApproval(c.Request.Context(), db.CreateTransferApprovalArgs{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		Description:   arg.Description,
+		Reference:     arg.Reference,
+		Metadata:      arg.Metadata,
+		RequestedBy:   arg.InitiatedBy,
+		ExpiresAt:     time.Now().Add(server.config.TransferApprovalDuration),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, transferApprovalResp(approval, currency))
+}
+
+type updateApprovalThresholdReq struct {
+	// ApprovalThreshold turns approvals off when empty.
+	ApprovalThreshold string `json:"approval_threshold"`
+}
+
+// updateApprovalThresholdAPI lets the owner require a second member's
+// approval for transfers of at least the threshold.
+func (server *Server) updateApprovalThresholdAPI(c *gin.Context) {
+	var uri accountMembersURI
+	err := c.ShouldBindUri(&uri)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateApprovalThresholdReq
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, ok := server.getMemberAccount(c, uri.ID)
+	if !ok {
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+	if !server.authorizeAccount(c, account, authPayload.Username, util.CanManageAccountMembers) {
+		return
+	}
+
+	arg := db.UpdateAccountApprovalThresholdArgs{ID: account.ID}
+	if req.ApprovalThreshold != "" {
+		threshold, err := util.ParseMoney(req.ApprovalThreshold, account.Currency)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		if threshold.Amount <= 0 {
+			c.JSON(http.StatusBadRequest, errorResponse(errApprovalThreshold))
+			return
+		}
+		arg.ApprovalThreshold = sql.NullInt64{Int64: threshold.Amount, Valid: true}
+	}
+
+	account, err = server.store.UpdateAccountApprovalThreshold(c.Request.Context(), arg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, accountResp(account))
+}
+
+type getListTransferApprovalsReq struct {
+	AccountID int64  `form:"account_id" binding:"required,min=1"`
+	Status    string `form:"status" binding:"omitempty,oneof=pending_approval approved rejected expired"`
+	PageID    int32  `form:"page_id" binding:"required,min=1"`
+	PageSize  int32  `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (server *Server) getListTransferApprovalsAPI(c *gin.Context) {
+	var req getListTransferApprovalsReq
+	err := c.ShouldBindQuery(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, ok := server.getMemberAccount(c, req.AccountID)
+	if !ok {
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+	if !server.authorizeAccount(c, account, authPayload.Username, nil) {
+		return
+	}
+
+	arg := db.GetListTransferApprovalsArgs{
+		AccountID: account.ID,
+		Status:    req.Status,
+		Limit:     req.PageSize,
+		Offset:    (req.PageID - 1) * req.PageSize,
+	}
+
+	approvals, err := server.store.GetListTransferApprovals(c.Request.Context(), arg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]transferApprovalResponse, len(approvals))
+	for i, approval := range approvals {
+		rsp[i] = transferApprovalResp(approval, account.Currency)
+	}
+
+	c.JSON(http.StatusOK, rsp)
+}
+
+type transferApprovalURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// getApprovalAccount loads the approval of the request and its from account,
+// writing the error response and returning false unless the user may
+// transfer from it.
+func (server *Server) getApprovalAccount(c *gin.Context) (db.TransferApproval, db.Account, bool) {
+	var uri transferApprovalURI
+	err := c.ShouldBindUri(&uri)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.TransferApproval{}, db.Account{}, false
+	}
+
+	approval, err := server.store.GetTransferApproval(c.Request.Context(), uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return approval, db.Account{}, false
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return approval, db.Account{}, false
+	}
+
+	account, ok := server.getMemberAccount(c, approval.FromAccountID)
+	if !ok {
+		return approval, account, false
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+	if !server.authorizeAccount(c, account, authPayload.Username, util.CanTransferFromAccount) {
+		return approval, account, false
+	}
+	return approval, account, true
+}
+
+type approveTransferResponse struct {
+	Approval transferApprovalResponse `json:"approval"`
+	transferTxResponse
+}
+
+// approveTransferAPI makes a pending transfer. The approver must be another
+// member allowed to transfer from the account.
+func (server *Server) approveTransferAPI(c *gin.Context) {
+	approval, account, ok := server.getApprovalAccount(c)
+	if !ok {
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+
+	result, err := server.store.ApproveTransferTx(c.Request.Context(), db.ApproveTransferTxArg{
+		ID:         approval.ID,
+		ApprovedBy: authPayload.Username,
+	})
+	if err != nil {
+		switch err {
+		case db.ErrApprovalNotPending:
+			c.JSON(http.StatusConflict, errorResponse(err))
+		case db.ErrSelfApproval, db.ErrTransferNotAllowed:
+			c.JSON(http.StatusForbidden, errorResponse(err))
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, approveTransferResponse{
+		Approval:           transferApprovalResp(result.Approval, account.Currency),
+		transferTxResponse: transferTxResp(result.TransferTxResult, account.Currency),
+	})
+}
+
+// rejectTransferAPI drops a pending transfer without moving any money. The
+// requester may reject their own transfer to cancel it.
+func (server *Server) rejectTransferAPI(c *gin.Context) {
+	approval, account, ok := server.getApprovalAccount(c)
+	if !ok {
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+
+	approval, err := server.store.DecideTransferApproval(c.Request.Context(), db.DecideTransferApprovalArgs{
+		ID:        approval.ID,
+		Status:    db.TransferRejected,
+		DecidedBy: authPayload.Username,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusConflict, errorResponse(db.ErrApprovalNotPending))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, transferApprovalResp(approval, account.Currency))
+}
@@ -0,0 +1,409 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/asshiddiq1306/simple_bank/db/mock"
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomTransferApproval(from db.Account, to db.Account, requestedBy string) db.TransferApproval {
+	return db.TransferApproval{
+		ID:            util.RandomInt(1, 1000),
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        util.RandomMoney(),
+		Metadata:      json.RawMessage(`{}`),
+		Status:        db.TransferPendingApproval,
+		RequestedBy:   requestedBy,
+		ExpiresAt:     time.Now().Add(time.Hour),
+		CreatedAt:     time.Now(),
+	}
+}
+
+func TestTransferRequiresApprovalAPI(t *testing.T) {
+	user1, _ := createRandomUser(t)
+	user2, _ := createRandomUser(t)
+
+	account1 := createRandomAccount(user1.Username)
+	account2 := createRandomAccount(user2.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+	account2.ID = account1.ID + 1
+	account1.ApprovalThreshold = sql.NullInt64{Int64: 500, Valid: true}
+
+	testCases := []struct {
+		name          string
+		amount        int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "AboveThreshold",
+			amount: 500,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateTransferApproval(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateTransferApprovalArgs) (db.TransferApproval, error) {
+						require.Equal(t, account1.ID, arg.FromAccountID)
+						require.Equal(t, account2.ID, arg.ToAccountID)
+						require.Equal(t, int64(500), arg.Amount)
+						require.Equal(t, user1.Username, arg.RequestedBy)
+
+						approval := createRandomTransferApproval(account1, account2, user1.Username)
+						approval.Amount = arg.Amount
+						return approval, nil
+					})
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+
+				var rsp map[string]interface{}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, db.TransferPendingApproval, rsp["status"])
+				require.Equal(t, "5.00", rsp["amount"])
+			},
+		},
+		{
+			name:   "BelowThreshold",
+			amount: 499,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateTransferApproval(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetAccountByID(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+			store.EXPECT().GetAccountByID(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          util.NewMoney(tc.amount, util.USD).String(),
+				"currency":        util.USD,
+			})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfer", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, user1.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestApproveTransferAPI(t *testing.T) {
+	owner, _ := createRandomUser(t)
+	coOwner, _ := createRandomUser(t)
+	viewer, _ := createRandomUser(t)
+	other, _ := createRandomUser(t)
+
+	account1 := createRandomAccount(owner.Username)
+	account2 := createRandomAccount(other.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+	approval := createRandomTransferApproval(account1, account2, owner.Username)
+
+	testCases := []struct {
+		name          string
+		username      string
+		member        db.AccountMember
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: coOwner.Username,
+			member:   createRandomMember(account1, coOwner.Username, util.AccountCoOwnerRole),
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ApproveTransferTxArg{ID: approval.ID, ApprovedBy: coOwner.Username}
+
+				approved := approval
+				approved.Status = db.TransferApproved
+				result := db.ApproveTransferTxResult{Approval: approved}
+				result.Transfer = db.Transfer{ID: 7, Amount: approval.Amount}
+				store.EXPECT().ApproveTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp map[string]interface{}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, db.TransferApproved, rsp["approval"].(map[string]interface{})["status"])
+				require.Equal(t, float64(7), rsp["transfer"].(map[string]interface{})["id"])
+			},
+		},
+		{
+			name:     "Viewer",
+			username: viewer.Username,
+			member:   createRandomMember(account1, viewer.Username, util.AccountViewerRole),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ApproveTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errAccountRole)
+			},
+		},
+		{
+			name:     "SelfApproval",
+			username: owner.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ApproveTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.ApproveTransferTxResult{}, db.ErrSelfApproval)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, db.ErrSelfApproval)
+			},
+		},
+		{
+			name:     "NotPending",
+			username: coOwner.Username,
+			member:   createRandomMember(account1, coOwner.Username, util.AccountCoOwnerRole),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ApproveTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.ApproveTransferTxResult{}, db.ErrApprovalNotPending)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetTransferApproval(gomock.Any(), gomock.Eq(approval.ID)).Times(1).Return(approval, nil)
+			store.EXPECT().GetAccountByID(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+			if tc.member.Username != "" {
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Any()).Times(1).Return(tc.member, nil)
+			}
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfers/%d/approve", approval.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestRejectTransferAPI(t *testing.T) {
+	owner, _ := createRandomUser(t)
+	other, _ := createRandomUser(t)
+
+	account1 := createRandomAccount(owner.Username)
+	account2 := createRandomAccount(other.Username)
+	approval := createRandomTransferApproval(account1, account2, owner.Username)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.DecideTransferApprovalArgs{
+					ID:        approval.ID,
+					Status:    db.TransferRejected,
+					DecidedBy: owner.Username,
+				}
+
+				rejected := approval
+				rejected.Status = db.TransferRejected
+				store.EXPECT().DecideTransferApproval(gomock.Any(), gomock.Eq(arg)).Times(1).Return(rejected, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp map[string]interface{}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, db.TransferRejected, rsp["status"])
+			},
+		},
+		{
+			name: "NotPending",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().DecideTransferApproval(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferApproval{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, db.ErrApprovalNotPending)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetTransferApproval(gomock.Any(), gomock.Eq(approval.ID)).Times(1).Return(approval, nil)
+			store.EXPECT().GetAccountByID(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfers/%d/reject", approval.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, owner.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestUpdateApprovalThresholdAPI(t *testing.T) {
+	owner, _ := createRandomUser(t)
+	coOwner, _ := createRandomUser(t)
+
+	account := createRandomAccount(owner.Username)
+	account.Currency = util.USD
+
+	testCases := []struct {
+		name          string
+		username      string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: owner.Username,
+			body:     gin.H{"approval_threshold": "1000.00"},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpdateAccountApprovalThresholdArgs{
+					ID:                account.ID,
+					ApprovalThreshold: sql.NullInt64{Int64: 100000, Valid: true},
+				}
+
+				updated := account
+				updated.ApprovalThreshold = arg.ApprovalThreshold
+				store.EXPECT().UpdateAccountApprovalThreshold(gomock.Any(), gomock.Eq(arg)).Times(1).Return(updated, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp map[string]interface{}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, "1000.00", rsp["approval_threshold"])
+			},
+		},
+		{
+			name:     "Disable",
+			username: owner.Username,
+			body:     gin.H{"approval_threshold": ""},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpdateAccountApprovalThresholdArgs{ID: account.ID}
+				store.EXPECT().UpdateAccountApprovalThreshold(gomock.Any(), gomock.Eq(arg)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp map[string]interface{}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Nil(t, rsp["approval_threshold"])
+			},
+		},
+		{
+			name:     "ZeroThreshold",
+			username: owner.Username,
+			body:     gin.H{"approval_threshold": "0"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAccountApprovalThreshold(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errApprovalThreshold)
+			},
+		},
+		{
+			name:     "CoOwner",
+			username: coOwner.Username,
+			body:     gin.H{"approval_threshold": "1000.00"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Any()).Times(1).
+					Return(createRandomMember(account, coOwner.Username, util.AccountCoOwnerRole), nil)
+				store.EXPECT().UpdateAccountApprovalThreshold(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetAccountByID(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/account/%d/approval_threshold", account.ID)
+			request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
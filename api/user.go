@@ -2,13 +2,20 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
 	"time"
 
 	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/token"
 	"github.com/asshiddiq1306/simple_bank/util"
 	"github.com/gin-gonic/gin"
 	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	errInvalidCredentials = errors.New("invalid username or password")
 )
 
 type createNewUserReq struct {
@@ -22,6 +29,8 @@ type newUserResponse struct {
 	Username          string    `json:"username"`
 	FullName          string    `json:"full_name"`
 	Email             string    `json:"email"`
+	Role              string    `json:"role"`
+	IsEmailVerified   bool      `json:"is_email_verified"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
 }
@@ -31,6 +40,8 @@ func userResp(user db.User) newUserResponse {
 		Username:          user.Username,
 		FullName:          user.FullName,
 		Email:             user.Email,
+		Role:              user.Role,
+		IsEmailVerified:   user.IsEmailVerified,
 		PasswordChangedAt: user.PasswordChangedAt,
 		CreatedAt:         user.CreatedAt,
 	}
@@ -44,6 +55,12 @@ func (server *Server) createNewUserAPI(c *gin.Context) {
 		return
 	}
 
+	err = server.policy.Validate(req.HashedPassword, req.Username, req.Email)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
 	hashPassword, err := util.HashedPassword(req.HashedPassword)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
@@ -57,7 +74,7 @@ func (server *Server) createNewUserAPI(c *gin.Context) {
 		Email:          req.Email,
 	}
 
-	user, err := server.store.CreateNewUser(c, arg)
+	user, err := server.store.CreateUserTx(c.Request.Context(), arg)
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
 			switch pqErr.Code.Name() {
@@ -70,6 +87,13 @@ func (server *Server) createNewUserAPI(c *gin.Context) {
 		return
 	}
 
+	// The user is already created, so a mail failure only means they have to
+	// ask for a new verification email later.
+	err = server.sendVerifyEmail(c.Request.Context(), user)
+	if err != nil {
+		log.Error().Err(err).Str("username", user.Username).Msg("cannot send verification email")
+	}
+
 	rsp := userResp(user)
 
 	c.JSON(http.StatusOK, rsp)
@@ -79,6 +103,8 @@ func (server *Server) createNewUserAPI(c *gin.Context) {
 type userLoginReq struct {
 	Username       string `json:"username" binding:"required,alphanum"`
 	HashedPassword string `json:"hashed_password" binding:"required,min=6"`
+	TOTPCode       string `json:"totp_code" binding:"omitempty,len=6,numeric"`
+	RecoveryCode   string `json:"recovery_code"`
 }
 
 type userLoginResp struct {
@@ -94,10 +120,26 @@ func (server *Server) userLoginAPI(c *gin.Context) {
 		return
 	}
 
-	user, err := server.store.GetUserByUsername(c, req.Username)
+	user, err := server.store.GetUserByUsername(c.Request.Context(), req.Username)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, errorResponse(err))
+			util.CheckDummyPassword(req.HashedPassword)
+			c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// The attempt is counted before the password is checked, so that
+	// concurrent guesses cannot get past the limit. A locked user gets the
+	// same answer as a wrong password.
+	_, err = server.takeLoginAttempt(c, user.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			util.CheckDummyPassword(req.HashedPassword)
+			c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
 			return
 		}
 
@@ -107,11 +149,48 @@ func (server *Server) userLoginAPI(c *gin.Context) {
 
 	err = util.CheckPassword(req.HashedPassword, user.HashedPassword)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+
+	mfaLevel := token.MFANone
+	userTOTP, enabled, err := server.getEnabledTOTP(c.Request.Context(), user.Username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if enabled {
+		if req.TOTPCode == "" && req.RecoveryCode == "" {
+			c.JSON(http.StatusUnauthorized, errorResponse(errMFARequired))
+			return
+		}
+
+		var ok bool
+		if req.TOTPCode != "" {
+			ok, err = server.verifyTOTPCode(c.Request.Context(), userTOTP, req.TOTPCode)
+		} else {
+			ok, err = server.verifyRecoveryCode(c.Request.Context(), user.Username, req.RecoveryCode)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		if !ok {
+			c.JSON(http.StatusUnauthorized, errorResponse(errInvalidMFACode))
+			return
+		}
+		mfaLevel = token.MFATOTP
+	}
+
+	err = server.store.ResetLoginAttempts(c.Request.Context(), user.Username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	accessToken, err := server.tokenMaker.CreateToken(user.Username, server.config.TokenAccessDuration)
+	accessToken, err := server.tokenMaker.CreateToken(user.Username, mfaLevel, server.config.TokenAccessDuration)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
@@ -124,3 +203,117 @@ func (server *Server) userLoginAPI(c *gin.Context) {
 
 	c.JSON(http.StatusOK, rsp)
 }
+
+func (server *Server) takeLoginAttempt(c *gin.Context, username string) (db.LoginAttempt, error) {
+	return server.store.TakeLoginAttempt(c.Request.Context(), db.TakeLoginAttemptArgs{
+		Username:    username,
+		MaxAttempts: server.config.LoginMaxAttempts,
+		Lockouts: util.LockoutDurations(
+			server.config.LoginMaxAttempts,
+			server.config.LoginLockout,
+			server.config.LoginMaxLockout,
+		),
+	})
+}
+
+type changePasswordReq struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,nefield=CurrentPassword"`
+}
+
+func (server *Server) changePasswordAPI(c *gin.Context) {
+	var req changePasswordReq
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+
+	user, err := server.store.GetUserByUsername(c.Request.Context(), authPayload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = util.CheckPassword(req.CurrentPassword, user.HashedPassword)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, errorResponse(errors.New("current password is incorrect")))
+		return
+	}
+
+	err = server.policy.Validate(req.NewPassword, user.Username, user.Email)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hashPassword, err := util.HashedPassword(req.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// password_changed_at comes from the server clock so that it can be
+	// compared with token.AuthPay.IssuedAt without any skew against the db.
+	user, err = server.store.UpdateUserPassword(c.Request.Context(), db.UpdateUserPasswordArgs{
+		Username:          user.Username,
+		HashedPassword:    hashPassword,
+		PasswordChangedAt: time.Now(),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	accessToken, err := server.tokenMaker.CreateToken(user.Username, authPayload.MFALevel, server.config.TokenAccessDuration)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := userLoginResp{
+		AccessToken: accessToken,
+		User:        userResp(user),
+	}
+
+	c.JSON(http.StatusOK, rsp)
+}
+
+type unlockUserReq struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+func (server *Server) unlockUserAPI(c *gin.Context) {
+	var req unlockUserReq
+	err := c.ShouldBindUri(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUserByUsername(c.Request.Context(), req.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.store.ResetLoginAttempts(c.Request.Context(), user.Username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, userResp(user))
+}
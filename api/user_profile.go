@@ -0,0 +1,140 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/token"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+var errEmptyProfileUpdate = errors.New("full_name or email is required")
+
+func (server *Server) getCurrentUserAPI(c *gin.Context) {
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+
+	user, err := server.store.GetUserByUsername(c.Request.Context(), authPayload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, userResp(user))
+}
+
+type updateCurrentUserReq struct {
+	FullName *string `json:"full_name" binding:"omitempty,min=1"`
+	Email    *string `json:"email" binding:"omitempty,email"`
+}
+
+func (server *Server) updateCurrentUserAPI(c *gin.Context) {
+	var req updateCurrentUserReq
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.FullName == nil && req.Email == nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errEmptyProfileUpdate))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+
+	arg := db.UpdateUserProfileArgs{
+		Username: authPayload.Username,
+	}
+	if req.FullName != nil {
+		arg.FullName = sql.NullString{String: *req.FullName, Valid: true}
+	}
+	if req.Email != nil {
+		arg.Email = sql.NullString{String: *req.Email, Valid: true}
+	}
+
+	user, err := server.store.UpdateUserProfile(c.Request.Context(), arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code.Name() {
+			case "unique_violation":
+				c.JSON(http.StatusForbidden, errorResponse(err))
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// The store resets verification when the address changes. As on sign up,
+	// a mail failure only means the user has to ask for a new link.
+	if !user.IsEmailVerified && req.Email != nil {
+		err = server.sendVerifyEmail(c.Request.Context(), user)
+		if err != nil {
+			log.Error().Err(err).Str("username", user.Username).Msg("cannot send verification email")
+		}
+	}
+
+	c.JSON(http.StatusOK, userResp(user))
+}
+
+type getListUsersReq struct {
+	PageID          int32  `form:"page_id" binding:"required,min=1"`
+	PageSize        int32  `form:"page_size" binding:"required,min=5,max=50"`
+	Role            string `form:"role" binding:"omitempty,oneof=depositor admin"`
+	IsEmailVerified *bool  `form:"is_email_verified"`
+	Search          string `form:"search" binding:"omitempty,max=64"`
+}
+
+func (server *Server) getListUsersAPI(c *gin.Context) {
+	var req getListUsersReq
+	err := c.ShouldBindQuery(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.GetListUserArgs{
+		Role:   req.Role,
+		Search: escapeLike(req.Search),
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	}
+	if req.IsEmailVerified != nil {
+		arg.IsEmailVerified = sql.NullBool{Bool: *req.IsEmailVerified, Valid: true}
+	}
+
+	users, err := server.store.GetListUser(c.Request.Context(), arg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := []newUserResponse{}
+	for _, user := range users {
+		rsp = append(rsp, userResp(user))
+	}
+
+	c.JSON(http.StatusOK, rsp)
+}
+
+// escapeLike makes the LIKE wildcards in s match literally.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
@@ -0,0 +1,333 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	mockdb "github.com/asshiddiq1306/simple_bank/db/mock"
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/mail"
+	"github.com/asshiddiq1306/simple_bank/token"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCurrentUserAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+				addAuth(t, request, store, tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchUser(t, recorder.Body, user)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, store *mockdb.MockStore, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/user/me", nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, store, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestUpdateCurrentUserAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	user.IsEmailVerified = true
+	fullName := util.RandomName()
+	email := util.RandomEmail()
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer)
+	}{
+		{
+			name: "FullName",
+			body: gin.H{
+				"full_name": fullName,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpdateUserProfileArgs{
+					Username: user.Username,
+					FullName: sql.NullString{String: fullName, Valid: true},
+				}
+				updated := user
+				updated.FullName = fullName
+				store.EXPECT().UpdateUserProfile(gomock.Any(), gomock.Eq(arg)).Times(1).Return(updated, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Empty(t, mailer.Messages())
+
+				var rsp newUserResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, fullName, rsp.FullName)
+				require.True(t, rsp.IsEmailVerified)
+			},
+		},
+		{
+			name: "Email",
+			body: gin.H{
+				"email": email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpdateUserProfileArgs{
+					Username: user.Username,
+					Email:    sql.NullString{String: email, Valid: true},
+				}
+				updated := user
+				updated.Email = email
+				updated.IsEmailVerified = false
+				store.EXPECT().UpdateUserProfile(gomock.Any(), gomock.Eq(arg)).Times(1).Return(updated, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				messages := mailer.Messages()
+				require.Len(t, messages, 1)
+				require.Equal(t, []string{email}, messages[0].To)
+
+				var rsp newUserResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, email, rsp.Email)
+				require.False(t, rsp.IsEmailVerified)
+			},
+		},
+		{
+			name: "EmailTaken",
+			body: gin.H{
+				"email": email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserProfile(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Empty(t, mailer.Messages())
+			},
+		},
+		{
+			name: "Empty",
+			body: gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserProfile(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errEmptyProfileUpdate)
+			},
+		},
+		{
+			name: "InvalidEmail",
+			body: gin.H{
+				"email": "invalid-email",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserProfile(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPatch, "/user/me", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder, server.mailer.(*mail.MemoryMailer))
+		})
+	}
+}
+
+func TestGetListUsersAPI(t *testing.T) {
+	admin, _ := createRandomUser(t)
+	admin.Role = util.AdminRole
+
+	n := 5
+	users := make([]db.User, n)
+	for i := 0; i < n; i++ {
+		users[i], _ = createRandomUser(t)
+	}
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			query: url.Values{
+				"page_id":           {"2"},
+				"page_size":         {"5"},
+				"role":              {util.DepositorRole},
+				"is_email_verified": {"true"},
+				"search":            {"a_b"},
+			},
+			username: admin.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.GetListUserArgs{
+					Role:            util.DepositorRole,
+					IsEmailVerified: sql.NullBool{Bool: true, Valid: true},
+					Search:          `a\_b`,
+					Limit:           5,
+					Offset:          5,
+				}
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().GetListUser(gomock.Any(), gomock.Eq(arg)).Times(1).Return(users, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp []newUserResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Len(t, rsp, n)
+				require.NotContains(t, recorder.Body.String(), "hashed_password")
+			},
+		},
+		{
+			name: "NoFilters",
+			query: url.Values{
+				"page_id":   {"1"},
+				"page_size": {"10"},
+			},
+			username: admin.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.GetListUserArgs{
+					Limit:  10,
+					Offset: 0,
+				}
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().GetListUser(gomock.Any(), gomock.Eq(arg)).Times(1).Return(users, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidRole",
+			query: url.Values{
+				"page_id":   {"1"},
+				"page_size": {"5"},
+				"role":      {"root"},
+			},
+			username: admin.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().GetListUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NotAdmin",
+			query: url.Values{
+				"page_id":   {"1"},
+				"page_size": {"5"},
+			},
+			username: users[0].Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(users[0].Username)).Times(1).Return(users[0], nil)
+				store.EXPECT().GetListUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/users?"+tc.query.Encode(), nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/mail"
+	"github.com/asshiddiq1306/simple_bank/token"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+)
+
+// verifyEmailPurpose separates verification signatures from any other HMAC
+// made with the token symmetric key.
+const verifyEmailPurpose = "verify_email"
+
+var (
+	errInvalidVerifyEmailLink = errors.New("verification link is invalid or expired")
+	errEmailNotVerified       = errors.New("email must be verified first")
+)
+
+func (server *Server) verifyEmailLink(user db.User) string {
+	expiredAt := strconv.FormatInt(time.Now().Add(server.config.VerifyEmailDuration).Unix(), 10)
+	signature := util.SignHMAC(server.config.TokenSymmetricKey, verifyEmailPurpose, user.Username, user.Email, expiredAt)
+
+	values := url.Values{}
+	values.Set("username", user.Username)
+	values.Set("email", user.Email)
+	values.Set("expired_at", expiredAt)
+	values.Set("signature", signature)
+	return fmt.Sprintf("%s?%s", server.config.VerifyEmailURL, values.Encode())
+}
+
+func (server *Server) sendVerifyEmail(ctx context.Context, user db.User) error {
+	body := fmt.Sprintf(`Hello %s,
+
+Welcome to Simple Bank! Please confirm your email address by opening the link
+below. It expires in %s.
+
+%s
+`, user.FullName, server.config.VerifyEmailDuration, server.verifyEmailLink(user))
+
+	return server.mailer.Send(ctx, mail.Message{
+		To:      []string{user.Email},
+		Subject: "Verify your Simple Bank email",
+		Body:    body,
+	})
+}
+
+type verifyEmailReq struct {
+	Username  string `form:"username" binding:"required,alphanum"`
+	Email     string `form:"email" binding:"required,email"`
+	ExpiredAt int64  `form:"expired_at" binding:"required"`
+	Signature string `form:"signature" binding:"required"`
+}
+
+func (server *Server) verifyEmailAPI(c *gin.Context) {
+	var req verifyEmailReq
+	err := c.ShouldBindQuery(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	expiredAt := strconv.FormatInt(req.ExpiredAt, 10)
+	if !util.VerifyHMAC(server.config.TokenSymmetricKey, req.Signature, verifyEmailPurpose, req.Username, req.Email, expiredAt) ||
+		time.Now().After(time.Unix(req.ExpiredAt, 0)) {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidVerifyEmailLink))
+		return
+	}
+
+	user, err := server.store.VerifyUserEmail(c.Request.Context(), db.VerifyUserEmailArgs{
+		Username: req.Username,
+		Email:    req.Email,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, errorResponse(errInvalidVerifyEmailLink))
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, userResp(user))
+}
+
+func (server *Server) resendVerifyEmailAPI(c *gin.Context) {
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+
+	user, err := server.store.GetUserByUsername(c.Request.Context(), authPayload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.IsEmailVerified {
+		c.JSON(http.StatusBadRequest, errorResponse(errors.New("email is already verified")))
+		return
+	}
+
+	err = server.sendVerifyEmail(c.Request.Context(), user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, userResp(user))
+}
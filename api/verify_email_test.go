@@ -0,0 +1,291 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	mockdb "github.com/asshiddiq1306/simple_bank/db/mock"
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/mail"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyEmailAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	verified := user
+	verified.IsEmailVerified = true
+
+	testCases := []struct {
+		name          string
+		buildQuery    func(server *Server) url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildQuery: func(server *Server) url.Values {
+				return verifyEmailQuery(t, server, user)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.VerifyUserEmailArgs{
+					Username: user.Username,
+					Email:    user.Email,
+				}
+				store.EXPECT().VerifyUserEmail(gomock.Any(), gomock.Eq(arg)).Times(1).Return(verified, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), `"is_email_verified":true`)
+			},
+		},
+		{
+			name: "InvalidSignature",
+			buildQuery: func(server *Server) url.Values {
+				query := verifyEmailQuery(t, server, user)
+				query.Set("email", util.RandomEmail())
+				return query
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().VerifyUserEmail(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidVerifyEmailLink)
+			},
+		},
+		{
+			name: "Expired",
+			buildQuery: func(server *Server) url.Values {
+				server.config.VerifyEmailDuration = -time.Minute
+				return verifyEmailQuery(t, server, user)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().VerifyUserEmail(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "EmailChanged",
+			buildQuery: func(server *Server) url.Values {
+				return verifyEmailQuery(t, server, user)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().VerifyUserEmail(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "MissingSignature",
+			buildQuery: func(server *Server) url.Values {
+				query := verifyEmailQuery(t, server, user)
+				query.Del("signature")
+				return query
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().VerifyUserEmail(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			server.config.VerifyEmailURL = "http://localhost/user/verify_email"
+			server.config.VerifyEmailDuration = time.Hour
+			recorder := httptest.NewRecorder()
+
+			url := "/user/verify_email?" + tc.buildQuery(server).Encode()
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func verifyEmailQuery(t *testing.T, server *Server, user db.User) url.Values {
+	link, err := url.Parse(server.verifyEmailLink(user))
+	require.NoError(t, err)
+	return link.Query()
+}
+
+func TestSendVerifyEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user, _ := createRandomUser(t)
+	server := newServerTest(t, mockdb.NewMockStore(ctrl))
+	server.config.VerifyEmailURL = "http://localhost/user/verify_email"
+	server.config.VerifyEmailDuration = time.Hour
+
+	err := server.sendVerifyEmail(context.Background(), user)
+	require.NoError(t, err)
+
+	messages := server.mailer.(*mail.MemoryMailer).Messages()
+	require.Len(t, messages, 1)
+	require.Equal(t, []string{user.Email}, messages[0].To)
+	require.Contains(t, messages[0].Body, "http://localhost/user/verify_email?")
+
+	query := verifyEmailQuery(t, server, user)
+	expiredAt, err := strconv.ParseInt(query.Get("expired_at"), 10, 64)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(time.Hour), time.Unix(expiredAt, 0), 2*time.Second)
+}
+
+func TestVerifiedEmailMiddleware(t *testing.T) {
+	user, _ := createRandomUser(t)
+	verified := user
+	verified.IsEmailVerified = true
+
+	testCases := []struct {
+		name          string
+		required      bool
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Verified",
+			required: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(verified, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "NotVerified",
+			required: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errEmailNotVerified)
+			},
+		},
+		{
+			name:     "NotRequired",
+			required: false,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			path := "/verified"
+			server.router.GET(
+				path,
+				authMiddleware(server.tokenMaker, server.store),
+				verifiedEmailMiddleware(server.store, tc.required),
+				func(c *gin.Context) {
+					c.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestResendVerifyEmailAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	verified := user
+	verified.IsEmailVerified = true
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Len(t, mailer.Messages(), 1)
+			},
+		},
+		{
+			name: "AlreadyVerified",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(verified, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Empty(t, mailer.Messages())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/user/verify_email/resend", nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder, server.mailer.(*mail.MemoryMailer))
+		})
+	}
+}
+
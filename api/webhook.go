@@ -0,0 +1,402 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/token"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errWebhookURL      = errors.New("url must be an absolute http or https url of a public host")
+	errWebhookDisabled = errors.New("webhook endpoint is disabled")
+)
+
+type webhookEndpointResponse struct {
+	ID                  int64      `json:"id"`
+	AccountID           int64      `json:"account_id"`
+	URL                 string     `json:"url"`
+	EventTypes          []string   `json:"event_types"`
+	Enabled             bool       `json:"enabled"`
+	ConsecutiveFailures int32      `json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at"`
+	CreatedBy           string     `json:"created_by"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+func webhookEndpointResp(endpoint db.WebhookEndpoint) webhookEndpointResponse {
+	rsp := webhookEndpointResponse{
+		ID:                  endpoint.ID,
+		AccountID:           endpoint.AccountID,
+		URL:                 endpoint.URL,
+		EventTypes:          endpoint.EventTypes,
+		Enabled:             endpoint.Enabled,
+		ConsecutiveFailures: endpoint.ConsecutiveFailures,
+		CreatedBy:           endpoint.CreatedBy,
+		CreatedAt:           endpoint.CreatedAt,
+	}
+	if endpoint.DisabledAt.Valid {
+		rsp.DisabledAt = &endpoint.DisabledAt.Time
+	}
+	return rsp
+}
+
+type webhookDeliveryResponse struct {
+	ID             int64      `json:"id"`
+	EndpointID     int64      `json:"endpoint_id"`
+	EventID        int64      `json:"event_id"`
+	Status         string     `json:"status"`
+	Attempts       int32      `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at"`
+	ResponseStatus *int32     `json:"response_status"`
+	LastError      string     `json:"last_error"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func webhookDeliveryResp(delivery db.WebhookDelivery) webhookDeliveryResponse {
+	rsp := webhookDeliveryResponse{
+		ID:            delivery.ID,
+		EndpointID:    delivery.EndpointID,
+		EventID:       delivery.EventID,
+		Status:        delivery.Status,
+		Attempts:      delivery.Attempts,
+		NextAttemptAt: delivery.NextAttemptAt,
+		LastError:     delivery.LastError,
+		CreatedAt:     delivery.CreatedAt,
+	}
+	if delivery.LastAttemptAt.Valid {
+		rsp.LastAttemptAt = &delivery.LastAttemptAt.Time
+	}
+	if delivery.ResponseStatus.Valid {
+		rsp.ResponseStatus = &delivery.ResponseStatus.Int32
+	}
+	return rsp
+}
+
+// validWebhookURL rejects urls that plainly point inside the network. A
+// host name can still resolve to an internal address, the dispatcher checks
+// the address it connects to when sending.
+func validWebhookURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return false
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return util.IsPublicWebhookIP(ip)
+	}
+	return true
+}
+
+type createWebhookEndpointReq struct {
+	URL string `json:"url" binding:"required,url"`
+	// EventTypes subscribes to every event when empty.
+	EventTypes []string `json:"event_types" binding:"omitempty,dive,oneof=AccountCreated TransferCompleted InterestPosted"`
+}
+
+type createWebhookEndpointResponse struct {
+	// Secret signs the deliveries and is only returned here.
+	Secret   string                  `json:"secret"`
+	Endpoint webhookEndpointResponse `json:"endpoint"`
+}
+
+// createWebhookEndpointAPI registers a url notified of the events of the
+// account. Only members allowed to transfer from it may do so.
+func (server *Server) createWebhookEndpointAPI(c *gin.Context) {
+	var uri accountMembersURI
+	err := c.ShouldBindUri(&uri)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req createWebhookEndpointReq
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !validWebhookURL(req.URL) {
+		c.JSON(http.StatusBadRequest, errorResponse(errWebhookURL))
+		return
+	}
+
+	account, ok := server.getMemberAccount(c, uri.ID)
+	if !ok {
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+	if !server.authorizeAccount(c, account, authPayload.Username, util.CanTransferFromAccount) {
+		return
+	}
+
+	secret, err := util.GenerateWebhookSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	encryptedSecret, err := util.Encrypt(server.config.WebhookEncryptionKey, secret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	eventTypes := req.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	endpoint, err := server.store.CreateWebhookEndpoint(c.Request.Context(), db.CreateWebhookEndpointArgs{
+		AccountID:       account.ID,
+		URL:             req.URL,
+		EncryptedSecret: encryptedSecret,
+		EventTypes:      eventTypes,
+		CreatedBy:       authPayload.Username,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, createWebhookEndpointResponse{
+		Secret:   secret,
+		Endpoint: webhookEndpointResp(endpoint),
+	})
+}
+
+func (server *Server) getListWebhookEndpointsAPI(c *gin.Context) {
+	var uri accountMembersURI
+	err := c.ShouldBindUri(&uri)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, ok := server.getMemberAccount(c, uri.ID)
+	if !ok {
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+	if !server.authorizeAccount(c, account, authPayload.Username, nil) {
+		return
+	}
+
+	endpoints, err := server.store.GetListWebhookEndpoints(c.Request.Context(), account.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]webhookEndpointResponse, len(endpoints))
+	for i, endpoint := range endpoints {
+		rsp[i] = webhookEndpointResp(endpoint)
+	}
+
+	c.JSON(http.StatusOK, rsp)
+}
+
+type webhookEndpointURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// getWebhookEndpoint loads the endpoint of the request, writing the error
+// response and returning false unless the user may transfer from its
+// account.
+func (server *Server) getWebhookEndpoint(c *gin.Context) (db.WebhookEndpoint, bool) {
+	var uri webhookEndpointURI
+	err := c.ShouldBindUri(&uri)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.WebhookEndpoint{}, false
+	}
+
+	endpoint, err := server.store.GetWebhookEndpoint(c.Request.Context(), uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return endpoint, false
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return endpoint, false
+	}
+
+	account, ok := server.getMemberAccount(c, endpoint.AccountID)
+	if !ok {
+		return endpoint, false
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.AuthPay)
+	if !server.authorizeAccount(c, account, authPayload.Username, util.CanTransferFromAccount) {
+		return endpoint, false
+	}
+	return endpoint, true
+}
+
+type updateWebhookEndpointReq struct {
+	Enabled    *bool     `json:"enabled"`
+	EventTypes *[]string `json:"event_types" binding:"omitempty,dive,oneof=AccountCreated TransferCompleted InterestPosted"`
+}
+
+// updateWebhookEndpointAPI changes the event filter of an endpoint or
+// turns it on and off. Turning it back on clears its failures.
+func (server *Server) updateWebhookEndpointAPI(c *gin.Context) {
+	var req updateWebhookEndpointReq
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	endpoint, ok := server.getWebhookEndpoint(c)
+	if !ok {
+		return
+	}
+
+	arg := db.UpdateWebhookEndpointArgs{
+		ID:         endpoint.ID,
+		Enabled:    endpoint.Enabled,
+		EventTypes: endpoint.EventTypes,
+	}
+	if req.Enabled != nil {
+		arg.Enabled = *req.Enabled
+	}
+	if req.EventTypes != nil {
+		arg.EventTypes = *req.EventTypes
+		if arg.EventTypes == nil {
+			arg.EventTypes = []string{}
+		}
+	}
+
+	endpoint, err = server.store.UpdateWebhookEndpoint(c.Request.Context(), arg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, webhookEndpointResp(endpoint))
+}
+
+func (server *Server) deleteWebhookEndpointAPI(c *gin.Context) {
+	endpoint, ok := server.getWebhookEndpoint(c)
+	if !ok {
+		return
+	}
+
+	err := server.store.DeleteWebhookEndpoint(c.Request.Context(), endpoint.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, webhookEndpointResp(endpoint))
+}
+
+type getListWebhookDeliveriesReq struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// getListWebhookDeliveriesAPI lists the delivery log of an endpoint, newest
+// first.
+func (server *Server) getListWebhookDeliveriesAPI(c *gin.Context) {
+	var req getListWebhookDeliveriesReq
+	err := c.ShouldBindQuery(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	endpoint, ok := server.getWebhookEndpoint(c)
+	if !ok {
+		return
+	}
+
+	deliveries, err := server.store.GetListWebhookDeliveries(c.Request.Context(), db.GetListWebhookDeliveriesArgs{
+		EndpointID: endpoint.ID,
+		Limit:      req.PageSize,
+		Offset:     (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]webhookDeliveryResponse, len(deliveries))
+	for i, delivery := range deliveries {
+		rsp[i] = webhookDeliveryResp(delivery)
+	}
+
+	c.JSON(http.StatusOK, rsp)
+}
+
+type redeliverWebhookURI struct {
+	ID         int64 `uri:"id" binding:"required,min=1"`
+	DeliveryID int64 `uri:"delivery_id" binding:"required,min=1"`
+}
+
+// redeliverWebhookAPI queues the event of a past delivery again as a new
+// delivery, leaving the log of the old one untouched.
+func (server *Server) redeliverWebhookAPI(c *gin.Context) {
+	var uri redeliverWebhookURI
+	err := c.ShouldBindUri(&uri)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	endpoint, ok := server.getWebhookEndpoint(c)
+	if !ok {
+		return
+	}
+
+	if !endpoint.Enabled {
+		c.JSON(http.StatusConflict, errorResponse(errWebhookDisabled))
+		return
+	}
+
+	delivery, err := server.store.GetWebhookDelivery(c.Request.Context(), uri.DeliveryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if delivery.EndpointID != endpoint.ID {
+		c.JSON(http.StatusNotFound, errorResponse(sql.ErrNoRows))
+		return
+	}
+
+	delivery, err = server.store.CreateWebhookDelivery(c.Request.Context(), db.CreateWebhookDeliveryArgs{
+		EndpointID: endpoint.ID,
+		EventID:    delivery.EventID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, webhookDeliveryResp(delivery))
+}
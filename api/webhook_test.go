@@ -0,0 +1,348 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/asshiddiq1306/simple_bank/db/mock"
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomWebhookEndpoint(account db.Account) db.WebhookEndpoint {
+	return db.WebhookEndpoint{
+		ID:         util.RandomInt(1, 1000),
+		AccountID:  account.ID,
+		URL:        "https://example.com/hooks",
+		EventTypes: []string{db.EventTransferCompleted},
+		Enabled:    true,
+		CreatedBy:  account.Owner,
+		CreatedAt:  time.Now(),
+	}
+}
+
+func createRandomWebhookDelivery(endpoint db.WebhookEndpoint) db.WebhookDelivery {
+	return db.WebhookDelivery{
+		ID:            util.RandomInt(1, 1000),
+		EndpointID:    endpoint.ID,
+		EventID:       util.RandomInt(1, 1000),
+		Status:        db.WebhookDeliveryFailed,
+		Attempts:      10,
+		NextAttemptAt: time.Now(),
+		CreatedAt:     time.Now(),
+	}
+}
+
+func TestValidWebhookURL(t *testing.T) {
+	testCases := []struct {
+		url   string
+		valid bool
+	}{
+		{url: "https://example.com/hooks", valid: true},
+		{url: "http://93.184.216.34:8080/hooks", valid: true},
+		{url: "ftp://example.com/hooks", valid: false},
+		{url: "https:///hooks", valid: false},
+		{url: "http://localhost:8080/hooks", valid: false},
+		{url: "http://LOCALHOST./hooks", valid: false},
+		{url: "http://api.localhost/hooks", valid: false},
+		{url: "http://127.0.0.1/hooks", valid: false},
+		{url: "http://[::1]/hooks", valid: false},
+		{url: "http://10.0.0.5/hooks", valid: false},
+		{url: "http://192.168.1.1/hooks", valid: false},
+		{url: "http://169.254.169.254/latest/meta-data", valid: false},
+		{url: "http://0.0.0.0/hooks", valid: false},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.url, func(t *testing.T) {
+			require.Equal(t, tc.valid, validWebhookURL(tc.url))
+		})
+	}
+}
+
+func TestCreateWebhookEndpointAPI(t *testing.T) {
+	owner, _ := createRandomUser(t)
+	viewer, _ := createRandomUser(t)
+	account := createRandomAccount(owner.Username)
+	key := util.RandomString(32)
+
+	var secret string
+
+	testCases := []struct {
+		name          string
+		username      string
+		member        db.AccountMember
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: owner.Username,
+			body: gin.H{
+				"url":         "https://example.com/hooks",
+				"event_types": []string{db.EventTransferCompleted},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByID(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					CreateWebhookEndpoint(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateWebhookEndpointArgs) (db.WebhookEndpoint, error) {
+						require.Equal(t, account.ID, arg.AccountID)
+						require.Equal(t, []string{db.EventTransferCompleted}, arg.EventTypes)
+						require.Equal(t, owner.Username, arg.CreatedBy)
+
+						var err error
+						secret, err = util.Decrypt(key, arg.EncryptedSecret)
+						require.NoError(t, err)
+
+						return createRandomWebhookEndpoint(account), nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp createWebhookEndpointResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, secret, rsp.Secret)
+				require.Equal(t, account.ID, rsp.Endpoint.AccountID)
+				require.NotContains(t, recorder.Body.String(), "encrypted_secret")
+			},
+		},
+		{
+			name:     "InvalidScheme",
+			username: owner.Username,
+			body:     gin.H{"url": "ftp://example.com/hooks"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookEndpoint(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errWebhookURL)
+			},
+		},
+		{
+			name:     "InternalURL",
+			username: owner.Username,
+			body:     gin.H{"url": "http://169.254.169.254/latest/meta-data"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookEndpoint(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errWebhookURL)
+			},
+		},
+		{
+			name:     "InvalidEventType",
+			username: owner.Username,
+			body: gin.H{
+				"url":         "https://example.com/hooks",
+				"event_types": []string{db.EventUserRegistered},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookEndpoint(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "Viewer",
+			username: viewer.Username,
+			member:   createRandomMember(account, viewer.Username, util.AccountViewerRole),
+			body:     gin.H{"url": "https://example.com/hooks"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByID(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().CreateWebhookEndpoint(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errAccountRole)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			if tc.member.Username != "" {
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Any()).Times(1).Return(tc.member, nil)
+			}
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			server.config.WebhookEncryptionKey = key
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/account/%d/webhooks", account.ID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestUpdateWebhookEndpointAPI(t *testing.T) {
+	owner, _ := createRandomUser(t)
+	account := createRandomAccount(owner.Username)
+
+	endpoint := createRandomWebhookEndpoint(account)
+	endpoint.Enabled = false
+	endpoint.ConsecutiveFailures = 20
+	endpoint.DisabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetWebhookEndpoint(gomock.Any(), gomock.Eq(endpoint.ID)).Times(1).Return(endpoint, nil)
+	store.EXPECT().GetAccountByID(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+	arg := db.UpdateWebhookEndpointArgs{
+		ID:         endpoint.ID,
+		Enabled:    true,
+		EventTypes: endpoint.EventTypes,
+	}
+	enabled := endpoint
+	enabled.Enabled = true
+	enabled.ConsecutiveFailures = 0
+	enabled.DisabledAt = sql.NullTime{}
+	store.EXPECT().UpdateWebhookEndpoint(gomock.Any(), gomock.Eq(arg)).Times(1).Return(enabled, nil)
+
+	server := newServerTest(t, store)
+	recorder := httptest.NewRecorder()
+
+	data, err := json.Marshal(gin.H{"enabled": true})
+	require.NoError(t, err)
+
+	url := fmt.Sprintf("/webhooks/%d", endpoint.ID)
+	request, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+	require.NoError(t, err)
+
+	addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, owner.Username, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp webhookEndpointResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.True(t, rsp.Enabled)
+	require.Zero(t, rsp.ConsecutiveFailures)
+	require.Nil(t, rsp.DisabledAt)
+}
+
+func TestRedeliverWebhookAPI(t *testing.T) {
+	owner, _ := createRandomUser(t)
+	account := createRandomAccount(owner.Username)
+
+	endpoint := createRandomWebhookEndpoint(account)
+	delivery := createRandomWebhookDelivery(endpoint)
+
+	testCases := []struct {
+		name          string
+		endpoint      func() db.WebhookEndpoint
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			endpoint: func() db.WebhookEndpoint { return endpoint },
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhookDelivery(gomock.Any(), gomock.Eq(delivery.ID)).Times(1).Return(delivery, nil)
+
+				arg := db.CreateWebhookDeliveryArgs{EndpointID: endpoint.ID, EventID: delivery.EventID}
+				redelivery := delivery
+				redelivery.ID = delivery.ID + 1
+				redelivery.Status = db.WebhookDeliveryPending
+				redelivery.Attempts = 0
+				store.EXPECT().CreateWebhookDelivery(gomock.Any(), gomock.Eq(arg)).Times(1).Return(redelivery, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+
+				var rsp webhookDeliveryResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, delivery.ID+1, rsp.ID)
+				require.Equal(t, delivery.EventID, rsp.EventID)
+				require.Equal(t, db.WebhookDeliveryPending, rsp.Status)
+			},
+		},
+		{
+			name:     "OtherEndpoint",
+			endpoint: func() db.WebhookEndpoint { return endpoint },
+			buildStubs: func(store *mockdb.MockStore) {
+				other := delivery
+				other.EndpointID = endpoint.ID + 1
+				store.EXPECT().GetWebhookDelivery(gomock.Any(), gomock.Eq(delivery.ID)).Times(1).Return(other, nil)
+				store.EXPECT().CreateWebhookDelivery(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "Disabled",
+			endpoint: func() db.WebhookEndpoint {
+				disabled := endpoint
+				disabled.Enabled = false
+				return disabled
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhookDelivery(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateWebhookDelivery(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errWebhookDisabled)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetWebhookEndpoint(gomock.Any(), gomock.Eq(endpoint.ID)).Times(1).Return(tc.endpoint(), nil)
+			store.EXPECT().GetAccountByID(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			tc.buildStubs(store)
+
+			server := newServerTest(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/webhooks/%d/deliveries/%d/redeliver", endpoint.ID, delivery.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, store, server.tokenMaker, authorizationBearerTypeKey, owner.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
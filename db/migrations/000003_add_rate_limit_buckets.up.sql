@@ -0,0 +1,5 @@
+CREATE TABLE "rate_limit_buckets" (
+  "key" varchar PRIMARY KEY,
+  "tokens" double precision NOT NULL,
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "login_attempts";
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "role";
\ No newline at end of file
@@ -0,0 +1,10 @@
+ALTER TABLE "users" ADD COLUMN "role" varchar NOT NULL DEFAULT 'depositor';
+
+CREATE TABLE "login_attempts" (
+  "username" varchar PRIMARY KEY,
+  "failed_attempts" int NOT NULL DEFAULT 0,
+  "locked_until" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z',
+  "last_failed_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "login_attempts" ADD FOREIGN KEY ("username") REFERENCES "users" ("username") ON DELETE CASCADE;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "reset_tokens";
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "is_email_verified";
\ No newline at end of file
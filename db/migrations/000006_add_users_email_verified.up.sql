@@ -0,0 +1,4 @@
+ALTER TABLE "users" ADD COLUMN "is_email_verified" boolean NOT NULL DEFAULT false;
+
+-- Users who signed up before verification existed keep working as before.
+UPDATE "users" SET "is_email_verified" = true;
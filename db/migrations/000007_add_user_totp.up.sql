@@ -0,0 +1,23 @@
+CREATE TABLE "user_totp" (
+  "username" varchar PRIMARY KEY,
+  "encrypted_secret" varchar NOT NULL,
+  "is_enabled" boolean NOT NULL DEFAULT false,
+  "last_used_step" bigint NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "recovery_codes" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "code_hash" varchar NOT NULL,
+  "is_used" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "user_totp" ADD FOREIGN KEY ("username") REFERENCES "users" ("username") ON DELETE CASCADE;
+
+ALTER TABLE "recovery_codes" ADD FOREIGN KEY ("username") REFERENCES "users" ("username") ON DELETE CASCADE;
+
+ALTER TABLE "recovery_codes" ADD CONSTRAINT "username_code_hash_key" UNIQUE ("username", "code_hash");
+
+COMMENT ON COLUMN "user_totp"."last_used_step" IS 'rejects replayed codes';
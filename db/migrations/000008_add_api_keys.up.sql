@@ -0,0 +1,16 @@
+CREATE TABLE "api_keys" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "name" varchar NOT NULL,
+  "prefix" varchar UNIQUE NOT NULL,
+  "key_hash" varchar UNIQUE NOT NULL,
+  "scopes" varchar[] NOT NULL,
+  "is_revoked" boolean NOT NULL DEFAULT false,
+  "expired_at" timestamptz NOT NULL,
+  "last_used_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "api_keys" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username") ON DELETE CASCADE;
+
+CREATE INDEX ON "api_keys" ("owner");
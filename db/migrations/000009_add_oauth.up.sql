@@ -0,0 +1,47 @@
+CREATE TABLE "oauth_clients" (
+  "client_id" varchar PRIMARY KEY,
+  "client_secret_hash" varchar NOT NULL DEFAULT '',
+  "owner" varchar NOT NULL,
+  "name" varchar NOT NULL,
+  "redirect_uris" varchar[] NOT NULL,
+  "scopes" varchar[] NOT NULL,
+  "is_confidential" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "oauth_codes" (
+  "code_hash" varchar PRIMARY KEY,
+  "client_id" varchar NOT NULL,
+  "username" varchar NOT NULL,
+  "redirect_uri" varchar NOT NULL,
+  "scopes" varchar[] NOT NULL,
+  "code_challenge" varchar NOT NULL,
+  "is_used" boolean NOT NULL DEFAULT false,
+  "expired_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "oauth_tokens" (
+  "id" uuid PRIMARY KEY,
+  "client_id" varchar NOT NULL,
+  "username" varchar NOT NULL,
+  "grant_type" varchar NOT NULL,
+  "scopes" varchar[] NOT NULL,
+  "is_revoked" boolean NOT NULL DEFAULT false,
+  "expired_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "oauth_clients" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username") ON DELETE CASCADE;
+
+ALTER TABLE "oauth_codes" ADD FOREIGN KEY ("client_id") REFERENCES "oauth_clients" ("client_id") ON DELETE CASCADE;
+
+ALTER TABLE "oauth_codes" ADD FOREIGN KEY ("username") REFERENCES "users" ("username") ON DELETE CASCADE;
+
+ALTER TABLE "oauth_tokens" ADD FOREIGN KEY ("client_id") REFERENCES "oauth_clients" ("client_id") ON DELETE CASCADE;
+
+ALTER TABLE "oauth_tokens" ADD FOREIGN KEY ("username") REFERENCES "users" ("username") ON DELETE CASCADE;
+
+CREATE INDEX ON "oauth_clients" ("owner");
+
+CREATE INDEX ON "oauth_tokens" ("username", "client_id");
@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "account_number";
@@ -0,0 +1,13 @@
+ALTER TABLE "accounts" ADD COLUMN "account_number" varchar;
+
+-- Backfill existing accounts with SB<check digits><12 digits>, where the
+-- check digits are 98 - mod97(bban || 'SB' || '00') and S=28, B=11.
+UPDATE "accounts" SET "account_number" = 'SB' || lpad((98 - (g.bban || '281100')::numeric % 97)::text, 2, '0') || g.bban
+FROM (
+  SELECT "id", lpad(floor(random() * 1e12)::bigint::text, 12, '0') AS bban FROM "accounts"
+) AS g
+WHERE "accounts"."id" = g."id";
+
+ALTER TABLE "accounts" ALTER COLUMN "account_number" SET NOT NULL;
+
+CREATE UNIQUE INDEX ON "accounts" ("account_number");
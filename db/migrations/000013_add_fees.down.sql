@@ -0,0 +1,12 @@
+DELETE FROM "entries" WHERE "account_id" IN (SELECT "id" FROM "accounts" WHERE "owner" = 'simplebank');
+
+DELETE FROM "transfers" WHERE "from_account_id" IN (SELECT "id" FROM "accounts" WHERE "owner" = 'simplebank')
+OR "to_account_id" IN (SELECT "id" FROM "accounts" WHERE "owner" = 'simplebank');
+
+DELETE FROM "accounts" WHERE "owner" = 'simplebank';
+
+DELETE FROM "users" WHERE "username" = 'simplebank';
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "fee";
+
+DROP TABLE IF EXISTS "fee_schedules";
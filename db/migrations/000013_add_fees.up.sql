@@ -0,0 +1,31 @@
+CREATE TABLE "fee_schedules" (
+  "id" bigserial PRIMARY KEY,
+  "currency" varchar NOT NULL,
+  "min_amount" bigint NOT NULL DEFAULT 0 CHECK ("min_amount" >= 0),
+  "flat_fee" bigint NOT NULL DEFAULT 0 CHECK ("flat_fee" >= 0),
+  "rate_bps" integer NOT NULL DEFAULT 0 CHECK ("rate_bps" BETWEEN 0 AND 10000),
+  "max_fee" bigint NOT NULL DEFAULT 0 CHECK ("max_fee" >= 0),
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX ON "fee_schedules" ("currency", "min_amount");
+
+COMMENT ON COLUMN "fee_schedules"."min_amount" IS 'the tier applies to transfers of at least this amount';
+
+COMMENT ON COLUMN "fee_schedules"."rate_bps" IS 'percentage fee in basis points';
+
+COMMENT ON COLUMN "fee_schedules"."max_fee" IS 'caps the fee, 0 means no cap';
+
+ALTER TABLE "transfers" ADD COLUMN "fee" bigint NOT NULL DEFAULT 0;
+
+-- Fees are posted to one revenue account per currency, owned by a system
+-- user that cannot log in.
+INSERT INTO "users" ("username", "hashed_password", "full_name", "email")
+VALUES ('simplebank', '', 'Simple Bank', 'fees@simplebank.invalid');
+
+INSERT INTO "accounts" ("owner", "balance", "currency", "account_number")
+SELECT 'simplebank', 0, g.currency, 'SB' || lpad((98 - (g.bban || '281100')::numeric % 97)::text, 2, '0') || g.bban
+FROM (
+  SELECT currency, lpad(floor(random() * 1e12)::bigint::text, 12, '0') AS bban
+  FROM unnest(ARRAY['USD', 'IDR', 'EUR']) AS currency
+) AS g;
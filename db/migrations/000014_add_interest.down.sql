@@ -0,0 +1,20 @@
+DROP TABLE IF EXISTS "interest_accruals";
+
+DROP TABLE IF EXISTS "interest_postings";
+
+DELETE FROM "entries" WHERE "account_id" IN (SELECT "id" FROM "accounts" WHERE "owner" = 'simplebankinterest' OR "type" <> 'checking');
+
+DELETE FROM "transfers" WHERE "from_account_id" IN (SELECT "id" FROM "accounts" WHERE "owner" = 'simplebankinterest' OR "type" <> 'checking')
+OR "to_account_id" IN (SELECT "id" FROM "accounts" WHERE "owner" = 'simplebankinterest' OR "type" <> 'checking');
+
+DELETE FROM "accounts" WHERE "owner" = 'simplebankinterest' OR "type" <> 'checking';
+
+DELETE FROM "users" WHERE "username" = 'simplebankinterest';
+
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "owner_currency_type_key";
+
+ALTER TABLE IF EXISTS "accounts" ADD CONSTRAINT "owner_currency_key" UNIQUE ("owner", "currency");
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "interest_rate_bps";
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "type";
@@ -0,0 +1,60 @@
+ALTER TABLE "accounts" ADD COLUMN "type" varchar NOT NULL DEFAULT 'checking';
+
+ALTER TABLE "accounts" ADD COLUMN "interest_rate_bps" integer NOT NULL DEFAULT 0 CHECK ("interest_rate_bps" >= 0);
+
+COMMENT ON COLUMN "accounts"."interest_rate_bps" IS 'annual interest rate in basis points, fixed when the account is opened';
+
+-- A user may now hold a checking and a savings account in the same currency.
+ALTER TABLE "accounts" DROP CONSTRAINT "owner_currency_key";
+
+ALTER TABLE "accounts" ADD CONSTRAINT "owner_currency_type_key" UNIQUE ("owner", "currency", "type");
+
+CREATE TABLE "interest_postings" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "period_end" date NOT NULL,
+  "accrued_micros" bigint NOT NULL DEFAULT 0,
+  "amount" bigint NOT NULL DEFAULT 0,
+  "carry_micros" bigint NOT NULL DEFAULT 0,
+  "transfer_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "interest_postings" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "interest_postings" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE UNIQUE INDEX ON "interest_postings" ("account_id", "period_end");
+
+COMMENT ON COLUMN "interest_postings"."carry_micros" IS 'fraction of a minor unit carried to the next posting';
+
+CREATE TABLE "interest_accruals" (
+  "account_id" bigint NOT NULL,
+  "accrual_date" date NOT NULL,
+  "balance" bigint NOT NULL,
+  "interest_rate_bps" integer NOT NULL,
+  "accrued_micros" bigint NOT NULL,
+  "posting_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "accrual_date")
+);
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("posting_id") REFERENCES "interest_postings" ("id");
+
+COMMENT ON COLUMN "interest_accruals"."balance" IS 'end of day balance computed from the entries ledger';
+
+COMMENT ON COLUMN "interest_accruals"."accrued_micros" IS 'interest in millionths of a minor unit';
+
+-- Interest is paid out of one expense account per currency, owned by a
+-- system user that cannot log in.
+INSERT INTO "users" ("username", "hashed_password", "full_name", "email")
+VALUES ('simplebankinterest', '', 'Simple Bank Interest', 'interest@simplebank.invalid');
+
+INSERT INTO "accounts" ("owner", "balance", "currency", "account_number")
+SELECT 'simplebankinterest', 0, g.currency, 'SB' || lpad((98 - (g.bban || '281100')::numeric % 97)::text, 2, '0') || g.bban
+FROM (
+  SELECT currency, lpad(floor(random() * 1e12)::bigint::text, 12, '0') AS bban
+  FROM unnest(ARRAY['USD', 'IDR', 'EUR']) AS currency
+) AS g;
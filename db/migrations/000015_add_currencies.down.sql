@@ -0,0 +1,5 @@
+ALTER TABLE IF EXISTS "fee_schedules" DROP CONSTRAINT IF EXISTS "fee_schedules_currency_fkey";
+
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "accounts_currency_fkey";
+
+DROP TABLE IF EXISTS "currencies";
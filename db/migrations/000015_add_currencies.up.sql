@@ -0,0 +1,18 @@
+CREATE TABLE "currencies" (
+  "code" varchar(3) PRIMARY KEY CHECK ("code" ~ '^[A-Z]{3}$'),
+  "minor_units" integer NOT NULL CHECK ("minor_units" BETWEEN 0 AND 4),
+  "enabled" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "currencies"."code" IS 'ISO 4217 alphabetic code';
+
+COMMENT ON COLUMN "currencies"."minor_units" IS 'decimal places of the minor unit amounts are stored in';
+
+COMMENT ON COLUMN "currencies"."enabled" IS 'new accounts can only be opened in enabled currencies';
+
+INSERT INTO "currencies" ("code", "minor_units") VALUES ('USD', 2), ('IDR', 0), ('EUR', 2);
+
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_currency_fkey" FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
+
+ALTER TABLE "fee_schedules" ADD CONSTRAINT "fee_schedules_currency_fkey" FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
@@ -0,0 +1,16 @@
+CREATE TABLE "exchange_rates" (
+  "id" bigserial PRIMARY KEY,
+  "base_currency" varchar(3) NOT NULL,
+  "currency" varchar(3) NOT NULL,
+  "rate" numeric(20, 10) NOT NULL CHECK ("rate" > 0),
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CHECK ("base_currency" <> "currency")
+);
+
+ALTER TABLE "exchange_rates" ADD FOREIGN KEY ("base_currency") REFERENCES "currencies" ("code");
+
+ALTER TABLE "exchange_rates" ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
+
+CREATE INDEX ON "exchange_rates" ("base_currency", "currency", "created_at");
+
+COMMENT ON COLUMN "exchange_rates"."rate" IS 'units of base_currency one unit of currency is worth, the latest row of a pair is used';
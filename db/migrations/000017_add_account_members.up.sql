@@ -0,0 +1,24 @@
+CREATE TABLE "account_members" (
+  "account_id" bigint NOT NULL,
+  "username" varchar NOT NULL,
+  "role" varchar NOT NULL CHECK ("role" IN ('owner', 'co_owner', 'viewer')),
+  "invited_by" varchar NOT NULL,
+  "accepted_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "username")
+);
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("invited_by") REFERENCES "users" ("username");
+
+CREATE INDEX ON "account_members" ("username");
+
+COMMENT ON COLUMN "account_members"."accepted_at" IS 'null while the invitation is pending';
+
+-- accounts.owner stays the holder of the account and is always its owner
+-- member.
+INSERT INTO "account_members" ("account_id", "username", "role", "invited_by", "accepted_at")
+SELECT "id", "owner", 'owner', "owner", "created_at" FROM "accounts";
@@ -0,0 +1,36 @@
+ALTER TABLE "accounts" ADD COLUMN "approval_threshold" bigint CHECK ("approval_threshold" > 0);
+
+COMMENT ON COLUMN "accounts"."approval_threshold" IS 'transfers of at least this amount need a second member''s approval, null when none do';
+
+CREATE TABLE "transfer_approvals" (
+  "id" bigserial PRIMARY KEY,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL CHECK ("amount" > 0),
+  "description" varchar NOT NULL DEFAULT '',
+  "reference" varchar NOT NULL DEFAULT '',
+  "metadata" jsonb NOT NULL DEFAULT '{}',
+  "status" varchar NOT NULL DEFAULT 'pending_approval' CHECK ("status" IN ('pending_approval', 'approved', 'rejected', 'expired')),
+  "requested_by" varchar NOT NULL,
+  "decided_by" varchar,
+  "decided_at" timestamptz,
+  "transfer_id" bigint,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "transfer_approvals" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_approvals" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_approvals" ADD FOREIGN KEY ("requested_by") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_approvals" ADD FOREIGN KEY ("decided_by") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_approvals" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "transfer_approvals" ("from_account_id", "status");
+
+CREATE INDEX ON "transfer_approvals" ("expires_at") WHERE "status" = 'pending_approval';
+
+COMMENT ON COLUMN "transfer_approvals"."transfer_id" IS 'the transfer made once approved';
@@ -0,0 +1,13 @@
+CREATE TABLE "outbox_events" (
+  "id" bigserial PRIMARY KEY,
+  "aggregate_type" varchar NOT NULL,
+  "aggregate_id" varchar NOT NULL,
+  "type" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "published_at" timestamptz
+);
+
+CREATE INDEX ON "outbox_events" ("id") WHERE "published_at" IS NULL;
+
+COMMENT ON COLUMN "outbox_events"."aggregate_id" IS 'events of the same aggregate are published in id order';
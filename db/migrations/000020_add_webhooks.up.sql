@@ -0,0 +1,41 @@
+CREATE TABLE "webhook_endpoints" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "url" varchar NOT NULL,
+  "encrypted_secret" varchar NOT NULL,
+  "event_types" varchar[] NOT NULL DEFAULT '{}',
+  "enabled" boolean NOT NULL DEFAULT true,
+  "consecutive_failures" integer NOT NULL DEFAULT 0,
+  "disabled_at" timestamptz,
+  "created_by" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "webhook_endpoints" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "webhook_endpoints" ADD FOREIGN KEY ("created_by") REFERENCES "users" ("username");
+
+CREATE INDEX ON "webhook_endpoints" ("account_id");
+
+COMMENT ON COLUMN "webhook_endpoints"."event_types" IS 'empty to receive every event of the account';
+
+CREATE TABLE "webhook_deliveries" (
+  "id" bigserial PRIMARY KEY,
+  "endpoint_id" bigint NOT NULL,
+  "event_id" bigint NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending' CHECK ("status" IN ('pending', 'succeeded', 'failed')),
+  "attempts" integer NOT NULL DEFAULT 0,
+  "next_attempt_at" timestamptz NOT NULL DEFAULT (now()),
+  "last_attempt_at" timestamptz,
+  "response_status" integer,
+  "last_error" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("endpoint_id") REFERENCES "webhook_endpoints" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("event_id") REFERENCES "outbox_events" ("id");
+
+CREATE INDEX ON "webhook_deliveries" ("endpoint_id", "id");
+
+CREATE INDEX ON "webhook_deliveries" ("next_attempt_at") WHERE "status" = 'pending';
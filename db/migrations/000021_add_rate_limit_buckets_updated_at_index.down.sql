@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "rate_limit_buckets_updated_at_idx";
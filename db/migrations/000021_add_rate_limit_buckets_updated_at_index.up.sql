@@ -0,0 +1 @@
+CREATE INDEX ON "rate_limit_buckets" ("updated_at");
@@ -0,0 +1,13 @@
+DELETE FROM "payees" WHERE "deleted_at" IS NOT NULL;
+
+DROP INDEX IF EXISTS "payees_owner_account_id_created_at_idx";
+
+DROP INDEX IF EXISTS "payees_owner_nickname_idx";
+
+DROP INDEX IF EXISTS "payees_owner_account_id_idx";
+
+CREATE UNIQUE INDEX ON "payees" ("owner", "nickname");
+
+CREATE UNIQUE INDEX ON "payees" ("owner", "account_id");
+
+ALTER TABLE "payees" DROP COLUMN IF EXISTS "deleted_at";
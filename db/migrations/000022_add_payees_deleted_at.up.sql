@@ -0,0 +1,12 @@
+-- Deleted payees are kept, so deleting one does not end its cool-down.
+ALTER TABLE "payees" ADD COLUMN "deleted_at" timestamptz;
+
+DROP INDEX IF EXISTS "payees_owner_nickname_idx";
+
+DROP INDEX IF EXISTS "payees_owner_account_id_idx";
+
+CREATE UNIQUE INDEX ON "payees" ("owner", "nickname") WHERE "deleted_at" IS NULL;
+
+CREATE UNIQUE INDEX ON "payees" ("owner", "account_id") WHERE "deleted_at" IS NULL;
+
+CREATE INDEX ON "payees" ("owner", "account_id", "created_at");
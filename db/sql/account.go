@@ -1,23 +1,53 @@
 package db
 
-import "context"
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Unique constraints of the accounts table, as pq.Error reports them.
+const (
+	AccountOwnerCurrencyTypeConstraint = "owner_currency_type_key"
+	AccountNumberConstraint            = "accounts_account_number_idx"
+)
 
 const insertNewAccount = `-- name: CreateNewAccount :one
-INSERT INTO accounts (
-	owner, balance, currency
-) VALUES (
-	$1, $2, $3
-) RETURNING *
+WITH account AS (
+	INSERT INTO accounts (
+		owner, balance, currency, account_number, type, interest_rate_bps
+	) VALUES (
+		$1, $2, $3, $4, COALESCE(NULLIF($5::varchar, ''), 'checking'), $6
+	) RETURNING *
+), member AS (
+	INSERT INTO account_members (account_id, username, role, invited_by, accepted_at)
+	SELECT id, owner, 'owner', owner, created_at FROM account
+)
+SELECT * FROM account
 `
 
+// CreateNewAccountArgs.Type defaults to a checking account when empty. The
+// owner becomes the account's owner member.
 type CreateNewAccountArgs struct {
-	Owner    string `json:"owner"`
-	Balance  int64  `json:"balance"`
-	Currency string `json:"currency"`
+	Owner           string `json:"owner"`
+	Balance         int64  `json:"balance"`
+	Currency        string `json:"currency"`
+	AccountNumber   string `json:"account_number"`
+	Type            string `json:"type"`
+	InterestRateBps int32  `json:"interest_rate_bps"`
 }
 
 func (query *Query) CreateNewAccount(ctx context.Context, arg CreateNewAccountArgs) (Account, error) {
-	row := query.db.QueryRowContext(ctx, insertNewAccount, arg.Owner, arg.Balance, arg.Currency)
+	row := query.db.QueryRowContext(ctx, insertNewAccount,
+		arg.Owner,
+		arg.Balance,
+		arg.Currency,
+		arg.AccountNumber,
+		arg.Type,
+		arg.InterestRateBps,
+	)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -25,6 +55,10 @@ func (query *Query) CreateNewAccount(ctx context.Context, arg CreateNewAccountAr
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.AccountNumber,
+		&i.Type,
+		&i.InterestRateBps,
+		&i.ApprovalThreshold,
 	)
 
 	return i, err
@@ -44,14 +78,130 @@ func (query *Query) GetAccountByID(ctx context.Context, id int64) (Account, erro
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.AccountNumber,
+		&i.Type,
+		&i.InterestRateBps,
+		&i.ApprovalThreshold,
+	)
+	return i, err
+}
+
+const selectAccountByNumber = `-- name: GetAccountByNumber :one
+SELECT * FROM accounts WHERE account_number = $1 LIMIT 1
+`
+
+func (query *Query) GetAccountByNumber(ctx context.Context, accountNumber string) (Account, error) {
+	row := query.db.QueryRowContext(ctx, selectAccountByNumber, accountNumber)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.AccountNumber,
+		&i.Type,
+		&i.InterestRateBps,
+		&i.ApprovalThreshold,
+	)
+	return i, err
+}
+
+const selectAccountsByIDsQuery = `-- name: GetAccountsByIDs :many
+SELECT * FROM accounts WHERE id = ANY($1::bigint[]) ORDER BY id
+`
+
+// GetAccountsByIDs reads the accounts without locking them.
+func (query *Query) GetAccountsByIDs(ctx context.Context, ids []int64) ([]Account, error) {
+	rows, err := query.db.QueryContext(ctx, selectAccountsByIDsQuery, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.AccountNumber,
+			&i.Type,
+			&i.InterestRateBps,
+			&i.ApprovalThreshold,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+const selectRecipientAccountQuery = `-- name: GetRecipientAccount :one
+SELECT a.id, a.owner, a.balance, a.currency, a.created_at, a.account_number, u.full_name
+FROM accounts a
+JOIN users u ON u.username = a.owner
+WHERE (u.username = $1 OR (u.email = $1 AND u.is_email_verified)) AND a.currency = $2 AND a.type = 'checking'
+LIMIT 1
+`
+
+type GetRecipientAccountArgs struct {
+	Recipient string `json:"recipient"`
+	Currency  string `json:"currency"`
+}
+
+type GetRecipientAccountRow struct {
+	ID            int64     `json:"id"`
+	Owner         string    `json:"owner"`
+	Balance       int64     `json:"balance"`
+	Currency      string    `json:"currency"`
+	CreatedAt     time.Time `json:"created_at"`
+	AccountNumber string    `json:"account_number"`
+	FullName      string    `json:"full_name"`
+}
+
+// GetRecipientAccount finds the checking account in the given currency of
+// the user whose username or verified email is recipient. Usernames cannot
+// contain an @, so the two never collide.
+func (query *Query) GetRecipientAccount(ctx context.Context, arg GetRecipientAccountArgs) (GetRecipientAccountRow, error) {
+	row := query.db.QueryRowContext(ctx, selectRecipientAccountQuery, arg.Recipient, arg.Currency)
+	var i GetRecipientAccountRow
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.AccountNumber,
+		&i.FullName,
 	)
 	return i, err
 }
 
 const selectAllAccounts = `-- name: GetListAccounts :many
-SELECT * FROM accounts WHERE owner = $1 LIMIT $2 OFFSET $3
+SELECT * FROM accounts
+WHERE id IN (
+	SELECT account_id FROM account_members
+	WHERE username = $1 AND accepted_at IS NOT NULL
+)
+ORDER BY id
+LIMIT $2 OFFSET $3
 `
 
+// GetListAccounts lists the accounts Owner is an accepted member of.
 type GetListAccountsArgs struct {
 	Owner  string `json:"owner"`
 	Limit  int32  `json:"limit"`
@@ -75,6 +225,10 @@ func (query *Query) GetListAccounts(ctx context.Context, arg GetListAccountsArgs
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.AccountNumber,
+			&i.Type,
+			&i.InterestRateBps,
+			&i.ApprovalThreshold,
 		); err != nil {
 			return nil, err
 		}
@@ -111,6 +265,10 @@ func (query *Query) UpdateAccountByID(ctx context.Context, arg UpdateAccountByID
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.AccountNumber,
+		&i.Type,
+		&i.InterestRateBps,
+		&i.ApprovalThreshold,
 	)
 	return i, err
 }
@@ -134,6 +292,39 @@ func (query *Query) UpdateAccountBalance(ctx context.Context, arg UpdateAccountB
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.AccountNumber,
+		&i.Type,
+		&i.InterestRateBps,
+		&i.ApprovalThreshold,
+	)
+	return i, err
+}
+
+const updateAccountApprovalThresholdQuery = `-- name: UpdateAccountApprovalThreshold :one
+UPDATE accounts SET approval_threshold = $2 WHERE id = $1
+RETURNING *
+`
+
+// UpdateAccountApprovalThresholdArgs.ApprovalThreshold turns approvals off
+// when null.
+type UpdateAccountApprovalThresholdArgs struct {
+	ID                int64         `json:"id"`
+	ApprovalThreshold sql.NullInt64 `json:"approval_threshold"`
+}
+
+func (query *Query) UpdateAccountApprovalThreshold(ctx context.Context, arg UpdateAccountApprovalThresholdArgs) (Account, error) {
+	row := query.db.QueryRowContext(ctx, updateAccountApprovalThresholdQuery, arg.ID, arg.ApprovalThreshold)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.AccountNumber,
+		&i.Type,
+		&i.InterestRateBps,
+		&i.ApprovalThreshold,
 	)
 	return i, err
 }
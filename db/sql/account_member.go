@@ -0,0 +1,224 @@
+package db
+
+import "context"
+
+const insertAccountMemberQuery = `-- name: CreateAccountMember :one
+INSERT INTO account_members (
+	account_id, username, role, invited_by
+) VALUES (
+	$1, $2, $3, $4
+) RETURNING *
+`
+
+type CreateAccountMemberArgs struct {
+	AccountID int64  `json:"account_id"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	InvitedBy string `json:"invited_by"`
+}
+
+// CreateAccountMember invites username to the account, the membership is
+// pending until AcceptAccountMember.
+func (query *Query) CreateAccountMember(ctx context.Context, arg CreateAccountMemberArgs) (AccountMember, error) {
+	row := query.db.QueryRowContext(ctx, insertAccountMemberQuery,
+		arg.AccountID,
+		arg.Username,
+		arg.Role,
+		arg.InvitedBy,
+	)
+	var i AccountMember
+	err := row.Scan(
+		&i.AccountID,
+		&i.Username,
+		&i.Role,
+		&i.InvitedBy,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const selectAccountMemberQuery = `-- name: GetAccountMember :one
+SELECT * FROM account_members
+WHERE account_id = $1 AND username = $2
+LIMIT 1
+`
+
+type GetAccountMemberArgs struct {
+	AccountID int64  `json:"account_id"`
+	Username  string `json:"username"`
+}
+
+func (query *Query) GetAccountMember(ctx context.Context, arg GetAccountMemberArgs) (AccountMember, error) {
+	row := query.db.QueryRowContext(ctx, selectAccountMemberQuery, arg.AccountID, arg.Username)
+	var i AccountMember
+	err := row.Scan(
+		&i.AccountID,
+		&i.Username,
+		&i.Role,
+		&i.InvitedBy,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const lockAccountMemberQuery = `-- name: GetAccountMemberForShare :one
+SELECT * FROM account_members
+WHERE account_id = $1 AND username = $2
+LIMIT 1
+FOR SHARE
+`
+
+// GetAccountMemberForShare keeps the membership from being removed or
+// changed until the transaction ends.
+func (query *Query) GetAccountMemberForShare(ctx context.Context, arg GetAccountMemberArgs) (AccountMember, error) {
+	row := query.db.QueryRowContext(ctx, lockAccountMemberQuery, arg.AccountID, arg.Username)
+	var i AccountMember
+	err := row.Scan(
+		&i.AccountID,
+		&i.Username,
+		&i.Role,
+		&i.InvitedBy,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const selectListAccountMembersQuery = `-- name: GetListAccountMembers :many
+SELECT * FROM account_members
+WHERE account_id = $1
+ORDER BY created_at, username
+`
+
+func (query *Query) GetListAccountMembers(ctx context.Context, accountID int64) ([]AccountMember, error) {
+	rows, err := query.db.QueryContext(ctx, selectListAccountMembersQuery, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	items := []AccountMember{}
+	for rows.Next() {
+		var i AccountMember
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.Username,
+			&i.Role,
+			&i.InvitedBy,
+			&i.AcceptedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+const selectListAccountInvitationsQuery = `-- name: GetListAccountInvitations :many
+SELECT * FROM account_members
+WHERE username = $1 AND accepted_at IS NULL
+ORDER BY created_at DESC
+`
+
+// GetListAccountInvitations lists the pending invitations of username.
+func (query *Query) GetListAccountInvitations(ctx context.Context, username string) ([]AccountMember, error) {
+	rows, err := query.db.QueryContext(ctx, selectListAccountInvitationsQuery, username)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	items := []AccountMember{}
+	for rows.Next() {
+		var i AccountMember
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.Username,
+			&i.Role,
+			&i.InvitedBy,
+			&i.AcceptedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+const acceptAccountMemberQuery = `-- name: AcceptAccountMember :one
+UPDATE account_members SET accepted_at = now()
+WHERE account_id = $1 AND username = $2 AND accepted_at IS NULL
+RETURNING *
+`
+
+type AcceptAccountMemberArgs struct {
+	AccountID int64  `json:"account_id"`
+	Username  string `json:"username"`
+}
+
+// AcceptAccountMember returns sql.ErrNoRows when there is no pending
+// invitation.
+func (query *Query) AcceptAccountMember(ctx context.Context, arg AcceptAccountMemberArgs) (AccountMember, error) {
+	row := query.db.QueryRowContext(ctx, acceptAccountMemberQuery, arg.AccountID, arg.Username)
+	var i AccountMember
+	err := row.Scan(
+		&i.AccountID,
+		&i.Username,
+		&i.Role,
+		&i.InvitedBy,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteAccountMemberQuery = `-- name: DeleteAccountMember :one
+DELETE FROM account_members
+WHERE account_id = $1 AND username = $2 AND role <> 'owner'
+RETURNING *
+`
+
+type DeleteAccountMemberArgs struct {
+	AccountID int64  `json:"account_id"`
+	Username  string `json:"username"`
+}
+
+// DeleteAccountMember removes a member or declines an invitation. The owner
+// cannot be removed, it returns sql.ErrNoRows instead.
+func (query *Query) DeleteAccountMember(ctx context.Context, arg DeleteAccountMemberArgs) (AccountMember, error) {
+	row := query.db.QueryRowContext(ctx, deleteAccountMemberQuery, arg.AccountID, arg.Username)
+	var i AccountMember
+	err := row.Scan(
+		&i.AccountID,
+		&i.Username,
+		&i.Role,
+		&i.InvitedBy,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
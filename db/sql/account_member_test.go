@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateNewAccountOwnerMember(t *testing.T) {
+	account := createRandomAccount(t)
+
+	member, err := testQuery.GetAccountMember(context.Background(), GetAccountMemberArgs{
+		AccountID: account.ID,
+		Username:  account.Owner,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.AccountOwnerRole, member.Role)
+	require.True(t, member.AcceptedAt.Valid)
+}
+
+func TestAccountMemberInvitation(t *testing.T) {
+	account := createRandomAccount(t)
+	user := createRandomUser(t)
+
+	member, err := testQuery.CreateAccountMember(context.Background(), CreateAccountMemberArgs{
+		AccountID: account.ID,
+		Username:  user.Username,
+		Role:      util.AccountCoOwnerRole,
+		InvitedBy: account.Owner,
+	})
+	require.NoError(t, err)
+	require.False(t, member.AcceptedAt.Valid)
+
+	invitations, err := testQuery.GetListAccountInvitations(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, invitations, 1)
+
+	accounts, err := testQuery.GetListAccounts(context.Background(), GetListAccountsArgs{
+		Owner:  user.Username,
+		Limit:  5,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Empty(t, accounts)
+
+	member, err = testQuery.AcceptAccountMember(context.Background(), AcceptAccountMemberArgs{
+		AccountID: account.ID,
+		Username:  user.Username,
+	})
+	require.NoError(t, err)
+	require.True(t, member.AcceptedAt.Valid)
+
+	_, err = testQuery.AcceptAccountMember(context.Background(), AcceptAccountMemberArgs{
+		AccountID: account.ID,
+		Username:  user.Username,
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	accounts, err = testQuery.GetListAccounts(context.Background(), GetListAccountsArgs{
+		Owner:  user.Username,
+		Limit:  5,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account.ID, accounts[0].ID)
+
+	members, err := testQuery.GetListAccountMembers(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+
+	_, err = testQuery.DeleteAccountMember(context.Background(), DeleteAccountMemberArgs{
+		AccountID: account.ID,
+		Username:  account.Owner,
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	_, err = testQuery.DeleteAccountMember(context.Background(), DeleteAccountMemberArgs{
+		AccountID: account.ID,
+		Username:  user.Username,
+	})
+	require.NoError(t, err)
+}
@@ -12,10 +12,14 @@ import (
 
 func createRandomAccount(t *testing.T) Account {
 	user := createRandomUser(t)
+	accountNumber, err := util.GenerateAccountNumber()
+	require.NoError(t, err)
+
 	arg := CreateNewAccountArgs{
-		Owner:    user.Username,
-		Balance:  util.RandomMoney(),
-		Currency: util.RandomCurrency(),
+		Owner:         user.Username,
+		Balance:       util.RandomMoney(),
+		Currency:      util.RandomCurrency(),
+		AccountNumber: accountNumber,
 	}
 	account, err := testQuery.CreateNewAccount(context.Background(), arg)
 	require.NoError(t, err)
@@ -24,6 +28,7 @@ func createRandomAccount(t *testing.T) Account {
 	require.Equal(t, arg.Owner, account.Owner)
 	require.Equal(t, arg.Balance, account.Balance)
 	require.Equal(t, arg.Currency, account.Currency)
+	require.Equal(t, arg.AccountNumber, account.AccountNumber)
 	require.NotZero(t, account.ID)
 	require.NotZero(t, account.CreatedAt)
 	return account
@@ -46,6 +51,50 @@ func TestGetAccountByID(t *testing.T) {
 	require.WithinDuration(t, account1.CreatedAt, account2.CreatedAt, time.Second)
 }
 
+func TestGetAccountByNumber(t *testing.T) {
+	account1 := createRandomAccount(t)
+
+	account2, err := testQuery.GetAccountByNumber(context.Background(), account1.AccountNumber)
+	require.NoError(t, err)
+	require.Equal(t, account1.ID, account2.ID)
+	require.Equal(t, account1.AccountNumber, account2.AccountNumber)
+
+	_, err = testQuery.GetAccountByNumber(context.Background(), "SB77000000000001")
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestGetRecipientAccount(t *testing.T) {
+	account := createRandomAccount(t)
+	user, err := testQuery.GetUserByUsername(context.Background(), account.Owner)
+	require.NoError(t, err)
+
+	recipient, err := testQuery.GetRecipientAccount(context.Background(), GetRecipientAccountArgs{
+		Recipient: user.Username,
+		Currency:  account.Currency,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.ID, recipient.ID)
+	require.Equal(t, user.FullName, recipient.FullName)
+
+	// Unverified emails are not aliases.
+	arg := GetRecipientAccountArgs{
+		Recipient: user.Email,
+		Currency:  account.Currency,
+	}
+	_, err = testQuery.GetRecipientAccount(context.Background(), arg)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	_, err = testQuery.VerifyUserEmail(context.Background(), VerifyUserEmailArgs{
+		Username: user.Username,
+		Email:    user.Email,
+	})
+	require.NoError(t, err)
+
+	recipient, err = testQuery.GetRecipientAccount(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, account.ID, recipient.ID)
+}
+
 func TestGetListAccounts(t *testing.T) {
 	n := 10
 	var lastAccount Account
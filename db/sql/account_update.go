@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// AccountUpdatesChannel is the Postgres channel notified of the balance
+// changes of accounts.
+const AccountUpdatesChannel = "account_updates"
+
+// AccountUpdate is the payload notified on AccountUpdatesChannel when the
+// balance of an account changes.
+type AccountUpdate struct {
+	AccountID int64   `json:"account_id"`
+	Balance   int64   `json:"balance"`
+	Currency  string  `json:"currency"`
+	Entries   []Entry `json:"entries"`
+}
+
+const notifyAccountUpdateQuery = `-- name: NotifyAccountUpdate :exec
+SELECT pg_notify($1, $2)
+`
+
+// NotifyAccountUpdate notifies the listeners of AccountUpdatesChannel. Sent
+// within a transaction, the notification is only delivered if it commits.
+func (query *Query) NotifyAccountUpdate(ctx context.Context, arg AccountUpdate) error {
+	payload, err := json.Marshal(arg)
+	if err != nil {
+		return err
+	}
+
+	_, err = query.db.ExecContext(ctx, notifyAccountUpdateQuery, AccountUpdatesChannel, string(payload))
+	return err
+}
+
+// notifyAccountUpdates notifies the new balance and entries of each account.
+func notifyAccountUpdates(ctx context.Context, query *Query, accounts []Account, entries []Entry) error {
+	for _, account := range accounts {
+		update := AccountUpdate{
+			AccountID: account.ID,
+			Balance:   account.Balance,
+			Currency:  account.Currency,
+			Entries:   []Entry{},
+		}
+		for _, entry := range entries {
+			if entry.AccountID == account.ID {
+				update.Entries = append(update.Entries, entry)
+			}
+		}
+
+		err := query.NotifyAccountUpdate(ctx, update)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
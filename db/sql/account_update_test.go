@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+// listenAccountUpdates returns a listener on AccountUpdatesChannel, failing
+// the test rather than hanging when the database cannot be reached.
+func listenAccountUpdates(t *testing.T) *pq.Listener {
+	events := make(chan error, 1)
+	listener := pq.NewListener(testDBSource, time.Second, time.Second, func(event pq.ListenerEventType, err error) {
+		if event == pq.ListenerEventConnected || event == pq.ListenerEventConnectionAttemptFailed {
+			select {
+			case events <- err:
+			default:
+			}
+		}
+	})
+	t.Cleanup(func() { listener.Close() })
+
+	select {
+	case err := <-events:
+		require.NoError(t, err, "cannot connect the listener")
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener did not connect")
+	}
+
+	require.NoError(t, listener.Listen(AccountUpdatesChannel))
+	return listener
+}
+
+// waitAccountUpdates returns the first update notified for each of the
+// accounts, failing the test if they do not all arrive in time.
+func waitAccountUpdates(t *testing.T, listener *pq.Listener, accountIDs ...int64) map[int64]AccountUpdate {
+	wanted := make(map[int64]bool)
+	for _, id := range accountIDs {
+		wanted[id] = true
+	}
+
+	updates := make(map[int64]AccountUpdate)
+	timeout := time.After(5 * time.Second)
+	for len(updates) < len(wanted) {
+		select {
+		case notification := <-listener.Notify:
+			require.NotNil(t, notification)
+
+			var update AccountUpdate
+			require.NoError(t, json.Unmarshal([]byte(notification.Extra), &update))
+			if _, ok := updates[update.AccountID]; wanted[update.AccountID] && !ok {
+				updates[update.AccountID] = update
+			}
+		case <-timeout:
+			t.Fatal("account updates were not notified")
+		}
+	}
+	return updates
+}
+
+func TestTransferTxNotifiesAccountUpdates(t *testing.T) {
+	listener := listenAccountUpdates(t)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	store := NewStore(testDB)
+
+	result, err := store.TransferTx(context.Background(), TransferTxArg{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		InitiatedBy:   account1.Owner,
+	})
+	require.NoError(t, err)
+
+	updates := waitAccountUpdates(t, listener, account1.ID, account2.ID)
+
+	from := updates[account1.ID]
+	require.Equal(t, result.FromAccount.Balance, from.Balance)
+	require.Equal(t, account1.Currency, from.Currency)
+	require.NotEmpty(t, from.Entries)
+	require.Equal(t, result.FromEntry.ID, from.Entries[0].ID)
+
+	to := updates[account2.ID]
+	require.Equal(t, result.ToAccount.Balance, to.Balance)
+	require.Len(t, to.Entries, 1)
+	require.Equal(t, result.ToEntry.ID, to.Entries[0].ID)
+}
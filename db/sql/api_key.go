@@ -0,0 +1,160 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const insertNewAPIKeyQuery = `-- name: CreateNewAPIKey :one
+INSERT INTO api_keys (
+	owner, name, prefix, key_hash, scopes, expired_at
+) VALUES (
+	$1, $2, $3, $4, $5, $6
+) RETURNING *
+`
+
+type CreateNewAPIKeyArgs struct {
+	Owner     string    `json:"owner"`
+	Name      string    `json:"name"`
+	Prefix    string    `json:"prefix"`
+	KeyHash   string    `json:"key_hash"`
+	Scopes    []string  `json:"scopes"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+func (query *Query) CreateNewAPIKey(ctx context.Context, arg CreateNewAPIKeyArgs) (APIKey, error) {
+	row := query.db.QueryRowContext(ctx, insertNewAPIKeyQuery,
+		arg.Owner,
+		arg.Name,
+		arg.Prefix,
+		arg.KeyHash,
+		pq.Array(arg.Scopes),
+		arg.ExpiredAt,
+	)
+	var i APIKey
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Name,
+		&i.Prefix,
+		&i.KeyHash,
+		pq.Array(&i.Scopes),
+		&i.IsRevoked,
+		&i.ExpiredAt,
+		&i.LastUsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const selectAPIKeyByPrefixQuery = `-- name: GetAPIKeyByPrefix :one
+SELECT * FROM api_keys WHERE prefix = $1 LIMIT 1
+`
+
+func (query *Query) GetAPIKeyByPrefix(ctx context.Context, prefix string) (APIKey, error) {
+	row := query.db.QueryRowContext(ctx, selectAPIKeyByPrefixQuery, prefix)
+	var i APIKey
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Name,
+		&i.Prefix,
+		&i.KeyHash,
+		pq.Array(&i.Scopes),
+		&i.IsRevoked,
+		&i.ExpiredAt,
+		&i.LastUsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const selectListAPIKeysQuery = `-- name: GetListAPIKeys :many
+SELECT * FROM api_keys WHERE owner = $1 ORDER BY id LIMIT $2 OFFSET $3
+`
+
+type GetListAPIKeysArgs struct {
+	Owner  string `json:"owner"`
+	Limit  int32  `json:"limit"`
+	Offset int32  `json:"offset"`
+}
+
+func (query *Query) GetListAPIKeys(ctx context.Context, arg GetListAPIKeysArgs) ([]APIKey, error) {
+	rows, err := query.db.QueryContext(ctx, selectListAPIKeysQuery, arg.Owner, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	items := []APIKey{}
+	for rows.Next() {
+		var i APIKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Name,
+			&i.Prefix,
+			&i.KeyHash,
+			pq.Array(&i.Scopes),
+			&i.IsRevoked,
+			&i.ExpiredAt,
+			&i.LastUsedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+const revokeAPIKeyQuery = `-- name: RevokeAPIKey :one
+UPDATE api_keys SET is_revoked = true
+WHERE id = $1 AND owner = $2
+RETURNING *
+`
+
+type RevokeAPIKeyArgs struct {
+	ID    int64  `json:"id"`
+	Owner string `json:"owner"`
+}
+
+// RevokeAPIKey returns sql.ErrNoRows when the key does not belong to owner.
+func (query *Query) RevokeAPIKey(ctx context.Context, arg RevokeAPIKeyArgs) (APIKey, error) {
+	row := query.db.QueryRowContext(ctx, revokeAPIKeyQuery, arg.ID, arg.Owner)
+	var i APIKey
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Name,
+		&i.Prefix,
+		&i.KeyHash,
+		pq.Array(&i.Scopes),
+		&i.IsRevoked,
+		&i.ExpiredAt,
+		&i.LastUsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const touchAPIKeyQuery = `-- name: TouchAPIKey :exec
+UPDATE api_keys SET last_used_at = now() WHERE id = $1
+`
+
+func (query *Query) TouchAPIKey(ctx context.Context, id int64) error {
+	_, err := query.db.ExecContext(ctx, touchAPIKeyQuery, id)
+	return err
+}
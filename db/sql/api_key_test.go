@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAPIKey(t *testing.T, user User) APIKey {
+	prefix, key, err := util.GenerateAPIKey()
+	require.NoError(t, err)
+
+	arg := CreateNewAPIKeyArgs{
+		Owner:     user.Username,
+		Name:      util.RandomName(),
+		Prefix:    prefix,
+		KeyHash:   util.HashSecureToken(key),
+		Scopes:    []string{util.AccountsReadScope, util.TransfersWriteScope},
+		ExpiredAt: time.Now().Add(time.Hour),
+	}
+
+	apiKey, err := testQuery.CreateNewAPIKey(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, apiKey.ID)
+	require.Equal(t, arg.Owner, apiKey.Owner)
+	require.Equal(t, arg.Name, apiKey.Name)
+	require.Equal(t, arg.Prefix, apiKey.Prefix)
+	require.Equal(t, arg.KeyHash, apiKey.KeyHash)
+	require.Equal(t, arg.Scopes, apiKey.Scopes)
+	require.False(t, apiKey.IsRevoked)
+	require.False(t, apiKey.LastUsedAt.Valid)
+	require.WithinDuration(t, arg.ExpiredAt, apiKey.ExpiredAt, time.Second)
+	return apiKey
+}
+
+func TestGetAPIKeyByPrefix(t *testing.T) {
+	user := createRandomUser(t)
+	apiKey1 := createRandomAPIKey(t, user)
+
+	err := testQuery.TouchAPIKey(context.Background(), apiKey1.ID)
+	require.NoError(t, err)
+
+	apiKey2, err := testQuery.GetAPIKeyByPrefix(context.Background(), apiKey1.Prefix)
+	require.NoError(t, err)
+	require.Equal(t, apiKey1.ID, apiKey2.ID)
+	require.Equal(t, apiKey1.Scopes, apiKey2.Scopes)
+	require.True(t, apiKey2.LastUsedAt.Valid)
+	require.WithinDuration(t, time.Now(), apiKey2.LastUsedAt.Time, time.Second)
+}
+
+func TestGetListAPIKeys(t *testing.T) {
+	user := createRandomUser(t)
+	for i := 0; i < 3; i++ {
+		createRandomAPIKey(t, user)
+	}
+
+	apiKeys, err := testQuery.GetListAPIKeys(context.Background(), GetListAPIKeysArgs{
+		Owner:  user.Username,
+		Limit:  5,
+		Offset: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, apiKeys, 2)
+	for _, apiKey := range apiKeys {
+		require.Equal(t, user.Username, apiKey.Owner)
+	}
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	user := createRandomUser(t)
+	other := createRandomUser(t)
+	apiKey := createRandomAPIKey(t, user)
+
+	_, err := testQuery.RevokeAPIKey(context.Background(), RevokeAPIKeyArgs{ID: apiKey.ID, Owner: other.Username})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	revoked, err := testQuery.RevokeAPIKey(context.Background(), RevokeAPIKeyArgs{ID: apiKey.ID, Owner: user.Username})
+	require.NoError(t, err)
+	require.True(t, revoked.IsRevoked)
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	// ErrApprovalNotPending is returned by ApproveTransferTx when the
+	// approval was already decided or has expired.
+	ErrApprovalNotPending = errors.New("this transfer is no longer pending approval")
+	// ErrSelfApproval is returned by ApproveTransferTx when the requester
+	// tries to approve their own transfer.
+	ErrSelfApproval = errors.New("a transfer must be approved by another account member")
+)
+
+type ApproveTransferTxArg struct {
+	ID         int64  `json:"id"`
+	ApprovedBy string `json:"approved_by"`
+}
+
+type ApproveTransferTxResult struct {
+	Approval TransferApproval `json:"approval"`
+	TransferTxResult
+}
+
+// ApproveTransferTx makes the transfer of a pending approval and marks it
+// approved in the same transaction. Both the requester and the approver must
+// still be allowed to transfer from the account.
+func (store *SQLStore) ApproveTransferTx(ctx context.Context, arg ApproveTransferTxArg) (ApproveTransferTxResult, error) {
+	ctx, span := startSpan(ctx, "ApproveTransferTx",
+		attribute.Int64("transfer_approval.id", arg.ID),
+	)
+	defer span.End()
+
+	var result ApproveTransferTxResult
+
+	err := store.execTx(ctx, func(query *Query) error {
+		approval, err := query.GetTransferApprovalForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		if approval.Status != TransferPendingApproval {
+			return ErrApprovalNotPending
+		}
+
+		if approval.RequestedBy == arg.ApprovedBy {
+			return ErrSelfApproval
+		}
+
+		for _, username := range []string{approval.RequestedBy, arg.ApprovedBy} {
+			err = authorizeTransfer(ctx, query, approval.FromAccountID, username)
+			if err != nil {
+				return err
+			}
+		}
+
+		fromAccount, toAccount, err := getTransferAccounts(ctx, query, approval.FromAccountID, approval.ToAccountID)
+		if err != nil {
+			return err
+		}
+
+		result.TransferTxResult, err = transfer(ctx, query, TransferTxArg{
+			FromAccountID: approval.FromAccountID,
+			ToAccountID:   approval.ToAccountID,
+			Amount:        approval.Amount,
+			Description:   approval.Description,
+			Reference:     approval.Reference,
+			Metadata:      approval.Metadata,
+		}, fromAccount, toAccount)
+		if err != nil {
+			return err
+		}
+
+		result.Approval, err = query.DecideTransferApproval(ctx, DecideTransferApprovalArgs{
+			ID:         approval.ID,
+			Status:     TransferApproved,
+			DecidedBy:  arg.ApprovedBy,
+			TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+		})
+		if err == sql.ErrNoRows {
+			// Still pending, so it expired.
+			return ErrApprovalNotPending
+		}
+		return err
+	})
+
+	recordSpanError(span, err)
+	return result, err
+}
@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestTransferApproval(t *testing.T, from Account, to Account, expiresAt time.Time) TransferApproval {
+	approval, err := testQuery.CreateTransferApproval(context.Background(), CreateTransferApprovalArgs{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        10,
+		RequestedBy:   from.Owner,
+		ExpiresAt:     expiresAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, TransferPendingApproval, approval.Status)
+	return approval
+}
+
+func TestApproveTransferTx(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	approver := createRandomUser(t)
+
+	_, err := testQuery.UpdateAccountApprovalThreshold(context.Background(), UpdateAccountApprovalThresholdArgs{
+		ID:                account1.ID,
+		ApprovalThreshold: sql.NullInt64{Int64: 10, Valid: true},
+	})
+	require.NoError(t, err)
+
+	store := NewStore(testDB)
+
+	_, err = store.TransferTx(context.Background(), TransferTxArg{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		InitiatedBy:   account1.Owner,
+	})
+	require.ErrorIs(t, err, ErrTransferRequiresApproval)
+
+	approval := createTestTransferApproval(t, account1, account2, time.Now().Add(time.Hour))
+
+	_, err = store.ApproveTransferTx(context.Background(), ApproveTransferTxArg{
+		ID:         approval.ID,
+		ApprovedBy: account1.Owner,
+	})
+	require.ErrorIs(t, err, ErrSelfApproval)
+
+	_, err = store.ApproveTransferTx(context.Background(), ApproveTransferTxArg{
+		ID:         approval.ID,
+		ApprovedBy: approver.Username,
+	})
+	require.ErrorIs(t, err, ErrTransferNotAllowed)
+
+	_, err = testQuery.CreateAccountMember(context.Background(), CreateAccountMemberArgs{
+		AccountID: account1.ID,
+		Username:  approver.Username,
+		Role:      util.AccountCoOwnerRole,
+		InvitedBy: account1.Owner,
+	})
+	require.NoError(t, err)
+	_, err = testQuery.AcceptAccountMember(context.Background(), AcceptAccountMemberArgs{
+		AccountID: account1.ID,
+		Username:  approver.Username,
+	})
+	require.NoError(t, err)
+
+	result, err := store.ApproveTransferTx(context.Background(), ApproveTransferTxArg{
+		ID:         approval.ID,
+		ApprovedBy: approver.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, TransferApproved, result.Approval.Status)
+	require.Equal(t, approver.Username, result.Approval.DecidedBy.String)
+	require.Equal(t, result.Transfer.ID, result.Approval.TransferID.Int64)
+	require.Equal(t, account1.Balance-10-result.Fee, result.FromAccount.Balance)
+
+	_, err = store.ApproveTransferTx(context.Background(), ApproveTransferTxArg{
+		ID:         approval.ID,
+		ApprovedBy: approver.Username,
+	})
+	require.ErrorIs(t, err, ErrApprovalNotPending)
+}
+
+func TestExpireTransferApprovals(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	approval := createTestTransferApproval(t, account1, account2, time.Now().Add(-time.Minute))
+
+	_, err := testQuery.DecideTransferApproval(context.Background(), DecideTransferApprovalArgs{
+		ID:        approval.ID,
+		Status:    TransferRejected,
+		DecidedBy: account1.Owner,
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	expired, err := testQuery.ExpireTransferApprovals(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, expired, int64(1))
+
+	approval, err = testQuery.GetTransferApproval(context.Background(), approval.ID)
+	require.NoError(t, err)
+	require.Equal(t, TransferExpired, approval.Status)
+}
@@ -0,0 +1,28 @@
+package db
+
+import "context"
+
+// CreateAccountTx opens the account and writes its AccountCreated event.
+func (store *SQLStore) CreateAccountTx(ctx context.Context, arg CreateNewAccountArgs) (Account, error) {
+	var account Account
+
+	err := store.execTx(ctx, func(query *Query) error {
+		var err error
+
+		account, err = query.CreateNewAccount(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return writeEvent(ctx, query, EventAccountCreated, AggregateAccount, accountAggregateID(account.ID), AccountCreatedEvent{
+			AccountID:     account.ID,
+			Owner:         account.Owner,
+			Currency:      account.Currency,
+			AccountNumber: account.AccountNumber,
+			Type:          account.Type,
+			CreatedAt:     account.CreatedAt,
+		}, account.ID)
+	})
+
+	return account, err
+}
@@ -0,0 +1,48 @@
+package db
+
+import "context"
+
+type CreateCurrencyTxArg struct {
+	Currency                     CreateCurrencyArgs `json:"currency"`
+	FeeAccountNumber             string             `json:"fee_account_number"`
+	InterestExpenseAccountNumber string             `json:"interest_expense_account_number"`
+}
+
+type CreateCurrencyTxResult struct {
+	Currency               Currency `json:"currency"`
+	FeeAccount             Account  `json:"fee_account"`
+	InterestExpenseAccount Account  `json:"interest_expense_account"`
+}
+
+// CreateCurrencyTx adds a currency together with the fee revenue and interest
+// expense accounts that transfers and interest postings in it need.
+func (store *SQLStore) CreateCurrencyTx(ctx context.Context, arg CreateCurrencyTxArg) (CreateCurrencyTxResult, error) {
+	var result CreateCurrencyTxResult
+
+	err := store.execTx(ctx, func(query *Query) error {
+		var err error
+
+		result.Currency, err = query.CreateCurrency(ctx, arg.Currency)
+		if err != nil {
+			return err
+		}
+
+		result.FeeAccount, err = query.CreateNewAccount(ctx, CreateNewAccountArgs{
+			Owner:         FeeAccountOwner,
+			Currency:      result.Currency.Code,
+			AccountNumber: arg.FeeAccountNumber,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.InterestExpenseAccount, err = query.CreateNewAccount(ctx, CreateNewAccountArgs{
+			Owner:         InterestExpenseAccountOwner,
+			Currency:      result.Currency.Code,
+			AccountNumber: arg.InterestExpenseAccountNumber,
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// randomUnusedCurrencyCode picks a code no earlier run has created, the
+// currencies table is never cleaned up.
+func randomUnusedCurrencyCode(t *testing.T) string {
+	for {
+		code := strings.ToUpper(util.RandomString(3))
+		_, err := testQuery.GetCurrency(context.Background(), code)
+		if err == sql.ErrNoRows {
+			return code
+		}
+		require.NoError(t, err)
+	}
+}
+
+func TestCreateCurrencyTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	arg := CreateCurrencyTxArg{
+		Currency: CreateCurrencyArgs{
+			Code:       randomUnusedCurrencyCode(t),
+			MinorUnits: 2,
+			Enabled:    true,
+		},
+		FeeAccountNumber:             createRandomAccountNumber(t),
+		InterestExpenseAccountNumber: createRandomAccountNumber(t),
+	}
+
+	result, err := store.CreateCurrencyTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Currency.Code, result.Currency.Code)
+
+	feeAccount, err := testQuery.GetFeeAccount(context.Background(), arg.Currency.Code)
+	require.NoError(t, err)
+	require.Equal(t, result.FeeAccount.ID, feeAccount.ID)
+	require.Equal(t, FeeAccountOwner, feeAccount.Owner)
+	require.Equal(t, arg.FeeAccountNumber, feeAccount.AccountNumber)
+	require.Zero(t, feeAccount.Balance)
+
+	expenseAccount, err := testQuery.GetInterestExpenseAccount(context.Background(), arg.Currency.Code)
+	require.NoError(t, err)
+	require.Equal(t, result.InterestExpenseAccount.ID, expenseAccount.ID)
+	require.Equal(t, InterestExpenseAccountOwner, expenseAccount.Owner)
+	require.Equal(t, arg.InterestExpenseAccountNumber, expenseAccount.AccountNumber)
+	require.Zero(t, expenseAccount.Balance)
+}
+
+func TestCreateCurrencyTxRollback(t *testing.T) {
+	store := NewStore(testDB)
+	existing := createRandomAccount(t)
+
+	arg := CreateCurrencyTxArg{
+		Currency: CreateCurrencyArgs{
+			Code:       randomUnusedCurrencyCode(t),
+			MinorUnits: 2,
+			Enabled:    true,
+		},
+		FeeAccountNumber:             createRandomAccountNumber(t),
+		InterestExpenseAccountNumber: existing.AccountNumber,
+	}
+
+	_, err := store.CreateCurrencyTx(context.Background(), arg)
+	require.Error(t, err)
+
+	_, err = testQuery.GetCurrency(context.Background(), arg.Currency.Code)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = testQuery.GetFeeAccount(context.Background(), arg.Currency.Code)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
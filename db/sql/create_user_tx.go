@@ -0,0 +1,26 @@
+package db
+
+import "context"
+
+// CreateUserTx creates the user and writes its UserRegistered event.
+func (store *SQLStore) CreateUserTx(ctx context.Context, arg CreateNewUserArgs) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(query *Query) error {
+		var err error
+
+		user, err = query.CreateNewUser(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return writeEvent(ctx, query, EventUserRegistered, AggregateUser, user.Username, UserRegisteredEvent{
+			Username:  user.Username,
+			FullName:  user.FullName,
+			Email:     user.Email,
+			CreatedAt: user.CreatedAt,
+		})
+	})
+
+	return user, err
+}
@@ -0,0 +1,107 @@
+package db
+
+import "context"
+
+const insertCurrencyQuery = `-- name: CreateCurrency :one
+INSERT INTO currencies (
+	code, minor_units, enabled
+) VALUES (
+	$1, $2, $3
+) RETURNING *
+`
+
+type CreateCurrencyArgs struct {
+	Code       string `json:"code"`
+	MinorUnits int32  `json:"minor_units"`
+	Enabled    bool   `json:"enabled"`
+}
+
+func (query *Query) CreateCurrency(ctx context.Context, arg CreateCurrencyArgs) (Currency, error) {
+	row := query.db.QueryRowContext(ctx, insertCurrencyQuery, arg.Code, arg.MinorUnits, arg.Enabled)
+	var i Currency
+	err := row.Scan(
+		&i.Code,
+		&i.MinorUnits,
+		&i.Enabled,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const selectCurrencyQuery = `-- name: GetCurrency :one
+SELECT * FROM currencies WHERE code = $1 LIMIT 1
+`
+
+func (query *Query) GetCurrency(ctx context.Context, code string) (Currency, error) {
+	row := query.db.QueryRowContext(ctx, selectCurrencyQuery, code)
+	var i Currency
+	err := row.Scan(
+		&i.Code,
+		&i.MinorUnits,
+		&i.Enabled,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const selectListCurrenciesQuery = `-- name: GetListCurrencies :many
+SELECT * FROM currencies ORDER BY code
+`
+
+func (query *Query) GetListCurrencies(ctx context.Context) ([]Currency, error) {
+	rows, err := query.db.QueryContext(ctx, selectListCurrenciesQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	items := []Currency{}
+	for rows.Next() {
+		var i Currency
+		if err := rows.Scan(
+			&i.Code,
+			&i.MinorUnits,
+			&i.Enabled,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// The minor units of a currency cannot change once it exists, that would
+// change the value of every amount stored in it.
+const updateCurrencyEnabledQuery = `-- name: UpdateCurrencyEnabled :one
+UPDATE currencies SET enabled = $2
+WHERE code = $1
+RETURNING *
+`
+
+type UpdateCurrencyEnabledArgs struct {
+	Code    string `json:"code"`
+	Enabled bool   `json:"enabled"`
+}
+
+func (query *Query) UpdateCurrencyEnabled(ctx context.Context, arg UpdateCurrencyEnabledArgs) (Currency, error) {
+	row := query.db.QueryRowContext(ctx, updateCurrencyEnabledQuery, arg.Code, arg.Enabled)
+	var i Currency
+	err := row.Scan(
+		&i.Code,
+		&i.MinorUnits,
+		&i.Enabled,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// createTestCurrency makes sure the testing currency exists, the accounts
+// and fee schedules of the fee tests reference it.
+func createTestCurrency(t *testing.T) Currency {
+	currency, err := testQuery.GetCurrency(context.Background(), testFeeCurrency)
+	if err == sql.ErrNoRows {
+		currency, err = testQuery.CreateCurrency(context.Background(), CreateCurrencyArgs{
+			Code:       testFeeCurrency,
+			MinorUnits: 0,
+			Enabled:    true,
+		})
+	}
+	require.NoError(t, err)
+	return currency
+}
+
+func TestGetListCurrencies(t *testing.T) {
+	createTestCurrency(t)
+
+	currencies, err := testQuery.GetListCurrencies(context.Background())
+	require.NoError(t, err)
+
+	codes := make([]string, len(currencies))
+	for i, currency := range currencies {
+		codes[i] = currency.Code
+	}
+	require.Subset(t, codes, []string{"EUR", "IDR", "USD", testFeeCurrency})
+}
+
+func TestUpdateCurrencyEnabled(t *testing.T) {
+	createTestCurrency(t)
+
+	currency, err := testQuery.UpdateCurrencyEnabled(context.Background(), UpdateCurrencyEnabledArgs{
+		Code:    testFeeCurrency,
+		Enabled: false,
+	})
+	require.NoError(t, err)
+	require.False(t, currency.Enabled)
+
+	// A disabled currency keeps its existing accounts usable.
+	account := createTestFeeCurrencyAccount(t, createRandomUser(t).Username)
+	require.Equal(t, testFeeCurrency, account.Currency)
+
+	currency, err = testQuery.UpdateCurrencyEnabled(context.Background(), UpdateCurrencyEnabledArgs{
+		Code:    testFeeCurrency,
+		Enabled: true,
+	})
+	require.NoError(t, err)
+	require.True(t, currency.Enabled)
+
+	_, err = testQuery.UpdateCurrencyEnabled(context.Background(), UpdateCurrencyEnabledArgs{
+		Code:    "XXZ",
+		Enabled: true,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestCreateCurrencyDuplicate(t *testing.T) {
+	createTestCurrency(t)
+
+	_, err := testQuery.CreateCurrency(context.Background(), CreateCurrencyArgs{
+		Code:       testFeeCurrency,
+		MinorUnits: 2,
+	})
+	require.Error(t, err)
+}
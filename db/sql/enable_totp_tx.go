@@ -0,0 +1,52 @@
+package db
+
+import "context"
+
+type EnableTOTPTxArg struct {
+	Username           string   `json:"username"`
+	Step               int64    `json:"step"`
+	RecoveryCodeHashes []string `json:"recovery_code_hashes"`
+}
+
+type EnableTOTPTxResult struct {
+	UserTOTP      UserTOTP       `json:"user_totp"`
+	RecoveryCodes []RecoveryCode `json:"recovery_codes"`
+}
+
+// EnableTOTPTx enables a pending TOTP secret and replaces the user's recovery
+// codes. It returns sql.ErrNoRows when there is no pending secret.
+func (store *SQLStore) EnableTOTPTx(ctx context.Context, arg EnableTOTPTxArg) (EnableTOTPTxResult, error) {
+	var result EnableTOTPTxResult
+
+	err := store.execTx(ctx, func(query *Query) error {
+		var err error
+
+		result.UserTOTP, err = query.EnableUserTOTP(ctx, EnableUserTOTPArgs{
+			Username:     arg.Username,
+			LastUsedStep: arg.Step,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = query.DeleteRecoveryCodes(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		for _, codeHash := range arg.RecoveryCodeHashes {
+			code, err := query.CreateNewRecoveryCode(ctx, CreateNewRecoveryCodeArgs{
+				Username: arg.Username,
+				CodeHash: codeHash,
+			})
+			if err != nil {
+				return err
+			}
+			result.RecoveryCodes = append(result.RecoveryCodes, code)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
@@ -0,0 +1,35 @@
+package db
+
+import "context"
+
+type ExchangeOAuthCodeTxArg struct {
+	Code  UseOAuthCodeArgs        `json:"code"`
+	Token CreateNewOAuthTokenArgs `json:"token"`
+}
+
+type ExchangeOAuthCodeTxResult struct {
+	Code  OAuthCode  `json:"code"`
+	Token OAuthToken `json:"token"`
+}
+
+// ExchangeOAuthCodeTx uses a code and records the token issued for it. A
+// concurrent exchange of the same code waits for this one to commit, so when
+// it finds the code used, the token is already there for it to revoke. It
+// returns sql.ErrNoRows when the code cannot be used.
+func (store *SQLStore) ExchangeOAuthCodeTx(ctx context.Context, arg ExchangeOAuthCodeTxArg) (ExchangeOAuthCodeTxResult, error) {
+	var result ExchangeOAuthCodeTxResult
+
+	err := store.execTx(ctx, func(query *Query) error {
+		var err error
+
+		result.Code, err = query.UseOAuthCode(ctx, arg.Code)
+		if err != nil {
+			return err
+		}
+
+		result.Token, err = query.CreateNewOAuthToken(ctx, arg.Token)
+		return err
+	})
+
+	return result, err
+}
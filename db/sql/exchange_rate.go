@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+const insertExchangeRateQuery = `-- name: CreateExchangeRate :one
+INSERT INTO exchange_rates (
+	base_currency, currency, rate
+) VALUES (
+	$1, $2, $3
+) RETURNING *
+`
+
+type CreateExchangeRateArgs struct {
+	BaseCurrency string `json:"base_currency"`
+	Currency     string `json:"currency"`
+	Rate         string `json:"rate"`
+}
+
+// CreateExchangeRate records a new rate for the pair, earlier ones are kept
+// as history.
+func (query *Query) CreateExchangeRate(ctx context.Context, arg CreateExchangeRateArgs) (ExchangeRate, error) {
+	row := query.db.QueryRowContext(ctx, insertExchangeRateQuery, arg.BaseCurrency, arg.Currency, arg.Rate)
+	var i ExchangeRate
+	err := row.Scan(
+		&i.ID,
+		&i.BaseCurrency,
+		&i.Currency,
+		&i.Rate,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const selectPortfolioQuery = `-- name: GetPortfolio :many
+SELECT a.id, a.account_number, a.type, a.currency, a.balance,
+	greatest(a.balance - coalesce(h.amount, 0), 0)::bigint AS available_balance,
+	r.rate::text,
+	floor(
+		a.balance * r.rate * 10::numeric ^ (b.minor_units - c.minor_units)
+	)::text AS value
+FROM accounts a
+JOIN currencies c ON c.code = a.currency
+JOIN currencies b ON b.code = $2
+LEFT JOIN LATERAL (
+	SELECT 1::numeric AS rate WHERE a.currency = $2
+	UNION ALL
+	SELECT * FROM (
+		SELECT e.rate FROM exchange_rates e
+		WHERE e.base_currency = $2 AND e.currency = a.currency
+		ORDER BY e.created_at DESC, e.id DESC
+		LIMIT 1
+	) latest
+) r ON true
+LEFT JOIN LATERAL (
+	SELECT sum(t.amount + coalesce(
+		CASE WHEN f.max_fee > 0 THEN least(f.fee, f.max_fee) ELSE f.fee END, 0
+	)) AS amount
+	FROM transfer_approvals t
+	JOIN accounts ta ON ta.id = t.to_account_id
+	LEFT JOIN LATERAL (
+		SELECT s.flat_fee + floor(t.amount::numeric * s.rate_bps / 10000) AS fee, s.max_fee
+		FROM fee_schedules s
+		WHERE s.currency = a.currency AND s.min_amount <= t.amount AND ta.owner <> a.owner
+		ORDER BY s.min_amount DESC
+		LIMIT 1
+	) f ON true
+	WHERE t.from_account_id = a.id AND t.status = 'pending_approval' AND t.expires_at > now()
+) h ON true
+WHERE a.id IN (
+	SELECT account_id FROM account_members
+	WHERE username = $1 AND accepted_at IS NOT NULL
+)
+ORDER BY a.currency, a.type, a.id
+`
+
+type GetPortfolioArgs struct {
+	Owner        string `json:"owner"`
+	BaseCurrency string `json:"base_currency"`
+}
+
+type GetPortfolioRow struct {
+	AccountID        int64          `json:"account_id"`
+	AccountNumber    string         `json:"account_number"`
+	Type             string         `json:"type"`
+	Currency         string         `json:"currency"`
+	Balance          int64          `json:"balance"`
+	AvailableBalance int64          `json:"available_balance"`
+	Rate             sql.NullString `json:"rate"`
+	Value            sql.NullString `json:"value"`
+}
+
+// GetPortfolio returns every account Owner is a member of with its value in
+// the minor units of BaseCurrency at the latest rate, rounded down. Value is
+// a decimal string as it may not fit in an int64. Rate and Value are null
+// when there is no rate for the account's currency. The available balance
+// holds back transfers still waiting for approval along with the fee
+// approving them would charge at the current fee schedule, and never goes
+// below zero.
+func (query *Query) GetPortfolio(ctx context.Context, arg GetPortfolioArgs) ([]GetPortfolioRow, error) {
+	rows, err := query.db.QueryContext(ctx, selectPortfolioQuery, arg.Owner, arg.BaseCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	items := []GetPortfolioRow{}
+	for rows.Next() {
+		var i GetPortfolioRow
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.AccountNumber,
+			&i.Type,
+			&i.Currency,
+			&i.Balance,
+			&i.AvailableBalance,
+			&i.Rate,
+			&i.Value,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
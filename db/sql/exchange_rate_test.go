@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPortfolio(t *testing.T) {
+	createTestCurrency(t)
+	user := createRandomUser(t)
+
+	testAccount := createTestFeeCurrencyAccount(t, user.Username)
+	usdAccount, err := testQuery.CreateNewAccount(context.Background(), CreateNewAccountArgs{
+		Owner:         user.Username,
+		Balance:       123,
+		Currency:      "USD",
+		AccountNumber: createRandomAccountNumber(t),
+	})
+	require.NoError(t, err)
+
+	_, err = testQuery.UpsertFeeSchedule(context.Background(), UpsertFeeScheduleArgs{
+		Currency:  testFeeCurrency,
+		MinAmount: 0,
+		FlatFee:   1,
+	})
+	require.NoError(t, err)
+
+	// Only transfers still waiting for approval are held, with the fee they
+	// will be charged. Transfers between the owner's own accounts are free.
+	payee := createTestFeeCurrencyAccount(t, createRandomUser(t).Username)
+	createTestTransferApproval(t, testAccount, usdAccount, time.Now().Add(time.Hour))
+	createTestTransferApproval(t, testAccount, payee, time.Now().Add(time.Hour))
+	createTestTransferApproval(t, testAccount, usdAccount, time.Now().Add(-time.Hour))
+
+	// Only the latest rate of a pair is used.
+	for _, rate := range []string{"2", "0.5"} {
+		_, err = testQuery.CreateExchangeRate(context.Background(), CreateExchangeRateArgs{
+			BaseCurrency: "USD",
+			Currency:     testFeeCurrency,
+			Rate:         rate,
+		})
+		require.NoError(t, err)
+	}
+
+	rows, err := testQuery.GetPortfolio(context.Background(), GetPortfolioArgs{
+		Owner:        user.Username,
+		BaseCurrency: "USD",
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	// 1000 XTS, which has no minor unit, at 0.5 is 500 USD or 50000 cents.
+	require.Equal(t, usdAccount.ID, rows[0].AccountID)
+	require.Equal(t, "123", rows[0].Value.String)
+	require.Equal(t, testAccount.ID, rows[1].AccountID)
+	require.Equal(t, testAccount.Balance-10-(10+1), rows[1].AvailableBalance)
+	require.Equal(t, "50000", rows[1].Value.String)
+
+	rows, err = testQuery.GetPortfolio(context.Background(), GetPortfolioArgs{
+		Owner:        user.Username,
+		BaseCurrency: "EUR",
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	for _, row := range rows {
+		require.False(t, row.Value.Valid)
+		require.False(t, row.Rate.Valid)
+	}
+}
@@ -0,0 +1,207 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// FeeAccountOwner owns the per-currency revenue accounts that fees are
+// posted to. The user and its accounts are created by a migration.
+const FeeAccountOwner = "simplebank"
+
+const upsertFeeScheduleQuery = `-- name: UpsertFeeSchedule :one
+INSERT INTO fee_schedules (
+	currency, min_amount, flat_fee, rate_bps, max_fee
+) VALUES (
+	$1, $2, $3, $4, $5
+) ON CONFLICT (currency, min_amount) DO UPDATE SET
+	flat_fee = EXCLUDED.flat_fee,
+	rate_bps = EXCLUDED.rate_bps,
+	max_fee = EXCLUDED.max_fee
+RETURNING *
+`
+
+type UpsertFeeScheduleArgs struct {
+	Currency  string `json:"currency"`
+	MinAmount int64  `json:"min_amount"`
+	FlatFee   int64  `json:"flat_fee"`
+	RateBps   int32  `json:"rate_bps"`
+	MaxFee    int64  `json:"max_fee"`
+}
+
+func (query *Query) UpsertFeeSchedule(ctx context.Context, arg UpsertFeeScheduleArgs) (FeeSchedule, error) {
+	row := query.db.QueryRowContext(ctx, upsertFeeScheduleQuery,
+		arg.Currency,
+		arg.MinAmount,
+		arg.FlatFee,
+		arg.RateBps,
+		arg.MaxFee,
+	)
+	var i FeeSchedule
+	err := row.Scan(
+		&i.ID,
+		&i.Currency,
+		&i.MinAmount,
+		&i.FlatFee,
+		&i.RateBps,
+		&i.MaxFee,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const selectListFeeSchedulesQuery = `-- name: GetListFeeSchedules :many
+SELECT * FROM fee_schedules
+WHERE ($1::varchar = '' OR currency = $1)
+ORDER BY currency, min_amount
+`
+
+func (query *Query) GetListFeeSchedules(ctx context.Context, currency string) ([]FeeSchedule, error) {
+	rows, err := query.db.QueryContext(ctx, selectListFeeSchedulesQuery, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	items := []FeeSchedule{}
+	for rows.Next() {
+		var i FeeSchedule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Currency,
+			&i.MinAmount,
+			&i.FlatFee,
+			&i.RateBps,
+			&i.MaxFee,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+const selectFeeTierQuery = `-- name: GetFeeTier :one
+SELECT * FROM fee_schedules
+WHERE currency = $1 AND min_amount <= $2
+ORDER BY min_amount DESC
+LIMIT 1
+`
+
+type GetFeeTierArgs struct {
+	Currency string `json:"currency"`
+	Amount   int64  `json:"amount"`
+}
+
+// GetFeeTier returns the tier with the highest minimum amount that amount
+// reaches, or sql.ErrNoRows when transfers of amount are free.
+func (query *Query) GetFeeTier(ctx context.Context, arg GetFeeTierArgs) (FeeSchedule, error) {
+	row := query.db.QueryRowContext(ctx, selectFeeTierQuery, arg.Currency, arg.Amount)
+	var i FeeSchedule
+	err := row.Scan(
+		&i.ID,
+		&i.Currency,
+		&i.MinAmount,
+		&i.FlatFee,
+		&i.RateBps,
+		&i.MaxFee,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteFeeScheduleQuery = `-- name: DeleteFeeSchedule :one
+DELETE FROM fee_schedules WHERE id = $1
+RETURNING *
+`
+
+func (query *Query) DeleteFeeSchedule(ctx context.Context, id int64) (FeeSchedule, error) {
+	row := query.db.QueryRowContext(ctx, deleteFeeScheduleQuery, id)
+	var i FeeSchedule
+	err := row.Scan(
+		&i.ID,
+		&i.Currency,
+		&i.MinAmount,
+		&i.FlatFee,
+		&i.RateBps,
+		&i.MaxFee,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const selectFeeAccountQuery = `-- name: GetFeeAccount :one
+SELECT * FROM accounts WHERE owner = $1 AND currency = $2 LIMIT 1
+`
+
+func (query *Query) GetFeeAccount(ctx context.Context, currency string) (Account, error) {
+	row := query.db.QueryRowContext(ctx, selectFeeAccountQuery, FeeAccountOwner, currency)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.AccountNumber,
+		&i.Type,
+		&i.InterestRateBps,
+		&i.ApprovalThreshold,
+	)
+	return i, err
+}
+
+// Fee returns the flat fee plus rate_bps basis points of amount, rounded
+// down and capped at max_fee when one is set.
+func (tier FeeSchedule) Fee(amount int64) int64 {
+	// Split amount so amount * rate_bps cannot overflow.
+	percentage := amount/10000*int64(tier.RateBps) + amount%10000*int64(tier.RateBps)/10000
+	fee := tier.FlatFee + percentage
+	if tier.MaxFee > 0 && fee > tier.MaxFee {
+		fee = tier.MaxFee
+	}
+	return fee
+}
+
+type TransferFeeArg struct {
+	FromOwner string `json:"from_owner"`
+	ToOwner   string `json:"to_owner"`
+	Currency  string `json:"currency"`
+	Amount    int64  `json:"amount"`
+}
+
+// QuoteTransferFee returns the fee TransferTx would charge right now.
+func (store *SQLStore) QuoteTransferFee(ctx context.Context, arg TransferFeeArg) (int64, error) {
+	return transferFee(ctx, store.Query, arg)
+}
+
+// transferFee waives the fee for transfers between accounts of the same
+// owner and for amounts below the lowest tier of the currency.
+func transferFee(ctx context.Context, query *Query, arg TransferFeeArg) (int64, error) {
+	if arg.FromOwner == arg.ToOwner {
+		return 0, nil
+	}
+
+	tier, err := query.GetFeeTier(ctx, GetFeeTierArgs{
+		Currency: arg.Currency,
+		Amount:   arg.Amount,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return tier.Fee(arg.Amount), nil
+}
@@ -0,0 +1,165 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"testing"
+
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// testFeeCurrency is the ISO 4217 code reserved for testing, so fee tiers
+// created here never apply to the transfers of other tests.
+const testFeeCurrency = "XTS"
+
+func createTestFeeAccount(t *testing.T) Account {
+	createTestCurrency(t)
+	account, err := testQuery.GetFeeAccount(context.Background(), testFeeCurrency)
+	if err == sql.ErrNoRows {
+		account, err = testQuery.CreateNewAccount(context.Background(), CreateNewAccountArgs{
+			Owner:         FeeAccountOwner,
+			Currency:      testFeeCurrency,
+			AccountNumber: createRandomAccountNumber(t),
+		})
+	}
+	require.NoError(t, err)
+	return account
+}
+
+func createTestFeeCurrencyAccount(t *testing.T, owner string) Account {
+	createTestCurrency(t)
+	account, err := testQuery.CreateNewAccount(context.Background(), CreateNewAccountArgs{
+		Owner:         owner,
+		Balance:       1000,
+		Currency:      testFeeCurrency,
+		AccountNumber: createRandomAccountNumber(t),
+	})
+	require.NoError(t, err)
+	return account
+}
+
+func createRandomAccountNumber(t *testing.T) string {
+	accountNumber, err := util.GenerateAccountNumber()
+	require.NoError(t, err)
+	return accountNumber
+}
+
+func TestFeeScheduleFee(t *testing.T) {
+	testCases := []struct {
+		name   string
+		tier   FeeSchedule
+		amount int64
+		fee    int64
+	}{
+		{"Flat", FeeSchedule{FlatFee: 50}, 1000, 50},
+		{"Percentage", FeeSchedule{RateBps: 150}, 1000, 15},
+		{"RoundsDown", FeeSchedule{RateBps: 150}, 999, 14},
+		{"FlatAndPercentage", FeeSchedule{FlatFee: 5, RateBps: 100}, 1000, 15},
+		{"Capped", FeeSchedule{FlatFee: 5, RateBps: 100, MaxFee: 12}, 1000, 12},
+		{"NoOverflow", FeeSchedule{RateBps: 10000}, math.MaxInt64, math.MaxInt64},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.fee, tc.tier.Fee(tc.amount))
+		})
+	}
+}
+
+func TestGetFeeTier(t *testing.T) {
+	createTestCurrency(t)
+
+	low, err := testQuery.UpsertFeeSchedule(context.Background(), UpsertFeeScheduleArgs{
+		Currency:  testFeeCurrency,
+		MinAmount: 0,
+		FlatFee:   1,
+	})
+	require.NoError(t, err)
+
+	high, err := testQuery.UpsertFeeSchedule(context.Background(), UpsertFeeScheduleArgs{
+		Currency:  testFeeCurrency,
+		MinAmount: 500,
+		RateBps:   100,
+	})
+	require.NoError(t, err)
+
+	tier, err := testQuery.GetFeeTier(context.Background(), GetFeeTierArgs{Currency: testFeeCurrency, Amount: 499})
+	require.NoError(t, err)
+	require.Equal(t, low.ID, tier.ID)
+
+	tier, err = testQuery.GetFeeTier(context.Background(), GetFeeTierArgs{Currency: testFeeCurrency, Amount: 500})
+	require.NoError(t, err)
+	require.Equal(t, high.ID, tier.ID)
+
+	updated, err := testQuery.UpsertFeeSchedule(context.Background(), UpsertFeeScheduleArgs{
+		Currency:  testFeeCurrency,
+		MinAmount: 500,
+		RateBps:   200,
+	})
+	require.NoError(t, err)
+	require.Equal(t, high.ID, updated.ID)
+	require.Equal(t, int32(200), updated.RateBps)
+
+	schedules, err := testQuery.GetListFeeSchedules(context.Background(), testFeeCurrency)
+	require.NoError(t, err)
+	require.Len(t, schedules, 2)
+
+	_, err = testQuery.GetFeeTier(context.Background(), GetFeeTierArgs{Currency: "XXX", Amount: 500})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestTransferTxFee(t *testing.T) {
+	feeAccount := createTestFeeAccount(t)
+	_, err := testQuery.UpsertFeeSchedule(context.Background(), UpsertFeeScheduleArgs{
+		Currency:  testFeeCurrency,
+		MinAmount: 0,
+		FlatFee:   1,
+	})
+	require.NoError(t, err)
+
+	user1 := createRandomUser(t)
+	user2 := createRandomUser(t)
+	account1 := createTestFeeCurrencyAccount(t, user1.Username)
+	account2 := createTestFeeCurrencyAccount(t, user2.Username)
+
+	store := NewStore(testDB)
+	amount := int64(10)
+
+	arg := TransferTxArg{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+	}
+
+	fee, err := store.QuoteTransferFee(context.Background(), TransferFeeArg{
+		FromOwner: user1.Username,
+		ToOwner:   user2.Username,
+		Currency:  testFeeCurrency,
+		Amount:    amount,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), fee)
+
+	result, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, fee, result.Fee)
+	require.Equal(t, fee, result.Transfer.Fee)
+	require.Equal(t, account1.ID, result.FeeEntry.AccountID)
+	require.Equal(t, -fee, result.FeeEntry.Amount)
+	require.Equal(t, feeAccount.ID, result.RevenueEntry.AccountID)
+	require.Equal(t, fee, result.RevenueEntry.Amount)
+	require.Equal(t, account1.Balance-amount-fee, result.FromAccount.Balance)
+	require.Equal(t, account2.Balance+amount, result.ToAccount.Balance)
+
+	// Transfers between accounts of the same owner are free.
+	fee, err = store.QuoteTransferFee(context.Background(), TransferFeeArg{
+		FromOwner: user1.Username,
+		ToOwner:   user1.Username,
+		Currency:  testFeeCurrency,
+		Amount:    amount,
+	})
+	require.NoError(t, err)
+	require.Zero(t, fee)
+}
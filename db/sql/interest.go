@@ -0,0 +1,312 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// InterestExpenseAccountOwner owns the per-currency accounts interest is
+// paid from. The user and its accounts are created by a migration.
+const InterestExpenseAccountOwner = "simplebankinterest"
+
+const selectInterestBalancesQuery = `-- name: GetInterestBalances :many
+SELECT a.id, a.balance - COALESCE((
+	SELECT SUM(e.amount) FROM entries e WHERE e.account_id = a.id AND e.created_at >= $1
+), 0)::bigint AS balance, a.interest_rate_bps
+FROM accounts a
+WHERE a.type = 'savings' AND a.interest_rate_bps > 0 AND a.created_at < $1
+ORDER BY a.id
+`
+
+type GetInterestBalancesRow struct {
+	AccountID       int64 `json:"account_id"`
+	Balance         int64 `json:"balance"`
+	InterestRateBps int32 `json:"interest_rate_bps"`
+}
+
+// GetInterestBalances returns the balance of every interest bearing account
+// as it was at endOfDay, by rolling back the entries booked since.
+func (query *Query) GetInterestBalances(ctx context.Context, endOfDay time.Time) ([]GetInterestBalancesRow, error) {
+	rows, err := query.db.QueryContext(ctx, selectInterestBalancesQuery, endOfDay)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	items := []GetInterestBalancesRow{}
+	for rows.Next() {
+		var i GetInterestBalancesRow
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.Balance,
+			&i.InterestRateBps,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+const insertInterestAccrualQuery = `-- name: CreateInterestAccrual :execrows
+INSERT INTO interest_accruals (
+	account_id, accrual_date, balance, interest_rate_bps, accrued_micros
+) VALUES (
+	$1, $2, $3, $4, $5
+) ON CONFLICT (account_id, accrual_date) DO NOTHING
+`
+
+type CreateInterestAccrualArgs struct {
+	AccountID       int64     `json:"account_id"`
+	AccrualDate     time.Time `json:"accrual_date"`
+	Balance         int64     `json:"balance"`
+	InterestRateBps int32     `json:"interest_rate_bps"`
+	AccruedMicros   int64     `json:"accrued_micros"`
+}
+
+// CreateInterestAccrual returns 0 when the day was already accrued.
+func (query *Query) CreateInterestAccrual(ctx context.Context, arg CreateInterestAccrualArgs) (int64, error) {
+	result, err := query.db.ExecContext(ctx, insertInterestAccrualQuery,
+		arg.AccountID,
+		arg.AccrualDate,
+		arg.Balance,
+		arg.InterestRateBps,
+		arg.AccruedMicros,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const selectListInterestAccrualsQuery = `-- name: GetListInterestAccruals :many
+SELECT * FROM interest_accruals
+WHERE account_id = $1
+ORDER BY accrual_date DESC
+LIMIT $2 OFFSET $3
+`
+
+type GetListInterestAccrualsArgs struct {
+	AccountID int64 `json:"account_id"`
+	Limit     int32 `json:"limit"`
+	Offset    int32 `json:"offset"`
+}
+
+func (query *Query) GetListInterestAccruals(ctx context.Context, arg GetListInterestAccrualsArgs) ([]InterestAccrual, error) {
+	rows, err := query.db.QueryContext(ctx, selectListInterestAccrualsQuery, arg.AccountID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	items := []InterestAccrual{}
+	for rows.Next() {
+		var i InterestAccrual
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.AccrualDate,
+			&i.Balance,
+			&i.InterestRateBps,
+			&i.AccruedMicros,
+			&i.PostingID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+const selectAccountsWithUnpostedInterestQuery = `-- name: GetAccountsWithUnpostedInterest :many
+SELECT DISTINCT account_id FROM interest_accruals
+WHERE posting_id IS NULL AND accrual_date <= $1
+ORDER BY account_id
+`
+
+func (query *Query) GetAccountsWithUnpostedInterest(ctx context.Context, periodEnd time.Time) ([]int64, error) {
+	rows, err := query.db.QueryContext(ctx, selectAccountsWithUnpostedInterestQuery, periodEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	items := []int64{}
+	for rows.Next() {
+		var accountID int64
+		if err := rows.Scan(&accountID); err != nil {
+			return nil, err
+		}
+		items = append(items, accountID)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+const insertInterestPostingQuery = `-- name: CreateInterestPosting :one
+INSERT INTO interest_postings (
+	account_id, period_end
+) VALUES (
+	$1, $2
+) ON CONFLICT (account_id, period_end) DO NOTHING
+RETURNING *
+`
+
+type CreateInterestPostingArgs struct {
+	AccountID int64     `json:"account_id"`
+	PeriodEnd time.Time `json:"period_end"`
+}
+
+// CreateInterestPosting returns sql.ErrNoRows when the period was already
+// posted for the account.
+func (query *Query) CreateInterestPosting(ctx context.Context, arg CreateInterestPostingArgs) (InterestPosting, error) {
+	row := query.db.QueryRowContext(ctx, insertInterestPostingQuery, arg.AccountID, arg.PeriodEnd)
+	var i InterestPosting
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.PeriodEnd,
+		&i.AccruedMicros,
+		&i.Amount,
+		&i.CarryMicros,
+		&i.TransferID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const assignInterestAccrualsQuery = `-- name: AssignInterestAccruals :one
+WITH assigned AS (
+	UPDATE interest_accruals SET posting_id = $1
+	WHERE account_id = $2 AND accrual_date <= $3 AND posting_id IS NULL
+	RETURNING accrued_micros
+)
+SELECT COALESCE(SUM(accrued_micros), 0)::bigint FROM assigned
+`
+
+type AssignInterestAccrualsArgs struct {
+	PostingID int64     `json:"posting_id"`
+	AccountID int64     `json:"account_id"`
+	PeriodEnd time.Time `json:"period_end"`
+}
+
+// AssignInterestAccruals attaches the unposted accruals of the period to
+// the posting and returns their sum in micros.
+func (query *Query) AssignInterestAccruals(ctx context.Context, arg AssignInterestAccrualsArgs) (int64, error) {
+	row := query.db.QueryRowContext(ctx, assignInterestAccrualsQuery, arg.PostingID, arg.AccountID, arg.PeriodEnd)
+	var accruedMicros int64
+	err := row.Scan(&accruedMicros)
+	return accruedMicros, err
+}
+
+const selectInterestCarryQuery = `-- name: GetInterestCarry :one
+SELECT carry_micros FROM interest_postings
+WHERE account_id = $1 AND period_end < $2
+ORDER BY period_end DESC
+LIMIT 1
+`
+
+type GetInterestCarryArgs struct {
+	AccountID int64     `json:"account_id"`
+	PeriodEnd time.Time `json:"period_end"`
+}
+
+// GetInterestCarry returns the remainder left by the previous posting, or
+// sql.ErrNoRows for the first posting of the account.
+func (query *Query) GetInterestCarry(ctx context.Context, arg GetInterestCarryArgs) (int64, error) {
+	row := query.db.QueryRowContext(ctx, selectInterestCarryQuery, arg.AccountID, arg.PeriodEnd)
+	var carryMicros int64
+	err := row.Scan(&carryMicros)
+	return carryMicros, err
+}
+
+const updateInterestPostingQuery = `-- name: UpdateInterestPosting :one
+UPDATE interest_postings SET
+	accrued_micros = $2,
+	amount = $3,
+	carry_micros = $4,
+	transfer_id = $5
+WHERE id = $1
+RETURNING *
+`
+
+type UpdateInterestPostingArgs struct {
+	ID            int64         `json:"id"`
+	AccruedMicros int64         `json:"accrued_micros"`
+	Amount        int64         `json:"amount"`
+	CarryMicros   int64         `json:"carry_micros"`
+	TransferID    sql.NullInt64 `json:"transfer_id"`
+}
+
+func (query *Query) UpdateInterestPosting(ctx context.Context, arg UpdateInterestPostingArgs) (InterestPosting, error) {
+	row := query.db.QueryRowContext(ctx, updateInterestPostingQuery,
+		arg.ID,
+		arg.AccruedMicros,
+		arg.Amount,
+		arg.CarryMicros,
+		arg.TransferID,
+	)
+	var i InterestPosting
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.PeriodEnd,
+		&i.AccruedMicros,
+		&i.Amount,
+		&i.CarryMicros,
+		&i.TransferID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const selectInterestExpenseAccountQuery = `-- name: GetInterestExpenseAccount :one
+SELECT * FROM accounts WHERE owner = $1 AND currency = $2 LIMIT 1
+`
+
+func (query *Query) GetInterestExpenseAccount(ctx context.Context, currency string) (Account, error) {
+	row := query.db.QueryRowContext(ctx, selectInterestExpenseAccountQuery, InterestExpenseAccountOwner, currency)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.AccountNumber,
+		&i.Type,
+		&i.InterestRateBps,
+		&i.ApprovalThreshold,
+	)
+	return i, err
+}
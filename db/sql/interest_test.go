@@ -0,0 +1,121 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestInterestExpenseAccount(t *testing.T) Account {
+	createTestCurrency(t)
+	account, err := testQuery.GetInterestExpenseAccount(context.Background(), testFeeCurrency)
+	if err == sql.ErrNoRows {
+		account, err = testQuery.CreateNewAccount(context.Background(), CreateNewAccountArgs{
+			Owner:         InterestExpenseAccountOwner,
+			Currency:      testFeeCurrency,
+			AccountNumber: createRandomAccountNumber(t),
+		})
+	}
+	require.NoError(t, err)
+	return account
+}
+
+func TestInterestTx(t *testing.T) {
+	store := NewStore(testDB)
+	expenseAccount := createTestInterestExpenseAccount(t)
+
+	listener := listenAccountUpdates(t)
+
+	user := createRandomUser(t)
+	account, err := testQuery.CreateNewAccount(context.Background(), CreateNewAccountArgs{
+		Owner:           user.Username,
+		Balance:         1000,
+		Currency:        testFeeCurrency,
+		AccountNumber:   createRandomAccountNumber(t),
+		Type:            util.SavingsAccount,
+		InterestRateBps: 10000,
+	})
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	// Accruing the same day twice only records it once.
+	for i := 0; i < 2; i++ {
+		_, err = store.AccrueInterestTx(context.Background(), today)
+		require.NoError(t, err)
+	}
+
+	accruals, err := testQuery.GetListInterestAccruals(context.Background(), GetListInterestAccrualsArgs{
+		AccountID: account.ID,
+		Limit:     5,
+	})
+	require.NoError(t, err)
+	require.Len(t, accruals, 1)
+	require.Equal(t, int64(1000), accruals[0].Balance)
+	micros, err := util.DailyInterestMicros(1000, 10000)
+	require.NoError(t, err)
+	require.Equal(t, micros, accruals[0].AccruedMicros)
+
+	result, err := store.PostInterestTx(context.Background(), PostInterestTxArg{
+		AccountID: account.ID,
+		PeriodEnd: today,
+	})
+	require.NoError(t, err)
+
+	amount, carry := util.SplitInterestMicros(accruals[0].AccruedMicros)
+	require.Equal(t, amount, result.Posting.Amount)
+	require.Equal(t, carry, result.Posting.CarryMicros)
+	require.True(t, result.Posting.TransferID.Valid)
+	require.Equal(t, account.ID, result.Transfer.ToAccountID)
+	require.Equal(t, amount, result.Transfer.Amount)
+	require.Equal(t, account.Balance+amount, result.Account.Balance)
+
+	// Both sides of the interest transfer reach the account streams.
+	updates := waitAccountUpdates(t, listener, account.ID, expenseAccount.ID)
+	require.Equal(t, result.Account.Balance, updates[account.ID].Balance)
+	require.Len(t, updates[account.ID].Entries, 1)
+	require.Equal(t, amount, updates[account.ID].Entries[0].Amount)
+	require.Len(t, updates[expenseAccount.ID].Entries, 1)
+	require.Equal(t, -amount, updates[expenseAccount.ID].Entries[0].Amount)
+
+	// The same period cannot be posted twice.
+	_, err = store.PostInterestTx(context.Background(), PostInterestTxArg{
+		AccountID: account.ID,
+		PeriodEnd: today,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestAccrueInterestTxOverflow(t *testing.T) {
+	store := NewStore(testDB)
+
+	user := createRandomUser(t)
+	account, err := testQuery.CreateNewAccount(context.Background(), CreateNewAccountArgs{
+		Owner:           user.Username,
+		Balance:         1e16,
+		Currency:        testFeeCurrency,
+		AccountNumber:   createRandomAccountNumber(t),
+		Type:            util.SavingsAccount,
+		InterestRateBps: 10000,
+	})
+	require.NoError(t, err)
+	// Other tests accrue interest on every savings account.
+	t.Cleanup(func() {
+		require.NoError(t, testQuery.DeleteAccountBuID(context.Background(), account.ID))
+	})
+
+	_, err = store.AccrueInterestTx(context.Background(), time.Now())
+	require.ErrorIs(t, err, util.ErrInterestOverflow)
+
+	accruals, err := testQuery.GetListInterestAccruals(context.Background(), GetListInterestAccrualsArgs{
+		AccountID: account.ID,
+		Limit:     5,
+	})
+	require.NoError(t, err)
+	require.Empty(t, accruals)
+}
@@ -0,0 +1,191 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/asshiddiq1306/simple_bank/util"
+)
+
+type AccrueInterestTxResult struct {
+	Date     time.Time `json:"date"`
+	Accounts int64     `json:"accounts"`
+}
+
+// AccrueInterestTx records one day of interest for every savings account,
+// based on its balance at the end of date in UTC. Days that were already
+// accrued are skipped, so the job can safely run again.
+func (store *SQLStore) AccrueInterestTx(ctx context.Context, date time.Time) (AccrueInterestTxResult, error) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	result := AccrueInterestTxResult{Date: day}
+
+	err := store.execTx(ctx, func(query *Query) error {
+		balances, err := query.GetInterestBalances(ctx, day.AddDate(0, 0, 1))
+		if err != nil {
+			return err
+		}
+
+		for _, balance := range balances {
+			micros, err := util.DailyInterestMicros(balance.Balance, balance.InterestRateBps)
+			if err != nil {
+				return fmt.Errorf("cannot accrue interest on account %d: %w", balance.AccountID, err)
+			}
+
+			n, err := query.CreateInterestAccrual(ctx, CreateInterestAccrualArgs{
+				AccountID:       balance.AccountID,
+				AccrualDate:     day,
+				Balance:         balance.Balance,
+				InterestRateBps: balance.InterestRateBps,
+				AccruedMicros:   micros,
+			})
+			if err != nil {
+				return err
+			}
+			result.Accounts += n
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+type PostInterestTxArg struct {
+	AccountID int64     `json:"account_id"`
+	PeriodEnd time.Time `json:"period_end"`
+}
+
+type PostInterestTxResult struct {
+	Posting  InterestPosting `json:"posting"`
+	Transfer Transfer        `json:"transfer"`
+	Account  Account         `json:"account"`
+}
+
+// PostInterestTx credits the interest accrued on the account up to and
+// including PeriodEnd, plus the remainder carried from the last posting,
+// from the interest expense account of its currency. Only whole minor units
+// are paid, the fraction is carried to the next posting. It returns
+// sql.ErrNoRows when the period was already posted.
+func (store *SQLStore) PostInterestTx(ctx context.Context, arg PostInterestTxArg) (PostInterestTxResult, error) {
+	var result PostInterestTxResult
+
+	err := store.execTx(ctx, func(query *Query) error {
+		posting, err := query.CreateInterestPosting(ctx, CreateInterestPostingArgs{
+			AccountID: arg.AccountID,
+			PeriodEnd: arg.PeriodEnd,
+		})
+		if err != nil {
+			return err
+		}
+
+		accruedMicros, err := query.AssignInterestAccruals(ctx, AssignInterestAccrualsArgs{
+			PostingID: posting.ID,
+			AccountID: arg.AccountID,
+			PeriodEnd: arg.PeriodEnd,
+		})
+		if err != nil {
+			return err
+		}
+
+		carryMicros, err := query.GetInterestCarry(ctx, GetInterestCarryArgs{
+			AccountID: arg.AccountID,
+			PeriodEnd: arg.PeriodEnd,
+		})
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+
+		amount, carry := util.SplitInterestMicros(accruedMicros + carryMicros)
+
+		var transferID sql.NullInt64
+		if amount > 0 {
+			result.Transfer, result.Account, err = payInterest(ctx, query, arg, amount)
+			if err != nil {
+				return err
+			}
+			transferID = sql.NullInt64{Int64: result.Transfer.ID, Valid: true}
+
+			err = writeEvent(ctx, query, EventInterestPosted, AggregateAccount, accountAggregateID(arg.AccountID), InterestPostedEvent{
+				AccountID:  arg.AccountID,
+				PeriodEnd:  arg.PeriodEnd,
+				Amount:     amount,
+				Currency:   result.Account.Currency,
+				TransferID: result.Transfer.ID,
+			}, arg.AccountID)
+			if err != nil {
+				return err
+			}
+		}
+
+		result.Posting, err = query.UpdateInterestPosting(ctx, UpdateInterestPostingArgs{
+			ID:            posting.ID,
+			AccruedMicros: accruedMicros,
+			Amount:        amount,
+			CarryMicros:   carry,
+			TransferID:    transferID,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+func payInterest(ctx context.Context, query *Query, arg PostInterestTxArg, amount int64) (Transfer, Account, error) {
+	accounts, err := query.GetAccountsByIDs(ctx, []int64{arg.AccountID})
+	if err != nil {
+		return Transfer{}, Account{}, err
+	}
+	if len(accounts) == 0 {
+		return Transfer{}, Account{}, sql.ErrNoRows
+	}
+
+	expenseAccount, err := query.GetInterestExpenseAccount(ctx, accounts[0].Currency)
+	if err != nil {
+		return Transfer{}, Account{}, fmt.Errorf("cannot find interest expense account for %s: %w", accounts[0].Currency, err)
+	}
+
+	period := arg.PeriodEnd.Format("2006-01-02")
+	transfer, err := query.CreateNewTransfer(ctx, CreateNewTransferArgs{
+		FromAccountID: expenseAccount.ID,
+		ToAccountID:   arg.AccountID,
+		Amount:        amount,
+		Description:   "Interest up to " + period,
+		Reference:     "INT-" + arg.PeriodEnd.Format("20060102"),
+	})
+	if err != nil {
+		return Transfer{}, Account{}, err
+	}
+
+	expenseEntry, err := query.CreateNewEntry(ctx, CreateNewEntryArgs{
+		AccountID: expenseAccount.ID,
+		Amount:    -amount,
+	})
+	if err != nil {
+		return Transfer{}, Account{}, err
+	}
+
+	entry, err := query.CreateNewEntry(ctx, CreateNewEntryArgs{
+		AccountID: arg.AccountID,
+		Amount:    amount,
+	})
+	if err != nil {
+		return Transfer{}, Account{}, err
+	}
+
+	changes := map[int64]int64{
+		expenseAccount.ID: -amount,
+		arg.AccountID:     amount,
+	}
+	updated, err := addMoney(ctx, query, changes)
+	if err != nil {
+		return Transfer{}, Account{}, err
+	}
+
+	err = notifyAccountUpdates(ctx, query, []Account{updated[arg.AccountID], updated[expenseAccount.ID]}, []Entry{entry, expenseEntry})
+	if err != nil {
+		return Transfer{}, Account{}, err
+	}
+
+	return transfer, updated[arg.AccountID], nil
+}
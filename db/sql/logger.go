@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const slowQueryThreshold = 200 * time.Millisecond
+
+// loggedDBTX wraps a DBTX and logs failing or slow queries together with the
+// request ID carried by the context.
+type loggedDBTX struct {
+	db DBTX
+}
+
+func newLoggedDBTX(db DBTX) DBTX {
+	return &loggedDBTX{
+		db: db,
+	}
+}
+
+func (l *loggedDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	result, err := l.db.ExecContext(ctx, query, args...)
+	logQuery(ctx, query, start, err)
+	return result, err
+}
+
+func (l *loggedDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	start := time.Now()
+	row := l.db.QueryRowContext(ctx, query, args...)
+	logQuery(ctx, query, start, row.Err())
+	return row
+}
+
+func (l *loggedDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := l.db.QueryContext(ctx, query, args...)
+	logQuery(ctx, query, start, err)
+	return rows, err
+}
+
+func logQuery(ctx context.Context, query string, start time.Time, err error) {
+	duration := time.Since(start)
+	logger := contextLogger(ctx)
+
+	var event *zerolog.Event
+	switch {
+	case err != nil:
+		event = logger.Error().Err(err)
+	case duration >= slowQueryThreshold:
+		event = logger.Warn()
+	default:
+		event = logger.Debug()
+	}
+
+	event.
+		Str("query", queryName(query)).
+		Dur("duration", duration).
+		Msg("db query")
+}
+
+func contextLogger(ctx context.Context) *zerolog.Logger {
+	logger := log.Logger
+	if requestID := util.RequestIDFromContext(ctx); requestID != "" {
+		logger = logger.With().Str("request_id", requestID).Logger()
+	}
+	return &logger
+}
+
+// queryName extracts the name from the "-- name: X :kind" header every query
+// in this package starts with.
+func queryName(query string) string {
+	line := strings.TrimSpace(strings.SplitN(query, "\n", 2)[0])
+	fields := strings.Fields(strings.TrimPrefix(line, "-- name:"))
+	if len(fields) == 0 {
+		return line
+	}
+	return fields[0]
+}
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const selectLoginAttemptQuery = `-- name: GetLoginAttempt :one
+SELECT * FROM login_attempts WHERE username = $1 LIMIT 1
+`
+
+func (query *Query) GetLoginAttempt(ctx context.Context, username string) (LoginAttempt, error) {
+	row := query.db.QueryRowContext(ctx, selectLoginAttemptQuery, username)
+	var i LoginAttempt
+	err := row.Scan(
+		&i.Username,
+		&i.FailedAttempts,
+		&i.LockedUntil,
+		&i.LastFailedAt,
+	)
+	return i, err
+}
+
+const takeLoginAttemptQuery = `-- name: TakeLoginAttempt :one
+INSERT INTO login_attempts AS a (
+	username, failed_attempts, locked_until, last_failed_at
+) VALUES (
+	$1,
+	1,
+	CASE WHEN $2::int > 0 AND 1 >= $2::int
+		THEN now() + ($3::bigint[])[1] * interval '1 millisecond'
+		ELSE '0001-01-01 00:00:00Z'
+	END,
+	now()
+) ON CONFLICT (username) DO UPDATE SET
+	failed_attempts = a.failed_attempts + 1,
+	locked_until = CASE WHEN $2::int > 0 AND a.failed_attempts + 1 >= $2::int
+		THEN now() + ($3::bigint[])[LEAST(a.failed_attempts + 2 - $2::int, cardinality($3::bigint[]))] * interval '1 millisecond'
+		ELSE a.locked_until
+	END,
+	last_failed_at = now()
+WHERE a.locked_until <= now()
+RETURNING *
+`
+
+type TakeLoginAttemptArgs struct {
+	Username    string `json:"username"`
+	MaxAttempts int32  `json:"max_attempts"`
+	// Lockouts[i] is applied once the attempts reach MaxAttempts+i; the last
+	// one applies to every later attempt.
+	Lockouts []time.Duration `json:"lockouts"`
+}
+
+// TakeLoginAttempt counts a login attempt up front and locks the username
+// once it reaches MaxAttempts, in one statement so that concurrent attempts
+// cannot slip past the limit. It returns sql.ErrNoRows while the username is
+// locked. A successful login should call ResetLoginAttempts.
+func (query *Query) TakeLoginAttempt(ctx context.Context, arg TakeLoginAttemptArgs) (LoginAttempt, error) {
+	lockouts := make([]int64, len(arg.Lockouts))
+	for i, lockout := range arg.Lockouts {
+		lockouts[i] = lockout.Milliseconds()
+	}
+
+	row := query.db.QueryRowContext(ctx, takeLoginAttemptQuery, arg.Username, arg.MaxAttempts, pq.Array(lockouts))
+	var i LoginAttempt
+	err := row.Scan(
+		&i.Username,
+		&i.FailedAttempts,
+		&i.LockedUntil,
+		&i.LastFailedAt,
+	)
+	return i, err
+}
+
+const deleteLoginAttemptQuery = `-- name: ResetLoginAttempts :exec
+DELETE FROM login_attempts WHERE username = $1
+`
+
+func (query *Query) ResetLoginAttempts(ctx context.Context, username string) error {
+	_, err := query.db.ExecContext(ctx, deleteLoginAttemptQuery, username)
+	return err
+}
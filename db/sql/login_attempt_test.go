@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func takeLoginAttemptArgs(username string) TakeLoginAttemptArgs {
+	return TakeLoginAttemptArgs{
+		Username:    username,
+		MaxAttempts: 3,
+		Lockouts:    []time.Duration{time.Minute, 2 * time.Minute},
+	}
+}
+
+func TestTakeLoginAttempt(t *testing.T) {
+	user := createRandomUser(t)
+	arg := takeLoginAttemptArgs(user.Username)
+
+	for i := 1; i < 3; i++ {
+		attempt, err := testQuery.TakeLoginAttempt(context.Background(), arg)
+		require.NoError(t, err)
+		require.Equal(t, user.Username, attempt.Username)
+		require.Equal(t, int32(i), attempt.FailedAttempts)
+		require.True(t, attempt.LockedUntil.Before(time.Now()))
+		require.WithinDuration(t, time.Now(), attempt.LastFailedAt, time.Second)
+	}
+
+	attempt, err := testQuery.TakeLoginAttempt(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), attempt.FailedAttempts)
+	require.WithinDuration(t, time.Now().Add(time.Minute), attempt.LockedUntil, time.Second)
+
+	attempt2, err := testQuery.TakeLoginAttempt(context.Background(), arg)
+	require.Error(t, err)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+	require.Empty(t, attempt2)
+
+	attempt2, err = testQuery.GetLoginAttempt(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, attempt.FailedAttempts, attempt2.FailedAttempts)
+	require.WithinDuration(t, attempt.LockedUntil, attempt2.LockedUntil, time.Second)
+}
+
+func TestTakeLoginAttemptAfterLockout(t *testing.T) {
+	user := createRandomUser(t)
+	arg := takeLoginAttemptArgs(user.Username)
+	arg.Lockouts = []time.Duration{time.Millisecond, 2 * time.Minute}
+
+	var attempt LoginAttempt
+	var err error
+	for i := 0; i < 3; i++ {
+		attempt, err = testQuery.TakeLoginAttempt(context.Background(), arg)
+		require.NoError(t, err)
+	}
+	require.Equal(t, int32(3), attempt.FailedAttempts)
+
+	time.Sleep(10 * time.Millisecond)
+
+	for i := 4; i <= 5; i++ {
+		attempt, err = testQuery.TakeLoginAttempt(context.Background(), arg)
+		if i == 5 {
+			require.EqualError(t, err, sql.ErrNoRows.Error())
+			break
+		}
+		require.NoError(t, err)
+		require.Equal(t, int32(i), attempt.FailedAttempts)
+		require.WithinDuration(t, time.Now().Add(2*time.Minute), attempt.LockedUntil, time.Second)
+	}
+}
+
+func TestTakeLoginAttemptConcurrent(t *testing.T) {
+	user := createRandomUser(t)
+	arg := takeLoginAttemptArgs(user.Username)
+
+	n := 10
+	errs := make(chan error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := testQuery.TakeLoginAttempt(context.Background(), arg)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	taken := 0
+	for err := range errs {
+		if err == nil {
+			taken++
+			continue
+		}
+		require.EqualError(t, err, sql.ErrNoRows.Error())
+	}
+	require.Equal(t, 3, taken)
+}
+
+func TestResetLoginAttempts(t *testing.T) {
+	user := createRandomUser(t)
+
+	_, err := testQuery.TakeLoginAttempt(context.Background(), takeLoginAttemptArgs(user.Username))
+	require.NoError(t, err)
+
+	err = testQuery.ResetLoginAttempts(context.Background(), user.Username)
+	require.NoError(t, err)
+
+	attempt, err := testQuery.GetLoginAttempt(context.Background(), user.Username)
+	require.Error(t, err)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+	require.Empty(t, attempt)
+}
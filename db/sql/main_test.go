@@ -12,6 +12,7 @@ import (
 
 var testQuery *Query
 var testDB *sql.DB
+var testDBSource string
 
 func TestMain(m *testing.M) {
 	var err error
@@ -19,6 +20,7 @@ func TestMain(m *testing.M) {
 	if err != nil {
 		log.Fatal("cannot load config file", err)
 	}
+	testDBSource = config.DbSource
 	testDB, err = sql.Open(config.DbDriver, config.DbSource)
 	if err != nil {
 		log.Fatal("cannot connect to db", err)
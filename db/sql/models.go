@@ -1,13 +1,23 @@
 package db
 
-import "time"
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Account struct {
-	ID        int64     `json:"id"`
-	Owner     string    `json:"owner"`
-	Balance   int64     `json:"balance"`
-	Currency  string    `json:"currency"`
-	CreatedAt time.Time `json:"created_at"`
+	ID                int64         `json:"id"`
+	Owner             string        `json:"owner"`
+	Balance           int64         `json:"balance"`
+	Currency          string        `json:"currency"`
+	CreatedAt         time.Time     `json:"created_at"`
+	AccountNumber     string        `json:"account_number"`
+	Type              string        `json:"type"`
+	InterestRateBps   int32         `json:"interest_rate_bps"`
+	ApprovalThreshold sql.NullInt64 `json:"approval_threshold"`
 }
 
 type Entry struct {
@@ -18,11 +28,15 @@ type Entry struct {
 }
 
 type Transfer struct {
-	ID            int64     `json:"id"`
-	FromAccountID int64     `json:"from_account_id"`
-	ToAccountID   int64     `json:"to_account_id"`
-	Amount        int64     `json:"amount"`
-	CreatedAt     time.Time `json:"created_at"`
+	ID            int64           `json:"id"`
+	FromAccountID int64           `json:"from_account_id"`
+	ToAccountID   int64           `json:"to_account_id"`
+	Amount        int64           `json:"amount"`
+	CreatedAt     time.Time       `json:"created_at"`
+	Description   string          `json:"description"`
+	Reference     string          `json:"reference"`
+	Metadata      json.RawMessage `json:"metadata"`
+	Fee           int64           `json:"fee"`
 }
 
 type User struct {
@@ -32,4 +46,203 @@ type User struct {
 	Email             string    `json:"email"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
+	Role              string    `json:"role"`
+	IsEmailVerified   bool      `json:"is_email_verified"`
+}
+
+type LoginAttempt struct {
+	Username       string    `json:"username"`
+	FailedAttempts int32     `json:"failed_attempts"`
+	LockedUntil    time.Time `json:"locked_until"`
+	LastFailedAt   time.Time `json:"last_failed_at"`
+}
+
+type ResetToken struct {
+	ID        int64     `json:"id"`
+	Username  string    `json:"username"`
+	TokenHash string    `json:"token_hash"`
+	IsUsed    bool      `json:"is_used"`
+	ExpiredAt time.Time `json:"expired_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type UserTOTP struct {
+	Username        string    `json:"username"`
+	EncryptedSecret string    `json:"encrypted_secret"`
+	IsEnabled       bool      `json:"is_enabled"`
+	LastUsedStep    int64     `json:"last_used_step"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type RecoveryCode struct {
+	ID        int64     `json:"id"`
+	Username  string    `json:"username"`
+	CodeHash  string    `json:"code_hash"`
+	IsUsed    bool      `json:"is_used"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type APIKey struct {
+	ID         int64        `json:"id"`
+	Owner      string       `json:"owner"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	KeyHash    string       `json:"key_hash"`
+	Scopes     []string     `json:"scopes"`
+	IsRevoked  bool         `json:"is_revoked"`
+	ExpiredAt  time.Time    `json:"expired_at"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+type OAuthClient struct {
+	ClientID         string    `json:"client_id"`
+	ClientSecretHash string    `json:"client_secret_hash"`
+	Owner            string    `json:"owner"`
+	Name             string    `json:"name"`
+	RedirectURIs     []string  `json:"redirect_uris"`
+	Scopes           []string  `json:"scopes"`
+	IsConfidential   bool      `json:"is_confidential"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type OAuthCode struct {
+	CodeHash      string    `json:"code_hash"`
+	ClientID      string    `json:"client_id"`
+	Username      string    `json:"username"`
+	RedirectURI   string    `json:"redirect_uri"`
+	Scopes        []string  `json:"scopes"`
+	CodeChallenge string    `json:"code_challenge"`
+	IsUsed        bool      `json:"is_used"`
+	ExpiredAt     time.Time `json:"expired_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type OAuthToken struct {
+	ID        uuid.UUID `json:"id"`
+	ClientID  string    `json:"client_id"`
+	Username  string    `json:"username"`
+	GrantType string    `json:"grant_type"`
+	Scopes    []string  `json:"scopes"`
+	IsRevoked bool      `json:"is_revoked"`
+	ExpiredAt time.Time `json:"expired_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Payee struct {
+	ID        int64        `json:"id"`
+	Owner     string       `json:"owner"`
+	Nickname  string       `json:"nickname"`
+	AccountID int64        `json:"account_id"`
+	Currency  string       `json:"currency"`
+	CreatedAt time.Time    `json:"created_at"`
+	DeletedAt sql.NullTime `json:"deleted_at"`
+}
+
+type FeeSchedule struct {
+	ID        int64     `json:"id"`
+	Currency  string    `json:"currency"`
+	MinAmount int64     `json:"min_amount"`
+	FlatFee   int64     `json:"flat_fee"`
+	RateBps   int32     `json:"rate_bps"`
+	MaxFee    int64     `json:"max_fee"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type InterestAccrual struct {
+	AccountID       int64         `json:"account_id"`
+	AccrualDate     time.Time     `json:"accrual_date"`
+	Balance         int64         `json:"balance"`
+	InterestRateBps int32         `json:"interest_rate_bps"`
+	AccruedMicros   int64         `json:"accrued_micros"`
+	PostingID       sql.NullInt64 `json:"posting_id"`
+	CreatedAt       time.Time     `json:"created_at"`
+}
+
+type InterestPosting struct {
+	ID            int64         `json:"id"`
+	AccountID     int64         `json:"account_id"`
+	PeriodEnd     time.Time     `json:"period_end"`
+	AccruedMicros int64         `json:"accrued_micros"`
+	Amount        int64         `json:"amount"`
+	CarryMicros   int64         `json:"carry_micros"`
+	TransferID    sql.NullInt64 `json:"transfer_id"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+type Currency struct {
+	Code       string    `json:"code"`
+	MinorUnits int32     `json:"minor_units"`
+	Enabled    bool      `json:"enabled"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type ExchangeRate struct {
+	ID           int64     `json:"id"`
+	BaseCurrency string    `json:"base_currency"`
+	Currency     string    `json:"currency"`
+	Rate         string    `json:"rate"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type AccountMember struct {
+	AccountID  int64        `json:"account_id"`
+	Username   string       `json:"username"`
+	Role       string       `json:"role"`
+	InvitedBy  string       `json:"invited_by"`
+	AcceptedAt sql.NullTime `json:"accepted_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+type TransferApproval struct {
+	ID            int64           `json:"id"`
+	FromAccountID int64           `json:"from_account_id"`
+	ToAccountID   int64           `json:"to_account_id"`
+	Amount        int64           `json:"amount"`
+	Description   string          `json:"description"`
+	Reference     string          `json:"reference"`
+	Metadata      json.RawMessage `json:"metadata"`
+	Status        string          `json:"status"`
+	RequestedBy   string          `json:"requested_by"`
+	DecidedBy     sql.NullString  `json:"decided_by"`
+	DecidedAt     sql.NullTime    `json:"decided_at"`
+	TransferID    sql.NullInt64   `json:"transfer_id"`
+	ExpiresAt     time.Time       `json:"expires_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+type OutboxEvent struct {
+	ID            int64           `json:"id"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	Type          string          `json:"type"`
+	Payload       json.RawMessage `json:"payload"`
+	CreatedAt     time.Time       `json:"created_at"`
+	PublishedAt   sql.NullTime    `json:"published_at"`
+}
+
+type WebhookEndpoint struct {
+	ID                  int64        `json:"id"`
+	AccountID           int64        `json:"account_id"`
+	URL                 string       `json:"url"`
+	EncryptedSecret     string       `json:"encrypted_secret"`
+	EventTypes          []string     `json:"event_types"`
+	Enabled             bool         `json:"enabled"`
+	ConsecutiveFailures int32        `json:"consecutive_failures"`
+	DisabledAt          sql.NullTime `json:"disabled_at"`
+	CreatedBy           string       `json:"created_by"`
+	CreatedAt           time.Time    `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID             int64         `json:"id"`
+	EndpointID     int64         `json:"endpoint_id"`
+	EventID        int64         `json:"event_id"`
+	Status         string        `json:"status"`
+	Attempts       int32         `json:"attempts"`
+	NextAttemptAt  time.Time     `json:"next_attempt_at"`
+	LastAttemptAt  sql.NullTime  `json:"last_attempt_at"`
+	ResponseStatus sql.NullInt32 `json:"response_status"`
+	LastError      string        `json:"last_error"`
+	CreatedAt      time.Time     `json:"created_at"`
 }
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+)
+
+const insertNewOAuthClientQuery = `-- name: CreateNewOAuthClient :one
+INSERT INTO oauth_clients (
+	client_id, client_secret_hash, owner, name, redirect_uris, scopes, is_confidential
+) VALUES (
+	$1, $2, $3, $4, $5, $6, $7
+) RETURNING *
+`
+
+type CreateNewOAuthClientArgs struct {
+	ClientID         string   `json:"client_id"`
+	ClientSecretHash string   `json:"client_secret_hash"`
+	Owner            string   `json:"owner"`
+	Name             string   `json:"name"`
+	RedirectURIs     []string `json:"redirect_uris"`
+	Scopes           []string `json:"scopes"`
+	IsConfidential   bool     `json:"is_confidential"`
+}
+
+func (query *Query) CreateNewOAuthClient(ctx context.Context, arg CreateNewOAuthClientArgs) (OAuthClient, error) {
+	row := query.db.QueryRowContext(ctx, insertNewOAuthClientQuery,
+		arg.ClientID,
+		arg.ClientSecretHash,
+		arg.Owner,
+		arg.Name,
+		pq.Array(arg.RedirectURIs),
+		pq.Array(arg.Scopes),
+		arg.IsConfidential,
+	)
+	var i OAuthClient
+	err := row.Scan(
+		&i.ClientID,
+		&i.ClientSecretHash,
+		&i.Owner,
+		&i.Name,
+		pq.Array(&i.RedirectURIs),
+		pq.Array(&i.Scopes),
+		&i.IsConfidential,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const selectOAuthClientQuery = `-- name: GetOAuthClient :one
+SELECT * FROM oauth_clients WHERE client_id = $1 LIMIT 1
+`
+
+func (query *Query) GetOAuthClient(ctx context.Context, clientID string) (OAuthClient, error) {
+	row := query.db.QueryRowContext(ctx, selectOAuthClientQuery, clientID)
+	var i OAuthClient
+	err := row.Scan(
+		&i.ClientID,
+		&i.ClientSecretHash,
+		&i.Owner,
+		&i.Name,
+		pq.Array(&i.RedirectURIs),
+		pq.Array(&i.Scopes),
+		&i.IsConfidential,
+		&i.CreatedAt,
+	)
+	return i, err
+}
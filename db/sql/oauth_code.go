@@ -0,0 +1,105 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const insertNewOAuthCodeQuery = `-- name: CreateNewOAuthCode :one
+INSERT INTO oauth_codes (
+	code_hash, client_id, username, redirect_uri, scopes, code_challenge, expired_at
+) VALUES (
+	$1, $2, $3, $4, $5, $6, $7
+) RETURNING *
+`
+
+type CreateNewOAuthCodeArgs struct {
+	CodeHash      string    `json:"code_hash"`
+	ClientID      string    `json:"client_id"`
+	Username      string    `json:"username"`
+	RedirectURI   string    `json:"redirect_uri"`
+	Scopes        []string  `json:"scopes"`
+	CodeChallenge string    `json:"code_challenge"`
+	ExpiredAt     time.Time `json:"expired_at"`
+}
+
+func (query *Query) CreateNewOAuthCode(ctx context.Context, arg CreateNewOAuthCodeArgs) (OAuthCode, error) {
+	row := query.db.QueryRowContext(ctx, insertNewOAuthCodeQuery,
+		arg.CodeHash,
+		arg.ClientID,
+		arg.Username,
+		arg.RedirectURI,
+		pq.Array(arg.Scopes),
+		arg.CodeChallenge,
+		arg.ExpiredAt,
+	)
+	var i OAuthCode
+	err := row.Scan(
+		&i.CodeHash,
+		&i.ClientID,
+		&i.Username,
+		&i.RedirectURI,
+		pq.Array(&i.Scopes),
+		&i.CodeChallenge,
+		&i.IsUsed,
+		&i.ExpiredAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const selectOAuthCodeQuery = `-- name: GetOAuthCode :one
+SELECT * FROM oauth_codes WHERE code_hash = $1 LIMIT 1
+`
+
+func (query *Query) GetOAuthCode(ctx context.Context, codeHash string) (OAuthCode, error) {
+	row := query.db.QueryRowContext(ctx, selectOAuthCodeQuery, codeHash)
+	var i OAuthCode
+	err := row.Scan(
+		&i.CodeHash,
+		&i.ClientID,
+		&i.Username,
+		&i.RedirectURI,
+		pq.Array(&i.Scopes),
+		&i.CodeChallenge,
+		&i.IsUsed,
+		&i.ExpiredAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const useOAuthCodeQuery = `-- name: UseOAuthCode :one
+UPDATE oauth_codes SET is_used = true
+WHERE code_hash = $1 AND client_id = $2 AND redirect_uri = $3
+	AND is_used = false AND expired_at > now()
+RETURNING *
+`
+
+type UseOAuthCodeArgs struct {
+	CodeHash    string `json:"code_hash"`
+	ClientID    string `json:"client_id"`
+	RedirectURI string `json:"redirect_uri"`
+}
+
+// UseOAuthCode marks a code as used. It returns sql.ErrNoRows when the code
+// does not exist, was issued to another client or redirect URI, was already
+// used or has expired.
+func (query *Query) UseOAuthCode(ctx context.Context, arg UseOAuthCodeArgs) (OAuthCode, error) {
+	row := query.db.QueryRowContext(ctx, useOAuthCodeQuery, arg.CodeHash, arg.ClientID, arg.RedirectURI)
+	var i OAuthCode
+	err := row.Scan(
+		&i.CodeHash,
+		&i.ClientID,
+		&i.Username,
+		&i.RedirectURI,
+		pq.Array(&i.Scopes),
+		&i.CodeChallenge,
+		&i.IsUsed,
+		&i.ExpiredAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
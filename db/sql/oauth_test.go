@@ -0,0 +1,197 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomOAuthClient(t *testing.T, owner User) OAuthClient {
+	arg := CreateNewOAuthClientArgs{
+		ClientID:         util.RandomString(16),
+		ClientSecretHash: util.HashSecureToken(util.RandomString(32)),
+		Owner:            owner.Username,
+		Name:             util.RandomName(),
+		RedirectURIs:     []string{"https://partner.example.com/callback"},
+		Scopes:           []string{util.AccountsReadScope},
+		IsConfidential:   true,
+	}
+
+	client, err := testQuery.CreateNewOAuthClient(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ClientID, client.ClientID)
+	require.Equal(t, arg.ClientSecretHash, client.ClientSecretHash)
+	require.Equal(t, arg.Owner, client.Owner)
+	require.Equal(t, arg.RedirectURIs, client.RedirectURIs)
+	require.Equal(t, arg.Scopes, client.Scopes)
+	require.True(t, client.IsConfidential)
+	return client
+}
+
+func createRandomOAuthToken(t *testing.T, client OAuthClient, user User) OAuthToken {
+	arg := CreateNewOAuthTokenArgs{
+		ID:        uuid.New(),
+		ClientID:  client.ClientID,
+		Username:  user.Username,
+		GrantType: "authorization_code",
+		Scopes:    client.Scopes,
+		ExpiredAt: time.Now().Add(time.Hour),
+	}
+
+	oauthToken, err := testQuery.CreateNewOAuthToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ID, oauthToken.ID)
+	require.Equal(t, arg.ClientID, oauthToken.ClientID)
+	require.Equal(t, arg.Username, oauthToken.Username)
+	require.Equal(t, arg.Scopes, oauthToken.Scopes)
+	require.False(t, oauthToken.IsRevoked)
+	return oauthToken
+}
+
+func TestGetOAuthClient(t *testing.T) {
+	client1 := createRandomOAuthClient(t, createRandomUser(t))
+
+	client2, err := testQuery.GetOAuthClient(context.Background(), client1.ClientID)
+	require.NoError(t, err)
+	require.Equal(t, client1.ClientID, client2.ClientID)
+	require.Equal(t, client1.RedirectURIs, client2.RedirectURIs)
+}
+
+func TestUseOAuthCode(t *testing.T) {
+	user := createRandomUser(t)
+	client := createRandomOAuthClient(t, createRandomUser(t))
+
+	arg := CreateNewOAuthCodeArgs{
+		CodeHash:      util.HashSecureToken(util.RandomString(32)),
+		ClientID:      client.ClientID,
+		Username:      user.Username,
+		RedirectURI:   client.RedirectURIs[0],
+		Scopes:        client.Scopes,
+		CodeChallenge: util.PKCEChallenge(util.RandomString(64)),
+		ExpiredAt:     time.Now().Add(time.Minute),
+	}
+
+	code, err := testQuery.CreateNewOAuthCode(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, code.IsUsed)
+
+	useArg := UseOAuthCodeArgs{
+		CodeHash:    arg.CodeHash,
+		ClientID:    arg.ClientID,
+		RedirectURI: arg.RedirectURI,
+	}
+
+	wrongRedirect := useArg
+	wrongRedirect.RedirectURI = "https://attacker.example.com/callback"
+	_, err = testQuery.UseOAuthCode(context.Background(), wrongRedirect)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	wrongClient := useArg
+	wrongClient.ClientID = createRandomOAuthClient(t, user).ClientID
+	_, err = testQuery.UseOAuthCode(context.Background(), wrongClient)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	code, err = testQuery.UseOAuthCode(context.Background(), useArg)
+	require.NoError(t, err)
+	require.True(t, code.IsUsed)
+	require.Equal(t, arg.CodeChallenge, code.CodeChallenge)
+
+	_, err = testQuery.UseOAuthCode(context.Background(), useArg)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	code2, err := testQuery.GetOAuthCode(context.Background(), arg.CodeHash)
+	require.NoError(t, err)
+	require.True(t, code2.IsUsed)
+
+	arg.CodeHash = util.HashSecureToken(util.RandomString(32))
+	arg.ExpiredAt = time.Now().Add(-time.Minute)
+	_, err = testQuery.CreateNewOAuthCode(context.Background(), arg)
+	require.NoError(t, err)
+
+	useArg.CodeHash = arg.CodeHash
+	_, err = testQuery.UseOAuthCode(context.Background(), useArg)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestExchangeOAuthCodeTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	client := createRandomOAuthClient(t, createRandomUser(t))
+
+	code, err := testQuery.CreateNewOAuthCode(context.Background(), CreateNewOAuthCodeArgs{
+		CodeHash:      util.HashSecureToken(util.RandomString(32)),
+		ClientID:      client.ClientID,
+		Username:      user.Username,
+		RedirectURI:   client.RedirectURIs[0],
+		Scopes:        client.Scopes,
+		CodeChallenge: util.PKCEChallenge(util.RandomString(64)),
+		ExpiredAt:     time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+
+	arg := ExchangeOAuthCodeTxArg{
+		Code: UseOAuthCodeArgs{
+			CodeHash:    code.CodeHash,
+			ClientID:    code.ClientID,
+			RedirectURI: code.RedirectURI,
+		},
+		Token: CreateNewOAuthTokenArgs{
+			ID:        uuid.New(),
+			ClientID:  code.ClientID,
+			Username:  code.Username,
+			GrantType: "authorization_code",
+			Scopes:    code.Scopes,
+			ExpiredAt: time.Now().Add(time.Hour),
+		},
+	}
+
+	result, err := store.ExchangeOAuthCodeTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result.Code.IsUsed)
+	require.Equal(t, arg.Token.ID, result.Token.ID)
+
+	arg.Token.ID = uuid.New()
+	_, err = store.ExchangeOAuthCodeTx(context.Background(), arg)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	_, err = testQuery.GetOAuthToken(context.Background(), arg.Token.ID)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestRevokeOAuthToken(t *testing.T) {
+	user := createRandomUser(t)
+	client := createRandomOAuthClient(t, createRandomUser(t))
+	oauthToken := createRandomOAuthToken(t, client, user)
+
+	err := testQuery.RevokeOAuthToken(context.Background(), RevokeOAuthTokenArgs{ID: oauthToken.ID, ClientID: client.ClientID})
+	require.NoError(t, err)
+
+	oauthToken, err = testQuery.GetOAuthToken(context.Background(), oauthToken.ID)
+	require.NoError(t, err)
+	require.True(t, oauthToken.IsRevoked)
+}
+
+func TestRevokeOAuthGrant(t *testing.T) {
+	user := createRandomUser(t)
+	client := createRandomOAuthClient(t, createRandomUser(t))
+	for i := 0; i < 2; i++ {
+		createRandomOAuthToken(t, client, user)
+	}
+
+	tokens, err := testQuery.GetListOAuthTokens(context.Background(), GetListOAuthTokensArgs{Username: user.Username, Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+
+	revoked, err := testQuery.RevokeOAuthGrant(context.Background(), RevokeOAuthGrantArgs{Username: user.Username, ClientID: client.ClientID})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), revoked)
+
+	revoked, err = testQuery.RevokeOAuthGrant(context.Background(), RevokeOAuthGrantArgs{Username: user.Username, ClientID: client.ClientID})
+	require.NoError(t, err)
+	require.Zero(t, revoked)
+}
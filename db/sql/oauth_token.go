@@ -0,0 +1,152 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const insertNewOAuthTokenQuery = `-- name: CreateNewOAuthToken :one
+INSERT INTO oauth_tokens (
+	id, client_id, username, grant_type, scopes, expired_at
+) VALUES (
+	$1, $2, $3, $4, $5, $6
+) RETURNING *
+`
+
+type CreateNewOAuthTokenArgs struct {
+	ID        uuid.UUID `json:"id"`
+	ClientID  string    `json:"client_id"`
+	Username  string    `json:"username"`
+	GrantType string    `json:"grant_type"`
+	Scopes    []string  `json:"scopes"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+func (query *Query) CreateNewOAuthToken(ctx context.Context, arg CreateNewOAuthTokenArgs) (OAuthToken, error) {
+	row := query.db.QueryRowContext(ctx, insertNewOAuthTokenQuery,
+		arg.ID,
+		arg.ClientID,
+		arg.Username,
+		arg.GrantType,
+		pq.Array(arg.Scopes),
+		arg.ExpiredAt,
+	)
+	var i OAuthToken
+	err := row.Scan(
+		&i.ID,
+		&i.ClientID,
+		&i.Username,
+		&i.GrantType,
+		pq.Array(&i.Scopes),
+		&i.IsRevoked,
+		&i.ExpiredAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const selectOAuthTokenQuery = `-- name: GetOAuthToken :one
+SELECT * FROM oauth_tokens WHERE id = $1 LIMIT 1
+`
+
+func (query *Query) GetOAuthToken(ctx context.Context, id uuid.UUID) (OAuthToken, error) {
+	row := query.db.QueryRowContext(ctx, selectOAuthTokenQuery, id)
+	var i OAuthToken
+	err := row.Scan(
+		&i.ID,
+		&i.ClientID,
+		&i.Username,
+		&i.GrantType,
+		pq.Array(&i.Scopes),
+		&i.IsRevoked,
+		&i.ExpiredAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const selectListOAuthTokensQuery = `-- name: GetListOAuthTokens :many
+SELECT * FROM oauth_tokens WHERE username = $1
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3
+`
+
+type GetListOAuthTokensArgs struct {
+	Username string `json:"username"`
+	Limit    int32  `json:"limit"`
+	Offset   int32  `json:"offset"`
+}
+
+func (query *Query) GetListOAuthTokens(ctx context.Context, arg GetListOAuthTokensArgs) ([]OAuthToken, error) {
+	rows, err := query.db.QueryContext(ctx, selectListOAuthTokensQuery, arg.Username, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	items := []OAuthToken{}
+	for rows.Next() {
+		var i OAuthToken
+		if err := rows.Scan(
+			&i.ID,
+			&i.ClientID,
+			&i.Username,
+			&i.GrantType,
+			pq.Array(&i.Scopes),
+			&i.IsRevoked,
+			&i.ExpiredAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+const revokeOAuthTokenQuery = `-- name: RevokeOAuthToken :exec
+UPDATE oauth_tokens SET is_revoked = true WHERE id = $1 AND client_id = $2
+`
+
+type RevokeOAuthTokenArgs struct {
+	ID       uuid.UUID `json:"id"`
+	ClientID string    `json:"client_id"`
+}
+
+func (query *Query) RevokeOAuthToken(ctx context.Context, arg RevokeOAuthTokenArgs) error {
+	_, err := query.db.ExecContext(ctx, revokeOAuthTokenQuery, arg.ID, arg.ClientID)
+	return err
+}
+
+const revokeOAuthGrantQuery = `-- name: RevokeOAuthGrant :execrows
+UPDATE oauth_tokens SET is_revoked = true
+WHERE username = $1 AND client_id = $2 AND is_revoked = false
+`
+
+type RevokeOAuthGrantArgs struct {
+	Username string `json:"username"`
+	ClientID string `json:"client_id"`
+}
+
+// RevokeOAuthGrant revokes every token the user granted to a client and
+// returns how many were still active.
+func (query *Query) RevokeOAuthGrant(ctx context.Context, arg RevokeOAuthGrantArgs) (int64, error) {
+	result, err := query.db.ExecContext(ctx, revokeOAuthGrantQuery, arg.Username, arg.ClientID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
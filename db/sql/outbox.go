@@ -0,0 +1,194 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// Domain events written to the outbox.
+const (
+	EventUserRegistered    = "UserRegistered"
+	EventAccountCreated    = "AccountCreated"
+	EventTransferCompleted = "TransferCompleted"
+	EventInterestPosted    = "InterestPosted"
+)
+
+const (
+	AggregateUser    = "user"
+	AggregateAccount = "account"
+)
+
+// outboxRelayLockKey is the advisory lock held by the relay publishing the
+// outbox, so a single relay publishes at a time.
+const outboxRelayLockKey int64 = 0x6f7574626f78 // "outbox"
+
+type UserRegisteredEvent struct {
+	Username  string    `json:"username"`
+	FullName  string    `json:"full_name"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AccountCreatedEvent struct {
+	AccountID     int64     `json:"account_id"`
+	Owner         string    `json:"owner"`
+	Currency      string    `json:"currency"`
+	AccountNumber string    `json:"account_number"`
+	Type          string    `json:"type"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TransferCompletedEvent amounts are in the minor unit of Currency.
+type TransferCompletedEvent struct {
+	TransferID    int64           `json:"transfer_id"`
+	FromAccountID int64           `json:"from_account_id"`
+	ToAccountID   int64           `json:"to_account_id"`
+	Amount        int64           `json:"amount"`
+	Fee           int64           `json:"fee"`
+	Currency      string          `json:"currency"`
+	Description   string          `json:"description"`
+	Reference     string          `json:"reference"`
+	Metadata      json.RawMessage `json:"metadata"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+type InterestPostedEvent struct {
+	AccountID  int64     `json:"account_id"`
+	PeriodEnd  time.Time `json:"period_end"`
+	Amount     int64     `json:"amount"`
+	Currency   string    `json:"currency"`
+	TransferID int64     `json:"transfer_id"`
+}
+
+const insertOutboxEventQuery = `-- name: CreateOutboxEvent :one
+INSERT INTO outbox_events (
+	aggregate_type, aggregate_id, type, payload
+) VALUES (
+	$1, $2, $3, $4::jsonb
+) RETURNING *
+`
+
+type CreateOutboxEventArgs struct {
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	Type          string          `json:"type"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+func (query *Query) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventArgs) (OutboxEvent, error) {
+	row := query.db.QueryRowContext(ctx, insertOutboxEventQuery,
+		arg.AggregateType,
+		arg.AggregateID,
+		arg.Type,
+		string(arg.Payload),
+	)
+	var i OutboxEvent
+	err := row.Scan(
+		&i.ID,
+		&i.AggregateType,
+		&i.AggregateID,
+		&i.Type,
+		&i.Payload,
+		&i.CreatedAt,
+		&i.PublishedAt,
+	)
+	return i, err
+}
+
+const selectUnpublishedOutboxEventsQuery = `-- name: GetUnpublishedOutboxEvents :many
+SELECT * FROM outbox_events
+WHERE published_at IS NULL
+ORDER BY id
+LIMIT $1
+`
+
+func (query *Query) GetUnpublishedOutboxEvents(ctx context.Context, limit int32) ([]OutboxEvent, error) {
+	rows, err := query.db.QueryContext(ctx, selectUnpublishedOutboxEventsQuery, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	items := []OutboxEvent{}
+	for rows.Next() {
+		var i OutboxEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.AggregateType,
+			&i.AggregateID,
+			&i.Type,
+			&i.Payload,
+			&i.CreatedAt,
+			&i.PublishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+const markOutboxEventPublishedQuery = `-- name: MarkOutboxEventPublished :exec
+UPDATE outbox_events SET published_at = now() WHERE id = $1
+`
+
+func (query *Query) MarkOutboxEventPublished(ctx context.Context, id int64) error {
+	_, err := query.db.ExecContext(ctx, markOutboxEventPublishedQuery, id)
+	return err
+}
+
+const tryOutboxRelayLockQuery = `-- name: TryOutboxRelayLock :one
+SELECT pg_try_advisory_xact_lock($1)
+`
+
+// TryOutboxRelayLock takes the relay lock until the end of the transaction,
+// returning false when another relay holds it.
+func (query *Query) TryOutboxRelayLock(ctx context.Context) (bool, error) {
+	row := query.db.QueryRowContext(ctx, tryOutboxRelayLockQuery, outboxRelayLockKey)
+	var locked bool
+	err := row.Scan(&locked)
+	return locked, err
+}
+
+// writeEvent adds an event to the outbox within the transaction of query,
+// so it is published if and only if the transaction commits. It is also
+// queued for the webhooks of accountIDs, the accounts the event concerns.
+func writeEvent(ctx context.Context, query *Query, eventType string, aggregateType string, aggregateID string, payload interface{}, accountIDs ...int64) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	event, err := query.CreateOutboxEvent(ctx, CreateOutboxEventArgs{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Type:          eventType,
+		Payload:       data,
+	})
+	if err != nil || len(accountIDs) == 0 {
+		return err
+	}
+
+	_, err = query.CreateWebhookDeliveries(ctx, CreateWebhookDeliveriesArgs{
+		EventID:    event.ID,
+		EventType:  eventType,
+		AccountIDs: accountIDs,
+	})
+	return err
+}
+
+func accountAggregateID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// relayAllOutboxEvents publishes the whole outbox and returns the events in
+// the order they were published.
+func relayAllOutboxEvents(t *testing.T, store Store) []OutboxEvent {
+	var published []OutboxEvent
+	for {
+		result, err := store.RelayOutboxTx(context.Background(), RelayOutboxTxArg{
+			Limit: 100,
+			Publish: func(ctx context.Context, event OutboxEvent) error {
+				published = append(published, event)
+				return nil
+			},
+		})
+		require.NoError(t, err)
+		require.True(t, result.Locked)
+		if result.Published < 100 {
+			return published
+		}
+	}
+}
+
+func TestCreateAccountTxEvent(t *testing.T) {
+	user := createRandomUser(t)
+	store := NewStore(testDB)
+
+	account, err := store.CreateAccountTx(context.Background(), CreateNewAccountArgs{
+		Owner:         user.Username,
+		Currency:      util.RandomCurrency(),
+		AccountNumber: createRandomAccountNumber(t),
+	})
+	require.NoError(t, err)
+
+	published := relayAllOutboxEvents(t, store)
+
+	var found *OutboxEvent
+	for i := range published {
+		if i > 0 {
+			require.Greater(t, published[i].ID, published[i-1].ID)
+		}
+		if published[i].Type == EventAccountCreated && published[i].AggregateID == strconv.FormatInt(account.ID, 10) {
+			found = &published[i]
+		}
+	}
+	require.NotNil(t, found)
+
+	var payload AccountCreatedEvent
+	require.NoError(t, json.Unmarshal(found.Payload, &payload))
+	require.Equal(t, account.ID, payload.AccountID)
+	require.Equal(t, user.Username, payload.Owner)
+
+	events, err := testQuery.GetUnpublishedOutboxEvents(context.Background(), 100)
+	require.NoError(t, err)
+	for _, event := range events {
+		require.NotEqual(t, found.ID, event.ID)
+	}
+}
+
+func TestTransferTxEvents(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	store := NewStore(testDB)
+
+	result, err := store.TransferTx(context.Background(), TransferTxArg{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		InitiatedBy:   account1.Owner,
+	})
+	require.NoError(t, err)
+
+	events := make(map[string]OutboxEvent)
+	for _, event := range relayAllOutboxEvents(t, store) {
+		if event.Type == EventTransferCompleted {
+			var payload TransferCompletedEvent
+			require.NoError(t, json.Unmarshal(event.Payload, &payload))
+			if payload.TransferID == result.Transfer.ID {
+				events[event.AggregateID] = event
+			}
+		}
+	}
+
+	require.Len(t, events, 2)
+	require.Contains(t, events, strconv.FormatInt(account1.ID, 10))
+	require.Contains(t, events, strconv.FormatInt(account2.ID, 10))
+}
+
+func TestRelayOutboxTxPublishFails(t *testing.T) {
+	store := NewStore(testDB)
+
+	_, err := store.CreateUserTx(context.Background(), CreateNewUserArgs{
+		Username:       util.RandomName(),
+		HashedPassword: "secret",
+		FullName:       util.RandomName(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+
+	publishErr := errors.New("sink unavailable")
+	result, err := store.RelayOutboxTx(context.Background(), RelayOutboxTxArg{
+		Limit: 100,
+		Publish: func(ctx context.Context, event OutboxEvent) error {
+			return publishErr
+		},
+	})
+	require.Equal(t, publishErr, err)
+	require.Zero(t, result.Published)
+
+	events, err := testQuery.GetUnpublishedOutboxEvents(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+}
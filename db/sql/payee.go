@@ -0,0 +1,167 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const insertNewPayeeQuery = `-- name: CreateNewPayee :one
+INSERT INTO payees (
+	owner, nickname, account_id, currency
+) VALUES (
+	$1, $2, $3, $4
+) RETURNING *
+`
+
+type CreateNewPayeeArgs struct {
+	Owner     string `json:"owner"`
+	Nickname  string `json:"nickname"`
+	AccountID int64  `json:"account_id"`
+	Currency  string `json:"currency"`
+}
+
+func (query *Query) CreateNewPayee(ctx context.Context, arg CreateNewPayeeArgs) (Payee, error) {
+	row := query.db.QueryRowContext(ctx, insertNewPayeeQuery, arg.Owner, arg.Nickname, arg.AccountID, arg.Currency)
+	var i Payee
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Nickname,
+		&i.AccountID,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const selectPayeeByIDQuery = `-- name: GetPayeeByID :one
+SELECT * FROM payees WHERE id = $1 AND deleted_at IS NULL LIMIT 1
+`
+
+func (query *Query) GetPayeeByID(ctx context.Context, id int64) (Payee, error) {
+	row := query.db.QueryRowContext(ctx, selectPayeeByIDQuery, id)
+	var i Payee
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Nickname,
+		&i.AccountID,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const selectCoolingPayeeQuery = `-- name: GetCoolingPayee :one
+SELECT p.* FROM payees p
+WHERE p.owner = $1 AND p.account_id = $2 AND p.created_at > $3
+	AND NOT EXISTS (
+		SELECT 1 FROM transfers t
+		JOIN accounts a ON a.id = t.from_account_id
+		WHERE a.owner = p.owner AND t.to_account_id = p.account_id AND t.created_at < p.created_at
+	)
+ORDER BY p.created_at DESC
+LIMIT 1
+`
+
+type GetCoolingPayeeArgs struct {
+	Owner     string    `json:"owner"`
+	AccountID int64     `json:"account_id"`
+	Since     time.Time `json:"since"`
+}
+
+// GetCoolingPayee returns the latest payee of owner for the account added
+// after since, deleted or not, unless owner had already sent money to the
+// account before adding it. It returns sql.ErrNoRows when there is none.
+func (query *Query) GetCoolingPayee(ctx context.Context, arg GetCoolingPayeeArgs) (Payee, error) {
+	row := query.db.QueryRowContext(ctx, selectCoolingPayeeQuery, arg.Owner, arg.AccountID, arg.Since)
+	var i Payee
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Nickname,
+		&i.AccountID,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const selectListPayeesQuery = `-- name: GetListPayees :many
+SELECT * FROM payees WHERE owner = $1 AND deleted_at IS NULL
+ORDER BY nickname LIMIT $2 OFFSET $3
+`
+
+type GetListPayeesArgs struct {
+	Owner  string `json:"owner"`
+	Limit  int32  `json:"limit"`
+	Offset int32  `json:"offset"`
+}
+
+func (query *Query) GetListPayees(ctx context.Context, arg GetListPayeesArgs) ([]Payee, error) {
+	rows, err := query.db.QueryContext(ctx, selectListPayeesQuery, arg.Owner, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	items := []Payee{}
+	for rows.Next() {
+		var i Payee
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Nickname,
+			&i.AccountID,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+const deletePayeeQuery = `-- name: DeletePayee :one
+UPDATE payees SET deleted_at = now()
+WHERE id = $1 AND owner = $2 AND deleted_at IS NULL
+RETURNING *
+`
+
+type DeletePayeeArgs struct {
+	ID    int64  `json:"id"`
+	Owner string `json:"owner"`
+}
+
+// DeletePayee only marks the payee deleted, GetCoolingPayee still sees when
+// it was added. It returns sql.ErrNoRows when the payee does not belong to
+// owner.
+func (query *Query) DeletePayee(ctx context.Context, arg DeletePayeeArgs) (Payee, error) {
+	row := query.db.QueryRowContext(ctx, deletePayeeQuery, arg.ID, arg.Owner)
+	var i Payee
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Nickname,
+		&i.AccountID,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPayee(t *testing.T, owner User) Payee {
+	account := createRandomAccount(t)
+
+	arg := CreateNewPayeeArgs{
+		Owner:     owner.Username,
+		Nickname:  util.RandomName(),
+		AccountID: account.ID,
+		Currency:  account.Currency,
+	}
+
+	payee, err := testQuery.CreateNewPayee(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, payee.ID)
+	require.Equal(t, arg.Owner, payee.Owner)
+	require.Equal(t, arg.Nickname, payee.Nickname)
+	require.Equal(t, arg.AccountID, payee.AccountID)
+	require.Equal(t, arg.Currency, payee.Currency)
+	require.NotZero(t, payee.CreatedAt)
+	return payee
+}
+
+func TestGetPayeeByID(t *testing.T) {
+	payee1 := createRandomPayee(t, createRandomUser(t))
+
+	payee2, err := testQuery.GetPayeeByID(context.Background(), payee1.ID)
+	require.NoError(t, err)
+	require.Equal(t, payee1, payee2)
+}
+
+func TestCreateDuplicatePayee(t *testing.T) {
+	user := createRandomUser(t)
+	payee := createRandomPayee(t, user)
+
+	_, err := testQuery.CreateNewPayee(context.Background(), CreateNewPayeeArgs{
+		Owner:     user.Username,
+		Nickname:  util.RandomName(),
+		AccountID: payee.AccountID,
+		Currency:  payee.Currency,
+	})
+	require.Error(t, err)
+}
+
+func TestGetListPayees(t *testing.T) {
+	user := createRandomUser(t)
+	for i := 0; i < 4; i++ {
+		createRandomPayee(t, user)
+	}
+
+	payees, err := testQuery.GetListPayees(context.Background(), GetListPayeesArgs{
+		Owner:  user.Username,
+		Limit:  5,
+		Offset: 2,
+	})
+	require.NoError(t, err)
+	require.Len(t, payees, 2)
+	for _, payee := range payees {
+		require.Equal(t, user.Username, payee.Owner)
+	}
+}
+
+func TestDeletePayee(t *testing.T) {
+	user := createRandomUser(t)
+	payee := createRandomPayee(t, user)
+
+	_, err := testQuery.DeletePayee(context.Background(), DeletePayeeArgs{ID: payee.ID, Owner: createRandomUser(t).Username})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	deleted, err := testQuery.DeletePayee(context.Background(), DeletePayeeArgs{ID: payee.ID, Owner: user.Username})
+	require.NoError(t, err)
+	require.True(t, deleted.DeletedAt.Valid)
+
+	_, err = testQuery.GetPayeeByID(context.Background(), payee.ID)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	_, err = testQuery.DeletePayee(context.Background(), DeletePayeeArgs{ID: payee.ID, Owner: user.Username})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	payees, err := testQuery.GetListPayees(context.Background(), GetListPayeesArgs{Owner: user.Username, Limit: 5})
+	require.NoError(t, err)
+	require.Empty(t, payees)
+
+	// The account can be added again under the same nickname.
+	_, err = testQuery.CreateNewPayee(context.Background(), CreateNewPayeeArgs{
+		Owner:     payee.Owner,
+		Nickname:  payee.Nickname,
+		AccountID: payee.AccountID,
+		Currency:  payee.Currency,
+	})
+	require.NoError(t, err)
+}
+
+func TestGetCoolingPayeeDeleted(t *testing.T) {
+	payee := createRandomPayee(t, createRandomUser(t))
+
+	_, err := testQuery.DeletePayee(context.Background(), DeletePayeeArgs{ID: payee.ID, Owner: payee.Owner})
+	require.NoError(t, err)
+
+	cooling, err := testQuery.GetCoolingPayee(context.Background(), GetCoolingPayeeArgs{
+		Owner:     payee.Owner,
+		AccountID: payee.AccountID,
+		Since:     time.Now().Add(-time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, payee.ID, cooling.ID)
+	require.True(t, cooling.DeletedAt.Valid)
+}
+
+func TestGetCoolingPayee(t *testing.T) {
+	payee := createRandomPayee(t, createRandomUser(t))
+
+	arg := GetCoolingPayeeArgs{
+		Owner:     payee.Owner,
+		AccountID: payee.AccountID,
+		Since:     time.Now().Add(-time.Hour),
+	}
+	payee2, err := testQuery.GetCoolingPayee(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, payee.ID, payee2.ID)
+
+	arg.Since = time.Now().Add(time.Minute)
+	_, err = testQuery.GetCoolingPayee(context.Background(), arg)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestGetCoolingPayeeAlreadyPaid(t *testing.T) {
+	fromAccount := createRandomAccount(t)
+	toAccount := createRandomAccount(t)
+
+	_, err := testQuery.CreateNewTransfer(context.Background(), CreateNewTransferArgs{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        util.RandomMoney(),
+	})
+	require.NoError(t, err)
+
+	payee, err := testQuery.CreateNewPayee(context.Background(), CreateNewPayeeArgs{
+		Owner:     fromAccount.Owner,
+		Nickname:  util.RandomName(),
+		AccountID: toAccount.ID,
+		Currency:  toAccount.Currency,
+	})
+	require.NoError(t, err)
+
+	_, err = testQuery.GetCoolingPayee(context.Background(), GetCoolingPayeeArgs{
+		Owner:     payee.Owner,
+		AccountID: payee.AccountID,
+		Since:     time.Now().Add(-time.Hour),
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
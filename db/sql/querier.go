@@ -1,16 +1,113 @@
 package db
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Querier interface {
 	CreateNewAccount(ctx context.Context, arg CreateNewAccountArgs) (Account, error)
 	GetAccountByID(ctx context.Context, id int64) (Account, error)
+	GetAccountByNumber(ctx context.Context, accountNumber string) (Account, error)
+	GetAccountsByIDs(ctx context.Context, ids []int64) ([]Account, error)
+	GetRecipientAccount(ctx context.Context, arg GetRecipientAccountArgs) (GetRecipientAccountRow, error)
 	GetListAccounts(ctx context.Context, arg GetListAccountsArgs) ([]Account, error)
 	UpdateAccountBalance(ctx context.Context, arg UpdateAccountBalanceArgs) (Account, error)
 	DeleteAccountBuID(ctx context.Context, id int64) error
 	CreateNewUser(ctx context.Context, arg CreateNewUserArgs) (User, error)
 	GetUserByUsername(ctx context.Context, username string) (User, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetListUser(ctx context.Context, arg GetListUserArgs) ([]User, error)
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordArgs) (User, error)
+	UpdateUserProfile(ctx context.Context, arg UpdateUserProfileArgs) (User, error)
+	VerifyUserEmail(ctx context.Context, arg VerifyUserEmailArgs) (User, error)
+	GetUserPasswordChangedAt(ctx context.Context, username string) (time.Time, error)
+	GetLoginAttempt(ctx context.Context, username string) (LoginAttempt, error)
+	TakeLoginAttempt(ctx context.Context, arg TakeLoginAttemptArgs) (LoginAttempt, error)
+	ResetLoginAttempts(ctx context.Context, username string) error
 	GetTransferByID(ctx context.Context, id int64) (Transfer, error)
+	GetListTransfers(ctx context.Context, arg GetListTransfersArgs) ([]Transfer, error)
+	UpsertFeeSchedule(ctx context.Context, arg UpsertFeeScheduleArgs) (FeeSchedule, error)
+	GetListFeeSchedules(ctx context.Context, currency string) ([]FeeSchedule, error)
+	GetFeeTier(ctx context.Context, arg GetFeeTierArgs) (FeeSchedule, error)
+	DeleteFeeSchedule(ctx context.Context, id int64) (FeeSchedule, error)
+	CreateCurrency(ctx context.Context, arg CreateCurrencyArgs) (Currency, error)
+	GetCurrency(ctx context.Context, code string) (Currency, error)
+	GetListCurrencies(ctx context.Context) ([]Currency, error)
+	UpdateCurrencyEnabled(ctx context.Context, arg UpdateCurrencyEnabledArgs) (Currency, error)
+	CreateExchangeRate(ctx context.Context, arg CreateExchangeRateArgs) (ExchangeRate, error)
+	GetPortfolio(ctx context.Context, arg GetPortfolioArgs) ([]GetPortfolioRow, error)
+	CreateAccountMember(ctx context.Context, arg CreateAccountMemberArgs) (AccountMember, error)
+	GetAccountMember(ctx context.Context, arg GetAccountMemberArgs) (AccountMember, error)
+	GetAccountMemberForShare(ctx context.Context, arg GetAccountMemberArgs) (AccountMember, error)
+	GetListAccountMembers(ctx context.Context, accountID int64) ([]AccountMember, error)
+	GetListAccountInvitations(ctx context.Context, username string) ([]AccountMember, error)
+	AcceptAccountMember(ctx context.Context, arg AcceptAccountMemberArgs) (AccountMember, error)
+	DeleteAccountMember(ctx context.Context, arg DeleteAccountMemberArgs) (AccountMember, error)
+	UpdateAccountApprovalThreshold(ctx context.Context, arg UpdateAccountApprovalThresholdArgs) (Account, error)
+	CreateTransferApproval(ctx context.Context, arg CreateTransferApprovalArgs) (TransferApproval, error)
+	GetTransferApproval(ctx context.Context, id int64) (TransferApproval, error)
+	GetTransferApprovalForUpdate(ctx context.Context, id int64) (TransferApproval, error)
+	GetListTransferApprovals(ctx context.Context, arg GetListTransferApprovalsArgs) ([]TransferApproval, error)
+	DecideTransferApproval(ctx context.Context, arg DecideTransferApprovalArgs) (TransferApproval, error)
+	ExpireTransferApprovals(ctx context.Context, now time.Time) (int64, error)
+	CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventArgs) (OutboxEvent, error)
+	GetUnpublishedOutboxEvents(ctx context.Context, limit int32) ([]OutboxEvent, error)
+	MarkOutboxEventPublished(ctx context.Context, id int64) error
+	TryOutboxRelayLock(ctx context.Context) (bool, error)
+	CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEndpointArgs) (WebhookEndpoint, error)
+	GetWebhookEndpoint(ctx context.Context, id int64) (WebhookEndpoint, error)
+	GetListWebhookEndpoints(ctx context.Context, accountID int64) ([]WebhookEndpoint, error)
+	UpdateWebhookEndpoint(ctx context.Context, arg UpdateWebhookEndpointArgs) (WebhookEndpoint, error)
+	RecordWebhookEndpointResult(ctx context.Context, arg RecordWebhookEndpointResultArgs) (WebhookEndpoint, error)
+	DeleteWebhookEndpoint(ctx context.Context, id int64) error
+	CreateWebhookDeliveries(ctx context.Context, arg CreateWebhookDeliveriesArgs) (int64, error)
+	CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryArgs) (WebhookDelivery, error)
+	GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error)
+	GetListWebhookDeliveries(ctx context.Context, arg GetListWebhookDeliveriesArgs) ([]WebhookDelivery, error)
+	ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesArgs) ([]ClaimWebhookDeliveriesRow, error)
+	UpdateWebhookDeliveryAttempt(ctx context.Context, arg UpdateWebhookDeliveryAttemptArgs) (WebhookDelivery, error)
+	NotifyAccountUpdate(ctx context.Context, arg AccountUpdate) error
+	GetFeeAccount(ctx context.Context, currency string) (Account, error)
+	GetInterestBalances(ctx context.Context, endOfDay time.Time) ([]GetInterestBalancesRow, error)
+	CreateInterestAccrual(ctx context.Context, arg CreateInterestAccrualArgs) (int64, error)
+	GetListInterestAccruals(ctx context.Context, arg GetListInterestAccrualsArgs) ([]InterestAccrual, error)
+	GetAccountsWithUnpostedInterest(ctx context.Context, periodEnd time.Time) ([]int64, error)
+	CreateInterestPosting(ctx context.Context, arg CreateInterestPostingArgs) (InterestPosting, error)
+	AssignInterestAccruals(ctx context.Context, arg AssignInterestAccrualsArgs) (int64, error)
+	GetInterestCarry(ctx context.Context, arg GetInterestCarryArgs) (int64, error)
+	UpdateInterestPosting(ctx context.Context, arg UpdateInterestPostingArgs) (InterestPosting, error)
+	GetInterestExpenseAccount(ctx context.Context, currency string) (Account, error)
 	GetEntryByID(ctx context.Context, id int64) (Entry, error)
+	CreateNewResetToken(ctx context.Context, arg CreateNewResetTokenArgs) (ResetToken, error)
+	GetResetTokenByHash(ctx context.Context, tokenHash string) (ResetToken, error)
+	CreatePendingUserTOTP(ctx context.Context, arg CreatePendingUserTOTPArgs) (UserTOTP, error)
+	GetUserTOTP(ctx context.Context, username string) (UserTOTP, error)
+	UseTOTPStep(ctx context.Context, arg UseTOTPStepArgs) (UserTOTP, error)
+	UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeArgs) (RecoveryCode, error)
+	CreateNewAPIKey(ctx context.Context, arg CreateNewAPIKeyArgs) (APIKey, error)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (APIKey, error)
+	GetListAPIKeys(ctx context.Context, arg GetListAPIKeysArgs) ([]APIKey, error)
+	RevokeAPIKey(ctx context.Context, arg RevokeAPIKeyArgs) (APIKey, error)
+	TouchAPIKey(ctx context.Context, id int64) error
+	CreateNewOAuthClient(ctx context.Context, arg CreateNewOAuthClientArgs) (OAuthClient, error)
+	GetOAuthClient(ctx context.Context, clientID string) (OAuthClient, error)
+	CreateNewOAuthCode(ctx context.Context, arg CreateNewOAuthCodeArgs) (OAuthCode, error)
+	GetOAuthCode(ctx context.Context, codeHash string) (OAuthCode, error)
+	UseOAuthCode(ctx context.Context, arg UseOAuthCodeArgs) (OAuthCode, error)
+	CreateNewOAuthToken(ctx context.Context, arg CreateNewOAuthTokenArgs) (OAuthToken, error)
+	GetOAuthToken(ctx context.Context, id uuid.UUID) (OAuthToken, error)
+	GetListOAuthTokens(ctx context.Context, arg GetListOAuthTokensArgs) ([]OAuthToken, error)
+	RevokeOAuthToken(ctx context.Context, arg RevokeOAuthTokenArgs) error
+	RevokeOAuthGrant(ctx context.Context, arg RevokeOAuthGrantArgs) (int64, error)
+	CreateNewPayee(ctx context.Context, arg CreateNewPayeeArgs) (Payee, error)
+	GetPayeeByID(ctx context.Context, id int64) (Payee, error)
+	GetCoolingPayee(ctx context.Context, arg GetCoolingPayeeArgs) (Payee, error)
+	GetListPayees(ctx context.Context, arg GetListPayeesArgs) ([]Payee, error)
+	DeletePayee(ctx context.Context, arg DeletePayeeArgs) (Payee, error)
+	TakeRateLimitToken(ctx context.Context, arg TakeRateLimitTokenArgs) (float64, error)
+	GetRateLimitTokens(ctx context.Context, arg GetRateLimitTokensArgs) (float64, error)
+	DeleteIdleRateLimitBuckets(ctx context.Context, idleBefore time.Time) (int64, error)
 }
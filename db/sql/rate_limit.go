@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const takeRateLimitTokenQuery = `-- name: TakeRateLimitToken :one
+INSERT INTO rate_limit_buckets (
+	key, tokens, updated_at
+) VALUES (
+	$1, $2::float8 - 1, now()
+) ON CONFLICT (key) DO UPDATE SET
+	tokens = LEAST($2::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM now() - rate_limit_buckets.updated_at) * $3::float8) - 1,
+	updated_at = now()
+WHERE LEAST($2::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM now() - rate_limit_buckets.updated_at) * $3::float8) >= 1
+RETURNING tokens
+`
+
+type TakeRateLimitTokenArgs struct {
+	Key   string  `json:"key"`
+	Burst float64 `json:"burst"`
+	Rate  float64 `json:"rate"`
+}
+
+// TakeRateLimitToken refills the bucket and takes one token from it in a
+// single statement. It returns sql.ErrNoRows when the bucket is empty.
+func (query *Query) TakeRateLimitToken(ctx context.Context, arg TakeRateLimitTokenArgs) (float64, error) {
+	row := query.db.QueryRowContext(ctx, takeRateLimitTokenQuery, arg.Key, arg.Burst, arg.Rate)
+	var tokens float64
+	err := row.Scan(&tokens)
+	return tokens, err
+}
+
+const selectRateLimitTokensQuery = `-- name: GetRateLimitTokens :one
+SELECT LEAST($2::float8, tokens + EXTRACT(EPOCH FROM now() - updated_at) * $3::float8)::float8
+FROM rate_limit_buckets WHERE key = $1 LIMIT 1
+`
+
+type GetRateLimitTokensArgs struct {
+	Key   string  `json:"key"`
+	Burst float64 `json:"burst"`
+	Rate  float64 `json:"rate"`
+}
+
+func (query *Query) GetRateLimitTokens(ctx context.Context, arg GetRateLimitTokensArgs) (float64, error) {
+	row := query.db.QueryRowContext(ctx, selectRateLimitTokensQuery, arg.Key, arg.Burst, arg.Rate)
+	var tokens float64
+	err := row.Scan(&tokens)
+	return tokens, err
+}
+
+const deleteIdleRateLimitBucketsQuery = `-- name: DeleteIdleRateLimitBuckets :execrows
+DELETE FROM rate_limit_buckets WHERE updated_at < $1
+`
+
+// DeleteIdleRateLimitBuckets drops the buckets untouched since idleBefore.
+// A bucket that had time to refill behaves exactly like a missing one.
+func (query *Query) DeleteIdleRateLimitBuckets(ctx context.Context, idleBefore time.Time) (int64, error) {
+	result, err := query.db.ExecContext(ctx, deleteIdleRateLimitBucketsQuery, idleBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
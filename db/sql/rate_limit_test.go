@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTakeRateLimitToken(t *testing.T) {
+	key := util.RandomString(12)
+	burst := 3
+
+	for i := 0; i < burst; i++ {
+		tokens, err := testQuery.TakeRateLimitToken(context.Background(), TakeRateLimitTokenArgs{
+			Key:   key,
+			Burst: float64(burst),
+			Rate:  0.001,
+		})
+		require.NoError(t, err)
+		require.InDelta(t, float64(burst-i-1), tokens, 0.1)
+	}
+
+	_, err := testQuery.TakeRateLimitToken(context.Background(), TakeRateLimitTokenArgs{
+		Key:   key,
+		Burst: float64(burst),
+		Rate:  0.001,
+	})
+	require.Error(t, err)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	tokens, err := testQuery.GetRateLimitTokens(context.Background(), GetRateLimitTokensArgs{
+		Key:   key,
+		Burst: float64(burst),
+		Rate:  0.001,
+	})
+	require.NoError(t, err)
+	require.True(t, tokens < 1)
+}
+
+func TestTakeRateLimitTokenRefill(t *testing.T) {
+	arg := TakeRateLimitTokenArgs{
+		Key:   util.RandomString(12),
+		Burst: 1,
+		Rate:  20,
+	}
+
+	_, err := testQuery.TakeRateLimitToken(context.Background(), arg)
+	require.NoError(t, err)
+
+	_, err = testQuery.TakeRateLimitToken(context.Background(), arg)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	// 20 tokens per second refill the bucket within 50ms, but never beyond
+	// the burst.
+	time.Sleep(200 * time.Millisecond)
+
+	tokens, err := testQuery.TakeRateLimitToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.InDelta(t, 0, tokens, 0.01)
+
+	_, err = testQuery.TakeRateLimitToken(context.Background(), arg)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestTakeRateLimitTokenConcurrent(t *testing.T) {
+	arg := TakeRateLimitTokenArgs{
+		Key:   util.RandomString(12),
+		Burst: 5,
+		Rate:  0.001,
+	}
+
+	n := 20
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := testQuery.TakeRateLimitToken(context.Background(), arg)
+			errs <- err
+		}()
+	}
+
+	allowed := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			allowed++
+			continue
+		}
+		require.EqualError(t, err, sql.ErrNoRows.Error())
+	}
+	require.Equal(t, int(arg.Burst), allowed)
+}
+
+func TestGetRateLimitTokensMissing(t *testing.T) {
+	_, err := testQuery.GetRateLimitTokens(context.Background(), GetRateLimitTokensArgs{
+		Key:   util.RandomString(12),
+		Burst: 3,
+		Rate:  1,
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestDeleteIdleRateLimitBuckets(t *testing.T) {
+	arg := TakeRateLimitTokenArgs{
+		Key:   util.RandomString(12),
+		Burst: 1,
+		Rate:  1,
+	}
+
+	_, err := testQuery.TakeRateLimitToken(context.Background(), arg)
+	require.NoError(t, err)
+
+	// A bucket used after idleBefore is kept.
+	_, err = testQuery.DeleteIdleRateLimitBuckets(context.Background(), time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	_, err = testQuery.GetRateLimitTokens(context.Background(), GetRateLimitTokensArgs{Key: arg.Key, Burst: 1, Rate: 1})
+	require.NoError(t, err)
+
+	deleted, err := testQuery.DeleteIdleRateLimitBuckets(context.Background(), time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, deleted, int64(1))
+	_, err = testQuery.GetRateLimitTokens(context.Background(), GetRateLimitTokensArgs{Key: arg.Key, Burst: 1, Rate: 1})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type RecordWebhookAttemptTxArg struct {
+	DeliveryID     int64         `json:"delivery_id"`
+	EndpointID     int64         `json:"endpoint_id"`
+	Succeeded      bool          `json:"succeeded"`
+	ResponseStatus sql.NullInt32 `json:"response_status"`
+	Error          string        `json:"error"`
+	// RetryIn schedules the next attempt of a failed delivery. Zero gives up
+	// on it.
+	RetryIn time.Duration `json:"retry_in"`
+	// DisableAfter is the number of consecutive failed attempts after which
+	// the endpoint is disabled.
+	DisableAfter int32 `json:"disable_after"`
+}
+
+type RecordWebhookAttemptTxResult struct {
+	Delivery WebhookDelivery `json:"delivery"`
+	Endpoint WebhookEndpoint `json:"endpoint"`
+}
+
+// RecordWebhookAttemptTx stores the outcome of sending a delivery and
+// updates the failure count of its endpoint, disabling it once it failed
+// DisableAfter times in a row.
+func (store *SQLStore) RecordWebhookAttemptTx(ctx context.Context, arg RecordWebhookAttemptTxArg) (RecordWebhookAttemptTxResult, error) {
+	var result RecordWebhookAttemptTxResult
+
+	err := store.execTx(ctx, func(query *Query) error {
+		status := WebhookDeliverySucceeded
+		if !arg.Succeeded {
+			status = WebhookDeliveryPending
+			if arg.RetryIn <= 0 {
+				status = WebhookDeliveryFailed
+			}
+		}
+
+		var err error
+		result.Delivery, err = query.UpdateWebhookDeliveryAttempt(ctx, UpdateWebhookDeliveryAttemptArgs{
+			ID:             arg.DeliveryID,
+			Status:         status,
+			RetryIn:        arg.RetryIn,
+			ResponseStatus: arg.ResponseStatus,
+			LastError:      arg.Error,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Endpoint, err = query.RecordWebhookEndpointResult(ctx, RecordWebhookEndpointResultArgs{
+			ID:           arg.EndpointID,
+			Succeeded:    arg.Succeeded,
+			DisableAfter: arg.DisableAfter,
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,57 @@
+package db
+
+import "context"
+
+type RelayOutboxTxArg struct {
+	Limit int32 `json:"limit"`
+	// Publish delivers one event, it is called in id order.
+	Publish func(ctx context.Context, event OutboxEvent) error `json:"-"`
+}
+
+type RelayOutboxTxResult struct {
+	Locked    bool  `json:"locked"`
+	Published int64 `json:"published"`
+}
+
+// RelayOutboxTx publishes up to Limit unpublished events in id order and
+// marks them published. Publishing stops at the first failure, so a later
+// event of an aggregate never overtakes an earlier one; the events already
+// published are still marked and the failure is returned. Locked is false
+// when another relay is running, in which case nothing is published.
+func (store *SQLStore) RelayOutboxTx(ctx context.Context, arg RelayOutboxTxArg) (RelayOutboxTxResult, error) {
+	var result RelayOutboxTxResult
+	var publishErr error
+
+	err := store.execTx(ctx, func(query *Query) error {
+		var err error
+
+		result.Locked, err = query.TryOutboxRelayLock(ctx)
+		if err != nil || !result.Locked {
+			return err
+		}
+
+		events, err := query.GetUnpublishedOutboxEvents(ctx, arg.Limit)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			publishErr = arg.Publish(ctx, event)
+			if publishErr != nil {
+				return nil
+			}
+
+			err = query.MarkOutboxEventPublished(ctx, event.ID)
+			if err != nil {
+				return err
+			}
+			result.Published++
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, publishErr
+}
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+type ResetPasswordTxArg struct {
+	TokenHash         string    `json:"token_hash"`
+	HashedPassword    string    `json:"hashed_password"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+}
+
+type ResetPasswordTxResult struct {
+	User       User       `json:"user"`
+	ResetToken ResetToken `json:"reset_token"`
+}
+
+// ResetPasswordTx consumes the reset token and sets the new password in one
+// transaction, so a token can never be used twice. It returns sql.ErrNoRows
+// when the token is unknown, used or expired.
+func (store *SQLStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxArg) (ResetPasswordTxResult, error) {
+	var result ResetPasswordTxResult
+
+	err := store.execTx(ctx, func(query *Query) error {
+		var err error
+
+		result.ResetToken, err = query.UseResetToken(ctx, arg.TokenHash)
+		if err != nil {
+			return err
+		}
+
+		result.User, err = query.UpdateUserPassword(ctx, UpdateUserPasswordArgs{
+			Username:          result.ResetToken.Username,
+			HashedPassword:    arg.HashedPassword,
+			PasswordChangedAt: arg.PasswordChangedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = query.InvalidateResetTokens(ctx, result.User.Username)
+		if err != nil {
+			return err
+		}
+
+		return query.ResetLoginAttempts(ctx, result.User.Username)
+	})
+
+	return result, err
+}
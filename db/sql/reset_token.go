@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const insertNewResetTokenQuery = `-- name: CreateNewResetToken :one
+INSERT INTO reset_tokens (
+	username, token_hash, expired_at
+) VALUES (
+	$1, $2, $3
+) RETURNING *
+`
+
+type CreateNewResetTokenArgs struct {
+	Username  string    `json:"username"`
+	TokenHash string    `json:"token_hash"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+func (query *Query) CreateNewResetToken(ctx context.Context, arg CreateNewResetTokenArgs) (ResetToken, error) {
+	row := query.db.QueryRowContext(ctx, insertNewResetTokenQuery, arg.Username, arg.TokenHash, arg.ExpiredAt)
+	var i ResetToken
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.TokenHash,
+		&i.IsUsed,
+		&i.ExpiredAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const selectResetTokenByHashQuery = `-- name: GetResetTokenByHash :one
+SELECT * FROM reset_tokens WHERE token_hash = $1 LIMIT 1
+`
+
+func (query *Query) GetResetTokenByHash(ctx context.Context, tokenHash string) (ResetToken, error) {
+	row := query.db.QueryRowContext(ctx, selectResetTokenByHashQuery, tokenHash)
+	var i ResetToken
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.TokenHash,
+		&i.IsUsed,
+		&i.ExpiredAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const useResetTokenQuery = `-- name: UseResetToken :one
+UPDATE reset_tokens SET is_used = true
+WHERE token_hash = $1 AND is_used = false AND expired_at > now()
+RETURNING *
+`
+
+// UseResetToken marks a token as used. It returns sql.ErrNoRows when the
+// token does not exist, was already used or has expired.
+func (query *Query) UseResetToken(ctx context.Context, tokenHash string) (ResetToken, error) {
+	row := query.db.QueryRowContext(ctx, useResetTokenQuery, tokenHash)
+	var i ResetToken
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.TokenHash,
+		&i.IsUsed,
+		&i.ExpiredAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const invalidateResetTokensQuery = `-- name: InvalidateResetTokens :exec
+UPDATE reset_tokens SET is_used = true WHERE username = $1 AND is_used = false
+`
+
+func (query *Query) InvalidateResetTokens(ctx context.Context, username string) error {
+	_, err := query.db.ExecContext(ctx, invalidateResetTokensQuery, username)
+	return err
+}
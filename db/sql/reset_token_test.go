@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomResetToken(t *testing.T, user User, expiredAt time.Time) ResetToken {
+	arg := CreateNewResetTokenArgs{
+		Username:  user.Username,
+		TokenHash: util.HashSecureToken(util.RandomString(32)),
+		ExpiredAt: expiredAt,
+	}
+
+	resetToken, err := testQuery.CreateNewResetToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, resetToken.ID)
+	require.Equal(t, arg.Username, resetToken.Username)
+	require.Equal(t, arg.TokenHash, resetToken.TokenHash)
+	require.False(t, resetToken.IsUsed)
+	require.WithinDuration(t, arg.ExpiredAt, resetToken.ExpiredAt, time.Second)
+	return resetToken
+}
+
+func TestGetResetTokenByHash(t *testing.T) {
+	user := createRandomUser(t)
+	resetToken1 := createRandomResetToken(t, user, time.Now().Add(time.Minute))
+
+	resetToken2, err := testQuery.GetResetTokenByHash(context.Background(), resetToken1.TokenHash)
+	require.NoError(t, err)
+	require.Equal(t, resetToken1.ID, resetToken2.ID)
+	require.Equal(t, resetToken1.Username, resetToken2.Username)
+}
+
+func TestResetPasswordTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	resetToken := createRandomResetToken(t, user, time.Now().Add(time.Minute))
+	otherToken := createRandomResetToken(t, user, time.Now().Add(time.Minute))
+
+	hashPassword, err := util.HashedPassword(util.RandomString(8))
+	require.NoError(t, err)
+
+	arg := ResetPasswordTxArg{
+		TokenHash:         resetToken.TokenHash,
+		HashedPassword:    hashPassword,
+		PasswordChangedAt: time.Now(),
+	}
+
+	result, err := store.ResetPasswordTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, user.Username, result.User.Username)
+	require.Equal(t, hashPassword, result.User.HashedPassword)
+	require.WithinDuration(t, arg.PasswordChangedAt, result.User.PasswordChangedAt, time.Millisecond)
+	require.True(t, result.ResetToken.IsUsed)
+
+	_, err = store.ResetPasswordTx(context.Background(), arg)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	otherToken, err = store.GetResetTokenByHash(context.Background(), otherToken.TokenHash)
+	require.NoError(t, err)
+	require.True(t, otherToken.IsUsed)
+}
+
+func TestResetPasswordTxExpired(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	resetToken := createRandomResetToken(t, user, time.Now().Add(-time.Minute))
+
+	_, err := store.ResetPasswordTx(context.Background(), ResetPasswordTxArg{
+		TokenHash:         resetToken.TokenHash,
+		HashedPassword:    user.HashedPassword,
+		PasswordChangedAt: time.Now(),
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	user2, err := store.GetUserByUsername(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, user.PasswordChangedAt, user2.PasswordChangedAt)
+}
@@ -3,12 +3,31 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"time"
+
+	"github.com/asshiddiq1306/simple_bank/util"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Store interface {
 	Querier
 	TransferTx(ctx context.Context, arg TransferTxArg) (TransferTxResult, error)
+	QuoteTransferFee(ctx context.Context, arg TransferFeeArg) (int64, error)
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordTxArg) (ResetPasswordTxResult, error)
+	EnableTOTPTx(ctx context.Context, arg EnableTOTPTxArg) (EnableTOTPTxResult, error)
+	ExchangeOAuthCodeTx(ctx context.Context, arg ExchangeOAuthCodeTxArg) (ExchangeOAuthCodeTxResult, error)
+	AccrueInterestTx(ctx context.Context, date time.Time) (AccrueInterestTxResult, error)
+	PostInterestTx(ctx context.Context, arg PostInterestTxArg) (PostInterestTxResult, error)
+	ApproveTransferTx(ctx context.Context, arg ApproveTransferTxArg) (ApproveTransferTxResult, error)
+	CreateUserTx(ctx context.Context, arg CreateNewUserArgs) (User, error)
+	CreateAccountTx(ctx context.Context, arg CreateNewAccountArgs) (Account, error)
+	CreateCurrencyTx(ctx context.Context, arg CreateCurrencyTxArg) (CreateCurrencyTxResult, error)
+	RelayOutboxTx(ctx context.Context, arg RelayOutboxTxArg) (RelayOutboxTxResult, error)
+	RecordWebhookAttemptTx(ctx context.Context, arg RecordWebhookAttemptTxArg) (RecordWebhookAttemptTxResult, error)
 }
 
 type SQLStore struct {
@@ -19,110 +38,321 @@ type SQLStore struct {
 func NewStore(db *sql.DB) Store {
 	return &SQLStore{
 		db:    db,
-		Query: NewQuery(db),
+		Query: NewQuery(instrumentDBTX(db)),
 	}
 }
 
 func (store *SQLStore) execTx(ctx context.Context, fn func(query *Query) error) error {
-	tx, err := store.db.BeginTx(ctx, nil)
+	tx, err := beginTx(ctx, store.db)
 	if err != nil {
 		return err
 	}
 
-	q := NewQuery(tx)
+	q := NewQuery(instrumentDBTX(tx))
 	err = fn(q)
 	if err != nil {
-		if rbErr := tx.Rollback(); rbErr != nil {
+		logger := contextLogger(ctx)
+		if rbErr := rollbackTx(ctx, tx); rbErr != nil {
+			logger.Error().Err(err).AnErr("rb_error", rbErr).Msg("db transaction rollback failed")
 			return fmt.Errorf("rb error: %s, tx error : %s", rbErr, err)
 		}
 
+		logger.Error().Err(err).Msg("db transaction rolled back")
 		return err
 	}
 
-	return tx.Commit()
+	err = commitTx(ctx, tx)
+	if err != nil {
+		contextLogger(ctx).Error().Err(err).Msg("db transaction commit failed")
+	}
+	return err
+}
+
+func beginTx(ctx context.Context, db *sql.DB) (*sql.Tx, error) {
+	ctx, span := startQuerySpan(ctx, "BEGIN", "BEGIN")
+	defer span.End()
+
+	tx, err := db.BeginTx(ctx, nil)
+	recordSpanError(span, err)
+	return tx, err
+}
+
+func commitTx(ctx context.Context, tx *sql.Tx) error {
+	_, span := startQuerySpan(ctx, "COMMIT", "COMMIT")
+	defer span.End()
+
+	err := tx.Commit()
+	recordSpanError(span, err)
+	return err
+}
+
+func rollbackTx(ctx context.Context, tx *sql.Tx) error {
+	_, span := startQuerySpan(ctx, "ROLLBACK", "ROLLBACK")
+	defer span.End()
+
+	err := tx.Rollback()
+	recordSpanError(span, err)
+	return err
 }
 
 type TransferTxArg struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID int64           `json:"from_account_id"`
+	ToAccountID   int64           `json:"to_account_id"`
+	Amount        int64           `json:"amount"`
+	Description   string          `json:"description"`
+	Reference     string          `json:"reference"`
+	Metadata      json.RawMessage `json:"metadata"`
+	// InitiatedBy is the user moving the money. When set, TransferTx checks
+	// that they are a member of the from account allowed to transfer, and
+	// that the amount does not need a second member's approval.
+	InitiatedBy string `json:"initiated_by"`
 }
 
+var (
+	// ErrTransferNotAllowed is returned by TransferTx when InitiatedBy may
+	// not transfer from the from account.
+	ErrTransferNotAllowed = errors.New("not allowed to transfer from this account")
+	// ErrTransferRequiresApproval is returned by TransferTx when the amount
+	// reaches the approval threshold of the from account.
+	ErrTransferRequiresApproval = errors.New("this transfer requires the approval of another account member")
+)
+
 type TransferTxResult struct {
-	Transfer    Transfer `json:"transfer"`
-	FromEntry   Entry    `json:"from_entry"`
-	ToEntry     Entry    `json:"to_entry"`
-	FromAccount Account  `json:"from_account"`
-	ToAccount   Account  `json:"to_account"`
+	Transfer     Transfer `json:"transfer"`
+	FromEntry    Entry    `json:"from_entry"`
+	ToEntry      Entry    `json:"to_entry"`
+	FromAccount  Account  `json:"from_account"`
+	ToAccount    Account  `json:"to_account"`
+	Fee          int64    `json:"fee"`
+	FeeEntry     Entry    `json:"fee_entry"`
+	RevenueEntry Entry    `json:"revenue_entry"`
 }
 
+// TransferTx moves amount between the accounts and charges the sender the
+// fee of the from account's currency schedule, posting it to the revenue
+// account of that currency in the same transaction, along with a
+// TransferCompleted event for each of the two accounts.
 func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxArg) (TransferTxResult, error) {
+	ctx, span := startSpan(ctx, "TransferTx",
+		attribute.Int64("transfer.from_account_id", arg.FromAccountID),
+		attribute.Int64("transfer.to_account_id", arg.ToAccountID),
+		attribute.String("transfer.reference", arg.Reference),
+	)
+	defer span.End()
+
 	var result TransferTxResult
 
 	err := store.execTx(ctx, func(query *Query) error {
-		var err error
+		fromAccount, toAccount, err := getTransferAccounts(ctx, query, arg.FromAccountID, arg.ToAccountID)
+		if err != nil {
+			return err
+		}
 
-		result.Transfer, err = query.CreateNewTransfer(ctx, CreateNewTransferArgs{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
-		})
+		if arg.InitiatedBy != "" {
+			err = authorizeTransfer(ctx, query, arg.FromAccountID, arg.InitiatedBy)
+			if err != nil {
+				return err
+			}
+
+			if fromAccount.RequiresApproval(arg.Amount) {
+				return ErrTransferRequiresApproval
+			}
+		}
+
+		result, err = transfer(ctx, query, arg, fromAccount, toAccount)
+		return err
+	})
+
+	recordSpanError(span, err)
+	return result, err
+}
+
+// transfer moves the money of arg between the accounts within the
+// transaction of query, once the caller has checked that it is allowed.
+func transfer(ctx context.Context, query *Query, arg TransferTxArg, fromAccount Account, toAccount Account) (TransferTxResult, error) {
+	var result TransferTxResult
+	var err error
+
+	result.Fee, err = transferFee(ctx, query, TransferFeeArg{
+		FromOwner: fromAccount.Owner,
+		ToOwner:   toAccount.Owner,
+		Currency:  fromAccount.Currency,
+		Amount:    arg.Amount,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	// The sender is debited amount plus fee, which must fit a balance.
+	_, err = util.AddAmounts(arg.Amount, result.Fee)
+	if err != nil {
+		return result, err
+	}
+
+	result.Transfer, err = query.CreateNewTransfer(ctx, CreateNewTransferArgs{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		Description:   arg.Description,
+		Reference:     arg.Reference,
+		Metadata:      arg.Metadata,
+		Fee:           result.Fee,
+	})
+
+	if err != nil {
+		return result, err
+	}
+
+	result.FromEntry, err = query.CreateNewEntry(ctx, CreateNewEntryArgs{
+		AccountID: arg.FromAccountID,
+		Amount:    -arg.Amount,
+	})
+
+	if err != nil {
+		return result, err
+	}
 
+	result.ToEntry, err = query.CreateNewEntry(ctx, CreateNewEntryArgs{
+		AccountID: arg.ToAccountID,
+		Amount:    arg.Amount,
+	})
+
+	if err != nil {
+		return result, err
+	}
+
+	changes := make(map[int64]int64)
+	changes[arg.FromAccountID] -= arg.Amount
+	changes[arg.ToAccountID] += arg.Amount
+
+	if result.Fee > 0 {
+		feeAccount, err := query.GetFeeAccount(ctx, fromAccount.Currency)
 		if err != nil {
-			return err
+			return result, fmt.Errorf("cannot find fee account for %s: %w", fromAccount.Currency, err)
 		}
 
-		result.FromEntry, err = query.CreateNewEntry(ctx, CreateNewEntryArgs{
+		result.FeeEntry, err = query.CreateNewEntry(ctx, CreateNewEntryArgs{
 			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
+			Amount:    -result.Fee,
 		})
-
 		if err != nil {
-			return err
+			return result, err
 		}
 
-		result.ToEntry, err = query.CreateNewEntry(ctx, CreateNewEntryArgs{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
+		result.RevenueEntry, err = query.CreateNewEntry(ctx, CreateNewEntryArgs{
+			AccountID: feeAccount.ID,
+			Amount:    result.Fee,
 		})
-
 		if err != nil {
-			return err
+			return result, err
 		}
 
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, query, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
-		} else {
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, query, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
+		changes[arg.FromAccountID] -= result.Fee
+		changes[feeAccount.ID] += result.Fee
+	}
+
+	accounts, err := addMoney(ctx, query, changes)
+	if err != nil {
+		return result, err
+	}
+
+	result.FromAccount = accounts[arg.FromAccountID]
+	result.ToAccount = accounts[arg.ToAccountID]
+
+	// Written once the accounts are locked, so the events of an account get
+	// increasing ids in the order their transfers commit. Each account gets
+	// its own event, keyed by its id, so both sides see the transfer.
+	event := TransferCompletedEvent{
+		TransferID:    result.Transfer.ID,
+		FromAccountID: result.Transfer.FromAccountID,
+		ToAccountID:   result.Transfer.ToAccountID,
+		Amount:        result.Transfer.Amount,
+		Fee:           result.Transfer.Fee,
+		Currency:      fromAccount.Currency,
+		Description:   result.Transfer.Description,
+		Reference:     result.Transfer.Reference,
+		Metadata:      result.Transfer.Metadata,
+		CreatedAt:     result.Transfer.CreatedAt,
+	}
+	for _, accountID := range []int64{arg.FromAccountID, arg.ToAccountID} {
+		err = writeEvent(ctx, query, EventTransferCompleted, AggregateAccount, accountAggregateID(accountID), event, accountID)
+		if err != nil {
+			return result, err
 		}
-		return nil
-	})
+	}
 
+	entries := []Entry{result.FromEntry, result.ToEntry}
+	if result.Fee > 0 {
+		entries = append(entries, result.FeeEntry)
+	}
+	err = notifyAccountUpdates(ctx, query, []Account{result.FromAccount, result.ToAccount}, entries)
 	return result, err
 }
 
-func addMoney(
-	ctx context.Context,
-	query *Query,
-	fromID int64,
-	amount1 int64,
-	toID int64,
-	amount2 int64,
-) (account1 Account, account2 Account, err error) {
-	account1, err = query.UpdateAccountBalance(ctx, UpdateAccountBalanceArgs{
-		ID:     fromID,
-		Amount: amount1,
-	})
-
+func getTransferAccounts(ctx context.Context, query *Query, fromID int64, toID int64) (fromAccount Account, toAccount Account, err error) {
+	accounts, err := query.GetAccountsByIDs(ctx, []int64{fromID, toID})
 	if err != nil {
 		return
 	}
 
-	account2, err = query.UpdateAccountBalance(ctx, UpdateAccountBalanceArgs{
-		ID:     toID,
-		Amount: amount2,
-	})
+	found := 0
+	for _, account := range accounts {
+		if account.ID == fromID {
+			fromAccount = account
+			found++
+		}
+		if account.ID == toID {
+			toAccount = account
+			found++
+		}
+	}
 
+	if found != 2 {
+		err = sql.ErrNoRows
+	}
 	return
+}
+
+// authorizeTransfer locks the membership of username, so it cannot be
+// revoked while the transfer commits.
+func authorizeTransfer(ctx context.Context, query *Query, accountID int64, username string) error {
+	member, err := query.GetAccountMemberForShare(ctx, GetAccountMemberArgs{
+		AccountID: accountID,
+		Username:  username,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrTransferNotAllowed
+		}
+		return err
+	}
+
+	if !member.AcceptedAt.Valid || !util.CanTransferFromAccount(member.Role) {
+		return ErrTransferNotAllowed
+	}
+	return nil
+}
 
+// addMoney applies the balance changes in ascending account ID order, so
+// concurrent transfers always lock accounts in the same order and cannot
+// deadlock, whichever accounts and fee account they involve.
+func addMoney(ctx context.Context, query *Query, changes map[int64]int64) (map[int64]Account, error) {
+	ids := make([]int64, 0, len(changes))
+	for id := range changes {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	accounts := make(map[int64]Account, len(ids))
+	for _, id := range ids {
+		account, err := query.UpdateAccountBalance(ctx, UpdateAccountBalanceArgs{
+			ID:     id,
+			Amount: changes[id],
+		})
+		if err != nil {
+			return nil, err
+		}
+		accounts[id] = account
+	}
+	return accounts, nil
 }
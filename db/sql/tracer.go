@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/asshiddiq1306/simple_bank/db/sql"
+
+// tracedDBTX wraps a DBTX and records an OpenTelemetry span for every query.
+type tracedDBTX struct {
+	db DBTX
+}
+
+func newTracedDBTX(db DBTX) DBTX {
+	return &tracedDBTX{
+		db: db,
+	}
+}
+
+func (t *tracedDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startQuerySpan(ctx, queryName(query), query)
+	defer span.End()
+
+	result, err := t.db.ExecContext(ctx, query, args...)
+	recordSpanError(span, err)
+	return result, err
+}
+
+func (t *tracedDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startQuerySpan(ctx, queryName(query), query)
+	defer span.End()
+
+	row := t.db.QueryRowContext(ctx, query, args...)
+	recordSpanError(span, row.Err())
+	return row
+}
+
+func (t *tracedDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startQuerySpan(ctx, queryName(query), query)
+	defer span.End()
+
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	recordSpanError(span, err)
+	return rows, err
+}
+
+func startQuerySpan(ctx context.Context, name string, statement string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBStatementKey.String(statement),
+		),
+	)
+}
+
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+func recordSpanError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// instrumentDBTX wraps db with tracing and logging. Tracing is outermost so
+// that logged queries run inside their span.
+func instrumentDBTX(db DBTX) DBTX {
+	return newTracedDBTX(newLoggedDBTX(db))
+}
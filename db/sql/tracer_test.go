@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// fakeDBTX answers every query with err, recording the context it got.
+type fakeDBTX struct {
+	err error
+	ctx context.Context
+}
+
+func (db *fakeDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	db.ctx = ctx
+	return nil, db.err
+}
+
+func (db *fakeDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	db.ctx = ctx
+	return nil
+}
+
+func (db *fakeDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	db.ctx = ctx
+	return nil, db.err
+}
+
+func newTestTracerProvider(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func spanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, attr := range span.Attributes() {
+		if attr.Key == key {
+			return attr.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTracedDBTX(t *testing.T) {
+	recorder := newTestTracerProvider(t)
+
+	fake := &fakeDBTX{}
+	traced := newTracedDBTX(fake)
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	_, err := traced.ExecContext(ctx, selectAccountByID, 1)
+	require.NoError(t, err)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	span := spans[0]
+	require.Equal(t, "GetAccountByID", span.Name())
+	require.Equal(t, trace.SpanKindClient, span.SpanKind())
+	require.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+	require.Equal(t, "postgresql", spanAttribute(span, "db.system").AsString())
+	require.Equal(t, selectAccountByID, spanAttribute(span, "db.statement").AsString())
+	require.Equal(t, codes.Unset, span.Status().Code)
+
+	// The wrapped query runs inside its span.
+	require.Equal(t, span.SpanContext().SpanID(), trace.SpanContextFromContext(fake.ctx).SpanID())
+}
+
+func TestTracedDBTXError(t *testing.T) {
+	recorder := newTestTracerProvider(t)
+
+	queryErr := errors.New("connection reset")
+	traced := newTracedDBTX(&fakeDBTX{err: queryErr})
+
+	_, err := traced.QueryContext(context.Background(), "BEGIN")
+	require.Equal(t, queryErr, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "BEGIN", spans[0].Name())
+	require.Equal(t, codes.Error, spans[0].Status().Code)
+	require.Equal(t, queryErr.Error(), spans[0].Status().Description)
+	require.Len(t, spans[0].Events(), 1)
+}
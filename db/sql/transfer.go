@@ -1,23 +1,38 @@
 package db
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+)
 
 const insertNewTransferQuery = `-- name: CreateNewTransfer :one
 INSERT INTO transfers (
-	from_account_id, to_account_id, amount
+	from_account_id, to_account_id, amount, description, reference, metadata, fee
 ) VALUES (
-	$1, $2, $3
+	$1, $2, $3, $4, $5, COALESCE($6::jsonb, '{}'), $7
 ) RETURNING *
 `
 
 type CreateNewTransferArgs struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID int64           `json:"from_account_id"`
+	ToAccountID   int64           `json:"to_account_id"`
+	Amount        int64           `json:"amount"`
+	Description   string          `json:"description"`
+	Reference     string          `json:"reference"`
+	Metadata      json.RawMessage `json:"metadata"`
+	Fee           int64           `json:"fee"`
 }
 
 func (query *Query) CreateNewTransfer(ctx context.Context, arg CreateNewTransferArgs) (Transfer, error) {
-	row := query.db.QueryRowContext(ctx, insertNewTransferQuery, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := query.db.QueryRowContext(ctx, insertNewTransferQuery,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Description,
+		arg.Reference,
+		jsonbParam(arg.Metadata),
+		arg.Fee,
+	)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -25,6 +40,10 @@ func (query *Query) CreateNewTransfer(ctx context.Context, arg CreateNewTransfer
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.Description,
+		&i.Reference,
+		&i.Metadata,
+		&i.Fee,
 	)
 	return i, err
 }
@@ -42,6 +61,75 @@ func (query *Query) GetTransferByID(ctx context.Context, id int64) (Transfer, er
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.Description,
+		&i.Reference,
+		&i.Metadata,
+		&i.Fee,
 	)
 	return i, err
 }
+
+const selectListTransfersQuery = `-- name: GetListTransfers :many
+SELECT * FROM transfers
+WHERE (from_account_id = $1 OR to_account_id = $1)
+AND ($2::varchar = '' OR description ILIKE '%' || $2 || '%' OR reference ILIKE $2 || '%')
+ORDER BY id DESC
+LIMIT $3 OFFSET $4
+`
+
+// GetListTransfersArgs.Search matches anywhere in the description or at the
+// start of the reference. It is used as a LIKE pattern, so callers must
+// escape user input.
+type GetListTransfersArgs struct {
+	AccountID int64  `json:"account_id"`
+	Search    string `json:"search"`
+	Limit     int32  `json:"limit"`
+	Offset    int32  `json:"offset"`
+}
+
+func (query *Query) GetListTransfers(ctx context.Context, arg GetListTransfersArgs) ([]Transfer, error) {
+	rows, err := query.db.QueryContext(ctx, selectListTransfersQuery, arg.AccountID, arg.Search, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.Description,
+			&i.Reference,
+			&i.Metadata,
+			&i.Fee,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// jsonbParam passes JSON as text, since lib/pq would send a []byte as bytea.
+// Empty JSON becomes NULL so the column default applies.
+func jsonbParam(data json.RawMessage) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
@@ -0,0 +1,243 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+const (
+	TransferPendingApproval = "pending_approval"
+	TransferApproved        = "approved"
+	TransferRejected        = "rejected"
+	TransferExpired         = "expired"
+)
+
+// RequiresApproval reports whether a transfer of amount out of the account
+// must wait for a second member's approval.
+func (account Account) RequiresApproval(amount int64) bool {
+	return account.ApprovalThreshold.Valid && amount >= account.ApprovalThreshold.Int64
+}
+
+const insertTransferApprovalQuery = `-- name: CreateTransferApproval :one
+INSERT INTO transfer_approvals (
+	from_account_id, to_account_id, amount, description, reference, metadata, requested_by, expires_at
+) VALUES (
+	$1, $2, $3, $4, $5, COALESCE($6::jsonb, '{}'), $7, $8
+) RETURNING *
+`
+
+type CreateTransferApprovalArgs struct {
+	FromAccountID int64           `json:"from_account_id"`
+	ToAccountID   int64           `json:"to_account_id"`
+	Amount        int64           `json:"amount"`
+	Description   string          `json:"description"`
+	Reference     string          `json:"reference"`
+	Metadata      json.RawMessage `json:"metadata"`
+	RequestedBy   string          `json:"requested_by"`
+	ExpiresAt     time.Time       `json:"expires_at"`
+}
+
+func (query *Query) CreateTransferApproval(ctx context.Context, arg CreateTransferApprovalArgs) (TransferApproval, error) {
+	row := query.db.QueryRowContext(ctx, insertTransferApprovalQuery,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Description,
+		arg.Reference,
+		jsonbParam(arg.Metadata),
+		arg.RequestedBy,
+		arg.ExpiresAt,
+	)
+	var i TransferApproval
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Description,
+		&i.Reference,
+		&i.Metadata,
+		&i.Status,
+		&i.RequestedBy,
+		&i.DecidedBy,
+		&i.DecidedAt,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const selectTransferApprovalQuery = `-- name: GetTransferApproval :one
+SELECT * FROM transfer_approvals WHERE id = $1 LIMIT 1
+`
+
+func (query *Query) GetTransferApproval(ctx context.Context, id int64) (TransferApproval, error) {
+	row := query.db.QueryRowContext(ctx, selectTransferApprovalQuery, id)
+	var i TransferApproval
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Description,
+		&i.Reference,
+		&i.Metadata,
+		&i.Status,
+		&i.RequestedBy,
+		&i.DecidedBy,
+		&i.DecidedAt,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const selectTransferApprovalForUpdateQuery = `-- name: GetTransferApprovalForUpdate :one
+SELECT * FROM transfer_approvals WHERE id = $1 LIMIT 1
+FOR UPDATE
+`
+
+// GetTransferApprovalForUpdate locks the approval, so it is decided once.
+func (query *Query) GetTransferApprovalForUpdate(ctx context.Context, id int64) (TransferApproval, error) {
+	row := query.db.QueryRowContext(ctx, selectTransferApprovalForUpdateQuery, id)
+	var i TransferApproval
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Description,
+		&i.Reference,
+		&i.Metadata,
+		&i.Status,
+		&i.RequestedBy,
+		&i.DecidedBy,
+		&i.DecidedAt,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const selectListTransferApprovalsQuery = `-- name: GetListTransferApprovals :many
+SELECT * FROM transfer_approvals
+WHERE from_account_id = $1
+AND ($2::varchar = '' OR status = $2)
+ORDER BY id DESC
+LIMIT $3 OFFSET $4
+`
+
+// GetListTransferApprovalsArgs.Status lists every status when empty.
+type GetListTransferApprovalsArgs struct {
+	AccountID int64  `json:"account_id"`
+	Status    string `json:"status"`
+	Limit     int32  `json:"limit"`
+	Offset    int32  `json:"offset"`
+}
+
+func (query *Query) GetListTransferApprovals(ctx context.Context, arg GetListTransferApprovalsArgs) ([]TransferApproval, error) {
+	rows, err := query.db.QueryContext(ctx, selectListTransferApprovalsQuery, arg.AccountID, arg.Status, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	items := []TransferApproval{}
+	for rows.Next() {
+		var i TransferApproval
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.Description,
+			&i.Reference,
+			&i.Metadata,
+			&i.Status,
+			&i.RequestedBy,
+			&i.DecidedBy,
+			&i.DecidedAt,
+			&i.TransferID,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+const decideTransferApprovalQuery = `-- name: DecideTransferApproval :one
+UPDATE transfer_approvals
+SET status = $2, decided_by = $3, decided_at = now(), transfer_id = $4
+WHERE id = $1 AND status = 'pending_approval' AND expires_at > now()
+RETURNING *
+`
+
+// DecideTransferApprovalArgs.TransferID is only set when approving.
+type DecideTransferApprovalArgs struct {
+	ID         int64         `json:"id"`
+	Status     string        `json:"status"`
+	DecidedBy  string        `json:"decided_by"`
+	TransferID sql.NullInt64 `json:"transfer_id"`
+}
+
+// DecideTransferApproval returns sql.ErrNoRows when the approval is no
+// longer pending or has expired.
+func (query *Query) DecideTransferApproval(ctx context.Context, arg DecideTransferApprovalArgs) (TransferApproval, error) {
+	row := query.db.QueryRowContext(ctx, decideTransferApprovalQuery,
+		arg.ID,
+		arg.Status,
+		arg.DecidedBy,
+		arg.TransferID,
+	)
+	var i TransferApproval
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Description,
+		&i.Reference,
+		&i.Metadata,
+		&i.Status,
+		&i.RequestedBy,
+		&i.DecidedBy,
+		&i.DecidedAt,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const expireTransferApprovalsQuery = `-- name: ExpireTransferApprovals :execrows
+UPDATE transfer_approvals SET status = 'expired'
+WHERE status = 'pending_approval' AND expires_at <= $1
+`
+
+// ExpireTransferApprovals marks the approvals still pending at now as
+// expired, none of them moved any money.
+func (query *Query) ExpireTransferApprovals(ctx context.Context, now time.Time) (int64, error) {
+	result, err := query.db.ExecContext(ctx, expireTransferApprovalsQuery, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
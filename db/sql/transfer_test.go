@@ -2,9 +2,12 @@ package db
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/asshiddiq1306/simple_bank/util"
 	"github.com/stretchr/testify/require"
 )
 
@@ -17,6 +20,9 @@ func createRandomTransfer(t *testing.T) Transfer {
 		FromAccountID: account1.ID,
 		ToAccountID:   account2.ID,
 		Amount:        amount,
+		Description:   util.RandomString(12),
+		Reference:     util.RandomString(8),
+		Metadata:      json.RawMessage(`{"order":"A-1"}`),
 	}
 
 	transfer, err := testQuery.CreateNewTransfer(context.Background(), arg)
@@ -25,6 +31,9 @@ func createRandomTransfer(t *testing.T) Transfer {
 	require.Equal(t, account1.ID, transfer.FromAccountID)
 	require.Equal(t, account2.ID, transfer.ToAccountID)
 	require.Equal(t, arg.Amount, transfer.Amount)
+	require.Equal(t, arg.Description, transfer.Description)
+	require.Equal(t, arg.Reference, transfer.Reference)
+	require.JSONEq(t, string(arg.Metadata), string(transfer.Metadata))
 	require.WithinDuration(t, account1.CreatedAt, transfer.CreatedAt, time.Second)
 	return transfer
 }
@@ -43,5 +52,65 @@ func TestGetTransferByID(t *testing.T) {
 	require.Equal(t, transfer1.FromAccountID, transfer2.FromAccountID)
 	require.Equal(t, transfer1.ToAccountID, transfer2.ToAccountID)
 	require.Equal(t, transfer1.Amount, transfer2.Amount)
+	require.Equal(t, transfer1.Description, transfer2.Description)
+	require.Equal(t, transfer1.Reference, transfer2.Reference)
+	require.JSONEq(t, string(transfer1.Metadata), string(transfer2.Metadata))
 	require.WithinDuration(t, transfer1.CreatedAt, transfer2.CreatedAt, time.Second)
 }
+
+func TestCreateTransferDefaultMetadata(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	transfer, err := testQuery.CreateNewTransfer(context.Background(), CreateNewTransferArgs{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, transfer.Description)
+	require.Empty(t, transfer.Reference)
+	require.JSONEq(t, `{}`, string(transfer.Metadata))
+}
+
+func TestGetListTransfers(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	descriptions := []string{"rent october", "groceries", "Rent november"}
+	for i, description := range descriptions {
+		_, err := testQuery.CreateNewTransfer(context.Background(), CreateNewTransferArgs{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        int64(i + 1),
+			Description:   description,
+			Reference:     fmt.Sprintf("INV-%d", i),
+		})
+		require.NoError(t, err)
+	}
+
+	transfers, err := testQuery.GetListTransfers(context.Background(), GetListTransfersArgs{
+		AccountID: account2.ID,
+		Limit:     5,
+	})
+	require.NoError(t, err)
+	require.Len(t, transfers, 3)
+	require.Equal(t, descriptions[2], transfers[0].Description)
+
+	transfers, err = testQuery.GetListTransfers(context.Background(), GetListTransfersArgs{
+		AccountID: account1.ID,
+		Search:    "rent",
+		Limit:     5,
+	})
+	require.NoError(t, err)
+	require.Len(t, transfers, 2)
+
+	transfers, err = testQuery.GetListTransfers(context.Background(), GetListTransfersArgs{
+		AccountID: account1.ID,
+		Search:    "INV-1",
+		Limit:     5,
+	})
+	require.NoError(t, err)
+	require.Len(t, transfers, 1)
+	require.Equal(t, descriptions[1], transfers[0].Description)
+}
@@ -1,6 +1,10 @@
 package db
 
-import "context"
+import (
+	"context"
+	"database/sql"
+	"time"
+)
 
 const insertNewUserQuery = `-- name: CreateNewUser :one
 INSERT INTO users(
@@ -27,6 +31,8 @@ func (query *Query) CreateNewUser(ctx context.Context, arg CreateNewUserArgs) (U
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.Role,
+		&i.IsEmailVerified,
 	)
 	return i, err
 }
@@ -45,21 +51,59 @@ func (query *Query) GetUserByUsername(ctx context.Context, username string) (Use
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.Role,
+		&i.IsEmailVerified,
+	)
+	return i, err
+}
+
+const selectUserByEmailQuery = `-- name: GetUserByEmail :one
+SELECT * FROM users WHERE email = $1 LIMIT 1
+`
+
+func (query *Query) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := query.db.QueryRowContext(ctx, selectUserByEmailQuery, email)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+		&i.IsEmailVerified,
 	)
 	return i, err
 }
 
 const selectListUserQuery = `-- name: GetListUser :many
-SELECT * FROM users LIMIT $1 OFFSET $2
+SELECT * FROM users
+WHERE ($1::varchar = '' OR role = $1)
+AND ($2::boolean IS NULL OR is_email_verified = $2)
+AND ($3::varchar = '' OR username ILIKE $3 || '%' OR email ILIKE $3 || '%')
+ORDER BY username
+LIMIT $4 OFFSET $5
 `
 
+// GetListUserArgs filters are optional: an empty Role or Search and an
+// invalid IsEmailVerified match every user.
 type GetListUserArgs struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	Role            string       `json:"role"`
+	IsEmailVerified sql.NullBool `json:"is_email_verified"`
+	Search          string       `json:"search"`
+	Limit           int32        `json:"limit"`
+	Offset          int32        `json:"offset"`
 }
 
 func (query *Query) GetListUser(ctx context.Context, arg GetListUserArgs) ([]User, error) {
-	rows, err := query.db.QueryContext(ctx, selectListUserQuery, arg.Limit, arg.Offset)
+	rows, err := query.db.QueryContext(ctx, selectListUserQuery,
+		arg.Role,
+		arg.IsEmailVerified,
+		arg.Search,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -77,6 +121,8 @@ func (query *Query) GetListUser(ctx context.Context, arg GetListUserArgs) ([]Use
 			&i.Email,
 			&i.PasswordChangedAt,
 			&i.CreatedAt,
+			&i.Role,
+			&i.IsEmailVerified,
 		); err != nil {
 			return nil, err
 		}
@@ -95,6 +141,105 @@ func (query *Query) GetListUser(ctx context.Context, arg GetListUserArgs) ([]Use
 	return items, nil
 }
 
+const updateUserPasswordQuery = `-- name: UpdateUserPassword :one
+UPDATE users SET hashed_password = $2, password_changed_at = $3 WHERE username = $1
+RETURNING *
+`
+
+type UpdateUserPasswordArgs struct {
+	Username          string    `json:"username"`
+	HashedPassword    string    `json:"hashed_password"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+}
+
+func (query *Query) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordArgs) (User, error) {
+	row := query.db.QueryRowContext(ctx, updateUserPasswordQuery, arg.Username, arg.HashedPassword, arg.PasswordChangedAt)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+		&i.IsEmailVerified,
+	)
+	return i, err
+}
+
+const selectUserPasswordChangedAtQuery = `-- name: GetUserPasswordChangedAt :one
+SELECT password_changed_at FROM users WHERE username = $1 LIMIT 1
+`
+
+func (query *Query) GetUserPasswordChangedAt(ctx context.Context, username string) (time.Time, error) {
+	row := query.db.QueryRowContext(ctx, selectUserPasswordChangedAtQuery, username)
+	var passwordChangedAt time.Time
+	err := row.Scan(&passwordChangedAt)
+	return passwordChangedAt, err
+}
+
+const updateUserEmailVerifiedQuery = `-- name: VerifyUserEmail :one
+UPDATE users SET is_email_verified = true WHERE username = $1 AND email = $2
+RETURNING *
+`
+
+type VerifyUserEmailArgs struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// VerifyUserEmail only matches while the user still has the email the link
+// was sent to, so an old link cannot verify a changed address.
+func (query *Query) VerifyUserEmail(ctx context.Context, arg VerifyUserEmailArgs) (User, error) {
+	row := query.db.QueryRowContext(ctx, updateUserEmailVerifiedQuery, arg.Username, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+		&i.IsEmailVerified,
+	)
+	return i, err
+}
+
+const updateUserProfileQuery = `-- name: UpdateUserProfile :one
+UPDATE users SET
+	full_name = COALESCE($2, full_name),
+	is_email_verified = CASE WHEN $3::varchar IS NULL OR $3 = email THEN is_email_verified ELSE false END,
+	email = COALESCE($3, email)
+WHERE username = $1
+RETURNING *
+`
+
+type UpdateUserProfileArgs struct {
+	Username string         `json:"username"`
+	FullName sql.NullString `json:"full_name"`
+	Email    sql.NullString `json:"email"`
+}
+
+// UpdateUserProfile only changes the fields that are set. A new email
+// address has to be verified again.
+func (query *Query) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileArgs) (User, error) {
+	row := query.db.QueryRowContext(ctx, updateUserProfileQuery, arg.Username, arg.FullName, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+		&i.IsEmailVerified,
+	)
+	return i, err
+}
+
 const deleteUserByIDQuery = `-- name: DeleteUserByID :exec
 DELETE FROM users WHERE username = $1
 `
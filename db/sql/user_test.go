@@ -67,6 +67,56 @@ func TestGetListUser(t *testing.T) {
 	}
 }
 
+func TestGetListUserFilter(t *testing.T) {
+	user1 := createRandomUser(t)
+
+	arg := GetListUserArgs{
+		Role:            user1.Role,
+		IsEmailVerified: sql.NullBool{Bool: false, Valid: true},
+		Search:          user1.Username,
+		Limit:           5,
+	}
+
+	userList, err := testQuery.GetListUser(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, userList)
+	require.Equal(t, user1.Username, userList[0].Username)
+
+	arg.IsEmailVerified.Bool = true
+	userList, err = testQuery.GetListUser(context.Background(), arg)
+	require.NoError(t, err)
+	require.Empty(t, userList)
+}
+
+func TestUpdateUserProfile(t *testing.T) {
+	user1 := createRandomUser(t)
+	_, err := testQuery.VerifyUserEmail(context.Background(), VerifyUserEmailArgs{
+		Username: user1.Username,
+		Email:    user1.Email,
+	})
+	require.NoError(t, err)
+
+	fullName := util.RandomName()
+	user2, err := testQuery.UpdateUserProfile(context.Background(), UpdateUserProfileArgs{
+		Username: user1.Username,
+		FullName: sql.NullString{String: fullName, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, fullName, user2.FullName)
+	require.Equal(t, user1.Email, user2.Email)
+	require.True(t, user2.IsEmailVerified)
+
+	email := util.RandomEmail()
+	user3, err := testQuery.UpdateUserProfile(context.Background(), UpdateUserProfileArgs{
+		Username: user1.Username,
+		Email:    sql.NullString{String: email, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, fullName, user3.FullName)
+	require.Equal(t, email, user3.Email)
+	require.False(t, user3.IsEmailVerified)
+}
+
 func TestDeleteUserByID(t *testing.T) {
 	user1 := createRandomUser(t)
 
@@ -78,3 +128,43 @@ func TestDeleteUserByID(t *testing.T) {
 	require.Empty(t, user2)
 	require.EqualError(t, err, sql.ErrNoRows.Error())
 }
+
+func TestUpdateUserPassword(t *testing.T) {
+	user1 := createRandomUser(t)
+
+	hashPassword, err := util.HashedPassword(util.RandomString(8))
+	require.NoError(t, err)
+
+	changedAt := time.Now()
+	user2, err := testQuery.UpdateUserPassword(context.Background(), UpdateUserPasswordArgs{
+		Username:          user1.Username,
+		HashedPassword:    hashPassword,
+		PasswordChangedAt: changedAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, user1.Username, user2.Username)
+	require.Equal(t, hashPassword, user2.HashedPassword)
+	require.WithinDuration(t, changedAt, user2.PasswordChangedAt, time.Millisecond)
+
+	passwordChangedAt, err := testQuery.GetUserPasswordChangedAt(context.Background(), user1.Username)
+	require.NoError(t, err)
+	require.WithinDuration(t, changedAt, passwordChangedAt, time.Millisecond)
+}
+
+func TestVerifyUserEmail(t *testing.T) {
+	user1 := createRandomUser(t)
+	require.False(t, user1.IsEmailVerified)
+
+	_, err := testQuery.VerifyUserEmail(context.Background(), VerifyUserEmailArgs{
+		Username: user1.Username,
+		Email:    util.RandomEmail(),
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	user2, err := testQuery.VerifyUserEmail(context.Background(), VerifyUserEmailArgs{
+		Username: user1.Username,
+		Email:    user1.Email,
+	})
+	require.NoError(t, err)
+	require.True(t, user2.IsEmailVerified)
+}
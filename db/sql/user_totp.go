@@ -0,0 +1,163 @@
+package db
+
+import "context"
+
+const upsertPendingUserTOTPQuery = `-- name: CreatePendingUserTOTP :one
+INSERT INTO user_totp (
+	username, encrypted_secret
+) VALUES (
+	$1, $2
+) ON CONFLICT (username) DO UPDATE SET
+	encrypted_secret = EXCLUDED.encrypted_secret,
+	last_used_step = 0,
+	created_at = now()
+WHERE user_totp.is_enabled = false
+RETURNING *
+`
+
+type CreatePendingUserTOTPArgs struct {
+	Username        string `json:"username"`
+	EncryptedSecret string `json:"encrypted_secret"`
+}
+
+// CreatePendingUserTOTP stores a secret that is not enabled until the user
+// confirms it. It returns sql.ErrNoRows when TOTP is already enabled, so an
+// enrolled secret cannot be replaced without going through a disable flow.
+func (query *Query) CreatePendingUserTOTP(ctx context.Context, arg CreatePendingUserTOTPArgs) (UserTOTP, error) {
+	row := query.db.QueryRowContext(ctx, upsertPendingUserTOTPQuery, arg.Username, arg.EncryptedSecret)
+	var i UserTOTP
+	err := row.Scan(
+		&i.Username,
+		&i.EncryptedSecret,
+		&i.IsEnabled,
+		&i.LastUsedStep,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const selectUserTOTPQuery = `-- name: GetUserTOTP :one
+SELECT * FROM user_totp WHERE username = $1 LIMIT 1
+`
+
+func (query *Query) GetUserTOTP(ctx context.Context, username string) (UserTOTP, error) {
+	row := query.db.QueryRowContext(ctx, selectUserTOTPQuery, username)
+	var i UserTOTP
+	err := row.Scan(
+		&i.Username,
+		&i.EncryptedSecret,
+		&i.IsEnabled,
+		&i.LastUsedStep,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const enableUserTOTPQuery = `-- name: EnableUserTOTP :one
+UPDATE user_totp SET is_enabled = true, last_used_step = $2
+WHERE username = $1 AND is_enabled = false
+RETURNING *
+`
+
+type EnableUserTOTPArgs struct {
+	Username     string `json:"username"`
+	LastUsedStep int64  `json:"last_used_step"`
+}
+
+func (query *Query) EnableUserTOTP(ctx context.Context, arg EnableUserTOTPArgs) (UserTOTP, error) {
+	row := query.db.QueryRowContext(ctx, enableUserTOTPQuery, arg.Username, arg.LastUsedStep)
+	var i UserTOTP
+	err := row.Scan(
+		&i.Username,
+		&i.EncryptedSecret,
+		&i.IsEnabled,
+		&i.LastUsedStep,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const useTOTPStepQuery = `-- name: UseTOTPStep :one
+UPDATE user_totp SET last_used_step = $2
+WHERE username = $1 AND is_enabled = true AND last_used_step < $2
+RETURNING *
+`
+
+type UseTOTPStepArgs struct {
+	Username string `json:"username"`
+	Step     int64  `json:"step"`
+}
+
+// UseTOTPStep records that the code for step was used. It returns
+// sql.ErrNoRows when that step, or a later one, was already used.
+func (query *Query) UseTOTPStep(ctx context.Context, arg UseTOTPStepArgs) (UserTOTP, error) {
+	row := query.db.QueryRowContext(ctx, useTOTPStepQuery, arg.Username, arg.Step)
+	var i UserTOTP
+	err := row.Scan(
+		&i.Username,
+		&i.EncryptedSecret,
+		&i.IsEnabled,
+		&i.LastUsedStep,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const insertNewRecoveryCodeQuery = `-- name: CreateNewRecoveryCode :one
+INSERT INTO recovery_codes (
+	username, code_hash
+) VALUES (
+	$1, $2
+) RETURNING *
+`
+
+type CreateNewRecoveryCodeArgs struct {
+	Username string `json:"username"`
+	CodeHash string `json:"code_hash"`
+}
+
+func (query *Query) CreateNewRecoveryCode(ctx context.Context, arg CreateNewRecoveryCodeArgs) (RecoveryCode, error) {
+	row := query.db.QueryRowContext(ctx, insertNewRecoveryCodeQuery, arg.Username, arg.CodeHash)
+	var i RecoveryCode
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.CodeHash,
+		&i.IsUsed,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteRecoveryCodesQuery = `-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes WHERE username = $1
+`
+
+func (query *Query) DeleteRecoveryCodes(ctx context.Context, username string) error {
+	_, err := query.db.ExecContext(ctx, deleteRecoveryCodesQuery, username)
+	return err
+}
+
+const useRecoveryCodeQuery = `-- name: UseRecoveryCode :one
+UPDATE recovery_codes SET is_used = true
+WHERE username = $1 AND code_hash = $2 AND is_used = false
+RETURNING *
+`
+
+type UseRecoveryCodeArgs struct {
+	Username string `json:"username"`
+	CodeHash string `json:"code_hash"`
+}
+
+func (query *Query) UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeArgs) (RecoveryCode, error) {
+	row := query.db.QueryRowContext(ctx, useRecoveryCodeQuery, arg.Username, arg.CodeHash)
+	var i RecoveryCode
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.CodeHash,
+		&i.IsUsed,
+		&i.CreatedAt,
+	)
+	return i, err
+}
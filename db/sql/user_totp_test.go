@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomUserTOTP(t *testing.T, user User) UserTOTP {
+	arg := CreatePendingUserTOTPArgs{
+		Username:        user.Username,
+		EncryptedSecret: util.RandomString(32),
+	}
+
+	userTOTP, err := testQuery.CreatePendingUserTOTP(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, userTOTP.Username)
+	require.Equal(t, arg.EncryptedSecret, userTOTP.EncryptedSecret)
+	require.False(t, userTOTP.IsEnabled)
+	require.Zero(t, userTOTP.LastUsedStep)
+	require.NotZero(t, userTOTP.CreatedAt)
+	return userTOTP
+}
+
+func enableRandomUserTOTP(t *testing.T, user User, step int64) []string {
+	createRandomUserTOTP(t, user)
+
+	codes := []string{util.RandomString(9), util.RandomString(9)}
+	arg := EnableTOTPTxArg{
+		Username: user.Username,
+		Step:     step,
+	}
+	for _, code := range codes {
+		arg.RecoveryCodeHashes = append(arg.RecoveryCodeHashes, util.HashSecureToken(code))
+	}
+
+	store := NewStore(testDB)
+	result, err := store.EnableTOTPTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result.UserTOTP.IsEnabled)
+	require.Equal(t, step, result.UserTOTP.LastUsedStep)
+	require.Len(t, result.RecoveryCodes, len(codes))
+	return codes
+}
+
+func TestCreatePendingUserTOTP(t *testing.T) {
+	user := createRandomUser(t)
+	userTOTP1 := createRandomUserTOTP(t, user)
+	userTOTP2 := createRandomUserTOTP(t, user)
+	require.NotEqual(t, userTOTP1.EncryptedSecret, userTOTP2.EncryptedSecret)
+
+	enableRandomUserTOTP(t, user, 10)
+
+	_, err := testQuery.CreatePendingUserTOTP(context.Background(), CreatePendingUserTOTPArgs{
+		Username:        user.Username,
+		EncryptedSecret: util.RandomString(32),
+	})
+	require.Error(t, err)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestUseTOTPStep(t *testing.T) {
+	user := createRandomUser(t)
+	enableRandomUserTOTP(t, user, 10)
+
+	for _, step := range []int64{10, 9} {
+		_, err := testQuery.UseTOTPStep(context.Background(), UseTOTPStepArgs{Username: user.Username, Step: step})
+		require.EqualError(t, err, sql.ErrNoRows.Error())
+	}
+
+	userTOTP, err := testQuery.UseTOTPStep(context.Background(), UseTOTPStepArgs{Username: user.Username, Step: 11})
+	require.NoError(t, err)
+	require.Equal(t, int64(11), userTOTP.LastUsedStep)
+}
+
+func TestUseRecoveryCode(t *testing.T) {
+	user := createRandomUser(t)
+	codes := enableRandomUserTOTP(t, user, 10)
+
+	arg := UseRecoveryCodeArgs{
+		Username: user.Username,
+		CodeHash: util.HashSecureToken(codes[0]),
+	}
+
+	recoveryCode, err := testQuery.UseRecoveryCode(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, recoveryCode.IsUsed)
+
+	_, err = testQuery.UseRecoveryCode(context.Background(), arg)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
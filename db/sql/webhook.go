@@ -0,0 +1,453 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+const insertWebhookEndpointQuery = `-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (
+	account_id, url, encrypted_secret, event_types, created_by
+) VALUES (
+	$1, $2, $3, $4, $5
+) RETURNING *
+`
+
+type CreateWebhookEndpointArgs struct {
+	AccountID       int64    `json:"account_id"`
+	URL             string   `json:"url"`
+	EncryptedSecret string   `json:"encrypted_secret"`
+	EventTypes      []string `json:"event_types"`
+	CreatedBy       string   `json:"created_by"`
+}
+
+func (query *Query) CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEndpointArgs) (WebhookEndpoint, error) {
+	row := query.db.QueryRowContext(ctx, insertWebhookEndpointQuery,
+		arg.AccountID,
+		arg.URL,
+		arg.EncryptedSecret,
+		pq.Array(arg.EventTypes),
+		arg.CreatedBy,
+	)
+	var i WebhookEndpoint
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.URL,
+		&i.EncryptedSecret,
+		pq.Array(&i.EventTypes),
+		&i.Enabled,
+		&i.ConsecutiveFailures,
+		&i.DisabledAt,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const selectWebhookEndpointQuery = `-- name: GetWebhookEndpoint :one
+SELECT * FROM webhook_endpoints WHERE id = $1 LIMIT 1
+`
+
+func (query *Query) GetWebhookEndpoint(ctx context.Context, id int64) (WebhookEndpoint, error) {
+	row := query.db.QueryRowContext(ctx, selectWebhookEndpointQuery, id)
+	var i WebhookEndpoint
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.URL,
+		&i.EncryptedSecret,
+		pq.Array(&i.EventTypes),
+		&i.Enabled,
+		&i.ConsecutiveFailures,
+		&i.DisabledAt,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const selectListWebhookEndpointsQuery = `-- name: GetListWebhookEndpoints :many
+SELECT * FROM webhook_endpoints WHERE account_id = $1 ORDER BY id
+`
+
+func (query *Query) GetListWebhookEndpoints(ctx context.Context, accountID int64) ([]WebhookEndpoint, error) {
+	rows, err := query.db.QueryContext(ctx, selectListWebhookEndpointsQuery, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	items := []WebhookEndpoint{}
+	for rows.Next() {
+		var i WebhookEndpoint
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.URL,
+			&i.EncryptedSecret,
+			pq.Array(&i.EventTypes),
+			&i.Enabled,
+			&i.ConsecutiveFailures,
+			&i.DisabledAt,
+			&i.CreatedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+const updateWebhookEndpointQuery = `-- name: UpdateWebhookEndpoint :one
+UPDATE webhook_endpoints
+SET enabled = $2,
+	event_types = $3,
+	consecutive_failures = CASE WHEN $2 THEN 0 ELSE consecutive_failures END,
+	disabled_at = CASE WHEN $2 THEN NULL ELSE COALESCE(disabled_at, now()) END
+WHERE id = $1
+RETURNING *
+`
+
+// UpdateWebhookEndpoint re-enabling an endpoint clears its failures.
+type UpdateWebhookEndpointArgs struct {
+	ID         int64    `json:"id"`
+	Enabled    bool     `json:"enabled"`
+	EventTypes []string `json:"event_types"`
+}
+
+func (query *Query) UpdateWebhookEndpoint(ctx context.Context, arg UpdateWebhookEndpointArgs) (WebhookEndpoint, error) {
+	row := query.db.QueryRowContext(ctx, updateWebhookEndpointQuery, arg.ID, arg.Enabled, pq.Array(arg.EventTypes))
+	var i WebhookEndpoint
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.URL,
+		&i.EncryptedSecret,
+		pq.Array(&i.EventTypes),
+		&i.Enabled,
+		&i.ConsecutiveFailures,
+		&i.DisabledAt,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const recordWebhookEndpointResultQuery = `-- name: RecordWebhookEndpointResult :one
+UPDATE webhook_endpoints
+SET consecutive_failures = CASE WHEN $2 THEN 0 ELSE consecutive_failures + 1 END,
+	enabled = enabled AND ($2 OR consecutive_failures + 1 < $3),
+	disabled_at = CASE
+		WHEN enabled AND NOT $2 AND consecutive_failures + 1 >= $3 THEN now()
+		ELSE disabled_at
+	END
+WHERE id = $1
+RETURNING *
+`
+
+// RecordWebhookEndpointResultArgs.DisableAfter is the number of consecutive
+// failed attempts after which the endpoint is disabled.
+type RecordWebhookEndpointResultArgs struct {
+	ID           int64 `json:"id"`
+	Succeeded    bool  `json:"succeeded"`
+	DisableAfter int32 `json:"disable_after"`
+}
+
+func (query *Query) RecordWebhookEndpointResult(ctx context.Context, arg RecordWebhookEndpointResultArgs) (WebhookEndpoint, error) {
+	row := query.db.QueryRowContext(ctx, recordWebhookEndpointResultQuery, arg.ID, arg.Succeeded, arg.DisableAfter)
+	var i WebhookEndpoint
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.URL,
+		&i.EncryptedSecret,
+		pq.Array(&i.EventTypes),
+		&i.Enabled,
+		&i.ConsecutiveFailures,
+		&i.DisabledAt,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteWebhookEndpointQuery = `-- name: DeleteWebhookEndpoint :exec
+DELETE FROM webhook_endpoints WHERE id = $1
+`
+
+func (query *Query) DeleteWebhookEndpoint(ctx context.Context, id int64) error {
+	_, err := query.db.ExecContext(ctx, deleteWebhookEndpointQuery, id)
+	return err
+}
+
+const insertWebhookDeliveriesQuery = `-- name: CreateWebhookDeliveries :execrows
+INSERT INTO webhook_deliveries (endpoint_id, event_id)
+SELECT id, $1 FROM webhook_endpoints
+WHERE account_id = ANY($3::bigint[])
+AND enabled
+AND (cardinality(event_types) = 0 OR $2 = ANY(event_types))
+`
+
+// CreateWebhookDeliveries queues the event for every enabled endpoint of
+// the accounts whose filter accepts its type.
+type CreateWebhookDeliveriesArgs struct {
+	EventID    int64   `json:"event_id"`
+	EventType  string  `json:"event_type"`
+	AccountIDs []int64 `json:"account_ids"`
+}
+
+func (query *Query) CreateWebhookDeliveries(ctx context.Context, arg CreateWebhookDeliveriesArgs) (int64, error) {
+	result, err := query.db.ExecContext(ctx, insertWebhookDeliveriesQuery, arg.EventID, arg.EventType, pq.Array(arg.AccountIDs))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const insertWebhookDeliveryQuery = `-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (endpoint_id, event_id) VALUES ($1, $2)
+RETURNING *
+`
+
+type CreateWebhookDeliveryArgs struct {
+	EndpointID int64 `json:"endpoint_id"`
+	EventID    int64 `json:"event_id"`
+}
+
+func (query *Query) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryArgs) (WebhookDelivery, error) {
+	row := query.db.QueryRowContext(ctx, insertWebhookDeliveryQuery, arg.EndpointID, arg.EventID)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.EndpointID,
+		&i.EventID,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastAttemptAt,
+		&i.ResponseStatus,
+		&i.LastError,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const selectWebhookDeliveryQuery = `-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries WHERE id = $1 LIMIT 1
+`
+
+func (query *Query) GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error) {
+	row := query.db.QueryRowContext(ctx, selectWebhookDeliveryQuery, id)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.EndpointID,
+		&i.EventID,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastAttemptAt,
+		&i.ResponseStatus,
+		&i.LastError,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const selectListWebhookDeliveriesQuery = `-- name: GetListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE endpoint_id = $1
+ORDER BY id DESC
+LIMIT $2 OFFSET $3
+`
+
+type GetListWebhookDeliveriesArgs struct {
+	EndpointID int64 `json:"endpoint_id"`
+	Limit      int32 `json:"limit"`
+	Offset     int32 `json:"offset"`
+}
+
+func (query *Query) GetListWebhookDeliveries(ctx context.Context, arg GetListWebhookDeliveriesArgs) ([]WebhookDelivery, error) {
+	rows, err := query.db.QueryContext(ctx, selectListWebhookDeliveriesQuery, arg.EndpointID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	items := []WebhookDelivery{}
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.EndpointID,
+			&i.EventID,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastAttemptAt,
+			&i.ResponseStatus,
+			&i.LastError,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+const claimWebhookDeliveriesQuery = `-- name: ClaimWebhookDeliveries :many
+WITH due AS (
+	SELECT d.id FROM webhook_deliveries d
+	JOIN webhook_endpoints e ON e.id = d.endpoint_id
+	WHERE d.status = 'pending' AND d.next_attempt_at <= now() AND e.enabled
+	ORDER BY d.next_attempt_at, d.id
+	LIMIT $1
+	FOR UPDATE OF d SKIP LOCKED
+)
+UPDATE webhook_deliveries d
+SET next_attempt_at = now() + make_interval(secs => $2)
+FROM due, webhook_endpoints e, outbox_events o
+WHERE d.id = due.id AND e.id = d.endpoint_id AND o.id = d.event_id
+RETURNING d.id, d.endpoint_id, d.event_id, d.attempts, e.url, e.encrypted_secret,
+	o.type, o.aggregate_type, o.aggregate_id, o.payload, o.created_at
+`
+
+// ClaimWebhookDeliveriesArgs.Lease postpones the claimed deliveries, so no
+// other dispatcher sends them while they are in flight.
+type ClaimWebhookDeliveriesArgs struct {
+	Limit int32         `json:"limit"`
+	Lease time.Duration `json:"lease"`
+}
+
+type ClaimWebhookDeliveriesRow struct {
+	ID                 int64           `json:"id"`
+	EndpointID         int64           `json:"endpoint_id"`
+	EventID            int64           `json:"event_id"`
+	Attempts           int32           `json:"attempts"`
+	URL                string          `json:"url"`
+	EncryptedSecret    string          `json:"encrypted_secret"`
+	EventType          string          `json:"event_type"`
+	EventAggregateType string          `json:"event_aggregate_type"`
+	EventAggregateID   string          `json:"event_aggregate_id"`
+	EventPayload       json.RawMessage `json:"event_payload"`
+	EventCreatedAt     time.Time       `json:"event_created_at"`
+}
+
+func (query *Query) ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesArgs) ([]ClaimWebhookDeliveriesRow, error) {
+	rows, err := query.db.QueryContext(ctx, claimWebhookDeliveriesQuery, arg.Limit, arg.Lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	items := []ClaimWebhookDeliveriesRow{}
+	for rows.Next() {
+		var i ClaimWebhookDeliveriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.EndpointID,
+			&i.EventID,
+			&i.Attempts,
+			&i.URL,
+			&i.EncryptedSecret,
+			&i.EventType,
+			&i.EventAggregateType,
+			&i.EventAggregateID,
+			&i.EventPayload,
+			&i.EventCreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+const updateWebhookDeliveryAttemptQuery = `-- name: UpdateWebhookDeliveryAttempt :one
+UPDATE webhook_deliveries
+SET status = $2,
+	attempts = attempts + 1,
+	last_attempt_at = now(),
+	next_attempt_at = now() + make_interval(secs => $3),
+	response_status = $4,
+	last_error = $5
+WHERE id = $1
+RETURNING *
+`
+
+// UpdateWebhookDeliveryAttemptArgs.RetryIn schedules the next attempt of a
+// delivery left pending.
+type UpdateWebhookDeliveryAttemptArgs struct {
+	ID             int64         `json:"id"`
+	Status         string        `json:"status"`
+	RetryIn        time.Duration `json:"retry_in"`
+	ResponseStatus sql.NullInt32 `json:"response_status"`
+	LastError      string        `json:"last_error"`
+}
+
+func (query *Query) UpdateWebhookDeliveryAttempt(ctx context.Context, arg UpdateWebhookDeliveryAttemptArgs) (WebhookDelivery, error) {
+	row := query.db.QueryRowContext(ctx, updateWebhookDeliveryAttemptQuery,
+		arg.ID,
+		arg.Status,
+		arg.RetryIn.Seconds(),
+		arg.ResponseStatus,
+		arg.LastError,
+	)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.EndpointID,
+		&i.EventID,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastAttemptAt,
+		&i.ResponseStatus,
+		&i.LastError,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,125 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomWebhookEndpoint(t *testing.T, account Account, eventTypes []string) WebhookEndpoint {
+	arg := CreateWebhookEndpointArgs{
+		AccountID:       account.ID,
+		URL:             "https://example.com/hooks",
+		EncryptedSecret: "encrypted",
+		EventTypes:      eventTypes,
+		CreatedBy:       account.Owner,
+	}
+
+	endpoint, err := testQuery.CreateWebhookEndpoint(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, endpoint.ID)
+	require.Equal(t, arg.AccountID, endpoint.AccountID)
+	require.Equal(t, arg.URL, endpoint.URL)
+	require.Equal(t, arg.EventTypes, endpoint.EventTypes)
+	require.True(t, endpoint.Enabled)
+	require.Zero(t, endpoint.ConsecutiveFailures)
+	require.False(t, endpoint.DisabledAt.Valid)
+
+	return endpoint
+}
+
+func createAccountEvent(t *testing.T, account Account, eventType string) OutboxEvent {
+	event, err := testQuery.CreateOutboxEvent(context.Background(), CreateOutboxEventArgs{
+		AggregateType: AggregateAccount,
+		AggregateID:   strconv.FormatInt(account.ID, 10),
+		Type:          eventType,
+		Payload:       json.RawMessage(`{}`),
+	})
+	require.NoError(t, err)
+	return event
+}
+
+func TestCreateWebhookDeliveries(t *testing.T) {
+	account := createRandomAccount(t)
+	all := createRandomWebhookEndpoint(t, account, []string{})
+	transfers := createRandomWebhookEndpoint(t, account, []string{EventTransferCompleted})
+
+	event := createAccountEvent(t, account, EventInterestPosted)
+	n, err := testQuery.CreateWebhookDeliveries(context.Background(), CreateWebhookDeliveriesArgs{
+		EventID:    event.ID,
+		EventType:  event.Type,
+		AccountIDs: []int64{account.ID},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+
+	deliveries, err := testQuery.GetListWebhookDeliveries(context.Background(), GetListWebhookDeliveriesArgs{
+		EndpointID: all.ID,
+		Limit:      5,
+	})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, event.ID, deliveries[0].EventID)
+	require.Equal(t, WebhookDeliveryPending, deliveries[0].Status)
+
+	deliveries, err = testQuery.GetListWebhookDeliveries(context.Background(), GetListWebhookDeliveriesArgs{
+		EndpointID: transfers.ID,
+		Limit:      5,
+	})
+	require.NoError(t, err)
+	require.Empty(t, deliveries)
+}
+
+func TestRecordWebhookAttemptTx(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+	endpoint := createRandomWebhookEndpoint(t, account, []string{})
+
+	event := createAccountEvent(t, account, EventTransferCompleted)
+	delivery, err := testQuery.CreateWebhookDelivery(context.Background(), CreateWebhookDeliveryArgs{
+		EndpointID: endpoint.ID,
+		EventID:    event.ID,
+	})
+	require.NoError(t, err)
+
+	arg := RecordWebhookAttemptTxArg{
+		DeliveryID:     delivery.ID,
+		EndpointID:     endpoint.ID,
+		ResponseStatus: sql.NullInt32{Int32: 500, Valid: true},
+		Error:          "endpoint responded 500 Internal Server Error",
+		RetryIn:        time.Minute,
+		DisableAfter:   2,
+	}
+
+	result, err := store.RecordWebhookAttemptTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, WebhookDeliveryPending, result.Delivery.Status)
+	require.Equal(t, int32(1), result.Delivery.Attempts)
+	require.WithinDuration(t, time.Now().Add(time.Minute), result.Delivery.NextAttemptAt, 5*time.Second)
+	require.Equal(t, arg.ResponseStatus, result.Delivery.ResponseStatus)
+	require.Equal(t, arg.Error, result.Delivery.LastError)
+	require.True(t, result.Endpoint.Enabled)
+	require.Equal(t, int32(1), result.Endpoint.ConsecutiveFailures)
+
+	arg.RetryIn = 0
+	result, err = store.RecordWebhookAttemptTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, WebhookDeliveryFailed, result.Delivery.Status)
+	require.False(t, result.Endpoint.Enabled)
+	require.True(t, result.Endpoint.DisabledAt.Valid)
+
+	endpoint, err = testQuery.UpdateWebhookEndpoint(context.Background(), UpdateWebhookEndpointArgs{
+		ID:         endpoint.ID,
+		Enabled:    true,
+		EventTypes: endpoint.EventTypes,
+	})
+	require.NoError(t, err)
+	require.True(t, endpoint.Enabled)
+	require.Zero(t, endpoint.ConsecutiveFailures)
+	require.False(t, endpoint.DisabledAt.Valid)
+}
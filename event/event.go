@@ -0,0 +1,37 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Event is the envelope published for every outbox event. Delivery is at
+// least once, so consumers should ignore an ID they have already seen.
+type Event struct {
+	ID            int64           `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	Payload       json.RawMessage `json:"payload"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// Sink delivers events somewhere. Publish must only return nil once the
+// event is safely delivered, since it is not retried afterwards.
+type Sink interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// MultiSink publishes every event to each of its sinks in turn.
+type MultiSink []Sink
+
+func (sinks MultiSink) Publish(ctx context.Context, event Event) error {
+	for _, sink := range sinks {
+		err := sink.Publish(ctx, event)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
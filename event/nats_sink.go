@@ -0,0 +1,144 @@
+package event
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NATSSink publishes every event to a NATS compatible server on the subject
+// <prefix>.<aggregate type>.<aggregate id>. It speaks the text protocol
+// directly and waits for the PONG following each message, so the server has
+// processed it before the event counts as published.
+type NATSSink struct {
+	mu      sync.Mutex
+	address string
+	prefix  string
+	timeout time.Duration
+	conn    net.Conn
+	reader  *bufio.Reader
+}
+
+func NewNATSSink(rawURL string, prefix string, timeout time.Duration) (*NATSSink, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "nats" || u.Host == "" {
+		return nil, fmt.Errorf("invalid nats url %q", rawURL)
+	}
+
+	address := u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), "4222")
+	}
+
+	return &NATSSink{
+		address: address,
+		prefix:  prefix,
+		timeout: timeout,
+	}, nil
+}
+
+func (sink *NATSSink) Subject(event Event) string {
+	return fmt.Sprintf("%s.%s.%s", sink.prefix, event.AggregateType, event.AggregateID)
+}
+
+func (sink *NATSSink) Publish(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+
+	err = sink.publish(ctx, sink.Subject(event), data)
+	if err != nil && sink.conn != nil {
+		// Reconnect on the next event rather than reuse a broken connection.
+		sink.conn.Close()
+		sink.conn = nil
+	}
+	return err
+}
+
+func (sink *NATSSink) publish(ctx context.Context, subject string, data []byte) error {
+	if sink.conn == nil {
+		err := sink.connect(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	deadline := time.Now().Add(sink.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	sink.conn.SetDeadline(deadline)
+
+	_, err := fmt.Fprintf(sink.conn, "PUB %s %d\r\n%s\r\nPING\r\n", subject, len(data), data)
+	if err != nil {
+		return err
+	}
+	return sink.waitPong()
+}
+
+func (sink *NATSSink) connect(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: sink.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", sink.address)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(sink.timeout))
+
+	sink.conn = conn
+	sink.reader = bufio.NewReader(conn)
+
+	line, err := sink.readLine()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("unexpected nats greeting %q", line)
+	}
+
+	_, err = fmt.Fprint(conn, "CONNECT {\"verbose\":false,\"pedantic\":false,\"name\":\"simple_bank\"}\r\nPING\r\n")
+	if err != nil {
+		return err
+	}
+	return sink.waitPong()
+}
+
+// waitPong reads until the server answers our PING, replying to its own
+// PINGs on the way.
+func (sink *NATSSink) waitPong() error {
+	for {
+		line, err := sink.readLine()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			_, err = fmt.Fprint(sink.conn, "PONG\r\n")
+			if err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("nats error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
+
+func (sink *NATSSink) readLine() (string, error) {
+	line, err := sink.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,97 @@
+package event
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type natsMessage struct {
+	subject string
+	data    string
+}
+
+// fakeNATS accepts connections speaking the subset of the NATS protocol the
+// sink uses and reports every published message.
+func fakeNATS(t *testing.T, messages chan<- natsMessage) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveNATS(conn, messages)
+		}
+	}()
+
+	return "nats://" + listener.Addr().String()
+}
+
+func serveNATS(conn net.Conn, messages chan<- natsMessage) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	fmt.Fprint(conn, "INFO {\"server_id\":\"fake\"}\r\n")
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		switch {
+		case line == "PING":
+			fmt.Fprint(conn, "PONG\r\n")
+		case strings.HasPrefix(line, "PUB "):
+			fields := strings.Fields(line)
+			size, _ := strconv.Atoi(fields[2])
+			data := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, data); err != nil {
+				return
+			}
+			messages <- natsMessage{subject: fields[1], data: string(data[:size])}
+		}
+	}
+}
+
+func TestNATSSink(t *testing.T) {
+	messages := make(chan natsMessage, 2)
+	sink, err := NewNATSSink(fakeNATS(t, messages), "simplebank", time.Second)
+	require.NoError(t, err)
+
+	for _, id := range []int64{1, 2} {
+		require.NoError(t, sink.Publish(context.Background(), randomEvent(id)))
+
+		message := <-messages
+		require.Equal(t, "simplebank.account.42", message.subject)
+		require.Contains(t, message.data, fmt.Sprintf(`"id":%d`, id))
+	}
+}
+
+func TestNATSSinkUnavailable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	listener.Close()
+
+	sink, err := NewNATSSink("nats://"+address, "simplebank", time.Second)
+	require.NoError(t, err)
+	require.Error(t, sink.Publish(context.Background(), randomEvent(1)))
+}
+
+func TestNewNATSSinkInvalidURL(t *testing.T) {
+	_, err := NewNATSSink("http://localhost:4222", "simplebank", time.Second)
+	require.Error(t, err)
+}
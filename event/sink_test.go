@@ -0,0 +1,106 @@
+package event
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func randomEvent(id int64) Event {
+	return Event{
+		ID:            id,
+		Type:          "TransferCompleted",
+		AggregateType: "account",
+		AggregateID:   "42",
+		Payload:       json.RawMessage(`{"amount":100}`),
+		CreatedAt:     time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewWriterSink(&buf)
+
+	for _, id := range []int64{1, 2} {
+		require.NoError(t, sink.Publish(context.Background(), randomEvent(id)))
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var event Event
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &event))
+	require.Equal(t, randomEvent(2), event)
+}
+
+func TestFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "event")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "events.jsonl")
+	sink, err := NewFileSink(path)
+	require.NoError(t, err)
+	require.NoError(t, sink.Publish(context.Background(), randomEvent(1)))
+
+	// A restarted relay appends to the same file.
+	sink, err = NewFileSink(path)
+	require.NoError(t, err)
+	require.NoError(t, sink.Publish(context.Background(), randomEvent(2)))
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, 2, strings.Count(string(data), "\n"))
+}
+
+func TestWebhookSink(t *testing.T) {
+	status := http.StatusNoContent
+	var received Event
+	var header http.Header
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL, time.Second)
+
+	event := randomEvent(7)
+	require.NoError(t, sink.Publish(context.Background(), event))
+	require.Equal(t, event, received)
+	require.Equal(t, "7", header.Get("X-Event-ID"))
+	require.Equal(t, event.Type, header.Get("X-Event-Type"))
+
+	status = http.StatusServiceUnavailable
+	require.Error(t, sink.Publish(context.Background(), event))
+}
+
+type failingSink struct{}
+
+func (failingSink) Publish(ctx context.Context, event Event) error {
+	return context.DeadlineExceeded
+}
+
+func TestMultiSink(t *testing.T) {
+	var first, second bytes.Buffer
+
+	sink := MultiSink{NewWriterSink(&first), NewWriterSink(&second)}
+	require.NoError(t, sink.Publish(context.Background(), randomEvent(1)))
+	require.Equal(t, first.String(), second.String())
+
+	sink = MultiSink{failingSink{}, NewWriterSink(&second)}
+	require.Error(t, sink.Publish(context.Background(), randomEvent(2)))
+	require.Equal(t, 1, strings.Count(second.String(), "\n"))
+}
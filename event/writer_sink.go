@@ -0,0 +1,64 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// WriterSink writes every event as a line of JSON.
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+func NewStdoutSink() *WriterSink {
+	return NewWriterSink(os.Stdout)
+}
+
+func (sink *WriterSink) Publish(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+
+	_, err = fmt.Fprintf(sink.w, "%s\n", data)
+	return err
+}
+
+// FileSink appends every event as a line of JSON to a file, syncing it
+// before the event counts as published.
+type FileSink struct {
+	writer *WriterSink
+	file   *os.File
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open event file %s", err)
+	}
+
+	return &FileSink{
+		writer: NewWriterSink(file),
+		file:   file,
+	}, nil
+}
+
+func (sink *FileSink) Publish(ctx context.Context, event Event) error {
+	err := sink.writer.Publish(ctx, event)
+	if err != nil {
+		return err
+	}
+	return sink.file.Sync()
+}
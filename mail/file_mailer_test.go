@@ -0,0 +1,38 @@
+package mail
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileMailer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mail")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	mailer, err := NewFileMailer(filepath.Join(dir, "outbox"), "bank@example.com")
+	require.NoError(t, err)
+
+	err = mailer.Send(context.Background(), Message{
+		To:      []string{"alice@example.com"},
+		Subject: "Hello",
+		Body:    "line one\nline two",
+	})
+	require.NoError(t, err)
+
+	files, err := ioutil.ReadDir(filepath.Join(dir, "outbox"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "outbox", files[0].Name()))
+	require.NoError(t, err)
+	require.Contains(t, string(data), "From: bank@example.com\r\n")
+	require.Contains(t, string(data), "To: alice@example.com\r\n")
+	require.Contains(t, string(data), "Subject: Hello\r\n")
+	require.Contains(t, string(data), "\r\n\r\nline one\r\nline two")
+}
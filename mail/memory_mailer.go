@@ -0,0 +1,33 @@
+package mail
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryMailer keeps sent messages in memory so tests can inspect them.
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (mailer *MemoryMailer) Send(ctx context.Context, msg Message) error {
+	mailer.mu.Lock()
+	defer mailer.mu.Unlock()
+
+	mailer.messages = append(mailer.messages, msg)
+	return nil
+}
+
+func (mailer *MemoryMailer) Messages() []Message {
+	mailer.mu.Lock()
+	defer mailer.mu.Unlock()
+
+	messages := make([]Message, len(mailer.messages))
+	copy(messages, mailer.messages)
+	return messages
+}
@@ -0,0 +1,111 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// startSMTPServer accepts a single connection and hands it to serve.
+func startSMTPServer(t *testing.T, serve func(conn net.Conn)) (string, int) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		serve(conn)
+	}()
+
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	portNumber, err := strconv.Atoi(port)
+	require.NoError(t, err)
+	return host, portNumber
+}
+
+func TestSMTPMailer(t *testing.T) {
+	received := make(chan string, 1)
+	host, port := startSMTPServer(t, func(conn net.Conn) {
+		reader := bufio.NewReader(conn)
+		fmt.Fprint(conn, "220 localhost ESMTP\r\n")
+
+		var data strings.Builder
+		inData := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					fmt.Fprint(conn, "250 OK\r\n")
+					continue
+				}
+				data.WriteString(line)
+				continue
+			}
+
+			switch strings.ToUpper(strings.Fields(line)[0]) {
+			case "EHLO":
+				fmt.Fprint(conn, "250 localhost\r\n")
+			case "DATA":
+				inData = true
+				fmt.Fprint(conn, "354 Go ahead\r\n")
+			case "QUIT":
+				fmt.Fprint(conn, "221 Bye\r\n")
+				received <- data.String()
+				return
+			default:
+				fmt.Fprint(conn, "250 OK\r\n")
+			}
+		}
+	})
+
+	mailer := NewSMTPMailer(host, port, "", "", "bank@example.com")
+	err := mailer.Send(context.Background(), Message{
+		To:      []string{"alice@example.com"},
+		Subject: "Hello",
+		Body:    "line one",
+	})
+	require.NoError(t, err)
+
+	data := <-received
+	require.Contains(t, data, "To: alice@example.com\r\n")
+	require.Contains(t, data, "Subject: Hello\r\n")
+	require.Contains(t, data, "line one")
+}
+
+func TestSMTPMailerStalledServer(t *testing.T) {
+	host, port := startSMTPServer(t, func(conn net.Conn) {
+		// Never greets the client, only waits for it to hang up.
+		ioutil.ReadAll(conn)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	mailer := NewSMTPMailer(host, port, "", "", "bank@example.com")
+	err := mailer.Send(ctx, Message{
+		To:      []string{"alice@example.com"},
+		Subject: "Hello",
+		Body:    "line one",
+	})
+	require.Error(t, err)
+	require.Less(t, int64(time.Since(start)), int64(2*time.Second))
+}
@@ -1,34 +1,111 @@
 package main
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
 	"github.com/asshiddiq1306/simple_bank/api"
 	db "github.com/asshiddiq1306/simple_bank/db/sql"
 	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/asshiddiq1306/simple_bank/worker"
 	_ "github.com/lib/pq"
+	"github.com/rs/zerolog/log"
 )
 
 func main() {
 	config, err := util.LoadConfig(".")
 	if err != nil {
-		log.Fatal("cannot load config file", err)
+		log.Fatal().Err(err).Msg("cannot load config file")
 	}
 
+	err = util.SetupLogger(config)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot setup logger")
+	}
+
+	shutdownTracing, err := util.SetupTracing(config)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot setup tracing")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err = run(ctx, config)
+
+	// Flushed on every exit, log.Fatal would skip deferred calls.
+	shutdownErr := shutdownTracing(context.Background())
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot run server")
+	}
+	if shutdownErr != nil {
+		log.Fatal().Err(shutdownErr).Msg("cannot shutdown tracing")
+	}
+	log.Info().Msg("server stopped")
+}
+
+// run serves the API and runs the workers until ctx is done, then waits for
+// the workers to stop.
+func run(ctx context.Context, config util.Config) error {
 	conn, err := sql.Open(config.DbDriver, config.DbSource)
 	if err != nil {
-		log.Fatal("cannot connect to db", err)
+		return fmt.Errorf("cannot connect to db: %w", err)
 	}
+	defer conn.Close()
 
 	store := db.NewStore(conn)
+
+	currencies := worker.NewCurrencyRefresher(store, config)
+	err = currencies.RunOnce(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot load currencies: %w", err)
+	}
+
+	sink, err := worker.NewOutboxSink(config)
+	if err != nil {
+		return fmt.Errorf("cannot create outbox sink: %w", err)
+	}
+
 	server, err := api.NewServer(config, store)
 	if err != nil {
-		log.Fatal("cannot create server", err)
+		return fmt.Errorf("cannot create server: %w", err)
+	}
+
+	// The workers stop with the server, whether it was asked to or failed.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	starts := []func(context.Context){
+		currencies.Start,
+		worker.NewInterestScheduler(store, config).Start,
+		worker.NewApprovalExpirer(store, config).Start,
+		worker.NewOutboxRelay(store, sink, config).Start,
+		worker.NewWebhookDispatcher(store, config).Start,
+		worker.NewAccountUpdateListener(config, server).Start,
+	}
+	if sweeper := worker.NewRateLimitSweeper(store, config); sweeper != nil {
+		starts = append(starts, sweeper.Start)
+	}
+
+	var workers sync.WaitGroup
+	for _, start := range starts {
+		workers.Add(1)
+		go func(start func(context.Context)) {
+			defer workers.Done()
+			start(ctx)
+		}(start)
 	}
 
-	err = server.Start(config.ServerAddress)
+	err = server.Start(ctx, config.ServerAddress)
+	cancel()
+	workers.Wait()
 	if err != nil {
-		log.Fatal("cannot start server", err)
+		return fmt.Errorf("cannot start server: %w", err)
 	}
+	return nil
 }
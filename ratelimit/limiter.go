@@ -0,0 +1,48 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit describes a token bucket that refills at Rate tokens per second and
+// holds at most Burst tokens.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+func PerMinute(requests int, burst int) Limit {
+	return Limit{
+		Rate:  float64(requests) / 60,
+		Burst: burst,
+	}
+}
+
+func (limit Limit) Enabled() bool {
+	return limit.Rate > 0 && limit.Burst > 0
+}
+
+// RefillTime is how long an empty bucket takes to fill up again. It is only
+// meaningful for an enabled limit.
+func (limit Limit) RefillTime() time.Duration {
+	return time.Duration(math.Ceil(float64(limit.Burst) / limit.Rate * float64(time.Second)))
+}
+
+type Result struct {
+	Allowed    bool
+	RetryAfter time.Duration
+}
+
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+func denied(tokens float64, limit Limit) Result {
+	seconds := (1 - tokens) / limit.Rate
+	return Result{
+		Allowed:    false,
+		RetryAfter: time.Duration(math.Ceil(seconds * float64(time.Second))),
+	}
+}
@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+const cleanupInterval = time.Minute
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	fullAt    time.Time
+}
+
+// MemoryLimiter keeps buckets in process memory. It is only accurate when a
+// single server instance is running.
+type MemoryLimiter struct {
+	mu          sync.Mutex
+	buckets     map[string]*bucket
+	lastCleanup time.Time
+	now         func() time.Time
+}
+
+func NewMemoryLimiter() Limiter {
+	return &MemoryLimiter{
+		buckets:     make(map[string]*bucket),
+		lastCleanup: time.Now(),
+		now:         time.Now,
+	}
+}
+
+func (limiter *MemoryLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	now := limiter.now()
+	limiter.cleanup(now)
+
+	burst := float64(limit.Burst)
+	b, ok := limiter.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, updatedAt: now}
+		limiter.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.updatedAt).Seconds()
+	b.tokens = math.Min(burst, b.tokens+elapsed*limit.Rate)
+	b.updatedAt = now
+
+	result := Result{Allowed: true}
+	if b.tokens >= 1 {
+		b.tokens--
+	} else {
+		result = denied(b.tokens, limit)
+	}
+
+	b.fullAt = now.Add(time.Duration((burst - b.tokens) / limit.Rate * float64(time.Second)))
+	return result, nil
+}
+
+// cleanup drops buckets that have refilled completely, since a full bucket
+// behaves exactly like a missing one.
+func (limiter *MemoryLimiter) cleanup(now time.Time) {
+	if now.Sub(limiter.lastCleanup) < cleanupInterval {
+		return
+	}
+
+	for key, b := range limiter.buckets {
+		if !now.Before(b.fullAt) {
+			delete(limiter.buckets, key)
+		}
+	}
+	limiter.lastCleanup = now
+}
@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMemoryLimiter(now *time.Time) *MemoryLimiter {
+	limiter := NewMemoryLimiter().(*MemoryLimiter)
+	limiter.now = func() time.Time { return *now }
+	limiter.lastCleanup = *now
+	return limiter
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	now := time.Now()
+	limiter := newTestMemoryLimiter(&now)
+	limit := PerMinute(60, 3)
+	key := util.RandomName()
+
+	for i := 0; i < limit.Burst; i++ {
+		result, err := limiter.Allow(context.Background(), key, limit)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+	}
+
+	result, err := limiter.Allow(context.Background(), key, limit)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Equal(t, time.Second, result.RetryAfter)
+
+	result, err = limiter.Allow(context.Background(), util.RandomName(), limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+
+	now = now.Add(time.Second)
+	result, err = limiter.Allow(context.Background(), key, limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+}
+
+func TestMemoryLimiterCleanup(t *testing.T) {
+	now := time.Now()
+	limiter := newTestMemoryLimiter(&now)
+	limit := PerMinute(60, 3)
+
+	_, err := limiter.Allow(context.Background(), util.RandomName(), limit)
+	require.NoError(t, err)
+	require.Len(t, limiter.buckets, 1)
+
+	now = now.Add(cleanupInterval)
+	_, err = limiter.Allow(context.Background(), util.RandomName(), limit)
+	require.NoError(t, err)
+	require.Len(t, limiter.buckets, 1)
+}
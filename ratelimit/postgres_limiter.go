@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+)
+
+// PostgresLimiter keeps buckets in the rate_limit_buckets table so that the
+// limits are shared by every server instance.
+type PostgresLimiter struct {
+	store db.Store
+}
+
+func NewPostgresLimiter(store db.Store) Limiter {
+	return &PostgresLimiter{
+		store: store,
+	}
+}
+
+func (limiter *PostgresLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	_, err := limiter.store.TakeRateLimitToken(ctx, db.TakeRateLimitTokenArgs{
+		Key:   key,
+		Burst: float64(limit.Burst),
+		Rate:  limit.Rate,
+	})
+	if err == nil {
+		return Result{Allowed: true}, nil
+	}
+
+	if err != sql.ErrNoRows {
+		return Result{}, err
+	}
+
+	tokens, err := limiter.store.GetRateLimitTokens(ctx, db.GetRateLimitTokensArgs{
+		Key:   key,
+		Burst: float64(limit.Burst),
+		Rate:  limit.Rate,
+	})
+	if err != nil && err != sql.ErrNoRows {
+		return Result{}, err
+	}
+
+	return denied(tokens, limit), nil
+}
@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	mockdb "github.com/asshiddiq1306/simple_bank/db/mock"
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresLimiter(t *testing.T) {
+	key := util.RandomName()
+	limit := PerMinute(60, 3)
+	takeArg := db.TakeRateLimitTokenArgs{Key: key, Burst: 3, Rate: 1}
+	getArg := db.GetRateLimitTokensArgs{Key: key, Burst: 3, Rate: 1}
+	backendErr := errors.New("connection refused")
+
+	testCases := []struct {
+		name        string
+		buildStubs  func(store *mockdb.MockStore)
+		checkResult func(t *testing.T, result Result, err error)
+	}{
+		{
+			name: "Allowed",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().TakeRateLimitToken(gomock.Any(), gomock.Eq(takeArg)).Times(1).Return(2.0, nil)
+				store.EXPECT().GetRateLimitTokens(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResult: func(t *testing.T, result Result, err error) {
+				require.NoError(t, err)
+				require.True(t, result.Allowed)
+			},
+		},
+		{
+			name: "Denied",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().TakeRateLimitToken(gomock.Any(), gomock.Eq(takeArg)).Times(1).Return(0.0, sql.ErrNoRows)
+				store.EXPECT().GetRateLimitTokens(gomock.Any(), gomock.Eq(getArg)).Times(1).Return(0.5, nil)
+			},
+			checkResult: func(t *testing.T, result Result, err error) {
+				require.NoError(t, err)
+				require.False(t, result.Allowed)
+				require.Equal(t, 500*time.Millisecond, result.RetryAfter)
+			},
+		},
+		{
+			name: "TakeError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().TakeRateLimitToken(gomock.Any(), gomock.Any()).Times(1).Return(0.0, backendErr)
+				store.EXPECT().GetRateLimitTokens(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResult: func(t *testing.T, result Result, err error) {
+				require.Equal(t, backendErr, err)
+			},
+		},
+		{
+			name: "GetError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().TakeRateLimitToken(gomock.Any(), gomock.Any()).Times(1).Return(0.0, sql.ErrNoRows)
+				store.EXPECT().GetRateLimitTokens(gomock.Any(), gomock.Any()).Times(1).Return(0.0, backendErr)
+			},
+			checkResult: func(t *testing.T, result Result, err error) {
+				require.Equal(t, backendErr, err)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			result, err := NewPostgresLimiter(store).Allow(context.Background(), key, limit)
+			tc.checkResult(t, result, err)
+		})
+	}
+}
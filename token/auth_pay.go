@@ -12,14 +12,26 @@ var (
 	ErrInvalidToken = errors.New("token invalid")
 )
 
+// MFA levels record which factors were presented when the token was issued.
+const (
+	MFANone = "none"
+	MFATOTP = "totp"
+)
+
+// AuthPay is the authenticated identity of a request. Scopes is nil for a
+// user session and lists the granted scopes for delegated credentials such
+// as API keys. ClientID is set on tokens issued to an OAuth client.
 type AuthPay struct {
 	ID        uuid.UUID `json:"id"`
 	Username  string    `json:"username"`
+	MFALevel  string    `json:"mfa_level"`
+	Scopes    []string  `json:"scopes"`
+	ClientID  string    `json:"client_id,omitempty"`
 	IssuedAt  time.Time `json:"issued_at"`
 	ExpiredAt time.Time `json:"expired_at"`
 }
 
-func NewAuthPay(username string, duration time.Duration) (*AuthPay, error) {
+func NewAuthPay(username string, mfaLevel string, duration time.Duration) (*AuthPay, error) {
 	generateID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
@@ -28,6 +40,7 @@ func NewAuthPay(username string, duration time.Duration) (*AuthPay, error) {
 	payload := &AuthPay{
 		ID:        generateID,
 		Username:  username,
+		MFALevel:  mfaLevel,
 		IssuedAt:  time.Now(),
 		ExpiredAt: time.Now().Add(duration),
 	}
@@ -35,9 +48,37 @@ func NewAuthPay(username string, duration time.Duration) (*AuthPay, error) {
 	return payload, nil
 }
 
+// NewDelegatedAuthPay returns a payload for a token issued to clientID on
+// behalf of username, limited to scopes.
+func NewDelegatedAuthPay(username string, clientID string, scopes []string, duration time.Duration) (*AuthPay, error) {
+	payload, err := NewAuthPay(username, MFANone, duration)
+	if err != nil {
+		return nil, err
+	}
+
+	payload.ClientID = clientID
+	payload.Scopes = append([]string{}, scopes...)
+	return payload, nil
+}
+
 func (payload *AuthPay) Valid() error {
 	if time.Now().After(payload.ExpiredAt) {
 		return ErrExpiredToken
 	}
 	return nil
 }
+
+// HasScope reports whether the payload grants scope. User sessions are not
+// restricted by scopes.
+func (payload *AuthPay) HasScope(scope string) bool {
+	if payload.Scopes == nil {
+		return true
+	}
+
+	for _, s := range payload.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
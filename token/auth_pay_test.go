@@ -0,0 +1,22 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasScope(t *testing.T) {
+	payload, err := NewAuthPay(util.RandomName(), MFANone, time.Minute)
+	require.NoError(t, err)
+	require.True(t, payload.HasScope(util.TransfersWriteScope))
+
+	payload.Scopes = []string{util.AccountsReadScope}
+	require.True(t, payload.HasScope(util.AccountsReadScope))
+	require.False(t, payload.HasScope(util.TransfersWriteScope))
+
+	payload.Scopes = []string{}
+	require.False(t, payload.HasScope(util.AccountsReadScope))
+}
@@ -3,6 +3,7 @@ package token
 import "time"
 
 type Maker interface {
-	CreateToken(username string, duration time.Duration) (string, error)
+	CreateToken(username string, mfaLevel string, duration time.Duration) (string, error)
+	CreateDelegatedToken(username string, clientID string, scopes []string, duration time.Duration) (string, *AuthPay, error)
 	VerifyToken(accessToken string) (*AuthPay, error)
 }
@@ -27,8 +27,8 @@ func NewPasetoMaker(symmetricKey string) (Maker, error) {
 	return paseto, nil
 }
 
-func (pasetoMaker *PasetoMaker) CreateToken(username string, duration time.Duration) (string, error) {
-	payload, err := NewAuthPay(username, duration)
+func (pasetoMaker *PasetoMaker) CreateToken(username string, mfaLevel string, duration time.Duration) (string, error) {
+	payload, err := NewAuthPay(username, mfaLevel, duration)
 	if err != nil {
 		return "", err
 	}
@@ -36,6 +36,19 @@ func (pasetoMaker *PasetoMaker) CreateToken(username string, duration time.Durat
 	return pasetoMaker.maker.Encrypt([]byte(pasetoMaker.symmetricKey), payload, nil)
 }
 
+func (pasetoMaker *PasetoMaker) CreateDelegatedToken(username string, clientID string, scopes []string, duration time.Duration) (string, *AuthPay, error) {
+	payload, err := NewDelegatedAuthPay(username, clientID, scopes, duration)
+	if err != nil {
+		return "", nil, err
+	}
+
+	token, err := pasetoMaker.maker.Encrypt([]byte(pasetoMaker.symmetricKey), payload, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, payload, nil
+}
+
 func (pasetoMaker *PasetoMaker) VerifyToken(accessToken string) (*AuthPay, error) {
 	payload := &AuthPay{}
 	err := pasetoMaker.maker.Decrypt(accessToken, pasetoMaker.symmetricKey, payload, nil)
@@ -17,7 +17,7 @@ func TestPasetoMaker(t *testing.T) {
 
 	issuedAt := time.Now()
 	expiredAt := time.Now().Add(duration)
-	accessToken, err := maker.CreateToken(username, duration)
+	accessToken, err := maker.CreateToken(username, MFATOTP, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, accessToken)
 
@@ -26,6 +26,7 @@ func TestPasetoMaker(t *testing.T) {
 	require.NotEmpty(t, payload)
 
 	require.Equal(t, payload.Username, username)
+	require.Equal(t, payload.MFALevel, MFATOTP)
 	require.WithinDuration(t, payload.IssuedAt, issuedAt, time.Second)
 	require.WithinDuration(t, payload.ExpiredAt, expiredAt, time.Second)
 }
@@ -34,7 +35,7 @@ func TestExpiredToken(t *testing.T) {
 	maker, err := NewPasetoMaker(util.RandomString(32))
 	require.NoError(t, err)
 
-	accessToken, err := maker.CreateToken(util.RandomName(), -time.Minute)
+	accessToken, err := maker.CreateToken(util.RandomName(), MFANone, -time.Minute)
 	require.NoError(t, err)
 	require.NotEmpty(t, accessToken)
 
@@ -43,3 +44,24 @@ func TestExpiredToken(t *testing.T) {
 	require.Nil(t, payload)
 	require.EqualError(t, err, ErrExpiredToken.Error())
 }
+
+func TestDelegatedToken(t *testing.T) {
+	maker, err := NewPasetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	username := util.RandomName()
+	clientID := util.RandomString(16)
+
+	accessToken, payload1, err := maker.CreateDelegatedToken(username, clientID, []string{}, time.Minute)
+	require.NoError(t, err)
+	require.NotEmpty(t, accessToken)
+
+	payload2, err := maker.VerifyToken(accessToken)
+	require.NoError(t, err)
+	require.Equal(t, payload1.ID, payload2.ID)
+	require.Equal(t, username, payload2.Username)
+	require.Equal(t, clientID, payload2.ClientID)
+	require.Equal(t, MFANone, payload2.MFALevel)
+	require.NotNil(t, payload2.Scopes)
+	require.False(t, payload2.HasScope(util.AccountsReadScope))
+}
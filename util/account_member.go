@@ -0,0 +1,20 @@
+package util
+
+// Roles of an account member, from most to least privileged.
+const (
+	AccountOwnerRole   = "owner"
+	AccountCoOwnerRole = "co_owner"
+	AccountViewerRole  = "viewer"
+)
+
+// CanTransferFromAccount reports whether role may move money out of the
+// account. Viewers can only see its balance and history.
+func CanTransferFromAccount(role string) bool {
+	return role == AccountOwnerRole || role == AccountCoOwnerRole
+}
+
+// CanManageAccountMembers reports whether role may invite and remove the
+// other members.
+func CanManageAccountMembers(role string) bool {
+	return role == AccountOwnerRole
+}
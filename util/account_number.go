@@ -0,0 +1,61 @@
+package util
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+// AccountNumberPrefix takes the place of the IBAN country code in account
+// numbers issued by Simple Bank.
+const AccountNumberPrefix = "SB"
+
+const (
+	accountNumberDigits = 12
+	AccountNumberLength = len(AccountNumberPrefix) + 2 + accountNumberDigits
+)
+
+// GenerateAccountNumber returns a random account number of the form
+// SB<check digits><12 digits>. The check digits follow ISO 7064 mod 97-10,
+// the same scheme IBANs use, so most typos are caught before a lookup.
+func GenerateAccountNumber() (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(accountNumberDigits), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", fmt.Errorf("cannot generate account number %s", err)
+	}
+
+	bban := fmt.Sprintf("%0*d", accountNumberDigits, n)
+	check := 98 - accountNumberMod97(AccountNumberPrefix+"00"+bban)
+	return fmt.Sprintf("%s%02d%s", AccountNumberPrefix, check, bban), nil
+}
+
+// IsValidAccountNumber reports whether number is well formed and its check
+// digits match.
+func IsValidAccountNumber(number string) bool {
+	if len(number) != AccountNumberLength || number[:len(AccountNumberPrefix)] != AccountNumberPrefix {
+		return false
+	}
+
+	for _, r := range number[len(AccountNumberPrefix):] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return accountNumberMod97(number) == 1
+}
+
+// accountNumberMod97 moves the first four characters to the end, replaces
+// letters with 10..35 and returns the remainder of the result modulo 97.
+func accountNumberMod97(number string) int {
+	remainder := 0
+	for _, r := range number[4:] + number[:4] {
+		switch {
+		case r >= '0' && r <= '9':
+			remainder = (remainder*10 + int(r-'0')) % 97
+		case r >= 'A' && r <= 'Z':
+			remainder = (remainder*100 + int(r-'A') + 10) % 97
+		}
+	}
+	return remainder
+}
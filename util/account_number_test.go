@@ -0,0 +1,51 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateAccountNumber(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		number, err := GenerateAccountNumber()
+		require.NoError(t, err)
+		require.Len(t, number, AccountNumberLength)
+		require.True(t, IsValidAccountNumber(number), number)
+	}
+}
+
+func TestIsValidAccountNumber(t *testing.T) {
+	number, err := GenerateAccountNumber()
+	require.NoError(t, err)
+
+	// Swapping two different adjacent digits must break the checksum.
+	swapped := []byte(number)
+	for i := len(swapped) - 1; i > 4; i-- {
+		if swapped[i] != swapped[i-1] {
+			swapped[i], swapped[i-1] = swapped[i-1], swapped[i]
+			break
+		}
+	}
+
+	testCases := []struct {
+		name   string
+		number string
+		valid  bool
+	}{
+		{"Known", "SB77000000000001", true},
+		{"Generated", number, true},
+		{"Swapped", string(swapped), false},
+		{"WrongCheckDigits", "SB78000000000001", false},
+		{"WrongPrefix", "GB77000000000001", false},
+		{"TooShort", "SB7700000000001", false},
+		{"NotDigits", "SB7700000000000A", false},
+		{"Empty", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.valid, IsValidAccountNumber(tc.number))
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package util
+
+import "strings"
+
+// APIKeyPrefix marks a credential as a Simple Bank API key, so that leaked
+// keys are easy to recognise by secret scanners.
+const APIKeyPrefix = "sbk_"
+
+// GenerateAPIKey returns a new key of the form sbk_<prefix>.<secret>. The
+// prefix is stored in clear to look the key up, the full key only as a hash.
+func GenerateAPIKey() (prefix string, key string, err error) {
+	prefix, err = RandomSecureToken(6)
+	if err != nil {
+		return "", "", err
+	}
+
+	secret, err := RandomSecureToken(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	return prefix, APIKeyPrefix + prefix + "." + secret, nil
+}
+
+// ParseAPIKeyPrefix returns the lookup prefix of key, or false when key is
+// not formatted as an API key.
+func ParseAPIKeyPrefix(key string) (string, bool) {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return "", false
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(key, APIKeyPrefix), ".", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", false
+	}
+	return parts[0], true
+}
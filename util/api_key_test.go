@@ -0,0 +1,29 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	prefix1, key1, err := GenerateAPIKey()
+	require.NoError(t, err)
+	require.NotEmpty(t, prefix1)
+
+	prefix2, key2, err := GenerateAPIKey()
+	require.NoError(t, err)
+	require.NotEqual(t, prefix1, prefix2)
+	require.NotEqual(t, key1, key2)
+
+	prefix, ok := ParseAPIKeyPrefix(key1)
+	require.True(t, ok)
+	require.Equal(t, prefix1, prefix)
+}
+
+func TestParseAPIKeyPrefix(t *testing.T) {
+	for _, key := range []string{"", "sbk_", "sbk_abc", "sbk_.secret", "sbk_abc.", "key_abc.secret"} {
+		_, ok := ParseAPIKeyPrefix(key)
+		require.False(t, ok, key)
+	}
+}
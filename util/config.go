@@ -7,11 +7,92 @@ import (
 )
 
 type Config struct {
+	Environment         string        `mapstructure:"ENVIRONMENT"`
+	LogLevel            string        `mapstructure:"LOG_LEVEL"`
 	DbDriver            string        `mapstructure:"DB_DRIVER"`
 	DbSource            string        `mapstructure:"DB_SOURCE"`
 	ServerAddress       string        `mapstructure:"SERVER_ADDRESS"`
 	TokenSymmetricKey   string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
 	TokenAccessDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	TracingExporter     string        `mapstructure:"TRACING_EXPORTER"`
+	TracingFilePath     string        `mapstructure:"TRACING_FILE_PATH"`
+	RateLimitBackend    string        `mapstructure:"RATE_LIMIT_BACKEND"`
+	PublicRateLimit     int           `mapstructure:"PUBLIC_RATE_LIMIT_PER_MINUTE"`
+	PublicRateBurst     int           `mapstructure:"PUBLIC_RATE_LIMIT_BURST"`
+	AuthRateLimit       int           `mapstructure:"AUTH_RATE_LIMIT_PER_MINUTE"`
+	AuthRateBurst       int           `mapstructure:"AUTH_RATE_LIMIT_BURST"`
+	WriteRateLimit      int           `mapstructure:"WRITE_RATE_LIMIT_PER_MINUTE"`
+	WriteRateBurst      int           `mapstructure:"WRITE_RATE_LIMIT_BURST"`
+	TransferRateLimit   int           `mapstructure:"TRANSFER_RATE_LIMIT_PER_MINUTE"`
+	TransferRateBurst   int           `mapstructure:"TRANSFER_RATE_LIMIT_BURST"`
+	LoginMaxAttempts    int32         `mapstructure:"LOGIN_MAX_ATTEMPTS"`
+	LoginLockout        time.Duration `mapstructure:"LOGIN_LOCKOUT_DURATION"`
+	LoginMaxLockout     time.Duration `mapstructure:"LOGIN_MAX_LOCKOUT_DURATION"`
+
+	PasswordMinLength        int    `mapstructure:"PASSWORD_MIN_LENGTH"`
+	PasswordRequireUpper     bool   `mapstructure:"PASSWORD_REQUIRE_UPPER"`
+	PasswordRequireLower     bool   `mapstructure:"PASSWORD_REQUIRE_LOWER"`
+	PasswordRequireDigit     bool   `mapstructure:"PASSWORD_REQUIRE_DIGIT"`
+	PasswordRequireSymbol    bool   `mapstructure:"PASSWORD_REQUIRE_SYMBOL"`
+	PasswordBreachedListFile string `mapstructure:"PASSWORD_BREACHED_LIST_FILE"`
+
+	Mailer       string `mapstructure:"MAILER"`
+	MailSender   string `mapstructure:"MAIL_SENDER"`
+	MailDir      string `mapstructure:"MAIL_DIR"`
+	SMTPHost     string `mapstructure:"SMTP_HOST"`
+	SMTPPort     int    `mapstructure:"SMTP_PORT"`
+	SMTPUsername string `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword string `mapstructure:"SMTP_PASSWORD"`
+
+	ResetPasswordURL      string        `mapstructure:"RESET_PASSWORD_URL"`
+	ResetPasswordDuration time.Duration `mapstructure:"RESET_PASSWORD_TOKEN_DURATION"`
+
+	VerifyEmailURL       string        `mapstructure:"VERIFY_EMAIL_URL"`
+	VerifyEmailDuration  time.Duration `mapstructure:"VERIFY_EMAIL_DURATION"`
+	RequireVerifiedEmail bool          `mapstructure:"REQUIRE_VERIFIED_EMAIL"`
+
+	TOTPEncryptionKey     string `mapstructure:"TOTP_ENCRYPTION_KEY"`
+	TransferMFAThresholds string `mapstructure:"TRANSFER_MFA_THRESHOLDS"`
+
+	APIKeyDuration time.Duration `mapstructure:"API_KEY_DURATION"`
+
+	OAuthCodeDuration  time.Duration `mapstructure:"OAUTH_CODE_DURATION"`
+	OAuthTokenDuration time.Duration `mapstructure:"OAUTH_TOKEN_DURATION"`
+
+	PayeeCooldown time.Duration `mapstructure:"PAYEE_COOLDOWN"`
+
+	SavingsInterestRates       string        `mapstructure:"SAVINGS_INTEREST_RATES"`
+	InterestJobInterval        time.Duration `mapstructure:"INTEREST_JOB_INTERVAL"`
+	InterestAccrualCatchupDays int           `mapstructure:"INTEREST_ACCRUAL_CATCHUP_DAYS"`
+
+	CurrencyRefreshInterval time.Duration `mapstructure:"CURRENCY_REFRESH_INTERVAL"`
+
+	TransferApprovalDuration       time.Duration `mapstructure:"TRANSFER_APPROVAL_DURATION"`
+	TransferApprovalExpiryInterval time.Duration `mapstructure:"TRANSFER_APPROVAL_EXPIRY_INTERVAL"`
+
+	OutboxSinks             string        `mapstructure:"OUTBOX_SINKS"`
+	OutboxFilePath          string        `mapstructure:"OUTBOX_FILE_PATH"`
+	OutboxWebhookURL        string        `mapstructure:"OUTBOX_WEBHOOK_URL"`
+	OutboxNATSURL           string        `mapstructure:"OUTBOX_NATS_URL"`
+	OutboxNATSSubjectPrefix string        `mapstructure:"OUTBOX_NATS_SUBJECT_PREFIX"`
+	OutboxPublishTimeout    time.Duration `mapstructure:"OUTBOX_PUBLISH_TIMEOUT"`
+	OutboxRelayInterval     time.Duration `mapstructure:"OUTBOX_RELAY_INTERVAL"`
+	OutboxBatchSize         int32         `mapstructure:"OUTBOX_BATCH_SIZE"`
+
+	WebhookEncryptionKey        string        `mapstructure:"WEBHOOK_ENCRYPTION_KEY"`
+	WebhookTimeout              time.Duration `mapstructure:"WEBHOOK_TIMEOUT"`
+	WebhookDispatchInterval     time.Duration `mapstructure:"WEBHOOK_DISPATCH_INTERVAL"`
+	WebhookBatchSize            int32         `mapstructure:"WEBHOOK_BATCH_SIZE"`
+	WebhookMaxAttempts          int32         `mapstructure:"WEBHOOK_MAX_ATTEMPTS"`
+	WebhookBackoffBase          time.Duration `mapstructure:"WEBHOOK_BACKOFF_BASE"`
+	WebhookBackoffMax           time.Duration `mapstructure:"WEBHOOK_BACKOFF_MAX"`
+	WebhookDisableAfterFailures int32         `mapstructure:"WEBHOOK_DISABLE_AFTER_FAILURES"`
+
+	AccountStreamHeartbeat time.Duration `mapstructure:"ACCOUNT_STREAM_HEARTBEAT"`
+
+	// RateLimitSweepInterval is how often the postgres rate limit buckets
+	// that refilled are deleted.
+	RateLimitSweepInterval time.Duration `mapstructure:"RATE_LIMIT_SWEEP_INTERVAL"`
 }
 
 func LoadConfig(path string) (config Config, err error) {
@@ -0,0 +1,49 @@
+package util
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+var ErrDecrypt = errors.New("cannot decrypt data")
+
+// Encrypt seals plaintext with XChaCha20-Poly1305 and returns the random nonce
+// followed by the ciphertext, base64 encoded for storage in a varchar column.
+func Encrypt(key string, plaintext string) (string, error) {
+	aead, err := chacha20poly1305.NewX([]byte(key))
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", fmt.Errorf("cannot generate nonce %s", err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func Decrypt(key string, ciphertext string) (string, error) {
+	aead, err := chacha20poly1305.NewX([]byte(key))
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrDecrypt
+	}
+
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	return string(plaintext), nil
+}
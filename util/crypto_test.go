@@ -0,0 +1,22 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncrypt(t *testing.T) {
+	key := RandomString(32)
+
+	ciphertext, err := Encrypt(key, "secret")
+	require.NoError(t, err)
+	require.NotContains(t, ciphertext, "secret")
+
+	plaintext, err := Decrypt(key, ciphertext)
+	require.NoError(t, err)
+	require.Equal(t, "secret", plaintext)
+
+	_, err = Decrypt(RandomString(32), ciphertext)
+	require.EqualError(t, err, ErrDecrypt.Error())
+}
@@ -1,15 +1,131 @@
 package util
 
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
 const (
 	USD = "USD"
 	IDR = "IDR"
 	EUR = "EUR"
 )
 
-func IsSupportedCurrency(currency string) bool {
-	switch currency {
-	case USD, IDR, EUR:
-		return true
+// Currency is an ISO 4217 currency the bank keeps balances in.
+type Currency struct {
+	Code       string
+	MinorUnits int
+	Enabled    bool
+}
+
+// currencies caches the currency table. It starts with the currencies the
+// migrations seed until SetCurrencies loads the table. IDR is kept in whole
+// rupiah, the sen has not been in circulation for decades.
+var currencies = struct {
+	sync.RWMutex
+	byCode map[string]Currency
+}{
+	byCode: map[string]Currency{
+		USD: {Code: USD, MinorUnits: 2, Enabled: true},
+		IDR: {Code: IDR, MinorUnits: 0, Enabled: true},
+		EUR: {Code: EUR, MinorUnits: 2, Enabled: true},
+	},
+}
+
+// SetCurrencies replaces the cached currencies with list.
+func SetCurrencies(list []Currency) {
+	byCode := make(map[string]Currency, len(list))
+	for _, currency := range list {
+		byCode[currency.Code] = currency
+	}
+
+	currencies.Lock()
+	currencies.byCode = byCode
+	currencies.Unlock()
+}
+
+// SetCurrency adds currency to the cache or replaces it.
+func SetCurrency(currency Currency) {
+	currencies.Lock()
+	currencies.byCode[currency.Code] = currency
+	currencies.Unlock()
+}
+
+func LookupCurrency(code string) (Currency, bool) {
+	currencies.RLock()
+	defer currencies.RUnlock()
+
+	currency, ok := currencies.byCode[code]
+	return currency, ok
+}
+
+// IsSupportedCurrency reports whether code is in the currency table. It
+// stays true after the currency is disabled, so existing balances can still
+// be moved.
+func IsSupportedCurrency(code string) bool {
+	_, ok := LookupCurrency(code)
+	return ok
+}
+
+// IsEnabledCurrency reports whether new accounts can be opened in code.
+func IsEnabledCurrency(code string) bool {
+	currency, ok := LookupCurrency(code)
+	return ok && currency.Enabled
+}
+
+// EnabledCurrencies returns the codes of the enabled currencies, sorted.
+func EnabledCurrencies() []string {
+	currencies.RLock()
+	defer currencies.RUnlock()
+
+	codes := make([]string, 0, len(currencies.byCode))
+	for code, currency := range currencies.byCode {
+		if currency.Enabled {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// ParseCurrencyAmounts parses a list such as "USD:1000,IDR:15000000" into a
+// map of amounts per currency.
+func ParseCurrencyAmounts(value string) (map[string]int64, error) {
+	return parseCurrencyValues(value, func(amount string, currency string) (int64, error) {
+		return strconv.ParseInt(amount, 10, 64)
+	})
+}
+
+// ParseCurrencyMoney parses a list of decimal amounts such as
+// "USD:1000.50,IDR:15000000" into a map of minor units per currency.
+func ParseCurrencyMoney(value string) (map[string]int64, error) {
+	return parseCurrencyValues(value, func(amount string, currency string) (int64, error) {
+		money, err := ParseMoney(amount, currency)
+		return money.Amount, err
+	})
+}
+
+func parseCurrencyValues(value string, parse func(amount string, currency string) (int64, error)) (map[string]int64, error) {
+	amounts := make(map[string]int64)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.SplitN(item, ":", 2)
+		if len(parts) != 2 || !IsSupportedCurrency(parts[0]) {
+			return nil, fmt.Errorf("invalid currency amount %s", item)
+		}
+
+		amount, err := parse(parts[1], parts[0])
+		if err != nil || amount < 0 {
+			return nil, fmt.Errorf("invalid currency amount %s", item)
+		}
+		amounts[parts[0]] = amount
 	}
-	return false
+	return amounts, nil
 }
@@ -0,0 +1,58 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCurrencyAmounts(t *testing.T) {
+	amounts, err := ParseCurrencyAmounts("USD:1000, IDR:15000000,")
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{USD: 1000, IDR: 15000000}, amounts)
+
+	amounts, err = ParseCurrencyAmounts("")
+	require.NoError(t, err)
+	require.Empty(t, amounts)
+
+	_, err = ParseCurrencyAmounts("XYZ:10")
+	require.Error(t, err)
+
+	_, err = ParseCurrencyAmounts("USD:-1")
+	require.Error(t, err)
+}
+
+func TestParseCurrencyMoney(t *testing.T) {
+	amounts, err := ParseCurrencyMoney("USD:1000.50,IDR:15000000")
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{USD: 100050, IDR: 15000000}, amounts)
+
+	_, err = ParseCurrencyMoney("IDR:10.5")
+	require.Error(t, err)
+
+	_, err = ParseCurrencyMoney("EUR:-1")
+	require.Error(t, err)
+}
+
+func TestCurrencyRegistry(t *testing.T) {
+	// XXE is not an ISO 4217 code, it only lives in this test.
+	SetCurrency(Currency{Code: "XXE", MinorUnits: 3, Enabled: false})
+
+	require.True(t, IsSupportedCurrency("XXE"))
+	require.False(t, IsEnabledCurrency("XXE"))
+	require.NotContains(t, EnabledCurrencies(), "XXE")
+
+	units, ok := CurrencyMinorUnits("XXE")
+	require.True(t, ok)
+	require.Equal(t, 3, units)
+
+	money, err := ParseMoney("1.5", "XXE")
+	require.NoError(t, err)
+	require.Equal(t, int64(1500), money.Amount)
+
+	SetCurrency(Currency{Code: "XXE", MinorUnits: 3, Enabled: true})
+	require.True(t, IsEnabledCurrency("XXE"))
+	require.Contains(t, EnabledCurrencies(), "XXE")
+
+	require.False(t, IsSupportedCurrency("XYZ"))
+}
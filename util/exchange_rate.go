@@ -0,0 +1,24 @@
+package util
+
+import (
+	"regexp"
+	"strings"
+)
+
+// exchangeRateRegexp matches the numeric(20, 10) column rates are stored in.
+var exchangeRateRegexp = regexp.MustCompile(`^[0-9]{1,10}(\.[0-9]{1,10})?$`)
+
+// IsValidExchangeRate reports whether rate is a positive decimal that fits
+// the exchange_rates table.
+func IsValidExchangeRate(rate string) bool {
+	return exchangeRateRegexp.MatchString(rate) && strings.Trim(rate, "0.") != ""
+}
+
+// TrimExchangeRate removes the trailing zeros of a rate read back from the
+// database, "15000.0000000000" becomes "15000".
+func TrimExchangeRate(rate string) string {
+	if !strings.Contains(rate, ".") {
+		return rate
+	}
+	return strings.TrimSuffix(strings.TrimRight(rate, "0"), ".")
+}
@@ -0,0 +1,24 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsValidExchangeRate(t *testing.T) {
+	for _, rate := range []string{"1", "0.0000625", "15000", "1.0823", "9999999999.9999999999"} {
+		require.True(t, IsValidExchangeRate(rate), rate)
+	}
+
+	for _, rate := range []string{"", "0", "0.000", "-1", "1.", ".5", "1e3", "12345678901", "0.12345678901"} {
+		require.False(t, IsValidExchangeRate(rate), rate)
+	}
+}
+
+func TestTrimExchangeRate(t *testing.T) {
+	require.Equal(t, "15000", TrimExchangeRate("15000.0000000000"))
+	require.Equal(t, "1.0823", TrimExchangeRate("1.0823000000"))
+	require.Equal(t, "1", TrimExchangeRate("1"))
+	require.Equal(t, "100", TrimExchangeRate("100"))
+}
@@ -0,0 +1,46 @@
+package util
+
+import (
+	"errors"
+	"math/big"
+)
+
+const (
+	CheckingAccount = "checking"
+	SavingsAccount  = "savings"
+)
+
+// InterestMicrosPerUnit is the number of accrual units in one minor unit of
+// a currency. Daily interest is tracked in millionths so that small balances
+// still earn interest over a month.
+const InterestMicrosPerUnit = 1000000
+
+const daysPerYear = 365
+
+// ErrInterestOverflow is returned when a day of interest does not fit in
+// int64 micros, which only balances above 10^15 minor units can reach.
+var ErrInterestOverflow = errors.New("daily interest is out of range")
+
+// DailyInterestMicros returns one day of interest on balance at an annual
+// rate of rateBps basis points, using the actual/365 day count and rounding
+// down to whole micros. Negative balances earn no interest.
+func DailyInterestMicros(balance int64, rateBps int32) (int64, error) {
+	if balance <= 0 || rateBps <= 0 {
+		return 0, nil
+	}
+
+	// balance * rate / 10000 / 365 * 1000000, in exact integer arithmetic.
+	micros := new(big.Int).Mul(big.NewInt(balance), big.NewInt(int64(rateBps)))
+	micros.Mul(micros, big.NewInt(InterestMicrosPerUnit/10000))
+	micros.Quo(micros, big.NewInt(daysPerYear))
+	if !micros.IsInt64() {
+		return 0, ErrInterestOverflow
+	}
+	return micros.Int64(), nil
+}
+
+// SplitInterestMicros splits accrued micros into the whole minor units to
+// credit and the remainder to carry to the next posting.
+func SplitInterestMicros(micros int64) (amount int64, carry int64) {
+	return micros / InterestMicrosPerUnit, micros % InterestMicrosPerUnit
+}
@@ -0,0 +1,54 @@
+package util
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDailyInterestMicros(t *testing.T) {
+	testCases := []struct {
+		name    string
+		balance int64
+		rateBps int32
+		micros  int64
+	}{
+		// 100000 * 2% / 365 = 5.479452054... units
+		{"RoundsDown", 100000, 200, 5479452},
+		{"Exact", 36500, 1000, 10000000},
+		{"SmallBalance", 1, 1, 0},
+		{"Zero", 0, 200, 0},
+		{"Negative", -100000, 200, 0},
+		{"NoRate", 100000, 0, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			micros, err := DailyInterestMicros(tc.balance, tc.rateBps)
+			require.NoError(t, err)
+			require.Equal(t, tc.micros, micros)
+		})
+	}
+}
+
+func TestDailyInterestMicrosOverflow(t *testing.T) {
+	micros, err := DailyInterestMicros(math.MaxInt64, 10000)
+	require.ErrorIs(t, err, ErrInterestOverflow)
+	require.Zero(t, micros)
+
+	// The largest balance that still fits at 100%.
+	balance := int64(math.MaxInt64 / InterestMicrosPerUnit * daysPerYear)
+	_, err = DailyInterestMicros(balance, 10000)
+	require.NoError(t, err)
+}
+
+func TestSplitInterestMicros(t *testing.T) {
+	amount, carry := SplitInterestMicros(5479452 * 30)
+	require.Equal(t, int64(164), amount)
+	require.Equal(t, int64(383560), carry)
+
+	amount, carry = SplitInterestMicros(999999)
+	require.Zero(t, amount)
+	require.Equal(t, int64(999999), carry)
+}
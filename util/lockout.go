@@ -0,0 +1,55 @@
+package util
+
+import (
+	"math"
+	"time"
+)
+
+// maxLockoutLevels bounds LockoutDurations when maxDuration is unset.
+const maxLockoutLevels = 32
+
+// LockoutDuration returns how long a login stays locked after the given number
+// of consecutive failures. The first lockout happens at maxAttempts and every
+// further failure doubles it, up to maxDuration. A zero maxDuration means no
+// cap.
+func LockoutDuration(failedAttempts int32, maxAttempts int32, duration time.Duration, maxDuration time.Duration) time.Duration {
+	if maxAttempts <= 0 || failedAttempts < maxAttempts {
+		return 0
+	}
+
+	lockout := duration
+	for i := maxAttempts; i < failedAttempts; i++ {
+		if lockout > math.MaxInt64/2 {
+			lockout = math.MaxInt64
+			break
+		}
+		lockout *= 2
+		if maxDuration > 0 && lockout >= maxDuration {
+			return maxDuration
+		}
+	}
+
+	if maxDuration > 0 && lockout > maxDuration {
+		return maxDuration
+	}
+	return lockout
+}
+
+// LockoutDurations lists the lockout after maxAttempts failures, then after
+// each further failure, until it stops growing. The last one applies to every
+// later failure.
+func LockoutDurations(maxAttempts int32, duration time.Duration, maxDuration time.Duration) []time.Duration {
+	if maxAttempts <= 0 {
+		return nil
+	}
+
+	lockouts := []time.Duration{}
+	for i := int32(0); i < maxLockoutLevels; i++ {
+		lockout := LockoutDuration(maxAttempts+i, maxAttempts, duration, maxDuration)
+		if i > 0 && lockout == lockouts[i-1] {
+			break
+		}
+		lockouts = append(lockouts, lockout)
+	}
+	return lockouts
+}
@@ -0,0 +1,52 @@
+package util
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	testCases := []struct {
+		name           string
+		failedAttempts int32
+		maxAttempts    int32
+		maxDuration    time.Duration
+		lockout        time.Duration
+	}{
+		{"BelowMax", 2, 3, time.Hour, 0},
+		{"AtMax", 3, 3, time.Hour, time.Minute},
+		{"Doubles", 5, 3, time.Hour, 4 * time.Minute},
+		{"Capped", 20, 3, time.Hour, time.Hour},
+		{"NoCap", 10, 3, 0, 128 * time.Minute},
+		{"NoCapSaturates", 100, 3, 0, math.MaxInt64},
+		{"Disabled", 100, 0, time.Hour, 0},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			lockout := LockoutDuration(tc.failedAttempts, tc.maxAttempts, time.Minute, tc.maxDuration)
+			require.Equal(t, tc.lockout, lockout)
+		})
+	}
+}
+
+func TestLockoutDurations(t *testing.T) {
+	require.Equal(t, []time.Duration{
+		time.Minute,
+		2 * time.Minute,
+		4 * time.Minute,
+		5 * time.Minute,
+	}, LockoutDurations(3, time.Minute, 5*time.Minute))
+
+	lockouts := LockoutDurations(3, time.Minute, 0)
+	require.LessOrEqual(t, len(lockouts), maxLockoutLevels)
+	require.Equal(t, time.Minute, lockouts[0])
+	require.Equal(t, time.Duration(math.MaxInt64), lockouts[len(lockouts)-1])
+
+	require.Nil(t, LockoutDurations(0, time.Minute, time.Hour))
+}
@@ -0,0 +1,28 @@
+package util
+
+import (
+	"os"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const DevelopmentEnvironment = "development"
+
+func SetupLogger(config Config) error {
+	if config.Environment == DevelopmentEnvironment {
+		log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+	}
+
+	level := zerolog.InfoLevel
+	if config.LogLevel != "" {
+		parsed, err := zerolog.ParseLevel(config.LogLevel)
+		if err != nil {
+			return err
+		}
+		level = parsed
+	}
+
+	zerolog.SetGlobalLevel(level)
+	return nil
+}
@@ -0,0 +1,16 @@
+package util
+
+import "strings"
+
+// MaskName keeps the first letter of every word in name and replaces the
+// rest with asterisks, e.g. "Alice Johnson" becomes "A**** J******". It is
+// enough for a sender to recognise the recipient without disclosing the
+// full name to anyone who knows their username.
+func MaskName(name string) string {
+	words := strings.Fields(name)
+	for i, word := range words {
+		runes := []rune(word)
+		words[i] = string(runes[0]) + strings.Repeat("*", len(runes)-1)
+	}
+	return strings.Join(words, " ")
+}
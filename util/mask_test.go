@@ -0,0 +1,24 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaskName(t *testing.T) {
+	testCases := []struct {
+		name   string
+		masked string
+	}{
+		{"Alice Johnson", "A**** J******"},
+		{"  Bob  ", "B**"},
+		{"Zoë", "Z**"},
+		{"X", "X"},
+		{"", ""},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.masked, MaskName(tc.name))
+	}
+}
@@ -0,0 +1,134 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrCurrencyMismatch    = errors.New("currency mismatch")
+	ErrMoneyOverflow       = errors.New("amount is out of range")
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+)
+
+var decimalRegexp = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// CurrencyMinorUnits returns the number of decimal places of currency.
+func CurrencyMinorUnits(currency string) (int, bool) {
+	c, ok := LookupCurrency(currency)
+	return c.MinorUnits, ok
+}
+
+// Money is an exact amount of a currency, counted in its minor unit. It is
+// stored as an integer and only becomes a decimal when encoded.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney parses a decimal such as "12.34" into the minor units of
+// currency. It rejects more decimal places than the currency has rather than
+// rounding them away.
+func ParseMoney(value string, currency string) (Money, error) {
+	units, ok := CurrencyMinorUnits(currency)
+	if !ok {
+		return Money{}, fmt.Errorf("%w %s", ErrUnsupportedCurrency, currency)
+	}
+
+	if !decimalRegexp.MatchString(value) {
+		return Money{}, fmt.Errorf("invalid amount %q", value)
+	}
+
+	whole, fraction := value, ""
+	if i := strings.IndexByte(value, '.'); i >= 0 {
+		whole, fraction = value[:i], value[i+1:]
+	}
+	if len(fraction) > units {
+		return Money{}, fmt.Errorf("invalid amount %q : %s has %d decimal places", value, currency, units)
+	}
+	fraction += strings.Repeat("0", units-len(fraction))
+
+	amount, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q : %w", value, ErrMoneyOverflow)
+	}
+	return NewMoney(amount, currency), nil
+}
+
+// AddAmounts returns a + b, or ErrMoneyOverflow when it does not fit.
+func AddAmounts(a, b int64) (int64, error) {
+	if (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b) {
+		return 0, ErrMoneyOverflow
+	}
+	return a + b, nil
+}
+
+// SubAmounts returns a - b, or ErrMoneyOverflow when it does not fit.
+func SubAmounts(a, b int64) (int64, error) {
+	if (b < 0 && a > math.MaxInt64+b) || (b > 0 && a < math.MinInt64+b) {
+		return 0, ErrMoneyOverflow
+	}
+	return a - b, nil
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+
+	amount, err := AddAmounts(m.Amount, other.Amount)
+	if err != nil {
+		return Money{}, err
+	}
+	return NewMoney(amount, m.Currency), nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+
+	amount, err := SubAmounts(m.Amount, other.Amount)
+	if err != nil {
+		return Money{}, err
+	}
+	return NewMoney(amount, m.Currency), nil
+}
+
+// String formats m as a decimal with the minor units of its currency, such
+// as "12.34" for 1234 USD cents. An unknown currency is formatted without
+// decimal places.
+func (m Money) String() string {
+	units, _ := CurrencyMinorUnits(m.Currency)
+
+	sign := ""
+	abs := uint64(m.Amount)
+	if m.Amount < 0 {
+		sign = "-"
+		abs = uint64(-(m.Amount + 1)) + 1
+	}
+
+	digits := strconv.FormatUint(abs, 10)
+	if units == 0 {
+		return sign + digits
+	}
+
+	if len(digits) <= units {
+		digits = strings.Repeat("0", units-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-units] + "." + digits[len(digits)-units:]
+}
+
+// MarshalJSON encodes m as a decimal string, so clients never lose
+// precision to floating point.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(m.String())), nil
+}
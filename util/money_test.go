@@ -0,0 +1,82 @@
+package util
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMoney(t *testing.T) {
+	testCases := []struct {
+		value    string
+		currency string
+		amount   int64
+		ok       bool
+	}{
+		{"12.34", USD, 1234, true},
+		{"12.3", USD, 1230, true},
+		{"12", USD, 1200, true},
+		{"0.05", EUR, 5, true},
+		{"-0.05", EUR, -5, true},
+		{"15000", IDR, 15000, true},
+		{"92233720368547758.07", USD, math.MaxInt64, true},
+		{"92233720368547758.08", USD, 0, false},
+		{"12.345", USD, 0, false},
+		{"10.5", IDR, 0, false},
+		{"1e3", USD, 0, false},
+		{".5", USD, 0, false},
+		{"5.", USD, 0, false},
+		{"", USD, 0, false},
+		{"1", "XYZ", 0, false},
+	}
+
+	for _, tc := range testCases {
+		money, err := ParseMoney(tc.value, tc.currency)
+		if !tc.ok {
+			require.Error(t, err, tc.value)
+			continue
+		}
+		require.NoError(t, err, tc.value)
+		require.Equal(t, NewMoney(tc.amount, tc.currency), money)
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	require.Equal(t, "12.34", NewMoney(1234, USD).String())
+	require.Equal(t, "0.05", NewMoney(5, USD).String())
+	require.Equal(t, "-0.05", NewMoney(-5, EUR).String())
+	require.Equal(t, "0.00", NewMoney(0, EUR).String())
+	require.Equal(t, "15000", NewMoney(15000, IDR).String())
+	require.Equal(t, "1234", NewMoney(1234, "XYZ").String())
+	require.Equal(t, "-92233720368547758.08", NewMoney(math.MinInt64, USD).String())
+
+	data, err := json.Marshal(struct {
+		Balance Money `json:"balance"`
+	}{NewMoney(1234, USD)})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"balance":"12.34"}`, string(data))
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	sum, err := NewMoney(150, USD).Add(NewMoney(75, USD))
+	require.NoError(t, err)
+	require.Equal(t, NewMoney(225, USD), sum)
+
+	diff, err := NewMoney(150, USD).Sub(NewMoney(175, USD))
+	require.NoError(t, err)
+	require.Equal(t, NewMoney(-25, USD), diff)
+
+	_, err = NewMoney(150, USD).Add(NewMoney(75, EUR))
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	_, err = NewMoney(math.MaxInt64, USD).Add(NewMoney(1, USD))
+	require.ErrorIs(t, err, ErrMoneyOverflow)
+
+	_, err = NewMoney(math.MinInt64, USD).Sub(NewMoney(1, USD))
+	require.ErrorIs(t, err, ErrMoneyOverflow)
+
+	_, err = NewMoney(0, USD).Sub(NewMoney(math.MinInt64, USD))
+	require.ErrorIs(t, err, ErrMoneyOverflow)
+}
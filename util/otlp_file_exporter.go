@@ -0,0 +1,288 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// otlpFileExporter writes spans in the OTLP file format: one OTLP/JSON
+// TracesData message per line, as read by the collector's otlpjsonfile
+// receiver.
+type otlpFileExporter struct {
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+func newOTLPFileExporter(writer io.Writer) *otlpFileExporter {
+	return &otlpFileExporter{writer: writer}
+}
+
+type otlpTracesData struct {
+	ResourceSpans []*otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource      `json:"resource"`
+	ScopeSpans []*otlpScopeSpans `json:"scopeSpans"`
+	SchemaURL  string            `json:"schemaUrl,omitempty"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope     otlpScope  `json:"scope"`
+	Spans     []otlpSpan `json:"spans"`
+	SchemaURL string     `json:"schemaUrl,omitempty"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID                string         `json:"traceId"`
+	SpanID                 string         `json:"spanId"`
+	TraceState             string         `json:"traceState,omitempty"`
+	ParentSpanID           string         `json:"parentSpanId,omitempty"`
+	Name                   string         `json:"name"`
+	Kind                   int            `json:"kind"`
+	StartTimeUnixNano      string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano        string         `json:"endTimeUnixNano"`
+	Attributes             []otlpKeyValue `json:"attributes"`
+	DroppedAttributesCount int            `json:"droppedAttributesCount,omitempty"`
+	Events                 []otlpEvent    `json:"events"`
+	DroppedEventsCount     int            `json:"droppedEventsCount,omitempty"`
+	Links                  []otlpLink     `json:"links"`
+	DroppedLinksCount      int            `json:"droppedLinksCount,omitempty"`
+	Status                 otlpStatus     `json:"status"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano           string         `json:"timeUnixNano"`
+	Name                   string         `json:"name"`
+	Attributes             []otlpKeyValue `json:"attributes"`
+	DroppedAttributesCount int            `json:"droppedAttributesCount,omitempty"`
+}
+
+type otlpLink struct {
+	TraceID                string         `json:"traceId"`
+	SpanID                 string         `json:"spanId"`
+	TraceState             string         `json:"traceState,omitempty"`
+	Attributes             []otlpKeyValue `json:"attributes"`
+	DroppedAttributesCount int            `json:"droppedAttributesCount,omitempty"`
+}
+
+// OTLP status codes, which differ from the values of codes.Code.
+const (
+	otlpStatusUnset = 0
+	otlpStatusOK    = 1
+	otlpStatusError = 2
+)
+
+type otlpStatus struct {
+	Message string `json:"message,omitempty"`
+	Code    int    `json:"code"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+// otlpAnyValue sets exactly one field. 64-bit integers are strings in
+// OTLP/JSON.
+type otlpAnyValue struct {
+	StringValue *string         `json:"stringValue,omitempty"`
+	BoolValue   *bool           `json:"boolValue,omitempty"`
+	IntValue    *string         `json:"intValue,omitempty"`
+	DoubleValue *float64        `json:"doubleValue,omitempty"`
+	ArrayValue  *otlpArrayValue `json:"arrayValue,omitempty"`
+}
+
+type otlpArrayValue struct {
+	Values []otlpAnyValue `json:"values"`
+}
+
+// ExportSpans writes spans as a single line, grouped by resource and
+// instrumentation library.
+func (exporter *otlpFileExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	var data otlpTracesData
+	resources := make(map[attribute.Distinct]*otlpResourceSpans)
+	scopes := make(map[attribute.Distinct]map[instrumentation.Library]*otlpScopeSpans)
+
+	for _, span := range spans {
+		var key attribute.Distinct
+		if span.Resource() != nil {
+			key = span.Resource().Equivalent()
+		}
+
+		resourceSpans, ok := resources[key]
+		if !ok {
+			resourceSpans = &otlpResourceSpans{Resource: otlpResource{Attributes: []otlpKeyValue{}}}
+			if span.Resource() != nil {
+				resourceSpans.Resource.Attributes = otlpAttributes(span.Resource().Attributes())
+				resourceSpans.SchemaURL = span.Resource().SchemaURL()
+			}
+			resources[key] = resourceSpans
+			scopes[key] = make(map[instrumentation.Library]*otlpScopeSpans)
+			data.ResourceSpans = append(data.ResourceSpans, resourceSpans)
+		}
+
+		library := span.InstrumentationLibrary()
+		scopeSpans, ok := scopes[key][library]
+		if !ok {
+			scopeSpans = &otlpScopeSpans{
+				Scope:     otlpScope{Name: library.Name, Version: library.Version},
+				Spans:     []otlpSpan{},
+				SchemaURL: library.SchemaURL,
+			}
+			scopes[key][library] = scopeSpans
+			resourceSpans.ScopeSpans = append(resourceSpans.ScopeSpans, scopeSpans)
+		}
+
+		scopeSpans.Spans = append(scopeSpans.Spans, newOTLPSpan(span))
+	}
+
+	line, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	exporter.mu.Lock()
+	defer exporter.mu.Unlock()
+
+	_, err = exporter.writer.Write(append(line, '\n'))
+	return err
+}
+
+func (exporter *otlpFileExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+func newOTLPSpan(span sdktrace.ReadOnlySpan) otlpSpan {
+	spanContext := span.SpanContext()
+	rsp := otlpSpan{
+		TraceID:                spanContext.TraceID().String(),
+		SpanID:                 spanContext.SpanID().String(),
+		TraceState:             spanContext.TraceState().String(),
+		Name:                   span.Name(),
+		Kind:                   int(span.SpanKind()),
+		StartTimeUnixNano:      otlpTime(span.StartTime()),
+		EndTimeUnixNano:        otlpTime(span.EndTime()),
+		Attributes:             otlpAttributes(span.Attributes()),
+		DroppedAttributesCount: span.DroppedAttributes(),
+		Events:                 []otlpEvent{},
+		DroppedEventsCount:     span.DroppedEvents(),
+		Links:                  []otlpLink{},
+		DroppedLinksCount:      span.DroppedLinks(),
+		Status:                 otlpStatus{Code: otlpStatusUnset},
+	}
+
+	if span.Parent().HasSpanID() {
+		rsp.ParentSpanID = span.Parent().SpanID().String()
+	}
+
+	for _, event := range span.Events() {
+		rsp.Events = append(rsp.Events, otlpEvent{
+			TimeUnixNano:           otlpTime(event.Time),
+			Name:                   event.Name,
+			Attributes:             otlpAttributes(event.Attributes),
+			DroppedAttributesCount: event.DroppedAttributeCount,
+		})
+	}
+
+	for _, link := range span.Links() {
+		rsp.Links = append(rsp.Links, otlpLink{
+			TraceID:                link.SpanContext.TraceID().String(),
+			SpanID:                 link.SpanContext.SpanID().String(),
+			TraceState:             link.SpanContext.TraceState().String(),
+			Attributes:             otlpAttributes(link.Attributes),
+			DroppedAttributesCount: link.DroppedAttributeCount,
+		})
+	}
+
+	switch span.Status().Code {
+	case codes.Ok:
+		rsp.Status.Code = otlpStatusOK
+	case codes.Error:
+		rsp.Status.Code = otlpStatusError
+		rsp.Status.Message = span.Status().Description
+	}
+
+	return rsp
+}
+
+func otlpTime(t time.Time) string {
+	if t.IsZero() {
+		return "0"
+	}
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func otlpAttributes(attrs []attribute.KeyValue) []otlpKeyValue {
+	values := make([]otlpKeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		values = append(values, otlpKeyValue{
+			Key:   string(attr.Key),
+			Value: otlpValue(attr.Value),
+		})
+	}
+	return values
+}
+
+func otlpValue(value attribute.Value) otlpAnyValue {
+	switch value.Type() {
+	case attribute.BOOL:
+		v := value.AsBool()
+		return otlpAnyValue{BoolValue: &v}
+	case attribute.INT64:
+		v := strconv.FormatInt(value.AsInt64(), 10)
+		return otlpAnyValue{IntValue: &v}
+	case attribute.FLOAT64:
+		v := value.AsFloat64()
+		return otlpAnyValue{DoubleValue: &v}
+	case attribute.BOOLSLICE:
+		array := &otlpArrayValue{Values: []otlpAnyValue{}}
+		for _, b := range value.AsBoolSlice() {
+			array.Values = append(array.Values, otlpValue(attribute.BoolValue(b)))
+		}
+		return otlpAnyValue{ArrayValue: array}
+	case attribute.INT64SLICE:
+		array := &otlpArrayValue{Values: []otlpAnyValue{}}
+		for _, i := range value.AsInt64Slice() {
+			array.Values = append(array.Values, otlpValue(attribute.Int64Value(i)))
+		}
+		return otlpAnyValue{ArrayValue: array}
+	case attribute.FLOAT64SLICE:
+		array := &otlpArrayValue{Values: []otlpAnyValue{}}
+		for _, f := range value.AsFloat64Slice() {
+			array.Values = append(array.Values, otlpValue(attribute.Float64Value(f)))
+		}
+		return otlpAnyValue{ArrayValue: array}
+	case attribute.STRINGSLICE:
+		array := &otlpArrayValue{Values: []otlpAnyValue{}}
+		for _, s := range value.AsStringSlice() {
+			array.Values = append(array.Values, otlpValue(attribute.StringValue(s)))
+		}
+		return otlpAnyValue{ArrayValue: array}
+	default:
+		v := value.Emit()
+		return otlpAnyValue{StringValue: &v}
+	}
+}
@@ -2,10 +2,16 @@ package util
 
 import (
 	"fmt"
+	"sync"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
 func HashedPassword(password string) (string, error) {
 	hashPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
@@ -18,3 +24,12 @@ func HashedPassword(password string) (string, error) {
 func CheckPassword(password string, hashPassword string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashPassword), []byte(password))
 }
+
+// CheckDummyPassword takes as long as CheckPassword, so that a login for an
+// unknown user cannot be told apart from a wrong password by timing.
+func CheckDummyPassword(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte(RandomString(32)), bcrypt.DefaultCost)
+	})
+	bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
@@ -0,0 +1,123 @@
+package util
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+const defaultPasswordMinLength = 6
+
+var (
+	ErrPasswordTooShort      = errors.New("password is too short")
+	ErrPasswordMissingUpper  = errors.New("password must contain an upper case letter")
+	ErrPasswordMissingLower  = errors.New("password must contain a lower case letter")
+	ErrPasswordMissingDigit  = errors.New("password must contain a digit")
+	ErrPasswordMissingSymbol = errors.New("password must contain a symbol")
+	ErrPasswordPersonalInfo  = errors.New("password must not contain the username or email")
+	ErrPasswordBreached      = errors.New("password appears in a list of breached passwords")
+)
+
+type PasswordPolicy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	breached      map[string]struct{}
+}
+
+func NewPasswordPolicy(config Config) (*PasswordPolicy, error) {
+	policy := &PasswordPolicy{
+		MinLength:     config.PasswordMinLength,
+		RequireUpper:  config.PasswordRequireUpper,
+		RequireLower:  config.PasswordRequireLower,
+		RequireDigit:  config.PasswordRequireDigit,
+		RequireSymbol: config.PasswordRequireSymbol,
+	}
+
+	if policy.MinLength < defaultPasswordMinLength {
+		policy.MinLength = defaultPasswordMinLength
+	}
+
+	if config.PasswordBreachedListFile != "" {
+		breached, err := loadBreachedPasswords(config.PasswordBreachedListFile)
+		if err != nil {
+			return nil, err
+		}
+		policy.breached = breached
+	}
+
+	return policy, nil
+}
+
+// loadBreachedPasswords reads a file with one password per line. Passwords
+// are compared case-insensitively.
+func loadBreachedPasswords(path string) (map[string]struct{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open breached password list %s", err)
+	}
+	defer file.Close()
+
+	breached := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		password := strings.TrimSpace(scanner.Text())
+		if password != "" {
+			breached[strings.ToLower(password)] = struct{}{}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read breached password list %s", err)
+	}
+	return breached, nil
+}
+
+func (policy *PasswordPolicy) Validate(password string, username string, email string) error {
+	if len([]rune(password)) < policy.MinLength {
+		return ErrPasswordTooShort
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	switch {
+	case policy.RequireUpper && !hasUpper:
+		return ErrPasswordMissingUpper
+	case policy.RequireLower && !hasLower:
+		return ErrPasswordMissingLower
+	case policy.RequireDigit && !hasDigit:
+		return ErrPasswordMissingDigit
+	case policy.RequireSymbol && !hasSymbol:
+		return ErrPasswordMissingSymbol
+	}
+
+	lower := strings.ToLower(password)
+	localPart := strings.SplitN(email, "@", 2)[0]
+	for _, info := range []string{username, email, localPart} {
+		if len(info) >= 3 && strings.Contains(lower, strings.ToLower(info)) {
+			return ErrPasswordPersonalInfo
+		}
+	}
+
+	if _, ok := policy.breached[lower]; ok {
+		return ErrPasswordBreached
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	file, err := ioutil.TempFile("", "breached")
+	require.NoError(t, err)
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString("Password123!\nqwerty\n")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	policy, err := NewPasswordPolicy(Config{
+		PasswordMinLength:        8,
+		PasswordRequireUpper:     true,
+		PasswordRequireLower:     true,
+		PasswordRequireDigit:     true,
+		PasswordRequireSymbol:    true,
+		PasswordBreachedListFile: file.Name(),
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		password string
+		err      error
+	}{
+		{"OK", "Tr0ub4dor&3x", nil},
+		{"TooShort", "Ab1!", ErrPasswordTooShort},
+		{"MissingUpper", "tr0ub4dor&3x", ErrPasswordMissingUpper},
+		{"MissingLower", "TR0UB4DOR&3X", ErrPasswordMissingLower},
+		{"MissingDigit", "Troubador&xx", ErrPasswordMissingDigit},
+		{"MissingSymbol", "Tr0ub4dor3xx", ErrPasswordMissingSymbol},
+		{"Username", "Alice-Tr0ub4dor", ErrPasswordPersonalInfo},
+		{"EmailLocalPart", "Xy1!wonderland", ErrPasswordPersonalInfo},
+		{"Breached", "Password123!", ErrPasswordBreached},
+		{"BreachedCaseInsensitive", "pASSWORD123!", ErrPasswordBreached},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := policy.Validate(tc.password, "alice", "wonderland@gmail.com")
+			require.Equal(t, tc.err, err)
+		})
+	}
+}
+
+func TestPasswordPolicyDefaultMinLength(t *testing.T) {
+	policy, err := NewPasswordPolicy(Config{})
+	require.NoError(t, err)
+	require.Equal(t, defaultPasswordMinLength, policy.MinLength)
+	require.NoError(t, policy.Validate("secret", "alice", "alice@gmail.com"))
+}
@@ -0,0 +1,24 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyPKCE(t *testing.T) {
+	verifier := "dBjftJeZ4CVP-mJ0-W0e1e5aMZePz6Lhhr2RMKLKHJ8"
+	challenge := "WJJyHH3tOq3JkD1usOpeIAyssvK4U-gaQhKel8uv7jo"
+
+	require.Equal(t, challenge, PKCEChallenge(verifier))
+	require.True(t, VerifyPKCE(verifier, challenge))
+	require.False(t, VerifyPKCE(verifier+"x", challenge))
+	require.False(t, VerifyPKCE(verifier, verifier))
+
+	short := "abc"
+	require.False(t, VerifyPKCE(short, PKCEChallenge(short)))
+
+	invalid := strings.Repeat("a", 42) + "/"
+	require.False(t, VerifyPKCE(invalid, PKCEChallenge(invalid)))
+}
@@ -38,7 +38,7 @@ func RandomName() string {
 }
 
 func RandomCurrency() string {
-	currency := []string{USD, IDR, EUR}
+	currency := EnabledCurrencies()
 	k := len(currency)
 	return currency[rand.Intn(k)]
 }
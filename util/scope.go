@@ -0,0 +1,16 @@
+package util
+
+// Scopes limit what an API key may do on behalf of its owner.
+const (
+	AccountsReadScope   = "accounts:read"
+	AccountsWriteScope  = "accounts:write"
+	TransfersWriteScope = "transfers:write"
+)
+
+func IsSupportedScope(scope string) bool {
+	switch scope {
+	case AccountsReadScope, AccountsWriteScope, TransfersWriteScope:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// RandomSecureToken returns n bytes from crypto/rand encoded as URL safe
+// base64, suitable for one-time tokens sent to users.
+func RandomSecureToken(n int) (string, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("cannot generate secure token %s", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashSecureToken returns the hex encoded SHA-256 of token. Only the hash is
+// stored, so a leaked database cannot be used to redeem tokens.
+func HashSecureToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// SignHMAC returns the URL safe HMAC-SHA256 of parts under key. The parts are
+// joined with a separator that cannot appear in usernames or emails.
+func SignHMAC(key string, parts ...string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(strings.Join(parts, "\n")))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func VerifyHMAC(key string, signature string, parts ...string) bool {
+	expected := SignHMAC(key, parts...)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
@@ -0,0 +1,72 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+const (
+	ServiceName = "simple_bank"
+
+	TracingExporterNone   = "none"
+	TracingExporterStdout = "stdout"
+	TracingExporterFile   = "file"
+)
+
+// SetupTracing installs the global tracer provider selected by
+// config.TracingExporter: "stdout" pretty-prints spans and "file" appends
+// them to config.TracingFilePath in the OTLP file format, so they can be
+// inspected locally or replayed into a collector later. Spans are exported
+// synchronously so nothing is lost when the server is killed; the returned
+// function releases the exporter.
+func SetupTracing(config Config) (func(context.Context) error, error) {
+	var exporter sdktrace.SpanExporter
+	var file *os.File
+	var err error
+
+	switch config.TracingExporter {
+	case "", TracingExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case TracingExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, err
+		}
+	case TracingExporterFile:
+		file, err = os.OpenFile(config.TracingFilePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open tracing file %s", err)
+		}
+		exporter = newOTLPFileExporter(file)
+	default:
+		return nil, fmt.Errorf("unsupported tracing exporter %s", config.TracingExporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String(ServiceName),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+
+	shutdown := func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if file != nil {
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}
+
+	return shutdown, nil
+}
@@ -0,0 +1,104 @@
+package util
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSetupTracingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+
+	shutdown, err := SetupTracing(Config{
+		TracingExporter: TracingExporterFile,
+		TracingFilePath: path,
+	})
+	require.NoError(t, err)
+
+	tracer := otel.Tracer("test")
+	ctx, parent := tracer.Start(context.Background(), "parent", trace.WithSpanKind(trace.SpanKindServer))
+	_, child := tracer.Start(ctx, "child", trace.WithAttributes(
+		attribute.Int64("rows", 3),
+		attribute.String("statement", "SELECT 1"),
+	))
+	child.RecordError(errors.New("boom"))
+	child.SetStatus(codes.Error, "boom")
+	child.End()
+	parent.End()
+
+	require.NoError(t, shutdown(context.Background()))
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	// Spans are exported synchronously, one line per span.
+	var spans []map[string]interface{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var data map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &data))
+
+		resourceSpans := data["resourceSpans"].([]interface{})
+		require.Len(t, resourceSpans, 1)
+		resource := resourceSpans[0].(map[string]interface{})["resource"].(map[string]interface{})
+		require.Contains(t, resource["attributes"], map[string]interface{}{
+			"key":   "service.name",
+			"value": map[string]interface{}{"stringValue": ServiceName},
+		})
+
+		scopeSpans := resourceSpans[0].(map[string]interface{})["scopeSpans"].([]interface{})
+		require.Len(t, scopeSpans, 1)
+		scope := scopeSpans[0].(map[string]interface{})
+		require.Equal(t, "test", scope["scope"].(map[string]interface{})["name"])
+		for _, span := range scope["spans"].([]interface{}) {
+			spans = append(spans, span.(map[string]interface{}))
+		}
+	}
+	require.NoError(t, scanner.Err())
+	require.Len(t, spans, 2)
+
+	childSpan, parentSpan := spans[0], spans[1]
+	require.Equal(t, "child", childSpan["name"])
+	require.Equal(t, "parent", parentSpan["name"])
+	require.Len(t, childSpan["traceId"], 32)
+	require.Len(t, childSpan["spanId"], 16)
+	require.Equal(t, parentSpan["traceId"], childSpan["traceId"])
+	require.Equal(t, parentSpan["spanId"], childSpan["parentSpanId"])
+	require.NotContains(t, parentSpan, "parentSpanId")
+
+	require.Equal(t, float64(trace.SpanKindServer), parentSpan["kind"])
+	require.Equal(t, map[string]interface{}{"code": float64(otlpStatusError), "message": "boom"}, childSpan["status"])
+	require.Equal(t, map[string]interface{}{"code": float64(otlpStatusUnset)}, parentSpan["status"])
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"key": "rows", "value": map[string]interface{}{"intValue": "3"}},
+		map[string]interface{}{"key": "statement", "value": map[string]interface{}{"stringValue": "SELECT 1"}},
+	}, childSpan["attributes"])
+	require.Len(t, childSpan["events"], 1)
+	require.IsType(t, "", childSpan["startTimeUnixNano"])
+}
+
+func TestSetupTracingExporters(t *testing.T) {
+	shutdown, err := SetupTracing(Config{TracingExporter: TracingExporterNone})
+	require.NoError(t, err)
+	require.NoError(t, shutdown(context.Background()))
+
+	_, err = SetupTracing(Config{TracingExporter: "zipkin"})
+	require.Error(t, err)
+
+	_, err = SetupTracing(Config{
+		TracingExporter: TracingExporterFile,
+		TracingFilePath: filepath.Join(t.TempDir(), "missing", "traces.jsonl"),
+	})
+	require.Error(t, err)
+}
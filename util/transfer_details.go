@@ -0,0 +1,92 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	MaxTransferDescriptionLength = 140
+	MaxTransferReferenceLength   = 35
+	MaxTransferMetadataSize      = 2048
+	MaxTransferMetadataKeys      = 20
+	maxTransferMetadataKeyLength = 40
+)
+
+// IsValidTransferDescription accepts free text of up to 140 characters
+// without control characters, so memos render on a single statement line.
+func IsValidTransferDescription(description string) bool {
+	if !utf8.ValidString(description) || utf8.RuneCountInString(description) > MaxTransferDescriptionLength {
+		return false
+	}
+
+	for _, r := range description {
+		if unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsValidTransferReference accepts up to 35 letters, digits and -_/.: like
+// an ISO 20022 end-to-end identification.
+func IsValidTransferReference(reference string) bool {
+	if len(reference) > MaxTransferReferenceLength {
+		return false
+	}
+
+	for _, r := range reference {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '/', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeTransferMetadata checks that metadata is a flat JSON object of at
+// most 20 keys with string, number or boolean values and returns it
+// compacted. Empty or null metadata is returned as nil.
+func NormalizeTransferMetadata(metadata json.RawMessage) (json.RawMessage, error) {
+	metadata = bytes.TrimSpace(metadata)
+	if len(metadata) == 0 || bytes.Equal(metadata, []byte("null")) {
+		return nil, nil
+	}
+
+	if len(metadata) > MaxTransferMetadataSize {
+		return nil, fmt.Errorf("metadata must not exceed %d bytes", MaxTransferMetadataSize)
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(metadata, &fields); err != nil {
+		return nil, errors.New("metadata must be a JSON object")
+	}
+
+	if len(fields) > MaxTransferMetadataKeys {
+		return nil, fmt.Errorf("metadata must not have more than %d keys", MaxTransferMetadataKeys)
+	}
+
+	for key, value := range fields {
+		if key == "" || len(key) > maxTransferMetadataKeyLength {
+			return nil, fmt.Errorf("metadata keys must be 1 to %d characters", maxTransferMetadataKeyLength)
+		}
+
+		switch value.(type) {
+		case string, float64, bool:
+		default:
+			return nil, fmt.Errorf("metadata value of %s must be a string, number or boolean", key)
+		}
+	}
+
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, metadata); err != nil {
+		return nil, err
+	}
+	return compacted.Bytes(), nil
+}
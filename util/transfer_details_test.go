@@ -0,0 +1,67 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsValidTransferDescription(t *testing.T) {
+	require.True(t, IsValidTransferDescription(""))
+	require.True(t, IsValidTransferDescription("Rent for März 🏠"))
+	require.True(t, IsValidTransferDescription(strings.Repeat("ä", MaxTransferDescriptionLength)))
+	require.False(t, IsValidTransferDescription(strings.Repeat("a", MaxTransferDescriptionLength+1)))
+	require.False(t, IsValidTransferDescription("line\nbreak"))
+	require.False(t, IsValidTransferDescription("\xff"))
+}
+
+func TestIsValidTransferReference(t *testing.T) {
+	require.True(t, IsValidTransferReference(""))
+	require.True(t, IsValidTransferReference("INV-2021/10.001_a:b"))
+	require.False(t, IsValidTransferReference(strings.Repeat("a", MaxTransferReferenceLength+1)))
+	require.False(t, IsValidTransferReference("with space"))
+	require.False(t, IsValidTransferReference("100%"))
+}
+
+func TestNormalizeTransferMetadata(t *testing.T) {
+	manyKeys := make(map[string]int)
+	for i := 0; i <= MaxTransferMetadataKeys; i++ {
+		manyKeys[fmt.Sprintf("key%d", i)] = i
+	}
+	manyKeysJSON, err := json.Marshal(manyKeys)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name       string
+		metadata   string
+		normalized string
+		valid      bool
+	}{
+		{"Empty", "", "", true},
+		{"Null", "null", "", true},
+		{"Object", `{ "order": "A-1", "items": 3, "gift": true }`, `{"order":"A-1","items":3,"gift":true}`, true},
+		{"Array", `[1, 2]`, "", false},
+		{"String", `"memo"`, "", false},
+		{"Nested", `{"order": {"id": 1}}`, "", false},
+		{"NullValue", `{"order": null}`, "", false},
+		{"EmptyKey", `{"": 1}`, "", false},
+		{"TooManyKeys", string(manyKeysJSON), "", false},
+		{"TooLarge", fmt.Sprintf(`{"note": "%s"}`, strings.Repeat("a", MaxTransferMetadataSize)), "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			normalized, err := NormalizeTransferMetadata(json.RawMessage(tc.metadata))
+			if !tc.valid {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.normalized, string(normalized))
+		})
+	}
+}
@@ -8,3 +8,38 @@ var CurrencyValidator validator.Func = func(fl validator.FieldLevel) bool {
 	}
 	return false
 }
+
+var ScopeValidator validator.Func = func(fl validator.FieldLevel) bool {
+	if scope, ok := fl.Field().Interface().(string); ok {
+		return IsSupportedScope(scope)
+	}
+	return false
+}
+
+var AccountNumberValidator validator.Func = func(fl validator.FieldLevel) bool {
+	if number, ok := fl.Field().Interface().(string); ok {
+		return IsValidAccountNumber(number)
+	}
+	return false
+}
+
+var TransferDescriptionValidator validator.Func = func(fl validator.FieldLevel) bool {
+	if description, ok := fl.Field().Interface().(string); ok {
+		return IsValidTransferDescription(description)
+	}
+	return false
+}
+
+var TransferReferenceValidator validator.Func = func(fl validator.FieldLevel) bool {
+	if reference, ok := fl.Field().Interface().(string); ok {
+		return IsValidTransferReference(reference)
+	}
+	return false
+}
+
+var ExchangeRateValidator validator.Func = func(fl validator.FieldLevel) bool {
+	if rate, ok := fl.Field().Interface().(string); ok {
+		return IsValidExchangeRate(rate)
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	webhookSignaturePrefix = "v1="
+)
+
+var (
+	ErrWebhookSignature = errors.New("invalid webhook signature")
+	ErrWebhookAddress   = errors.New("webhook address is not public")
+)
+
+// reservedNetworks are special-purpose ranges net.IP does not classify: this
+// network, carrier-grade NAT, benchmarking, limited broadcast and the NAT64
+// prefix, which reaches IPv4 addresses through a translator.
+var reservedNetworks = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),
+	mustParseCIDR("100.64.0.0/10"),
+	mustParseCIDR("198.18.0.0/15"),
+	mustParseCIDR("255.255.255.255/32"),
+	mustParseCIDR("64:ff9b::/96"),
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
+// GenerateWebhookSecret returns a new signing secret for a webhook endpoint.
+func GenerateWebhookSecret() (string, error) {
+	token, err := RandomSecureToken(32)
+	if err != nil {
+		return "", err
+	}
+	return "whsec_" + token, nil
+}
+
+// SignWebhook returns the X-Webhook-Signature of body sent at timestamp: the
+// hex HMAC-SHA256 of "<timestamp>.<body>" under secret. Signing the
+// timestamp lets receivers reject replayed deliveries.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return webhookSignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook checks the signature and timestamp headers of a delivery, as
+// a receiver would, rejecting timestamps more than tolerance away from now.
+func VerifyWebhook(secret string, timestamp string, signature string, body []byte, tolerance time.Duration, now time.Time) error {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrWebhookSignature
+	}
+
+	age := now.Sub(time.Unix(unix, 0))
+	if age > tolerance || age < -tolerance {
+		return ErrWebhookSignature
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(SignWebhook(secret, unix, body))) {
+		return ErrWebhookSignature
+	}
+	return nil
+}
+
+// WebhookBackoff returns how long to wait before retrying a delivery after
+// its attempts-th failed attempt: base doubled for every earlier failure,
+// capped at max.
+func WebhookBackoff(attempts int32, base time.Duration, max time.Duration) time.Duration {
+	backoff := base
+	for i := int32(1); i < attempts && backoff < max; i++ {
+		backoff *= 2
+	}
+	if backoff > max {
+		backoff = max
+	}
+	return backoff
+}
+
+// IsPublicWebhookIP reports whether webhooks may be sent to ip: only global
+// unicast addresses outside the private and reserved ranges.
+func IsPublicWebhookIP(ip net.IP) bool {
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() || ip.IsMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, network := range reservedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// WebhookDialControl is the net.Dialer Control of webhook clients. It runs
+// on the resolved address of every connection, so a host name pointing, or
+// later rebound, to an internal address is refused as well.
+func WebhookDialControl(network string, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !IsPublicWebhookIP(ip) {
+		return fmt.Errorf("cannot connect to %s: %w", host, ErrWebhookAddress)
+	}
+	return nil
+}
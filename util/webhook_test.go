@@ -0,0 +1,92 @@
+package util
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignWebhook(t *testing.T) {
+	secret, err := GenerateWebhookSecret()
+	require.NoError(t, err)
+
+	now := time.Now()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	body := []byte(`{"id":1}`)
+	signature := SignWebhook(secret, now.Unix(), body)
+
+	require.NoError(t, VerifyWebhook(secret, timestamp, signature, body, time.Minute, now))
+	require.Equal(t, ErrWebhookSignature, VerifyWebhook(secret, timestamp, signature, []byte(`{"id":2}`), time.Minute, now))
+	require.Equal(t, ErrWebhookSignature, VerifyWebhook("whsec_other", timestamp, signature, body, time.Minute, now))
+	require.Equal(t, ErrWebhookSignature, VerifyWebhook(secret, timestamp, signature, body, time.Minute, now.Add(2*time.Minute)))
+	require.Equal(t, ErrWebhookSignature, VerifyWebhook(secret, "not a time", signature, body, time.Minute, now))
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	base := 30 * time.Second
+	max := time.Hour
+
+	require.Equal(t, base, WebhookBackoff(1, base, max))
+	require.Equal(t, 2*base, WebhookBackoff(2, base, max))
+	require.Equal(t, 8*base, WebhookBackoff(4, base, max))
+	require.Equal(t, max, WebhookBackoff(20, base, max))
+	require.Equal(t, max, WebhookBackoff(1000, base, max))
+}
+
+func TestIsPublicWebhookIP(t *testing.T) {
+	testCases := []struct {
+		ip     string
+		public bool
+	}{
+		{ip: "93.184.216.34", public: true},
+		{ip: "2606:2800:220:1:248:1893:25c8:1946", public: true},
+		{ip: "172.32.0.1", public: true},
+		{ip: "127.0.0.1", public: false},
+		{ip: "::1", public: false},
+		{ip: "10.1.2.3", public: false},
+		{ip: "172.16.0.1", public: false},
+		{ip: "192.168.1.1", public: false},
+		{ip: "fd00::1", public: false},
+		{ip: "169.254.169.254", public: false},
+		{ip: "fe80::1", public: false},
+		{ip: "0.0.0.0", public: false},
+		{ip: "::", public: false},
+		{ip: "::ffff:127.0.0.1", public: false},
+		{ip: "::ffff:10.0.0.1", public: false},
+		{ip: "100.64.0.1", public: false},
+		{ip: "100.127.255.254", public: false},
+		{ip: "100.128.0.1", public: true},
+		{ip: "0.1.2.3", public: false},
+		{ip: "198.18.0.1", public: false},
+		{ip: "198.19.255.254", public: false},
+		{ip: "198.20.0.1", public: true},
+		{ip: "255.255.255.255", public: false},
+		{ip: "64:ff9b::7f00:1", public: false},
+		{ip: "64:ff9b::5db8:d822", public: false},
+		{ip: "224.0.0.1", public: false},
+		{ip: "239.255.255.250", public: false},
+		{ip: "ff01::1", public: false},
+		{ip: "ff02::1", public: false},
+		{ip: "ff0e::1", public: false},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.ip, func(t *testing.T) {
+			ip := net.ParseIP(tc.ip)
+			require.NotNil(t, ip)
+			require.Equal(t, tc.public, IsPublicWebhookIP(ip))
+		})
+	}
+}
+
+func TestWebhookDialControl(t *testing.T) {
+	require.NoError(t, WebhookDialControl("tcp4", "93.184.216.34:443", nil))
+	require.ErrorIs(t, WebhookDialControl("tcp4", "127.0.0.1:8080", nil), ErrWebhookAddress)
+	require.ErrorIs(t, WebhookDialControl("tcp6", "[fe80::1]:443", nil), ErrWebhookAddress)
+	require.Error(t, WebhookDialControl("tcp4", "127.0.0.1", nil))
+}
@@ -0,0 +1,88 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	listenerMinReconnect = 10 * time.Second
+	listenerMaxReconnect = time.Minute
+	listenerPingInterval = 90 * time.Second
+)
+
+// AccountUpdatePublisher receives the account updates notified by any
+// instance.
+type AccountUpdatePublisher interface {
+	PublishAccountUpdate(update db.AccountUpdate)
+	// ResetAccountUpdates is called after the listener reconnects, since
+	// notifications sent while it was away are lost.
+	ResetAccountUpdates()
+}
+
+// AccountUpdateListener listens to db.AccountUpdatesChannel and forwards
+// the updates to the publisher.
+type AccountUpdateListener struct {
+	dbSource  string
+	publisher AccountUpdatePublisher
+}
+
+func NewAccountUpdateListener(config util.Config, publisher AccountUpdatePublisher) *AccountUpdateListener {
+	return &AccountUpdateListener{
+		dbSource:  config.DbSource,
+		publisher: publisher,
+	}
+}
+
+// Start listens until ctx is done, reconnecting whenever the connection is
+// lost.
+func (listener *AccountUpdateListener) Start(ctx context.Context) {
+	pqListener := pq.NewListener(listener.dbSource, listenerMinReconnect, listenerMaxReconnect, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Error().Err(err).Msg("account update listener connection failed")
+		}
+	})
+	defer pqListener.Close()
+
+	err := pqListener.Listen(db.AccountUpdatesChannel)
+	if err != nil {
+		log.Error().Err(err).Msg("cannot listen to account updates")
+		return
+	}
+
+	ping := time.NewTicker(listenerPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case notification := <-pqListener.Notify:
+			if notification == nil {
+				listener.publisher.ResetAccountUpdates()
+				continue
+			}
+			listener.Handle(notification.Extra)
+		case <-ping.C:
+			go pqListener.Ping()
+		}
+	}
+}
+
+// Handle publishes the account update notified with payload.
+func (listener *AccountUpdateListener) Handle(payload string) {
+	var update db.AccountUpdate
+	err := json.Unmarshal([]byte(payload), &update)
+	if err != nil {
+		log.Error().Err(err).Msg("cannot decode account update")
+		return
+	}
+
+	listener.publisher.PublishAccountUpdate(update)
+}
@@ -0,0 +1,38 @@
+package worker
+
+import (
+	"testing"
+
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+type testAccountUpdatePublisher struct {
+	updates []db.AccountUpdate
+	resets  int
+}
+
+func (publisher *testAccountUpdatePublisher) PublishAccountUpdate(update db.AccountUpdate) {
+	publisher.updates = append(publisher.updates, update)
+}
+
+func (publisher *testAccountUpdatePublisher) ResetAccountUpdates() {
+	publisher.resets++
+}
+
+func TestAccountUpdateListenerHandle(t *testing.T) {
+	publisher := &testAccountUpdatePublisher{}
+	listener := NewAccountUpdateListener(util.Config{}, publisher)
+
+	listener.Handle(`{"account_id":1,"balance":110,"currency":"USD","entries":[{"id":3,"account_id":1,"amount":10}]}`)
+	listener.Handle(`not json`)
+
+	require.Len(t, publisher.updates, 1)
+	update := publisher.updates[0]
+	require.Equal(t, int64(1), update.AccountID)
+	require.Equal(t, int64(110), update.Balance)
+	require.Equal(t, util.USD, update.Currency)
+	require.Len(t, update.Entries, 1)
+	require.Equal(t, int64(10), update.Entries[0].Amount)
+}
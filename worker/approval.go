@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/rs/zerolog/log"
+)
+
+// ApprovalExpirer marks transfers left pending approval past their expiry
+// as expired. ApproveTransferTx refuses them either way, this only keeps
+// their status accurate.
+type ApprovalExpirer struct {
+	store    db.Store
+	interval time.Duration
+}
+
+func NewApprovalExpirer(store db.Store, config util.Config) *ApprovalExpirer {
+	return &ApprovalExpirer{
+		store:    store,
+		interval: config.TransferApprovalExpiryInterval,
+	}
+}
+
+// Start expires approvals every interval until ctx is done.
+func (expirer *ApprovalExpirer) Start(ctx context.Context) {
+	ticker := time.NewTicker(expirer.interval)
+	defer ticker.Stop()
+
+	for {
+		err := expirer.RunOnce(ctx, time.Now())
+		if err != nil {
+			log.Error().Err(err).Msg("cannot expire transfer approvals")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (expirer *ApprovalExpirer) RunOnce(ctx context.Context, now time.Time) error {
+	expired, err := expirer.store.ExpireTransferApprovals(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	if expired > 0 {
+		log.Info().Int64("approvals", expired).Msg("expired transfer approvals")
+	}
+	return nil
+}
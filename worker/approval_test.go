@@ -0,0 +1,26 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mockdb "github.com/asshiddiq1306/simple_bank/db/mock"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApprovalExpirerRunOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	expirer := NewApprovalExpirer(store, util.Config{})
+
+	now := time.Now()
+	store.EXPECT().ExpireTransferApprovals(gomock.Any(), gomock.Eq(now)).Times(1).Return(int64(2), nil)
+
+	err := expirer.RunOnce(context.Background(), now)
+	require.NoError(t, err)
+}
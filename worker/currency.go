@@ -0,0 +1,62 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/rs/zerolog/log"
+)
+
+// CurrencyRefresher reloads the currency table into the cache behind
+// util.IsSupportedCurrency, so changes made through another replica are
+// picked up.
+type CurrencyRefresher struct {
+	store    db.Store
+	interval time.Duration
+}
+
+func NewCurrencyRefresher(store db.Store, config util.Config) *CurrencyRefresher {
+	return &CurrencyRefresher{
+		store:    store,
+		interval: config.CurrencyRefreshInterval,
+	}
+}
+
+// Start reloads the currencies every interval until ctx is done.
+func (refresher *CurrencyRefresher) Start(ctx context.Context) {
+	ticker := time.NewTicker(refresher.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := refresher.RunOnce(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("cannot refresh currencies")
+		}
+	}
+}
+
+func (refresher *CurrencyRefresher) RunOnce(ctx context.Context) error {
+	currencies, err := refresher.store.GetListCurrencies(ctx)
+	if err != nil {
+		return err
+	}
+
+	list := make([]util.Currency, len(currencies))
+	for i, currency := range currencies {
+		list[i] = util.Currency{
+			Code:       currency.Code,
+			MinorUnits: int(currency.MinorUnits),
+			Enabled:    currency.Enabled,
+		}
+	}
+	util.SetCurrencies(list)
+	return nil
+}
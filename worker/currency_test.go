@@ -0,0 +1,34 @@
+package worker
+
+import (
+	"context"
+	"testing"
+
+	mockdb "github.com/asshiddiq1306/simple_bank/db/mock"
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurrencyRefresherRunOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	refresher := NewCurrencyRefresher(store, util.Config{})
+
+	currencies := []db.Currency{
+		{Code: util.EUR, MinorUnits: 2, Enabled: true},
+		{Code: util.IDR, MinorUnits: 0, Enabled: false},
+		{Code: util.USD, MinorUnits: 2, Enabled: true},
+	}
+	store.EXPECT().GetListCurrencies(gomock.Any()).Times(1).Return(currencies, nil)
+
+	err := refresher.RunOnce(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, []string{util.EUR, util.USD}, util.EnabledCurrencies())
+	require.True(t, util.IsSupportedCurrency(util.IDR))
+	require.False(t, util.IsEnabledCurrency(util.IDR))
+}
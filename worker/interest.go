@@ -0,0 +1,102 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/rs/zerolog/log"
+)
+
+// InterestScheduler accrues interest on savings accounts every day and
+// posts it on the first day of the next month.
+type InterestScheduler struct {
+	store       db.Store
+	interval    time.Duration
+	catchupDays int
+}
+
+func NewInterestScheduler(store db.Store, config util.Config) *InterestScheduler {
+	catchupDays := config.InterestAccrualCatchupDays
+	if catchupDays < 1 {
+		catchupDays = 1
+	}
+
+	return &InterestScheduler{
+		store:       store,
+		interval:    config.InterestJobInterval,
+		catchupDays: catchupDays,
+	}
+}
+
+// Start runs the scheduler every interval until ctx is done.
+func (scheduler *InterestScheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(scheduler.interval)
+	defer ticker.Stop()
+
+	for {
+		err := scheduler.RunOnce(ctx, time.Now())
+		if err != nil {
+			log.Error().Err(err).Msg("interest job failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce accrues the last catchupDays complete days in UTC and posts the
+// interest of the previous month. Both steps skip work that was already
+// done, so a day missed while the server was down is caught up on the next
+// run and several replicas can run the job at the same time.
+func (scheduler *InterestScheduler) RunOnce(ctx context.Context, now time.Time) error {
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	for i := scheduler.catchupDays; i >= 1; i-- {
+		result, err := scheduler.store.AccrueInterestTx(ctx, today.AddDate(0, 0, -i))
+		if err != nil {
+			return err
+		}
+
+		if result.Accounts > 0 {
+			log.Info().
+				Time("date", result.Date).
+				Int64("accounts", result.Accounts).
+				Msg("accrued interest")
+		}
+	}
+
+	periodEnd := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+	accountIDs, err := scheduler.store.GetAccountsWithUnpostedInterest(ctx, periodEnd)
+	if err != nil {
+		return err
+	}
+
+	for _, accountID := range accountIDs {
+		result, err := scheduler.store.PostInterestTx(ctx, db.PostInterestTxArg{
+			AccountID: accountID,
+			PeriodEnd: periodEnd,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				// Already posted, the accruals are picked up next month.
+				continue
+			}
+			return err
+		}
+
+		log.Info().
+			Int64("account_id", accountID).
+			Int64("amount", result.Posting.Amount).
+			Int64("carry_micros", result.Posting.CarryMicros).
+			Msg("posted interest")
+	}
+
+	return nil
+}
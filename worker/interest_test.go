@@ -0,0 +1,59 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/asshiddiq1306/simple_bank/db/mock"
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterestSchedulerRunOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	scheduler := NewInterestScheduler(store, util.Config{
+		InterestJobInterval:        time.Hour,
+		InterestAccrualCatchupDays: 2,
+	})
+
+	now := time.Date(2021, time.November, 1, 10, 30, 0, 0, time.FixedZone("WIB", 7*60*60))
+	periodEnd := time.Date(2021, time.October, 31, 0, 0, 0, 0, time.UTC)
+
+	gomock.InOrder(
+		store.EXPECT().AccrueInterestTx(gomock.Any(), gomock.Eq(time.Date(2021, time.October, 30, 0, 0, 0, 0, time.UTC))).
+			Times(1).Return(db.AccrueInterestTxResult{}, nil),
+		store.EXPECT().AccrueInterestTx(gomock.Any(), gomock.Eq(periodEnd)).
+			Times(1).Return(db.AccrueInterestTxResult{}, nil),
+		store.EXPECT().GetAccountsWithUnpostedInterest(gomock.Any(), gomock.Eq(periodEnd)).
+			Times(1).Return([]int64{1, 2}, nil),
+	)
+
+	store.EXPECT().PostInterestTx(gomock.Any(), gomock.Eq(db.PostInterestTxArg{AccountID: 1, PeriodEnd: periodEnd})).
+		Times(1).Return(db.PostInterestTxResult{}, sql.ErrNoRows)
+	store.EXPECT().PostInterestTx(gomock.Any(), gomock.Eq(db.PostInterestTxArg{AccountID: 2, PeriodEnd: periodEnd})).
+		Times(1).Return(db.PostInterestTxResult{}, nil)
+
+	err := scheduler.RunOnce(context.Background(), now)
+	require.NoError(t, err)
+}
+
+func TestInterestSchedulerAccrualError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	scheduler := NewInterestScheduler(store, util.Config{InterestJobInterval: time.Hour})
+
+	store.EXPECT().AccrueInterestTx(gomock.Any(), gomock.Any()).Times(1).Return(db.AccrueInterestTxResult{}, sql.ErrConnDone)
+	store.EXPECT().GetAccountsWithUnpostedInterest(gomock.Any(), gomock.Any()).Times(0)
+
+	err := scheduler.RunOnce(context.Background(), time.Now())
+	require.ErrorIs(t, err, sql.ErrConnDone)
+}
@@ -0,0 +1,124 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/event"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	outboxSinkStdout  = "stdout"
+	outboxSinkFile    = "file"
+	outboxSinkWebhook = "webhook"
+	outboxSinkNATS    = "nats"
+)
+
+// NewOutboxSink builds the sinks listed, comma separated, in OUTBOX_SINKS.
+func NewOutboxSink(config util.Config) (event.Sink, error) {
+	var sinks event.MultiSink
+
+	for _, name := range strings.Split(config.OutboxSinks, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case outboxSinkStdout:
+			sinks = append(sinks, event.NewStdoutSink())
+		case outboxSinkFile:
+			sink, err := event.NewFileSink(config.OutboxFilePath)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, sink)
+		case outboxSinkWebhook:
+			if config.OutboxWebhookURL == "" {
+				return nil, fmt.Errorf("the webhook outbox sink requires OUTBOX_WEBHOOK_URL")
+			}
+			sinks = append(sinks, event.NewWebhookSink(config.OutboxWebhookURL, config.OutboxPublishTimeout))
+		case outboxSinkNATS:
+			sink, err := event.NewNATSSink(config.OutboxNATSURL, config.OutboxNATSSubjectPrefix, config.OutboxPublishTimeout)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, sink)
+		default:
+			return nil, fmt.Errorf("unsupported outbox sink %s", name)
+		}
+	}
+	return sinks, nil
+}
+
+// OutboxRelay publishes the events written to the outbox by the store
+// transactions. An event is marked published only once its sink accepted
+// it, so every event is delivered at least once, in id order.
+type OutboxRelay struct {
+	store     db.Store
+	sink      event.Sink
+	interval  time.Duration
+	batchSize int32
+}
+
+func NewOutboxRelay(store db.Store, sink event.Sink, config util.Config) *OutboxRelay {
+	batchSize := config.OutboxBatchSize
+	if batchSize < 1 {
+		batchSize = 1
+	}
+
+	return &OutboxRelay{
+		store:     store,
+		sink:      sink,
+		interval:  config.OutboxRelayInterval,
+		batchSize: batchSize,
+	}
+}
+
+// Start relays the outbox every interval until ctx is done.
+func (relay *OutboxRelay) Start(ctx context.Context) {
+	ticker := time.NewTicker(relay.interval)
+	defer ticker.Stop()
+
+	for {
+		err := relay.RunOnce(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("cannot relay outbox events")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce publishes batches until the outbox is drained, a publish fails or
+// another relay holds the lock.
+func (relay *OutboxRelay) RunOnce(ctx context.Context) error {
+	for {
+		result, err := relay.store.RelayOutboxTx(ctx, db.RelayOutboxTxArg{
+			Limit:   relay.batchSize,
+			Publish: relay.publish,
+		})
+		if result.Published > 0 {
+			log.Debug().Int64("events", result.Published).Msg("published outbox events")
+		}
+		if err != nil || result.Published < int64(relay.batchSize) {
+			return err
+		}
+	}
+}
+
+func (relay *OutboxRelay) publish(ctx context.Context, outboxEvent db.OutboxEvent) error {
+	return relay.sink.Publish(ctx, event.Event{
+		ID:            outboxEvent.ID,
+		Type:          outboxEvent.Type,
+		AggregateType: outboxEvent.AggregateType,
+		AggregateID:   outboxEvent.AggregateID,
+		Payload:       outboxEvent.Payload,
+		CreatedAt:     outboxEvent.CreatedAt,
+	})
+}
@@ -0,0 +1,100 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "github.com/asshiddiq1306/simple_bank/db/mock"
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/event"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// relayEvents fakes RelayOutboxTx over events, publishing up to the limit.
+func relayEvents(events []db.OutboxEvent) func(ctx context.Context, arg db.RelayOutboxTxArg) (db.RelayOutboxTxResult, error) {
+	return func(ctx context.Context, arg db.RelayOutboxTxArg) (db.RelayOutboxTxResult, error) {
+		result := db.RelayOutboxTxResult{Locked: true}
+		for _, outboxEvent := range events {
+			if result.Published == int64(arg.Limit) {
+				break
+			}
+
+			err := arg.Publish(ctx, outboxEvent)
+			if err != nil {
+				return result, err
+			}
+			result.Published++
+		}
+		return result, nil
+	}
+}
+
+func TestOutboxRelayRunOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	events := make([]db.OutboxEvent, 3)
+	for i := range events {
+		events[i] = db.OutboxEvent{
+			ID:            int64(i + 1),
+			AggregateType: db.AggregateAccount,
+			AggregateID:   "1",
+			Type:          db.EventTransferCompleted,
+			Payload:       json.RawMessage(`{}`),
+			CreatedAt:     time.Now(),
+		}
+	}
+
+	store := mockdb.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().RelayOutboxTx(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(relayEvents(events[:2])),
+		store.EXPECT().RelayOutboxTx(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(relayEvents(events[2:])),
+	)
+
+	var buf bytes.Buffer
+	relay := NewOutboxRelay(store, event.NewWriterSink(&buf), util.Config{OutboxBatchSize: 2})
+
+	err := relay.RunOnce(context.Background())
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	for i, line := range lines {
+		var published event.Event
+		require.NoError(t, json.Unmarshal([]byte(line), &published))
+		require.Equal(t, events[i].ID, published.ID)
+		require.Equal(t, events[i].Type, published.Type)
+	}
+}
+
+func TestOutboxRelayPublishFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	publishErr := errors.New("sink unavailable")
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().RelayOutboxTx(gomock.Any(), gomock.Any()).Times(1).Return(db.RelayOutboxTxResult{Locked: true}, publishErr)
+
+	relay := NewOutboxRelay(store, event.MultiSink{}, util.Config{OutboxBatchSize: 2})
+	require.Equal(t, publishErr, relay.RunOnce(context.Background()))
+}
+
+func TestNewOutboxSink(t *testing.T) {
+	sink, err := NewOutboxSink(util.Config{OutboxSinks: "stdout, nats", OutboxNATSURL: "nats://localhost:4222"})
+	require.NoError(t, err)
+	require.Len(t, sink, 2)
+
+	_, err = NewOutboxSink(util.Config{OutboxSinks: "webhook"})
+	require.Error(t, err)
+
+	_, err = NewOutboxSink(util.Config{OutboxSinks: "kafka"})
+	require.Error(t, err)
+}
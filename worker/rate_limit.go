@@ -0,0 +1,77 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/ratelimit"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/rs/zerolog/log"
+)
+
+// RateLimitSweeper deletes the buckets of the postgres rate limiter that
+// had time to refill, otherwise every client key ever seen keeps a row.
+type RateLimitSweeper struct {
+	store    db.Store
+	interval time.Duration
+	idle     time.Duration
+}
+
+// NewRateLimitSweeper returns nil unless the postgres rate limiter is used.
+func NewRateLimitSweeper(store db.Store, config util.Config) *RateLimitSweeper {
+	if config.RateLimitBackend != "postgres" {
+		return nil
+	}
+
+	// The limits the server applies, a bucket idle for the longest refill
+	// is full whichever of them it belongs to.
+	limits := []ratelimit.Limit{
+		ratelimit.PerMinute(config.PublicRateLimit, config.PublicRateBurst),
+		ratelimit.PerMinute(config.AuthRateLimit, config.AuthRateBurst),
+		ratelimit.PerMinute(config.WriteRateLimit, config.WriteRateBurst),
+		ratelimit.PerMinute(config.TransferRateLimit, config.TransferRateBurst),
+	}
+
+	sweeper := &RateLimitSweeper{
+		store:    store,
+		interval: config.RateLimitSweepInterval,
+	}
+	for _, limit := range limits {
+		if limit.Enabled() && limit.RefillTime() > sweeper.idle {
+			sweeper.idle = limit.RefillTime()
+		}
+	}
+	return sweeper
+}
+
+// Start deletes the refilled buckets every interval until ctx is done.
+func (sweeper *RateLimitSweeper) Start(ctx context.Context) {
+	ticker := time.NewTicker(sweeper.interval)
+	defer ticker.Stop()
+
+	for {
+		err := sweeper.RunOnce(ctx, time.Now())
+		if err != nil {
+			log.Error().Err(err).Msg("cannot delete idle rate limit buckets")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (sweeper *RateLimitSweeper) RunOnce(ctx context.Context, now time.Time) error {
+	deleted, err := sweeper.store.DeleteIdleRateLimitBuckets(ctx, now.Add(-sweeper.idle))
+	if err != nil {
+		return err
+	}
+
+	if deleted > 0 {
+		log.Info().Int64("buckets", deleted).Msg("deleted idle rate limit buckets")
+	}
+	return nil
+}
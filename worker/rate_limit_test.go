@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mockdb "github.com/asshiddiq1306/simple_bank/db/mock"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitSweeperRunOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	sweeper := NewRateLimitSweeper(store, util.Config{
+		RateLimitBackend:  "postgres",
+		PublicRateLimit:   20,
+		PublicRateBurst:   5,
+		AuthRateLimit:     120,
+		AuthRateBurst:     20,
+		TransferRateLimit: 10,
+		TransferRateBurst: 5,
+	})
+	require.NotNil(t, sweeper)
+
+	// 5 transfers at 10 per minute take the longest to refill, the disabled
+	// write limit is ignored.
+	now := time.Now()
+	store.EXPECT().DeleteIdleRateLimitBuckets(gomock.Any(), gomock.Eq(now.Add(-30*time.Second))).Times(1).Return(int64(3), nil)
+
+	err := sweeper.RunOnce(context.Background(), now)
+	require.NoError(t, err)
+}
+
+func TestRateLimitSweeperMemoryBackend(t *testing.T) {
+	require.Nil(t, NewRateLimitSweeper(nil, util.Config{RateLimitBackend: "memory"}))
+	require.Nil(t, NewRateLimitSweeper(nil, util.Config{}))
+}
@@ -0,0 +1,205 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/event"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/rs/zerolog/log"
+)
+
+// WebhookDispatcher sends the pending webhook deliveries, signed with the
+// secret of their endpoint. A failed delivery is retried with exponential
+// backoff until it runs out of attempts.
+type WebhookDispatcher struct {
+	store         db.Store
+	client        *http.Client
+	encryptionKey string
+	interval      time.Duration
+	batchSize     int32
+	maxAttempts   int32
+	backoffBase   time.Duration
+	backoffMax    time.Duration
+	disableAfter  int32
+}
+
+func NewWebhookDispatcher(store db.Store, config util.Config) *WebhookDispatcher {
+	dispatcher := &WebhookDispatcher{
+		store:         store,
+		client:        newWebhookClient(config.WebhookTimeout, util.WebhookDialControl),
+		encryptionKey: config.WebhookEncryptionKey,
+		interval:      config.WebhookDispatchInterval,
+		batchSize:     config.WebhookBatchSize,
+		maxAttempts:   config.WebhookMaxAttempts,
+		backoffBase:   config.WebhookBackoffBase,
+		backoffMax:    config.WebhookBackoffMax,
+		disableAfter:  config.WebhookDisableAfterFailures,
+	}
+	if dispatcher.batchSize < 1 {
+		dispatcher.batchSize = 1
+	}
+	if dispatcher.maxAttempts < 1 {
+		dispatcher.maxAttempts = 1
+	}
+	if dispatcher.disableAfter < 1 {
+		dispatcher.disableAfter = 1
+	}
+	return dispatcher
+}
+
+// newWebhookClient returns the client sending the deliveries. Every
+// connection it makes goes through control, it ignores proxies and it does
+// not follow redirects, which would lead it to urls no one checked.
+func newWebhookClient(timeout time.Duration, control func(network string, address string, c syscall.RawConn) error) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: control,
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+		CheckRedirect: func(request *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Start sends the due deliveries every interval until ctx is done.
+func (dispatcher *WebhookDispatcher) Start(ctx context.Context) {
+	ticker := time.NewTicker(dispatcher.interval)
+	defer ticker.Stop()
+
+	for {
+		err := dispatcher.RunOnce(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("cannot dispatch webhooks")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce claims a batch of due deliveries and sends them. The claim leases
+// them for longer than a batch can take, so another dispatcher does not send
+// them at the same time.
+func (dispatcher *WebhookDispatcher) RunOnce(ctx context.Context) error {
+	deliveries, err := dispatcher.store.ClaimWebhookDeliveries(ctx, db.ClaimWebhookDeliveriesArgs{
+		Limit: dispatcher.batchSize,
+		Lease: dispatcher.client.Timeout*time.Duration(dispatcher.batchSize) + time.Minute,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		err = dispatcher.dispatch(ctx, delivery)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (dispatcher *WebhookDispatcher) dispatch(ctx context.Context, delivery db.ClaimWebhookDeliveriesRow) error {
+	status, sendErr := dispatcher.send(ctx, delivery)
+
+	arg := db.RecordWebhookAttemptTxArg{
+		DeliveryID:   delivery.ID,
+		EndpointID:   delivery.EndpointID,
+		Succeeded:    sendErr == nil,
+		DisableAfter: dispatcher.disableAfter,
+	}
+	if status != 0 {
+		arg.ResponseStatus = sql.NullInt32{Int32: int32(status), Valid: true}
+	}
+	if sendErr != nil {
+		arg.Error = sendErr.Error()
+
+		attempts := delivery.Attempts + 1
+		if attempts < dispatcher.maxAttempts {
+			arg.RetryIn = util.WebhookBackoff(attempts, dispatcher.backoffBase, dispatcher.backoffMax)
+		}
+	}
+
+	result, err := dispatcher.store.RecordWebhookAttemptTx(ctx, arg)
+	if err != nil {
+		return err
+	}
+
+	if sendErr != nil {
+		log.Warn().
+			Err(sendErr).
+			Int64("delivery_id", delivery.ID).
+			Int64("endpoint_id", delivery.EndpointID).
+			Int32("attempts", result.Delivery.Attempts).
+			Msg("webhook delivery failed")
+	}
+	if sendErr != nil && !result.Endpoint.Enabled {
+		log.Warn().
+			Int64("endpoint_id", delivery.EndpointID).
+			Int32("consecutive_failures", result.Endpoint.ConsecutiveFailures).
+			Msg("webhook endpoint disabled")
+	}
+	return nil
+}
+
+// send POSTs the event of delivery to its endpoint, returning the response
+// status, if any, and an error unless it is 2xx.
+func (dispatcher *WebhookDispatcher) send(ctx context.Context, delivery db.ClaimWebhookDeliveriesRow) (int, error) {
+	secret, err := util.Decrypt(dispatcher.encryptionKey, delivery.EncryptedSecret)
+	if err != nil {
+		return 0, err
+	}
+
+	body, err := json.Marshal(event.Event{
+		ID:            delivery.EventID,
+		Type:          delivery.EventType,
+		AggregateType: delivery.EventAggregateType,
+		AggregateID:   delivery.EventAggregateID,
+		Payload:       delivery.EventPayload,
+		CreatedAt:     delivery.EventCreatedAt,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Webhook-Delivery", strconv.FormatInt(delivery.ID, 10))
+	request.Header.Set("X-Webhook-Event", delivery.EventType)
+	request.Header.Set(util.WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	request.Header.Set(util.WebhookSignatureHeader, util.SignWebhook(secret, timestamp, body))
+
+	response, err := dispatcher.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(response.Body, 64<<10))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("endpoint responded %s", response.Status)
+	}
+	return response.StatusCode, nil
+}
@@ -0,0 +1,214 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	mockdb "github.com/asshiddiq1306/simple_bank/db/mock"
+	db "github.com/asshiddiq1306/simple_bank/db/sql"
+	"github.com/asshiddiq1306/simple_bank/event"
+	"github.com/asshiddiq1306/simple_bank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestWebhookDispatcher returns a dispatcher allowed to reach the test
+// servers, which listen on loopback.
+func newTestWebhookDispatcher(store db.Store) *WebhookDispatcher {
+	dispatcher := newDefaultTestWebhookDispatcher(store)
+	dispatcher.client = newWebhookClient(time.Second, nil)
+	return dispatcher
+}
+
+func newDefaultTestWebhookDispatcher(store db.Store) *WebhookDispatcher {
+	return NewWebhookDispatcher(store, util.Config{
+		WebhookEncryptionKey:        util.RandomString(32),
+		WebhookTimeout:              time.Second,
+		WebhookBatchSize:            10,
+		WebhookMaxAttempts:          3,
+		WebhookBackoffBase:          time.Second,
+		WebhookBackoffMax:           time.Minute,
+		WebhookDisableAfterFailures: 5,
+	})
+}
+
+func createClaimedDelivery(t *testing.T, dispatcher *WebhookDispatcher, url string, secret string, attempts int32) db.ClaimWebhookDeliveriesRow {
+	encryptedSecret, err := util.Encrypt(dispatcher.encryptionKey, secret)
+	require.NoError(t, err)
+
+	return db.ClaimWebhookDeliveriesRow{
+		ID:                 util.RandomInt(1, 1000),
+		EndpointID:         util.RandomInt(1, 1000),
+		EventID:            util.RandomInt(1, 1000),
+		Attempts:           attempts,
+		URL:                url,
+		EncryptedSecret:    encryptedSecret,
+		EventType:          db.EventTransferCompleted,
+		EventAggregateType: db.AggregateAccount,
+		EventAggregateID:   "1",
+		EventPayload:       json.RawMessage(`{"amount":100}`),
+		EventCreatedAt:     time.Now(),
+	}
+}
+
+func TestWebhookDispatcherSignsDelivery(t *testing.T) {
+	secret, err := util.GenerateWebhookSecret()
+	require.NoError(t, err)
+
+	var received event.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		timestamp := r.Header.Get(util.WebhookTimestampHeader)
+		signature := r.Header.Get(util.WebhookSignatureHeader)
+		require.NoError(t, util.VerifyWebhook(secret, timestamp, signature, body, time.Minute, time.Now()))
+		require.Equal(t, db.EventTransferCompleted, r.Header.Get("X-Webhook-Event"))
+		require.NoError(t, json.Unmarshal(body, &received))
+	}))
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	dispatcher := newTestWebhookDispatcher(store)
+	delivery := createClaimedDelivery(t, dispatcher, server.URL, secret, 0)
+
+	store.EXPECT().ClaimWebhookDeliveries(gomock.Any(), gomock.Any()).Times(1).Return([]db.ClaimWebhookDeliveriesRow{delivery}, nil)
+	store.EXPECT().
+		RecordWebhookAttemptTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.RecordWebhookAttemptTxArg) (db.RecordWebhookAttemptTxResult, error) {
+			require.Equal(t, delivery.ID, arg.DeliveryID)
+			require.Equal(t, delivery.EndpointID, arg.EndpointID)
+			require.True(t, arg.Succeeded)
+			require.Equal(t, int32(http.StatusOK), arg.ResponseStatus.Int32)
+			require.Empty(t, arg.Error)
+			require.Equal(t, int32(5), arg.DisableAfter)
+			return db.RecordWebhookAttemptTxResult{Endpoint: db.WebhookEndpoint{Enabled: true}}, nil
+		})
+
+	require.NoError(t, dispatcher.RunOnce(context.Background()))
+	require.Equal(t, delivery.EventID, received.ID)
+	require.JSONEq(t, string(delivery.EventPayload), string(received.Payload))
+}
+
+func TestWebhookDispatcherRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name     string
+		attempts int32
+		retryIn  time.Duration
+	}{
+		{
+			name:     "Backoff",
+			attempts: 1,
+			retryIn:  2 * time.Second,
+		},
+		{
+			name:     "GiveUp",
+			attempts: 2,
+			retryIn:  0,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			dispatcher := newTestWebhookDispatcher(store)
+			delivery := createClaimedDelivery(t, dispatcher, server.URL, "whsec_test", tc.attempts)
+
+			store.EXPECT().ClaimWebhookDeliveries(gomock.Any(), gomock.Any()).Times(1).Return([]db.ClaimWebhookDeliveriesRow{delivery}, nil)
+			store.EXPECT().
+				RecordWebhookAttemptTx(gomock.Any(), gomock.Any()).
+				Times(1).
+				DoAndReturn(func(_ context.Context, arg db.RecordWebhookAttemptTxArg) (db.RecordWebhookAttemptTxResult, error) {
+					require.False(t, arg.Succeeded)
+					require.Equal(t, int32(http.StatusServiceUnavailable), arg.ResponseStatus.Int32)
+					require.NotEmpty(t, arg.Error)
+					require.Equal(t, tc.retryIn, arg.RetryIn)
+					return db.RecordWebhookAttemptTxResult{}, nil
+				})
+
+			require.NoError(t, dispatcher.RunOnce(context.Background()))
+		})
+	}
+}
+
+func TestWebhookDispatcherRefusesInternalAddress(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+	}))
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	dispatcher := newDefaultTestWebhookDispatcher(store)
+	delivery := createClaimedDelivery(t, dispatcher, server.URL, "whsec_test", 0)
+
+	store.EXPECT().ClaimWebhookDeliveries(gomock.Any(), gomock.Any()).Times(1).Return([]db.ClaimWebhookDeliveriesRow{delivery}, nil)
+	store.EXPECT().
+		RecordWebhookAttemptTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.RecordWebhookAttemptTxArg) (db.RecordWebhookAttemptTxResult, error) {
+			require.False(t, arg.Succeeded)
+			require.False(t, arg.ResponseStatus.Valid)
+			require.Contains(t, arg.Error, util.ErrWebhookAddress.Error())
+			return db.RecordWebhookAttemptTxResult{Endpoint: db.WebhookEndpoint{Enabled: true}}, nil
+		})
+
+	require.NoError(t, dispatcher.RunOnce(context.Background()))
+	require.Zero(t, atomic.LoadInt32(&requests))
+}
+
+func TestWebhookDispatcherDoesNotFollowRedirects(t *testing.T) {
+	var redirected int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hooks", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/internal", http.StatusFound)
+	})
+	mux.HandleFunc("/internal", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&redirected, 1)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	dispatcher := newTestWebhookDispatcher(store)
+	delivery := createClaimedDelivery(t, dispatcher, server.URL+"/hooks", "whsec_test", 0)
+
+	store.EXPECT().ClaimWebhookDeliveries(gomock.Any(), gomock.Any()).Times(1).Return([]db.ClaimWebhookDeliveriesRow{delivery}, nil)
+	store.EXPECT().
+		RecordWebhookAttemptTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.RecordWebhookAttemptTxArg) (db.RecordWebhookAttemptTxResult, error) {
+			require.False(t, arg.Succeeded)
+			require.Equal(t, int32(http.StatusFound), arg.ResponseStatus.Int32)
+			return db.RecordWebhookAttemptTxResult{Endpoint: db.WebhookEndpoint{Enabled: true}}, nil
+		})
+
+	require.NoError(t, dispatcher.RunOnce(context.Background()))
+	require.Zero(t, atomic.LoadInt32(&redirected))
+}